@@ -19,12 +19,15 @@ import (
 
 	"github.com/osbuild/osbuild-composer/internal/auth"
 	"github.com/osbuild/osbuild-composer/internal/cloudapi"
+	"github.com/osbuild/osbuild-composer/internal/distro"
 	"github.com/osbuild/osbuild-composer/internal/distroregistry"
 	"github.com/osbuild/osbuild-composer/internal/jobqueue"
 	"github.com/osbuild/osbuild-composer/internal/jobqueue/dbjobqueue"
 	"github.com/osbuild/osbuild-composer/internal/jobqueue/fsjobqueue"
 	"github.com/osbuild/osbuild-composer/internal/kojiapi"
+	"github.com/osbuild/osbuild-composer/internal/reporegistry"
 	"github.com/osbuild/osbuild-composer/internal/rpmmd"
+	"github.com/osbuild/osbuild-composer/internal/snapshot"
 	"github.com/osbuild/osbuild-composer/internal/weldr"
 	"github.com/osbuild/osbuild-composer/internal/worker"
 )
@@ -36,7 +39,10 @@ type Composer struct {
 	logger   *log.Logger
 	distros  *distroregistry.Registry
 
-	rpm rpmmd.RPMMD
+	rpm       rpmmd.RPMMD
+	rpmCache  *rpmmd.CacheManager
+	snapshots *snapshot.Store
+	repos     *reporegistry.RepoRegistry // shared by all APIs, nil until loaded
 
 	workers *worker.Server
 	weldr   *weldr.API
@@ -65,7 +71,41 @@ func NewComposer(config *ComposerConfigFile, stateDir, cacheDir string) (*Compos
 	c.distros = distroregistry.NewDefault()
 	logrus.Infof("Loaded %d distros", len(c.distros.List()))
 
-	c.rpm = rpmmd.NewRPMMD(path.Join(c.cacheDir, "rpmmd"))
+	imageTypes, err := distro.LoadImageTypeDefinitions(imageTypesDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load image type definitions: %v", err)
+	}
+	err = c.distros.AddImageTypes(imageTypes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot add image types: %v", err)
+	}
+	if len(imageTypes) > 0 {
+		logrus.Infof("Loaded %d user-defined image types", len(imageTypes))
+	}
+
+	err = c.distros.SetAliases(config.DistroAliases)
+	if err != nil {
+		return nil, fmt.Errorf("cannot set distro aliases: %v", err)
+	}
+	for alias, target := range c.distros.Aliases() {
+		logrus.Infof("Distro alias %s resolves to %s", alias, target)
+	}
+
+	cacheConfig, err := config.rpmmdCacheConfig()
+	if err != nil {
+		return nil, err
+	}
+	c.rpmCache = rpmmd.NewCacheManager(path.Join(c.cacheDir, "rpmmd"), cacheConfig)
+	c.rpm = rpmmd.NewRPMMDWithCache(c.rpmCache)
+
+	snapshotDir, err := c.ensureStateDirectory("snapshots", 0700)
+	if err != nil {
+		return nil, err
+	}
+	c.snapshots, err = snapshot.NewStore(&snapshotDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load snapshots: %v", err)
+	}
 
 	var jobs jobqueue.JobQueue
 	if config.Worker.PGDatabase != "" {
@@ -107,9 +147,34 @@ func NewComposer(config *ComposerConfigFile, stateDir, cacheDir string) (*Compos
 	return &c, nil
 }
 
+// repositoryReloadInterval is how often the repository definition files
+// are checked for changes
+const repositoryReloadInterval = 10 * time.Second
+
+// repoRegistry loads the repository definitions from repoPaths the first
+// time it is called, and returns the same registry afterwards
+func (c *Composer) repoRegistry(repoPaths []string) (*reporegistry.RepoRegistry, error) {
+	if c.repos != nil {
+		return c.repos, nil
+	}
+
+	repos, err := reporegistry.New(repoPaths)
+	if err != nil {
+		return nil, err
+	}
+	c.repos = repos
+
+	return c.repos, nil
+}
+
 func (c *Composer) InitWeldr(repoPaths []string, weldrListener net.Listener,
 	distrosImageTypeDenylist map[string][]string) (err error) {
-	c.weldr, err = weldr.New(repoPaths, c.stateDir, c.rpm, c.distros, c.logger, c.workers, distrosImageTypeDenylist)
+	repos, err := c.repoRegistry(repoPaths)
+	if err != nil {
+		return fmt.Errorf("error loading repository definitions: %v", err)
+	}
+
+	c.weldr, err = weldr.New(repos, c.stateDir, c.rpm, c.rpmCache, c.distros, c.logger, c.workers, c.snapshots, distrosImageTypeDenylist)
 	if err != nil {
 		return err
 	}
@@ -118,8 +183,15 @@ func (c *Composer) InitWeldr(repoPaths []string, weldrListener net.Listener,
 	return nil
 }
 
-func (c *Composer) InitAPI(cert, key string, enableTLS bool, enableMTLS bool, enableJWT bool, l net.Listener) error {
-	c.api = cloudapi.NewServer(c.workers, c.rpm, c.distros, c.config.Koji.AWS.Bucket)
+func (c *Composer) InitAPI(repoPaths []string, cert, key string, enableTLS bool, enableMTLS bool, enableJWT bool, l net.Listener) error {
+	// the repositories are only needed to search and depsolve packages,
+	// composes bring their own
+	repos, err := c.repoRegistry(repoPaths)
+	if err != nil {
+		logrus.Warnf("Cannot load repository definitions, packages can only be depsolved against given repositories: %v", err)
+	}
+
+	c.api = cloudapi.NewServer(c.workers, c.rpm, c.rpmCache, c.distros, repos, c.snapshots, c.config.Koji.AWS.Bucket)
 	c.koji = kojiapi.NewServer(c.logger, c.workers, c.rpm, c.distros)
 
 	if !enableTLS {
@@ -202,6 +274,10 @@ func (c *Composer) Start() error {
 		logrus.Fatal("neither the weldr API socket nor the composer API socket is enabled, osbuild-composer is useless without one of these APIs enabled")
 	}
 
+	if c.repos != nil {
+		go c.repos.Watch(repositoryReloadInterval, nil)
+	}
+
 	if c.localWorkerListener != nil {
 		go func() {
 			s := &http.Server{
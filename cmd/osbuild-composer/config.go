@@ -6,16 +6,21 @@ import (
 	"os"
 	"reflect"
 	"strconv"
+	"time"
 
 	"github.com/BurntSushi/toml"
+
+	"github.com/osbuild/osbuild-composer/internal/rpmmd"
 )
 
 type ComposerConfigFile struct {
-	Koji      KojiAPIConfig   `toml:"koji"`
-	Worker    WorkerAPIConfig `toml:"worker"`
-	WeldrAPI  WeldrAPIConfig  `toml:"weldr_api"`
-	LogLevel  string          `toml:"log_level"`
-	LogFormat string          `toml:"log_format"`
+	Koji          KojiAPIConfig       `toml:"koji"`
+	Worker        WorkerAPIConfig     `toml:"worker"`
+	WeldrAPI      WeldrAPIConfig      `toml:"weldr_api"`
+	MetadataCache MetadataCacheConfig `toml:"metadata_cache"`
+	DistroAliases map[string]string   `toml:"distro_aliases"`
+	LogLevel      string              `toml:"log_level"`
+	LogFormat     string              `toml:"log_format"`
 }
 
 type KojiAPIConfig struct {
@@ -63,6 +68,27 @@ type WeldrDistroConfig struct {
 	ImageTypeDenyList []string `toml:"image_type_denylist"`
 }
 
+type MetadataCacheConfig struct {
+	TTL     string `toml:"ttl"`
+	MaxSize int    `toml:"max_size"`
+}
+
+// rpmmdCacheConfig returns the limits of the repository metadata cache.
+func (c *ComposerConfigFile) rpmmdCacheConfig() (rpmmd.CacheConfig, error) {
+	ttl, err := time.ParseDuration(c.MetadataCache.TTL)
+	if err != nil {
+		return rpmmd.CacheConfig{}, fmt.Errorf("Unable to parse metadata cache TTL: %v", err)
+	}
+	if c.MetadataCache.MaxSize < 0 {
+		return rpmmd.CacheConfig{}, fmt.Errorf("Invalid metadata cache size: %d", c.MetadataCache.MaxSize)
+	}
+
+	return rpmmd.CacheConfig{
+		TTL:     ttl,
+		MaxSize: int64(c.MetadataCache.MaxSize),
+	}, nil
+}
+
 // weldrDistrosImageTypeDenyList returns a map of distro-specific Image Type
 // deny lists for Weldr API.
 func (c *ComposerConfigFile) weldrDistrosImageTypeDenyList() map[string][]string {
@@ -109,6 +135,9 @@ func GetDefaultConfig() *ComposerConfigFile {
 				},
 			},
 		},
+		MetadataCache: MetadataCacheConfig{
+			TTL: "24h",
+		},
 		LogLevel:  "info",
 		LogFormat: "text",
 	}
@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/osbuild-composer/internal/rpmmd"
 )
 
 func TestEmpty(t *testing.T) {
@@ -60,7 +63,12 @@ func TestDefaultConfig(t *testing.T) {
 	}
 
 	require.Equal(t, expectedWeldrAPIConfig, defaultConfig.WeldrAPI)
+	require.Empty(t, defaultConfig.DistroAliases)
 	require.Equal(t, "text", defaultConfig.LogFormat)
+
+	cacheConfig, err := defaultConfig.rpmmdCacheConfig()
+	require.NoError(t, err)
+	require.Equal(t, rpmmd.DefaultCacheConfig, cacheConfig)
 }
 
 func TestConfig(t *testing.T) {
@@ -77,6 +85,8 @@ func TestConfig(t *testing.T) {
 	require.Equal(t, []string{"qcow2", "vmdk"}, config.WeldrAPI.DistroConfigs["*"].ImageTypeDenyList)
 	require.Equal(t, []string{"qcow2"}, config.WeldrAPI.DistroConfigs["rhel-84"].ImageTypeDenyList)
 
+	require.Equal(t, map[string]string{"rhel-8-latest": "rhel-8*", "fedora": "fedora-*"}, config.DistroAliases)
+
 	require.Equal(t, "overwrite-me-db", config.Worker.PGDatabase)
 
 	require.NoError(t, os.Setenv("PGDATABASE", "composer-db"))
@@ -89,6 +99,10 @@ func TestConfig(t *testing.T) {
 	require.Equal(t, "https://sso.redhat.com/auth/realms/redhat-external/protocol/openid-connect/certs", config.Koji.JWTKeysURL)
 	require.Equal(t, "", config.Koji.JWTKeysCA)
 	require.Equal(t, "/var/lib/osbuild-composer/acl", config.Koji.JWTACLFile)
+
+	cacheConfig, err := config.rpmmdCacheConfig()
+	require.NoError(t, err)
+	require.Equal(t, rpmmd.CacheConfig{TTL: 6 * time.Hour, MaxSize: 1073741824}, cacheConfig)
 }
 
 func TestWeldrDistrosImageTypeDenyList(t *testing.T) {
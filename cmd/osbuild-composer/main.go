@@ -12,6 +12,7 @@ const (
 	configFile     = "/etc/osbuild-composer/osbuild-composer.toml"
 	ServerKeyFile  = "/etc/osbuild-composer/composer-key.pem"
 	ServerCertFile = "/etc/osbuild-composer/composer-crt.pem"
+	imageTypesDir  = "/etc/osbuild-composer/image-types.d"
 )
 
 var repositoryConfigs = []string{
@@ -103,7 +104,7 @@ func main() {
 			logrus.Fatal("The osbuild-composer-api.socket unit is misconfigured. It should contain only one socket.")
 		}
 
-		err = composer.InitAPI(ServerCertFile, ServerKeyFile, config.Koji.EnableTLS, config.Koji.EnableMTLS, config.Koji.EnableJWT, l[0])
+		err = composer.InitAPI(repositoryConfigs, ServerCertFile, ServerKeyFile, config.Koji.EnableTLS, config.Koji.EnableMTLS, config.Koji.EnableJWT, l[0])
 		if err != nil {
 			logrus.Fatalf("Error initializing koji API: %v", err)
 		}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -98,3 +99,48 @@ func TestCrossArchDepsolve(t *testing.T) {
 		})
 	}
 }
+
+// dnf-json keeps the solvers of repository sets between requests and serves
+// the requests concurrently. Requests for the same repositories must not see
+// the excludes of each other.
+func TestConcurrentDepsolveExcludes(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "rpmmd-test-")
+	require.Nilf(t, err, "Failed to create tmp dir for depsolve test: %v", err)
+	defer os.RemoveAll(dir)
+
+	distroStruct := fedora33.New()
+	repos, err := rpmmd.LoadRepositories([]string{"/usr/share/tests/osbuild-composer"}, distroStruct.Name())
+	require.NoErrorf(t, err, "Failed to LoadRepositories %v", distroStruct.Name())
+	arch := "x86_64"
+
+	rpm := rpmmd.NewRPMMD(dir)
+	_, _, err = rpm.Depsolve(rpmmd.PackageSet{Include: []string{"bash"}}, repos[arch], distroStruct.ModulePlatformID(), arch, distroStruct.Releasever())
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(exclude bool) {
+			defer wg.Done()
+			packageSet := rpmmd.PackageSet{Include: []string{"bash"}}
+			if exclude {
+				packageSet.Exclude = []string{"bash"}
+			}
+			specs, _, err := rpm.Depsolve(packageSet, repos[arch], distroStruct.ModulePlatformID(), arch, distroStruct.Releasever())
+			if exclude {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			found := false
+			for _, spec := range specs {
+				found = found || spec.Name == "bash"
+			}
+			assert.True(t, found, "bash was excluded by another request")
+		}(i%2 == 0)
+	}
+	wg.Wait()
+
+	_, _, err = rpm.Depsolve(rpmmd.PackageSet{Include: []string{"bash"}}, repos[arch], distroStruct.ModulePlatformID(), arch, distroStruct.Releasever())
+	assert.NoError(t, err)
+}
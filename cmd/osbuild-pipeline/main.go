@@ -101,12 +101,16 @@ func main() {
 
 	repos := make([]rpmmd.RepoConfig, len(composeRequest.Repositories))
 	for i, repo := range composeRequest.Repositories {
+		var gpgKeys []string
+		if repo.GPGKey != "" {
+			gpgKeys = []string{repo.GPGKey}
+		}
 		repos[i] = rpmmd.RepoConfig{
 			Name:       fmt.Sprintf("repo-%d", i),
 			BaseURL:    repo.BaseURL,
 			Metalink:   repo.Metalink,
 			MirrorList: repo.MirrorList,
-			GPGKey:     repo.GPGKey,
+			GPGKeys:    gpgKeys,
 			CheckGPG:   repo.CheckGPG,
 		}
 	}
@@ -151,7 +155,7 @@ func main() {
 					URL:    composeRequest.OSTree.URL,
 				},
 			},
-			repos,
+			rpmmd.ResolveGPGKeys(repos, arch.Name(), d.Releasever()),
 			packageSpecSets,
 			seedArg)
 		if err != nil {
@@ -162,6 +162,7 @@ func main() {
 		t1,
 		&bp2,
 		0,
+		distro.UnsetCompression,
 		[]*target.Target{
 			awsTarget,
 		},
@@ -177,6 +178,7 @@ func main() {
 		t2,
 		&bp1,
 		0,
+		distro.UnsetCompression,
 		[]*target.Target{
 			awsTarget,
 		},
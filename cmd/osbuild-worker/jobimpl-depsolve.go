@@ -9,26 +9,30 @@ import (
 )
 
 type DepsolveJobImpl struct {
-	RPMMDCache string
+	RPMMDCache       string
+	RPMMDCacheConfig rpmmd.CacheConfig
 }
 
-func (impl *DepsolveJobImpl) depsolve(packageSets map[string]rpmmd.PackageSet, repos []rpmmd.RepoConfig, modulePlatformID, arch, releasever string, packageSetsRepositories map[string][]rpmmd.RepoConfig) (map[string][]rpmmd.PackageSpec, error) {
-	rpmMD := rpmmd.NewRPMMD(impl.RPMMDCache)
+func (impl *DepsolveJobImpl) depsolve(rpmMD rpmmd.RPMMD, packageSets map[string]rpmmd.PackageSet, repos []rpmmd.RepoConfig, modulePlatformID, arch, releasever string, packageSetsRepositories map[string][]rpmmd.RepoConfig) (map[string][]rpmmd.PackageSpec, map[string]string, error) {
 
 	packageSpecs := make(map[string][]rpmmd.PackageSpec)
+	repoChecksums := make(map[string]string)
 	for name, packageSet := range packageSets {
 		repositories := make([]rpmmd.RepoConfig, len(repos))
 		copy(repositories, repos)
 		if packageSetRepositories, ok := packageSetsRepositories[name]; ok {
 			repositories = append(repositories, packageSetRepositories...)
 		}
-		packageSpec, _, err := rpmMD.Depsolve(packageSet, repositories, modulePlatformID, arch, releasever)
+		packageSpec, checksums, err := rpmMD.Depsolve(packageSet, repositories, modulePlatformID, arch, releasever)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		packageSpecs[name] = packageSpec
+		for repo, checksum := range rpmmd.NamedChecksums(repositories, checksums) {
+			repoChecksums[repo] = checksum
+		}
 	}
-	return packageSpecs, nil
+	return packageSpecs, repoChecksums, nil
 }
 
 func (impl *DepsolveJobImpl) Run(job worker.Job) error {
@@ -38,27 +42,16 @@ func (impl *DepsolveJobImpl) Run(job worker.Job) error {
 		return err
 	}
 
+	// the statistics of the cache are reported for this job only
+	cache := rpmmd.NewCacheManager(impl.RPMMDCache, impl.RPMMDCacheConfig)
+	cache.AddRefreshRequests(args.CacheRefresh)
+
 	var result worker.DepsolveJobResult
-	result.PackageSpecs, err = impl.depsolve(args.PackageSets, args.Repos, args.ModulePlatformID, args.Arch, args.Releasever, args.PackageSetsRepositories)
+	result.PackageSpecs, result.RepoChecksums, err = impl.depsolve(rpmmd.NewRPMMDWithCache(cache), args.PackageSets, args.Repos, args.ModulePlatformID, args.Arch, args.Releasever, args.PackageSetsRepositories)
 	if err != nil {
-		switch e := err.(type) {
-		case *rpmmd.DNFError:
-			// Error originates from dnf-json (the http call dnf-json wasn't StatusOK)
-			switch e.Kind {
-			case "DepsolveError":
-				result.JobError = clienterrors.WorkerClientError(clienterrors.ErrorDNFDepsolveError, err.Error())
-			case "MarkingError":
-				result.JobError = clienterrors.WorkerClientError(clienterrors.ErrorDNFMarkingError, err.Error())
-			default:
-				// This still has the kind/reason format but a kind that's returned
-				// by dnf-json and not explicitly handled here.
-				result.JobError = clienterrors.WorkerClientError(clienterrors.ErrorDNFOtherError, err.Error())
-			}
-		case error:
-			// Error originates from internal/rpmmd, not from dnf-json
-			result.JobError = clienterrors.WorkerClientError(clienterrors.ErrorRPMMDError, err.Error())
-		}
+		result.JobError = dnfJobError(err)
 	}
+	result.CacheStats = cache.Stats()
 
 	err = job.Update(&result)
 	if err != nil {
@@ -67,3 +60,24 @@ func (impl *DepsolveJobImpl) Run(job worker.Job) error {
 
 	return nil
 }
+
+// dnfJobError converts an error returned by rpmmd into a job error
+func dnfJobError(err error) *clienterrors.Error {
+	switch e := err.(type) {
+	case *rpmmd.DNFError:
+		// Error originates from dnf-json (the http call dnf-json wasn't StatusOK)
+		switch e.Kind {
+		case "DepsolveError":
+			return clienterrors.WorkerClientError(clienterrors.ErrorDNFDepsolveError, err.Error())
+		case "MarkingError":
+			return clienterrors.WorkerClientError(clienterrors.ErrorDNFMarkingError, err.Error())
+		default:
+			// This still has the kind/reason format but a kind that's returned
+			// by dnf-json and not explicitly handled here.
+			return clienterrors.WorkerClientError(clienterrors.ErrorDNFOtherError, err.Error())
+		}
+	default:
+		// Error originates from internal/rpmmd, not from dnf-json
+		return clienterrors.WorkerClientError(clienterrors.ErrorRPMMDError, err.Error())
+	}
+}
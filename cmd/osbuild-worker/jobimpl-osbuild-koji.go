@@ -21,6 +21,8 @@ type OSBuildKojiJobImpl struct {
 	Store       string
 	Output      string
 	KojiServers map[string]koji.GSSAPICredentials
+
+	RepositoryMTLS *RepositoryMTLSConfig
 }
 
 func (impl *OSBuildKojiJobImpl) kojiUpload(file *os.File, server, directory, filename string) (string, uint64, error) {
@@ -97,7 +99,7 @@ func (impl *OSBuildKojiJobImpl) Run(job worker.Job) error {
 			// this worker only supports returning one (1) export
 			return fmt.Errorf("at most one build artifact can be exported")
 		}
-		result.OSBuildOutput, err = RunOSBuild(args.Manifest, impl.Store, outputDirectory, exports, os.Stderr)
+		result.OSBuildOutput, err = RunOSBuild(args.Manifest, impl.Store, outputDirectory, exports, impl.RepositoryMTLS, os.Stderr)
 		if err != nil {
 			return err
 		}
@@ -6,7 +6,9 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
@@ -15,9 +17,12 @@ import (
 	"github.com/osbuild/osbuild-composer/internal/cloud/gcp"
 	"github.com/osbuild/osbuild-composer/internal/common"
 	osbuild "github.com/osbuild/osbuild-composer/internal/osbuild2"
+	"github.com/osbuild/osbuild-composer/internal/snapshot"
 	"github.com/osbuild/osbuild-composer/internal/target"
 	"github.com/osbuild/osbuild-composer/internal/upload/azure"
 	"github.com/osbuild/osbuild-composer/internal/upload/koji"
+	"github.com/osbuild/osbuild-composer/internal/upload/libvirt"
+	"github.com/osbuild/osbuild-composer/internal/upload/openstack"
 	"github.com/osbuild/osbuild-composer/internal/upload/vmware"
 	"github.com/osbuild/osbuild-composer/internal/worker"
 	"github.com/osbuild/osbuild-composer/internal/worker/clienterrors"
@@ -30,6 +35,12 @@ type OSBuildJobImpl struct {
 	GCPCreds    []byte
 	AzureCreds  *azure.Credentials
 	AWSCreds    string
+	S3Creds     string
+	// CA bundle and TLS verification settings for S3-compatible endpoints
+	S3CABundle            string
+	S3SkipSSLVerification bool
+
+	RepositoryMTLS *RepositoryMTLSConfig
 }
 
 // Returns an *awscloud.AWS object with the credentials of the request. If they
@@ -43,6 +54,86 @@ func (impl *OSBuildJobImpl) getAWS(region string, accessId string, secret string
 	}
 }
 
+// Returns an *awscloud.AWS object with the credentials of the request, set
+// up to talk to the given S3-compatible endpoint. If the credentials are not
+// accessible, then try to use the one obtained in the worker configuration.
+// The CA bundle and TLS verification settings always come from the worker
+// configuration.
+func (impl *OSBuildJobImpl) getAWSForEndpoint(options *target.GenericS3TargetOptions) (*awscloud.AWS, error) {
+	if options.AccessKeyID != "" && options.SecretAccessKey != "" {
+		return awscloud.NewForEndpoint(options.Endpoint, options.Region, options.AccessKeyID, options.SecretAccessKey, options.SessionToken, impl.S3CABundle, impl.S3SkipSSLVerification, options.PathStyle)
+	}
+	return awscloud.NewForEndpointFromFile(impl.S3Creds, options.Endpoint, options.Region, impl.S3CABundle, impl.S3SkipSSLVerification, options.PathStyle)
+}
+
+// Uploads the built image to an S3 bucket and returns a presigned URL of the
+// uploaded object.
+func uploadToS3(a *awscloud.AWS, imagePath, bucket, key string) (string, *clienterrors.Error) {
+	_, err := a.Upload(imagePath, bucket, key)
+	if err != nil {
+		return "", clienterrors.WorkerClientError(clienterrors.ErrorUploadingImage, err.Error())
+	}
+
+	url, err := a.S3ObjectPresignedURL(bucket, key)
+	if err != nil {
+		return "", clienterrors.WorkerClientError(clienterrors.ErrorUploadingImage, err.Error())
+	}
+
+	return url, nil
+}
+
+// Copies the AMI registered in the target's region to all the additional
+// regions in parallel. The result of every copy is returned in the order of
+// the regions in the target options, including the failed ones, so that the
+// successful copies are reported even if the job fails.
+func (impl *OSBuildJobImpl) copyAMIToRegions(options *target.AWSTargetOptions, name, ami string) ([]target.AWSImageCopy, *clienterrors.Error) {
+	var regions []string
+	seen := map[string]bool{options.Region: true}
+	for _, region := range options.CopyToRegions {
+		if !target.IsValidAWSRegion(region) {
+			return nil, clienterrors.WorkerClientError(clienterrors.ErrorInvalidConfig, fmt.Sprintf("invalid AWS region: %q", region))
+		}
+		if !seen[region] {
+			seen[region] = true
+			regions = append(regions, region)
+		}
+	}
+
+	copies := make([]target.AWSImageCopy, len(regions))
+	var wg sync.WaitGroup
+	for i, region := range regions {
+		wg.Add(1)
+		go func(i int, region string) {
+			defer wg.Done()
+			copies[i].Region = region
+			a, err := impl.getAWS(region, options.AccessKeyID, options.SecretAccessKey, options.SessionToken)
+			if err != nil {
+				copies[i].Error = err.Error()
+				return
+			}
+			copyID, err := a.CopyImage(name, ami, options.Region, options.ShareWithAccounts)
+			if err != nil {
+				copies[i].Error = err.Error()
+				return
+			}
+			copies[i].Ami = *copyID
+		}(i, region)
+	}
+	wg.Wait()
+
+	var failures []string
+	for _, c := range copies {
+		if c.Error != "" {
+			failures = append(failures, fmt.Sprintf("%s: %s", c.Region, c.Error))
+		}
+	}
+	if len(failures) > 0 {
+		return copies, clienterrors.WorkerClientError(clienterrors.ErrorImportingImage, fmt.Sprintf("copying the AMI failed in %s", strings.Join(failures, "; ")))
+	}
+
+	return copies, nil
+}
+
 func validateResult(result *worker.OSBuildJobResult, jobID string) {
 	logWithId := logrus.WithField("jobId", jobID)
 	if result.JobError != nil {
@@ -60,6 +151,21 @@ func validateResult(result *worker.OSBuildJobResult, jobID string) {
 	result.Success = true
 }
 
+// exportSize returns the size of all files of an exported pipeline
+func exportSize(dir string) (uint64, error) {
+	var size uint64
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += uint64(info.Size())
+		}
+		return nil
+	})
+	return size, err
+}
+
 func (impl *OSBuildJobImpl) Run(job worker.Job) error {
 	logWithId := logrus.WithField("jobId", job.Id().String())
 	// Initialize variable needed for reporting back to osbuild-composer.
@@ -72,9 +178,18 @@ func (impl *OSBuildJobImpl) Run(job worker.Job) error {
 	}
 
 	var outputDirectory string
+	var args worker.OSBuildJob
 
 	// In all cases it is necessary to report result back to osbuild-composer worker API.
 	defer func() {
+		// results which aren't tagged yet belong to the target that was
+		// uploaded, which is always the first one of the job
+		for _, tr := range osbuildJobResult.TargetResults {
+			if tr.TargetUuid == uuid.Nil && len(args.Targets) > 0 {
+				tr.TargetUuid = args.Targets[0].Uuid
+			}
+		}
+
 		validateResult(osbuildJobResult, job.Id().String())
 
 		err := job.Update(osbuildJobResult)
@@ -94,7 +209,6 @@ func (impl *OSBuildJobImpl) Run(job worker.Job) error {
 	}
 
 	// Read the job specification
-	var args worker.OSBuildJob
 	err = job.Args(&args)
 	if err != nil {
 		return err
@@ -115,6 +229,17 @@ func (impl *OSBuildJobImpl) Run(job worker.Job) error {
 		}
 		args.Manifest = manifestJR.Manifest
 	}
+
+	// fail clearly instead of in the middle of the build if packages of a
+	// snapshot are gone
+	if len(args.CheckPackages) > 0 {
+		err = snapshot.CheckPackages(nil, args.CheckPackages)
+		if err != nil {
+			osbuildJobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorSnapshotPackages, err.Error())
+			return nil
+		}
+	}
+
 	// copy pipeline info to the result
 	osbuildJobResult.PipelineNames = args.PipelineNames
 
@@ -137,7 +262,7 @@ func (impl *OSBuildJobImpl) Run(job worker.Job) error {
 	}
 
 	// Run osbuild and handle two kinds of errors
-	osbuildJobResult.OSBuildOutput, err = RunOSBuild(args.Manifest, impl.Store, outputDirectory, exports, os.Stderr)
+	osbuildJobResult.OSBuildOutput, err = RunOSBuild(args.Manifest, impl.Store, outputDirectory, exports, impl.RepositoryMTLS, os.Stderr)
 	// First handle the case when "running" osbuild failed
 	if err != nil {
 		osbuildJobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorBuildJob, "osbuild build failed")
@@ -172,6 +297,12 @@ func (impl *OSBuildJobImpl) Run(job worker.Job) error {
 		return nil
 	}
 
+	// the size of the image shows the effect of the install options
+	osbuildJobResult.ImageSize, err = exportSize(path.Join(outputDirectory, exports[0]))
+	if err != nil {
+		logWithId.Warnf("Error computing the size of the image: %v", err)
+	}
+
 	streamOptimizedPath := ""
 
 	// NOTE: Currently OSBuild supports multiple exports, but this isn't used
@@ -243,6 +374,112 @@ func (impl *OSBuildJobImpl) Run(job worker.Job) error {
 				return nil
 			}
 
+			osbuildJobResult.TargetResults = append(osbuildJobResult.TargetResults, target.NewVMWareTargetResult(&target.VMWareTargetResultOptions{
+				Host:       options.Host,
+				Datacenter: options.Datacenter,
+				Datastore:  options.Datastore,
+				ImageName:  imageName,
+			}))
+
+			osbuildJobResult.Success = true
+			osbuildJobResult.UploadStatus = "success"
+		case *target.OpenStackTargetOptions:
+			credentials := openstack.Credentials{
+				AuthURL:     options.AuthURL,
+				Username:    options.Username,
+				Password:    options.Password,
+				ProjectName: options.ProjectName,
+				ProjectID:   options.ProjectID,
+				DomainName:  options.DomainName,
+				Region:      options.Region,
+			}
+
+			logWithId.Infof("[OpenStack] 🚀 Uploading image to Glance as '%s'", args.Targets[0].ImageName)
+			imageID, err := openstack.UploadImage(credentials, path.Join(outputDirectory, exportPath, options.Filename), openstack.ImageOptions{
+				Name:            args.Targets[0].ImageName,
+				DiskFormat:      options.DiskFormat,
+				ContainerFormat: options.ContainerFormat,
+				Visibility:      options.Visibility,
+				Properties:      options.Properties,
+			})
+			if err != nil {
+				if imageID != "" {
+					logWithId.Errorf("[OpenStack] Image %s could not be cleaned up after a failed upload", imageID)
+				}
+				osbuildJobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorUploadingImage, err.Error())
+				return nil
+			}
+			logWithId.Infof("[OpenStack] 🎉 Image uploaded: %s", imageID)
+
+			osbuildJobResult.TargetResults = append(osbuildJobResult.TargetResults, target.NewOpenStackTargetResult(&target.OpenStackTargetResultOptions{
+				ImageID: imageID,
+				Region:  options.Region,
+			}))
+
+			osbuildJobResult.Success = true
+			osbuildJobResult.UploadStatus = "success"
+		case *target.LibvirtTargetOptions:
+			conn := libvirt.Connection{URI: options.URI}
+			volumeName := args.Targets[0].ImageName
+			format := options.Format
+			if format == "" {
+				format = "qcow2"
+			}
+			if !strings.HasSuffix(volumeName, "."+format) {
+				volumeName += "." + format
+			}
+
+			logWithId.Infof("[libvirt] 🚀 Uploading image to pool '%s' as '%s'", options.Pool, volumeName)
+			volumePath, err := conn.UploadVolume(path.Join(outputDirectory, exportPath, options.Filename), libvirt.VolumeOptions{
+				Pool:   options.Pool,
+				Name:   volumeName,
+				Format: format,
+			})
+			if err != nil {
+				osbuildJobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorUploadingImage, err.Error())
+				return nil
+			}
+
+			result := &target.LibvirtTargetResultOptions{
+				Pool:       options.Pool,
+				Volume:     volumeName,
+				VolumePath: volumePath,
+			}
+
+			if options.DefineDomain {
+				memory := options.Memory
+				if memory == 0 {
+					memory = 2048
+				}
+				vcpus := options.VCPUs
+				if vcpus == 0 {
+					vcpus = 2
+				}
+
+				logWithId.Infof("[libvirt] 📝 Defining domain '%s'", args.Targets[0].ImageName)
+				err = conn.DefineDomain(options.DomainTemplate, libvirt.DomainOptions{
+					Name:       args.Targets[0].ImageName,
+					Memory:     memory,
+					VCPUs:      vcpus,
+					Arch:       common.CurrentArch(),
+					Format:     format,
+					VolumePath: volumePath,
+				})
+				if err != nil {
+					// the volume is of no use without the domain
+					deleteErr := conn.DeleteVolume(options.Pool, volumeName)
+					if deleteErr != nil {
+						logWithId.Errorf("[libvirt] Volume '%s' could not be cleaned up: %v", volumeName, deleteErr)
+					}
+					osbuildJobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorImportingImage, err.Error())
+					return nil
+				}
+				result.Domain = args.Targets[0].ImageName
+			}
+			logWithId.Info("[libvirt] 🎉 Image uploaded")
+
+			osbuildJobResult.TargetResults = append(osbuildJobResult.TargetResults, target.NewLibvirtTargetResult(result))
+
 			osbuildJobResult.Success = true
 			osbuildJobResult.UploadStatus = "success"
 		case *target.AWSTargetOptions:
@@ -274,10 +511,18 @@ func (impl *OSBuildJobImpl) Run(job worker.Job) error {
 				return nil
 			}
 
+			// the registered AMI and all copies are reported, even if some
+			// of the copies failed
+			copies, jobErr := impl.copyAMIToRegions(options, args.Targets[0].ImageName, *ami)
 			osbuildJobResult.TargetResults = append(osbuildJobResult.TargetResults, target.NewAWSTargetResult(&target.AWSTargetResultOptions{
 				Ami:    *ami,
 				Region: options.Region,
+				Copies: copies,
 			}))
+			if jobErr != nil {
+				osbuildJobResult.JobError = jobErr
+				return nil
+			}
 
 			osbuildJobResult.Success = true
 			osbuildJobResult.UploadStatus = "success"
@@ -294,18 +539,36 @@ func (impl *OSBuildJobImpl) Run(job worker.Job) error {
 			}
 			key += "-" + options.Filename
 
-			_, err = a.Upload(path.Join(outputDirectory, exportPath, options.Filename), options.Bucket, key)
-			if err != nil {
-				osbuildJobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorUploadingImage, err.Error())
+			url, jobErr := uploadToS3(a, path.Join(outputDirectory, exportPath, options.Filename), options.Bucket, key)
+			if jobErr != nil {
+				osbuildJobResult.JobError = jobErr
 				return nil
 			}
-			url, err := a.S3ObjectPresignedURL(options.Bucket, key)
+
+			osbuildJobResult.TargetResults = append(osbuildJobResult.TargetResults, target.NewAWSS3TargetResult(&target.AWSS3TargetResultOptions{URL: url}))
+
+			osbuildJobResult.Success = true
+			osbuildJobResult.UploadStatus = "success"
+		case *target.GenericS3TargetOptions:
+			a, err := impl.getAWSForEndpoint(options)
 			if err != nil {
-				osbuildJobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorUploadingImage, err.Error())
+				osbuildJobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorInvalidConfig, err.Error())
 				return nil
 			}
 
-			osbuildJobResult.TargetResults = append(osbuildJobResult.TargetResults, target.NewAWSS3TargetResult(&target.AWSS3TargetResultOptions{URL: url}))
+			key := options.Key
+			if key == "" {
+				key = uuid.New().String()
+			}
+			key += "-" + options.Filename
+
+			url, jobErr := uploadToS3(a, path.Join(outputDirectory, exportPath, options.Filename), options.Bucket, key)
+			if jobErr != nil {
+				osbuildJobResult.JobError = jobErr
+				return nil
+			}
+
+			osbuildJobResult.TargetResults = append(osbuildJobResult.TargetResults, target.NewGenericS3TargetResult(&target.GenericS3TargetResultOptions{URL: url}))
 
 			osbuildJobResult.Success = true
 			osbuildJobResult.UploadStatus = "success"
@@ -334,6 +597,10 @@ func (impl *OSBuildJobImpl) Run(job worker.Job) error {
 				return nil
 			}
 
+			osbuildJobResult.TargetResults = append(osbuildJobResult.TargetResults, target.NewAzureTargetResult(&target.AzureTargetResultOptions{
+				URL: metadata.URL(),
+			}))
+
 			osbuildJobResult.Success = true
 			osbuildJobResult.UploadStatus = "success"
 		case *target.GCPTargetOptions:
@@ -505,6 +772,7 @@ func (impl *OSBuildJobImpl) Run(job worker.Job) error {
 				blobName,
 				args.Targets[0].ImageName,
 				options.Location,
+				azure.HyperVGenerationType(options.HyperVGeneration),
 			)
 			if err != nil {
 				osbuildJobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorImportingImage, fmt.Sprintf("registering the image failed: %v", err))
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/osbuild/osbuild-composer/internal/rpmmd"
+	"github.com/osbuild/osbuild-composer/internal/worker"
+)
+
+type SearchJobImpl struct {
+	RPMMDCache       string
+	RPMMDCacheConfig rpmmd.CacheConfig
+}
+
+func (impl *SearchJobImpl) search(rpmMD rpmmd.RPMMD, patterns []string, repos []rpmmd.RepoConfig, modulePlatformID, arch, releasever string) (rpmmd.PackageList, map[string]string, error) {
+	packages, checksums, err := rpmMD.FetchMetadata(repos, modulePlatformID, arch, releasever)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	found, err := packages.Search(patterns...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return found, rpmmd.NamedChecksums(repos, checksums), nil
+}
+
+func (impl *SearchJobImpl) Run(job worker.Job) error {
+	var args worker.SearchJob
+	err := job.Args(&args)
+	if err != nil {
+		return err
+	}
+
+	// the statistics of the cache are reported for this job only
+	cache := rpmmd.NewCacheManager(impl.RPMMDCache, impl.RPMMDCacheConfig)
+	cache.AddRefreshRequests(args.CacheRefresh)
+
+	var result worker.SearchJobResult
+	result.Packages, result.RepoChecksums, err = impl.search(rpmmd.NewRPMMDWithCache(cache), args.Patterns, args.Repos, args.ModulePlatformID, args.Arch, args.Releasever)
+	if err != nil {
+		result.JobError = dnfJobError(err)
+	}
+	result.CacheStats = cache.Stats()
+
+	err = job.Update(&result)
+	if err != nil {
+		return fmt.Errorf("Error reporting job result: %v", err)
+	}
+
+	return nil
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/osbuild/osbuild-composer/internal/common"
+	"github.com/osbuild/osbuild-composer/internal/rpmmd"
 	"github.com/osbuild/osbuild-composer/internal/upload/azure"
 	"github.com/osbuild/osbuild-composer/internal/upload/koji"
 	"github.com/osbuild/osbuild-composer/internal/worker"
@@ -138,6 +139,21 @@ func main() {
 		AWS *struct {
 			Credentials string `toml:"credentials"`
 		} `toml:"aws"`
+		GenericS3 *struct {
+			Credentials         string `toml:"credentials"`
+			CABundle            string `toml:"ca_bundle"`
+			SkipSSLVerification bool   `toml:"skip_ssl_verification"`
+		} `toml:"generic_s3"`
+		RepositoryMTLS *struct {
+			CA             string `toml:"ca"`
+			MTLSClientKey  string `toml:"mtls_client_key"`
+			MTLSClientCert string `toml:"mtls_client_cert"`
+			Proxy          string `toml:"proxy"`
+		} `toml:"repository_mtls"`
+		MetadataCache *struct {
+			TTL     string `toml:"ttl"`
+			MaxSize int    `toml:"max_size"`
+		} `toml:"metadata_cache"`
 		Authentication *struct {
 			OAuthURL         string `toml:"oauth_url"`
 			OfflineTokenPath string `toml:"offline_token"`
@@ -185,6 +201,22 @@ func main() {
 	output := path.Join(cacheDirectory, "output")
 	_ = os.Mkdir(output, os.ModeDir)
 
+	// Limits of the repository metadata cache used by depsolve and search
+	// jobs. They default to the ones of composer.
+	rpmmdCacheConfig := rpmmd.DefaultCacheConfig
+	if config.MetadataCache != nil {
+		if config.MetadataCache.TTL != "" {
+			rpmmdCacheConfig.TTL, err = time.ParseDuration(config.MetadataCache.TTL)
+			if err != nil {
+				logrus.Fatalf("Unable to parse metadata cache TTL: %v", err)
+			}
+		}
+		if config.MetadataCache.MaxSize < 0 {
+			logrus.Fatalf("Invalid metadata cache size: %d", config.MetadataCache.MaxSize)
+		}
+		rpmmdCacheConfig.MaxSize = int64(config.MetadataCache.MaxSize)
+	}
+
 	kojiServers := make(map[string]koji.GSSAPICredentials)
 	for server, creds := range config.KojiServers {
 		if creds.Kerberos == nil {
@@ -197,6 +229,16 @@ func main() {
 		}
 	}
 
+	var repositoryMTLS *RepositoryMTLSConfig
+	if config.RepositoryMTLS != nil {
+		repositoryMTLS = &RepositoryMTLSConfig{
+			CA:         config.RepositoryMTLS.CA,
+			ClientKey:  config.RepositoryMTLS.MTLSClientKey,
+			ClientCert: config.RepositoryMTLS.MTLSClientCert,
+			Proxy:      config.RepositoryMTLS.Proxy,
+		}
+	}
+
 	var client *worker.Client
 	if unix {
 		client = worker.NewClientUnix(address, config.BasePath)
@@ -281,13 +323,29 @@ func main() {
 		awsCredentials = config.AWS.Credentials
 	}
 
-	// depsolve jobs can be done during other jobs
+	// Credentials for S3-compatible object storages which are not AWS. Same
+	// fallbacks as for AWS apply if they are not set.
+	var genericS3Credentials = ""
+	var genericS3CABundle = ""
+	var genericS3SkipSSLVerification = false
+	if config.GenericS3 != nil {
+		genericS3Credentials = config.GenericS3.Credentials
+		genericS3CABundle = config.GenericS3.CABundle
+		genericS3SkipSSLVerification = config.GenericS3.SkipSSLVerification
+	}
+
+	// depsolve and search jobs can be done during other jobs
 	depsolveCtx, depsolveCtxCancel := context.WithCancel(context.Background())
 	defer depsolveCtxCancel()
 	go func() {
 		jobImpls := map[string]JobImplementation{
 			"depsolve": &DepsolveJobImpl{
-				RPMMDCache: rpmmd_cache,
+				RPMMDCache:       rpmmd_cache,
+				RPMMDCacheConfig: rpmmdCacheConfig,
+			},
+			"search": &SearchJobImpl{
+				RPMMDCache:       rpmmd_cache,
+				RPMMDCacheConfig: rpmmdCacheConfig,
 			},
 		}
 		acceptedJobTypes := []string{}
@@ -321,11 +379,19 @@ func main() {
 			GCPCreds:    gcpCredentials,
 			AzureCreds:  azureCredentials,
 			AWSCreds:    awsCredentials,
+			S3Creds:     genericS3Credentials,
+
+			S3CABundle:            genericS3CABundle,
+			S3SkipSSLVerification: genericS3SkipSSLVerification,
+
+			RepositoryMTLS: repositoryMTLS,
 		},
 		"osbuild-koji": &OSBuildKojiJobImpl{
 			Store:       store,
 			Output:      output,
 			KojiServers: kojiServers,
+
+			RepositoryMTLS: repositoryMTLS,
 		},
 		"koji-init": &KojiInitJobImpl{
 			KojiServers: kojiServers,
@@ -5,24 +5,61 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
 
 	"github.com/osbuild/osbuild-composer/internal/distro"
 	osbuild "github.com/osbuild/osbuild-composer/internal/osbuild2"
 )
 
+// RepositoryMTLSConfig holds the TLS client and proxy settings osbuild uses
+// to download packages. The credentials of the repositories are never sent
+// with the jobs, packages of repositories requiring a client certificate
+// only name the org.osbuild.mtls secrets, which are resolved from this
+// configuration of the worker.
+type RepositoryMTLSConfig struct {
+	CA         string
+	ClientKey  string
+	ClientCert string
+	Proxy      string
+}
+
+// Environ returns the environment variables passing the configuration to
+// the osbuild curl source.
+func (c *RepositoryMTLSConfig) Environ() []string {
+	if c == nil {
+		return nil
+	}
+
+	var env []string
+	if c.CA != "" {
+		env = append(env, "OSBUILD_SOURCES_CURL_SSL_CA_CERT="+c.CA)
+	}
+	if c.ClientKey != "" {
+		env = append(env, "OSBUILD_SOURCES_CURL_SSL_CLIENT_KEY="+c.ClientKey)
+	}
+	if c.ClientCert != "" {
+		env = append(env, "OSBUILD_SOURCES_CURL_SSL_CLIENT_CERT="+c.ClientCert)
+	}
+	if c.Proxy != "" {
+		env = append(env, "OSBUILD_SOURCES_CURL_PROXY="+c.Proxy)
+	}
+	return env
+}
+
 // Run an instance of osbuild, returning a parsed osbuild.Result.
 //
 // Note that osbuild returns non-zero when the pipeline fails. This function
 // does not return an error in this case. Instead, the failure is communicated
 // with its corresponding logs through osbuild.Result.
-func RunOSBuild(manifest distro.Manifest, store, outputDirectory string, exports []string, errorWriter io.Writer) (*osbuild.Result, error) {
+func RunOSBuild(manifest distro.Manifest, store, outputDirectory string, exports []string, mtls *RepositoryMTLSConfig, errorWriter io.Writer) (*osbuild.Result, error) {
 	cmd := exec.Command(
 		"osbuild",
 		"--store", store,
 		"--output-directory", outputDirectory,
 		"--json", "-",
 	)
+	cmd.Env = append(os.Environ(), mtls.Environ()...)
 
 	for _, export := range exports {
 		cmd.Args = append(cmd.Args, "--export", export)
@@ -74,6 +74,12 @@ func (b *Blueprint) Initialize() error {
 	if err != nil {
 		return fmt.Errorf("Invalid 'version', must use Semantic Versioning: %s", err.Error())
 	}
+	if err := b.Customizations.validateModuleStreams(); err != nil {
+		return fmt.Errorf("Invalid 'module_streams': %s", err.Error())
+	}
+	if err := b.Customizations.validateContainer(); err != nil {
+		return fmt.Errorf("Invalid 'container': %s", err.Error())
+	}
 	return nil
 }
 
@@ -92,7 +98,8 @@ func (b *Blueprint) BumpVersion(old string) {
 }
 
 // packages, modules, and groups all resolve to rpm packages right now. This
-// function returns a combined list of "name-version" strings.
+// function returns a combined list of "name-version" strings. The profiles of
+// module streams are included as "@name:stream/profile" specs.
 func (b *Blueprint) GetPackages() []string {
 	packages := []string{}
 	for _, pkg := range b.Packages {
@@ -104,6 +111,11 @@ func (b *Blueprint) GetPackages() []string {
 	for _, group := range b.Groups {
 		packages = append(packages, "@"+group.Name)
 	}
+	for _, module := range b.Customizations.GetModuleStreams() {
+		for _, profile := range module.Profiles {
+			packages = append(packages, "@"+module.Spec()+"/"+profile)
+		}
+	}
 
 	kc := b.Customizations.GetKernel()
 	kpkg := Package{Name: kc.Name}
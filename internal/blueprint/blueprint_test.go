@@ -89,6 +89,33 @@ func TestBlueprintInitialize(t *testing.T) {
 		{Blueprint{Name: "bp-test-5", Description: "Invalid version 5", Version: "foo"}, true},
 		{Blueprint{Name: "bp-test-7", Description: "Zero version", Version: "0.0.0"}, false},
 		{Blueprint{Name: "bp-test-8", Description: "X.Y.Z version", Version: "2.1.3"}, false},
+		{Blueprint{Name: "bp-test-9", Description: "Module stream", Customizations: &Customizations{
+			ModuleStreams: []ModuleStreamCustomization{{Name: "postgresql", Stream: "13"}, {Name: "nodejs", State: "disabled"}},
+		}}, false},
+		{Blueprint{Name: "bp-test-10", Description: "Module without stream", Customizations: &Customizations{
+			ModuleStreams: []ModuleStreamCustomization{{Name: "postgresql"}},
+		}}, true},
+		{Blueprint{Name: "bp-test-11", Description: "Disabled module with profile", Customizations: &Customizations{
+			ModuleStreams: []ModuleStreamCustomization{{Name: "nodejs", State: "disabled", Profiles: []string{"common"}}},
+		}}, true},
+		{Blueprint{Name: "bp-test-12", Description: "Invalid module state", Customizations: &Customizations{
+			ModuleStreams: []ModuleStreamCustomization{{Name: "nodejs", Stream: "16", State: "installed"}},
+		}}, true},
+		{Blueprint{Name: "bp-test-13", Description: "Duplicate module", Customizations: &Customizations{
+			ModuleStreams: []ModuleStreamCustomization{{Name: "nodejs", Stream: "16"}, {Name: "nodejs", Stream: "14"}},
+		}}, true},
+		{Blueprint{Name: "bp-test-14", Description: "Container", Customizations: &Customizations{
+			Container: &ContainerCustomization{Env: []string{"LANG=C.UTF-8", "EMPTY="}, ExposedPorts: []string{"80", "8080/tcp", "53/udp"}},
+		}}, false},
+		{Blueprint{Name: "bp-test-15", Description: "Container env without name", Customizations: &Customizations{
+			Container: &ContainerCustomization{Env: []string{"=value"}},
+		}}, true},
+		{Blueprint{Name: "bp-test-16", Description: "Container invalid port", Customizations: &Customizations{
+			Container: &ContainerCustomization{ExposedPorts: []string{"65536"}},
+		}}, true},
+		{Blueprint{Name: "bp-test-17", Description: "Container invalid protocol", Customizations: &Customizations{
+			Container: &ContainerCustomization{ExposedPorts: []string{"8080/http"}},
+		}}, true},
 	}
 
 	for _, c := range cases {
@@ -140,6 +167,35 @@ func TestGetPackages(t *testing.T) {
 	assert.ElementsMatch(t, []string{"tmux-1.2", "openssh-server", "@anaconda-tools", "kernel"}, Received_packages)
 }
 
+func TestGetPackagesModuleProfiles(t *testing.T) {
+	blueprint := `
+name = "module-test"
+
+[[customizations.module_streams]]
+name = "postgresql"
+stream = "13"
+profiles = ["server", "client"]
+
+[[customizations.module_streams]]
+name = "nodejs"
+stream = "16"
+
+[[customizations.module_streams]]
+name = "ruby"
+state = "disabled"
+`
+	var bp Blueprint
+	err := toml.Unmarshal([]byte(blueprint), &bp)
+	require.NoError(t, err)
+	require.NoError(t, bp.Initialize())
+
+	assert.ElementsMatch(t, []string{"@postgresql:13/server", "@postgresql:13/client", "kernel"}, bp.GetPackages())
+
+	enabled, disabled := bp.Customizations.GetModuleSpecs()
+	assert.Equal(t, []string{"postgresql:13", "nodejs:16"}, enabled)
+	assert.Equal(t, []string{"ruby"}, disabled)
+}
+
 func TestKernelNameCustomization(t *testing.T) {
 	kernels := []string{"kernel", "kernel-debug", "kernel-rt"}
 
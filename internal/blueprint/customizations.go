@@ -4,22 +4,27 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/osbuild/osbuild-composer/internal/common"
 )
 
 type Customizations struct {
-	Hostname           *string                   `json:"hostname,omitempty" toml:"hostname,omitempty"`
-	Kernel             *KernelCustomization      `json:"kernel,omitempty" toml:"kernel,omitempty"`
-	SSHKey             []SSHKeyCustomization     `json:"sshkey,omitempty" toml:"sshkey,omitempty"`
-	User               []UserCustomization       `json:"user,omitempty" toml:"user,omitempty"`
-	Group              []GroupCustomization      `json:"group,omitempty" toml:"group,omitempty"`
-	Timezone           *TimezoneCustomization    `json:"timezone,omitempty" toml:"timezone,omitempty"`
-	Locale             *LocaleCustomization      `json:"locale,omitempty" toml:"locale,omitempty"`
-	Firewall           *FirewallCustomization    `json:"firewall,omitempty" toml:"firewall,omitempty"`
-	Services           *ServicesCustomization    `json:"services,omitempty" toml:"services,omitempty"`
-	Filesystem         []FilesystemCustomization `json:"filesystem,omitempty" toml:"filesystem,omitempty"`
-	InstallationDevice string                    `json:"installation_device,omitempty" toml:"installation_device,omitempty"`
+	Hostname           *string                     `json:"hostname,omitempty" toml:"hostname,omitempty"`
+	Kernel             *KernelCustomization        `json:"kernel,omitempty" toml:"kernel,omitempty"`
+	SSHKey             []SSHKeyCustomization       `json:"sshkey,omitempty" toml:"sshkey,omitempty"`
+	User               []UserCustomization         `json:"user,omitempty" toml:"user,omitempty"`
+	Group              []GroupCustomization        `json:"group,omitempty" toml:"group,omitempty"`
+	Timezone           *TimezoneCustomization      `json:"timezone,omitempty" toml:"timezone,omitempty"`
+	Locale             *LocaleCustomization        `json:"locale,omitempty" toml:"locale,omitempty"`
+	Firewall           *FirewallCustomization      `json:"firewall,omitempty" toml:"firewall,omitempty"`
+	Services           *ServicesCustomization      `json:"services,omitempty" toml:"services,omitempty"`
+	Filesystem         []FilesystemCustomization   `json:"filesystem,omitempty" toml:"filesystem,omitempty"`
+	InstallationDevice string                      `json:"installation_device,omitempty" toml:"installation_device,omitempty"`
+	ModuleStreams      []ModuleStreamCustomization `json:"module_streams,omitempty" toml:"module_streams,omitempty"`
+	Install            *InstallCustomization       `json:"install,omitempty" toml:"install,omitempty"`
+	Container          *ContainerCustomization     `json:"container,omitempty" toml:"container,omitempty"`
 }
 
 type KernelCustomization struct {
@@ -74,6 +79,74 @@ type ServicesCustomization struct {
 	Disabled []string `json:"disabled,omitempty" toml:"disabled,omitempty"`
 }
 
+// ModuleStreamCustomization enables a stream of a module, optionally
+// installing some of its profiles, or disables a module altogether.
+type ModuleStreamCustomization struct {
+	Name     string   `json:"name" toml:"name"`
+	Stream   string   `json:"stream,omitempty" toml:"stream,omitempty"`
+	Profiles []string `json:"profiles,omitempty" toml:"profiles,omitempty"`
+	// "enabled" (the default) or "disabled"
+	State string `json:"state,omitempty" toml:"state,omitempty"`
+}
+
+const (
+	ModuleStateEnabled  = "enabled"
+	ModuleStateDisabled = "disabled"
+)
+
+// Spec returns the "name:stream" module spec understood by dnf.
+func (m ModuleStreamCustomization) Spec() string {
+	if m.Stream == "" {
+		return m.Name
+	}
+	return m.Name + ":" + m.Stream
+}
+
+func (m ModuleStreamCustomization) validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("module stream customization is missing the name of the module")
+	}
+	switch m.State {
+	case "", ModuleStateEnabled:
+		if m.Stream == "" {
+			return fmt.Errorf("module '%s' is missing the stream to enable", m.Name)
+		}
+	case ModuleStateDisabled:
+		if len(m.Profiles) > 0 {
+			return fmt.Errorf("profiles of the disabled module '%s' cannot be installed", m.Name)
+		}
+	default:
+		return fmt.Errorf("invalid state '%s' of module '%s', must be '%s' or '%s'", m.State, m.Name, ModuleStateEnabled, ModuleStateDisabled)
+	}
+	return nil
+}
+
+// InstallCustomization controls what is installed with the packages.
+type InstallCustomization struct {
+	// Install the weak dependencies (Recommends) of packages, true when unset
+	WeakDeps    *bool `json:"weak_deps,omitempty" toml:"weak_deps,omitempty"`
+	ExcludeDocs bool  `json:"exclude_docs,omitempty" toml:"exclude_docs,omitempty"`
+	// Only install the translations of these languages, e.g. "en_US"
+	InstallLangs []string `json:"install_langs,omitempty" toml:"install_langs,omitempty"`
+}
+
+// ContainerCustomization sets the configuration of container images, i.e.
+// how a container created from the image is run.
+type ContainerCustomization struct {
+	Entrypoint []string `json:"entrypoint,omitempty" toml:"entrypoint,omitempty"`
+	Cmd        []string `json:"cmd,omitempty" toml:"cmd,omitempty"`
+	// Environment variables in the "NAME=value" form
+	Env []string `json:"env,omitempty" toml:"env,omitempty"`
+	// Ports in the "port/protocol" form, the protocol defaults to tcp
+	ExposedPorts []string          `json:"exposed_ports,omitempty" toml:"exposed_ports,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty" toml:"labels,omitempty"`
+	User         string            `json:"user,omitempty" toml:"user,omitempty"`
+	WorkingDir   string            `json:"working_dir,omitempty" toml:"working_dir,omitempty"`
+	// The CPU architecture recorded in the image, defaults to the
+	// architecture the image is built for
+	Architecture string `json:"architecture,omitempty" toml:"architecture,omitempty"`
+}
+
 type FilesystemCustomization struct {
 	Mountpoint string `json:"mountpoint,omitempty" toml:"mountpoint,omitempty"`
 	MinSize    uint64 `json:"minsize,omitempty" toml:"size,omitempty"`
@@ -337,3 +410,101 @@ func (c *Customizations) GetInstallationDevice() string {
 	}
 	return c.InstallationDevice
 }
+
+// GetInstall returns the install customization, with the defaults filled in.
+func (c *Customizations) GetInstall() *InstallCustomization {
+	install := InstallCustomization{}
+	if c != nil && c.Install != nil {
+		install = *c.Install
+	}
+	if install.WeakDeps == nil {
+		install.WeakDeps = common.BoolToPtr(true)
+	}
+	return &install
+}
+
+func (c *Customizations) GetContainer() *ContainerCustomization {
+	if c == nil {
+		return nil
+	}
+	return c.Container
+}
+
+// GetModuleStreams returns the module stream customizations, with the
+// default state filled in.
+func (c *Customizations) GetModuleStreams() []ModuleStreamCustomization {
+	if c == nil {
+		return nil
+	}
+
+	modules := make([]ModuleStreamCustomization, 0, len(c.ModuleStreams))
+	for _, m := range c.ModuleStreams {
+		if m.State == "" {
+			m.State = ModuleStateEnabled
+		}
+		modules = append(modules, m)
+	}
+	return modules
+}
+
+// GetModuleSpecs returns the "name:stream" specs of the module streams to
+// enable and the names of the modules to disable.
+func (c *Customizations) GetModuleSpecs() ([]string, []string) {
+	var enabled, disabled []string
+	for _, m := range c.GetModuleStreams() {
+		if m.State == ModuleStateDisabled {
+			disabled = append(disabled, m.Name)
+		} else {
+			enabled = append(enabled, m.Spec())
+		}
+	}
+	return enabled, disabled
+}
+
+// validateModuleStreams returns an error if a module stream customization
+// is incomplete or if a module is customized more than once.
+func (c *Customizations) validateModuleStreams() error {
+	if c == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for _, m := range c.ModuleStreams {
+		if err := m.validate(); err != nil {
+			return err
+		}
+		if seen[m.Name] {
+			return fmt.Errorf("module '%s' is customized more than once", m.Name)
+		}
+		seen[m.Name] = true
+	}
+	return nil
+}
+
+// validateContainer returns an error if an environment variable or an exposed
+// port of the container customization is malformed.
+func (c *Customizations) validateContainer() error {
+	container := c.GetContainer()
+	if container == nil {
+		return nil
+	}
+
+	for _, env := range container.Env {
+		if strings.Index(env, "=") < 1 {
+			return fmt.Errorf("environment variable '%s' must be in the NAME=value form", env)
+		}
+	}
+	for _, port := range container.ExposedPorts {
+		number, protocol := port, "tcp"
+		if i := strings.Index(port, "/"); i >= 0 {
+			number, protocol = port[:i], port[i+1:]
+		}
+		if n, err := strconv.ParseUint(number, 10, 16); err != nil || n == 0 {
+			return fmt.Errorf("exposed port '%s' has an invalid port number", port)
+		}
+		if protocol != "tcp" && protocol != "udp" && protocol != "sctp" {
+			return fmt.Errorf("exposed port '%s' has an invalid protocol, must be tcp, udp or sctp", port)
+		}
+	}
+	return nil
+}
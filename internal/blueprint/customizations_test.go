@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/osbuild/osbuild-composer/internal/common"
 )
 
 func TestCheckAllowed(t *testing.T) {
@@ -371,3 +373,59 @@ func TestGetFilesystemsMinSizeNonSectorSize(t *testing.T) {
 
 	assert.EqualValues(t, uint64(5632), retFilesystemsSize)
 }
+
+func TestGetModuleStreams(t *testing.T) {
+	expected := []ModuleStreamCustomization{
+		{Name: "postgresql", Stream: "13", Profiles: []string{"server"}, State: "enabled"},
+		{Name: "nodejs", State: "disabled"},
+	}
+
+	TestCustomizations := Customizations{
+		ModuleStreams: []ModuleStreamCustomization{
+			{Name: "postgresql", Stream: "13", Profiles: []string{"server"}},
+			{Name: "nodejs", State: "disabled"},
+		},
+	}
+
+	assert.Equal(t, expected, TestCustomizations.GetModuleStreams())
+
+	var nilCustomizations *Customizations
+	assert.Nil(t, nilCustomizations.GetModuleStreams())
+	enabled, disabled := nilCustomizations.GetModuleSpecs()
+	assert.Nil(t, enabled)
+	assert.Nil(t, disabled)
+}
+
+func TestGetInstall(t *testing.T) {
+	var nilCustomizations *Customizations
+	install := nilCustomizations.GetInstall()
+	assert.True(t, *install.WeakDeps)
+	assert.False(t, install.ExcludeDocs)
+
+	TestCustomizations := Customizations{
+		Install: &InstallCustomization{
+			WeakDeps:     common.BoolToPtr(false),
+			ExcludeDocs:  true,
+			InstallLangs: []string{"en_US"},
+		},
+	}
+	install = TestCustomizations.GetInstall()
+	assert.False(t, *install.WeakDeps)
+	assert.True(t, install.ExcludeDocs)
+	assert.Equal(t, []string{"en_US"}, install.InstallLangs)
+}
+
+func TestGetContainer(t *testing.T) {
+	var nilCustomizations *Customizations
+	assert.Nil(t, nilCustomizations.GetContainer())
+
+	expected := &ContainerCustomization{
+		Entrypoint:   []string{"/usr/bin/bash"},
+		ExposedPorts: []string{"8080/tcp"},
+		Labels:       map[string]string{"name": "base"},
+	}
+	TestCustomizations := Customizations{
+		Container: expected,
+	}
+	assert.Equal(t, expected, TestCustomizations.GetContainer())
+}
@@ -133,6 +133,36 @@ func GetComposeInfoV0(socket *http.Client, uuid string) (weldr.ComposeInfoRespon
 	return info, nil, nil
 }
 
+// GetComposeInfoV1 returns detailed information about the selected compose,
+// including its uploads and their results
+func GetComposeInfoV1(socket *http.Client, uuid string) (weldr.ComposeInfoResponseV0, *APIResponse, error) {
+	body, resp, err := GetRaw(socket, "GET", "/api/v1/compose/info/"+uuid)
+	if resp != nil || err != nil {
+		return weldr.ComposeInfoResponseV0{}, resp, err
+	}
+	var info weldr.ComposeInfoResponseV0
+	err = json.Unmarshal(body, &info)
+	if err != nil {
+		return weldr.ComposeInfoResponseV0{}, nil, err
+	}
+	return info, nil, nil
+}
+
+// GetUploadInfoV1 returns information about the selected upload, including
+// its result once it finished
+func GetUploadInfoV1(socket *http.Client, uuid string) (weldr.UploadInfoResponseV1, *APIResponse, error) {
+	body, resp, err := GetRaw(socket, "GET", "/api/v1/upload/info/"+uuid)
+	if resp != nil || err != nil {
+		return weldr.UploadInfoResponseV1{}, resp, err
+	}
+	var info weldr.UploadInfoResponseV1
+	err = json.Unmarshal(body, &info)
+	if err != nil {
+		return weldr.UploadInfoResponseV1{}, nil, err
+	}
+	return info, nil, nil
+}
+
 // GetComposeQueueV0 returns the list of composes in the queue
 func GetComposeQueueV0(socket *http.Client) (weldr.ComposeQueueResponseV0, *APIResponse, error) {
 	body, resp, err := GetRaw(socket, "GET", "/api/v0/compose/queue")
@@ -146,6 +146,17 @@ func TestUnknownComposeInfoV0(t *testing.T) {
 	require.Contains(t, resp.Errors[0].Msg, "c91818f9-8025-47af-89d2-f030d7000c2c")
 }
 
+// Test upload info for unknown uuid
+func TestUnknownUploadInfoV1(t *testing.T) {
+	_, resp, err := GetUploadInfoV1(testState.socket, "c91818f9-8025-47af-89d2-f030d7000c2c")
+	require.NoError(t, err, "failed with a client error")
+	require.NotNil(t, resp)
+	require.False(t, resp.Status)
+	require.Equal(t, 1, len(resp.Errors))
+	require.Equal(t, "UnknownUUID", resp.Errors[0].ID)
+	require.Contains(t, resp.Errors[0].Msg, "c91818f9-8025-47af-89d2-f030d7000c2c")
+}
+
 // Test compose metadata for unknown uuid
 // TODO osbuild-composer has not implemented compose/metadata yet
 
@@ -1,7 +1,9 @@
 package awscloud
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net/http"
 	"os"
 	"time"
 
@@ -44,6 +46,59 @@ func New(region string, accessKeyID string, accessKey string, sessionToken strin
 	return newAwsFromCreds(credentials.NewStaticCredentials(accessKeyID, accessKey, sessionToken), region)
 }
 
+// Create a new session from the credentials and the region and returns an *AWS object initialized with it.
+// The session is configured to use the given S3-compatible endpoint instead of the AWS one.
+func newAwsFromCredsWithEndpoint(creds *credentials.Credentials, region, endpoint, caBundle string, skipSSLVerification, pathStyle bool) (*AWS, error) {
+	sessionOptions := session.Options{
+		Config: aws.Config{
+			Credentials:      creds,
+			Region:           aws.String(region),
+			Endpoint:         aws.String(endpoint),
+			S3ForcePathStyle: aws.Bool(pathStyle),
+		},
+	}
+
+	if caBundle != "" {
+		caBundleReader, err := os.Open(caBundle)
+		if err != nil {
+			return nil, err
+		}
+		defer caBundleReader.Close()
+		sessionOptions.CustomCABundle = caBundleReader
+	}
+
+	if skipSSLVerification {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} // #nosec G402
+		sessionOptions.Config.HTTPClient = &http.Client{
+			Transport: transport,
+		}
+	}
+
+	// Create a Session with a custom region and endpoint
+	sess, err := session.NewSessionWithOptions(sessionOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AWS{
+		uploader: s3manager.NewUploader(sess),
+		ec2:      ec2.New(sess),
+		s3:       s3.New(sess),
+	}, nil
+}
+
+// Initialize a new AWS object targeting a specific S3-compatible endpoint from individual bits. SessionToken is optional
+func NewForEndpoint(endpoint, region, accessKeyID, accessKey, sessionToken, caBundle string, skipSSLVerification, pathStyle bool) (*AWS, error) {
+	return newAwsFromCredsWithEndpoint(credentials.NewStaticCredentials(accessKeyID, accessKey, sessionToken), region, endpoint, caBundle, skipSSLVerification, pathStyle)
+}
+
+// Initializes a new AWS object targeting a specific S3-compatible endpoint with the credentials info found at filename's location.
+// The credential files should match the AWS format, see NewFromFile.
+func NewForEndpointFromFile(filename, endpoint, region, caBundle string, skipSSLVerification, pathStyle bool) (*AWS, error) {
+	return newAwsFromCredsWithEndpoint(credentials.NewSharedCredentials(filename, "default"), region, endpoint, caBundle, skipSSLVerification, pathStyle)
+}
+
 // Initializes a new AWS object with the credentials info found at filename's location.
 // The credential files should match the AWS format, such as:
 // [default]
@@ -199,41 +254,14 @@ func (a *AWS) Register(name, bucket, key string, shareWith []string, rpmArch str
 	snapshotID := importOutput.ImportSnapshotTasks[0].SnapshotTaskDetail.SnapshotId
 
 	if len(shareWith) > 0 {
-		logrus.Info("[AWS] 🎥 Sharing ec2 snapshot")
-		var userIds []*string
-		for _, v := range shareWith {
-			// Implicit memory alasing doesn't couse any bug in this case
-			/* #nosec G601 */
-			userIds = append(userIds, &v)
-		}
-		_, err := a.ec2.ModifySnapshotAttribute(
-			&ec2.ModifySnapshotAttributeInput{
-				Attribute:     aws.String("createVolumePermission"),
-				OperationType: aws.String("add"),
-				SnapshotId:    snapshotID,
-				UserIds:       userIds,
-			},
-		)
+		err = a.shareSnapshot(snapshotID, shareWith)
 		if err != nil {
-			logrus.Warnf("[AWS] 📨 Error sharing ec2 snapshot: %v", err)
 			return nil, err
 		}
-		logrus.Info("[AWS] 📨 Shared ec2 snapshot")
 	}
 
 	// Tag the snapshot with the image name.
-	req, _ := a.ec2.CreateTagsRequest(
-		&ec2.CreateTagsInput{
-			Resources: []*string{snapshotID},
-			Tags: []*ec2.Tag{
-				{
-					Key:   aws.String("Name"),
-					Value: aws.String(name),
-				},
-			},
-		},
-	)
-	err = req.Send()
+	err = a.tagWithName([]*string{snapshotID}, name)
 	if err != nil {
 		return nil, err
 	}
@@ -263,9 +291,78 @@ func (a *AWS) Register(name, bucket, key string, shareWith []string, rpmArch str
 	logrus.Infof("[AWS] 🎉 AMI registered: %s", *registerOutput.ImageId)
 
 	// Tag the image with the image name.
-	req, _ = a.ec2.CreateTagsRequest(
+	err = a.tagWithName([]*string{registerOutput.ImageId}, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(shareWith) > 0 {
+		err = a.shareImage(registerOutput.ImageId, shareWith)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return registerOutput.ImageId, nil
+}
+
+// shareSnapshot allows the given accounts to create volumes from the snapshot.
+func (a *AWS) shareSnapshot(snapshotID *string, shareWith []string) error {
+	logrus.Info("[AWS] 🎥 Sharing ec2 snapshot")
+	var userIds []*string
+	for _, v := range shareWith {
+		// Implicit memory alasing doesn't couse any bug in this case
+		/* #nosec G601 */
+		userIds = append(userIds, &v)
+	}
+	_, err := a.ec2.ModifySnapshotAttribute(
+		&ec2.ModifySnapshotAttributeInput{
+			Attribute:     aws.String("createVolumePermission"),
+			OperationType: aws.String("add"),
+			SnapshotId:    snapshotID,
+			UserIds:       userIds,
+		},
+	)
+	if err != nil {
+		logrus.Warnf("[AWS] 📨 Error sharing ec2 snapshot: %v", err)
+		return err
+	}
+	logrus.Info("[AWS] 📨 Shared ec2 snapshot")
+	return nil
+}
+
+// shareImage allows the given accounts to launch instances from the AMI.
+func (a *AWS) shareImage(imageID *string, shareWith []string) error {
+	logrus.Info("[AWS] 💿 Sharing ec2 AMI")
+	var launchPerms []*ec2.LaunchPermission
+	for _, id := range shareWith {
+		launchPerms = append(launchPerms, &ec2.LaunchPermission{
+			// Implicit memory alasing doesn't couse any bug in this case
+			/* #nosec G601 */
+			UserId: &id,
+		})
+	}
+	_, err := a.ec2.ModifyImageAttribute(
+		&ec2.ModifyImageAttributeInput{
+			ImageId: imageID,
+			LaunchPermission: &ec2.LaunchPermissionModifications{
+				Add: launchPerms,
+			},
+		},
+	)
+	if err != nil {
+		logrus.Warnf("[AWS] 📨 Error sharing AMI: %v", err)
+		return err
+	}
+	logrus.Info("[AWS] 💿 Shared AMI")
+	return nil
+}
+
+// tagWithName sets the Name tag of the given resources.
+func (a *AWS) tagWithName(resources []*string, name string) error {
+	req, _ := a.ec2.CreateTagsRequest(
 		&ec2.CreateTagsInput{
-			Resources: []*string{registerOutput.ImageId},
+			Resources: resources,
 			Tags: []*ec2.Tag{
 				{
 					Key:   aws.String("Name"),
@@ -274,37 +371,81 @@ func (a *AWS) Register(name, bucket, key string, shareWith []string, rpmArch str
 			},
 		},
 	)
-	err = req.Send()
+	return req.Send()
+}
+
+// CopyImage copies the AMI from sourceRegion into the region of this AWS
+// object and waits until the copy is available. The copy and its snapshots
+// are tagged with the image name and shared with the given accounts, the
+// same way Register does it for the original AMI.
+func (a *AWS) CopyImage(name, sourceImageID, sourceRegion string, shareWith []string) (*string, error) {
+	logrus.Infof("[AWS] 📋 Copying AMI %s from %s to %s", sourceImageID, sourceRegion, *a.ec2.Config.Region)
+	copyOutput, err := a.ec2.CopyImage(
+		&ec2.CopyImageInput{
+			Name:          aws.String(name),
+			SourceImageId: aws.String(sourceImageID),
+			SourceRegion:  aws.String(sourceRegion),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("[AWS] 🚚 Waiting for AMI copy to become available: %s", *copyOutput.ImageId)
+	describeInput := &ec2.DescribeImagesInput{
+		ImageIds: []*string{copyOutput.ImageId},
+	}
+	// Copying an image between regions takes considerably longer than
+	// the default waiter allows, wait for up to one hour.
+	err = a.ec2.WaitUntilImageAvailableWithContext(
+		aws.BackgroundContext(),
+		describeInput,
+		request.WithWaiterDelay(request.ConstantWaiterDelay(15*time.Second)),
+		request.WithWaiterMaxAttempts(240),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	describeOutput, err := a.ec2.DescribeImages(describeInput)
+	if err != nil {
+		return nil, err
+	}
+	if len(describeOutput.Images) != 1 {
+		return nil, fmt.Errorf("expected one image with ID %s, got %d", *copyOutput.ImageId, len(describeOutput.Images))
+	}
+
+	resources := []*string{copyOutput.ImageId}
+	var snapshots []*string
+	for _, bdm := range describeOutput.Images[0].BlockDeviceMappings {
+		if bdm.Ebs != nil && bdm.Ebs.SnapshotId != nil {
+			snapshots = append(snapshots, bdm.Ebs.SnapshotId)
+		}
+	}
+	resources = append(resources, snapshots...)
+
+	err = a.tagWithName(resources, name)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(shareWith) > 0 {
-		logrus.Info("[AWS] 💿 Sharing ec2 AMI")
-		var launchPerms []*ec2.LaunchPermission
-		for _, id := range shareWith {
-			launchPerms = append(launchPerms, &ec2.LaunchPermission{
-				// Implicit memory alasing doesn't couse any bug in this case
-				/* #nosec G601 */
-				UserId: &id,
-			})
+		for _, snapshotID := range snapshots {
+			err = a.shareSnapshot(snapshotID, shareWith)
+			if err != nil {
+				return nil, err
+			}
 		}
-		_, err := a.ec2.ModifyImageAttribute(
-			&ec2.ModifyImageAttributeInput{
-				ImageId: registerOutput.ImageId,
-				LaunchPermission: &ec2.LaunchPermissionModifications{
-					Add: launchPerms,
-				},
-			},
-		)
+
+		err = a.shareImage(copyOutput.ImageId, shareWith)
 		if err != nil {
-			logrus.Warnf("[AWS] 📨 Error sharing AMI: %v", err)
 			return nil, err
 		}
-		logrus.Info("[AWS] 💿 Shared AMI")
 	}
 
-	return registerOutput.ImageId, nil
+	logrus.Infof("[AWS] 🎉 AMI copied: %s", *copyOutput.ImageId)
+
+	return copyOutput.ImageId, nil
 }
 
 func (a *AWS) RemoveSnapshotAndDeregisterImage(image *ec2.Image) error {
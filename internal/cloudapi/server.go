@@ -4,7 +4,9 @@ import (
 	"net/http"
 
 	"github.com/osbuild/osbuild-composer/internal/distroregistry"
+	"github.com/osbuild/osbuild-composer/internal/reporegistry"
 	"github.com/osbuild/osbuild-composer/internal/rpmmd"
+	"github.com/osbuild/osbuild-composer/internal/snapshot"
 	"github.com/osbuild/osbuild-composer/internal/worker"
 
 	v2 "github.com/osbuild/osbuild-composer/internal/cloudapi/v2"
@@ -14,9 +16,9 @@ type Server struct {
 	v2 *v2.Server
 }
 
-func NewServer(workers *worker.Server, rpmMetadata rpmmd.RPMMD, distros *distroregistry.Registry, awsBucket string) *Server {
+func NewServer(workers *worker.Server, rpmMetadata rpmmd.RPMMD, rpmCache *rpmmd.CacheManager, distros *distroregistry.Registry, repos *reporegistry.RepoRegistry, snapshots *snapshot.Store, awsBucket string) *Server {
 	server := &Server{
-		v2: v2.NewServer(workers, rpmMetadata, distros, awsBucket),
+		v2: v2.NewServer(workers, rpmMetadata, rpmCache, distros, repos, snapshots, awsBucket),
 	}
 	return server
 }
@@ -37,6 +37,21 @@ const (
 	ErrorMethodNotAllowed             ServiceErrorCode = 22
 	ErrorNotAcceptable                ServiceErrorCode = 23
 	ErrorNoBaseURLInPayloadRepository ServiceErrorCode = 24
+	ErrorInvalidUploadOptions         ServiceErrorCode = 25
+	ErrorSnapshotNotFound             ServiceErrorCode = 26
+	ErrorSnapshotExists               ServiceErrorCode = 27
+	ErrorSnapshotMismatch             ServiceErrorCode = 28
+	ErrorInvalidSnapshotName          ServiceErrorCode = 29
+	ErrorInvalidSnapshotOptions       ServiceErrorCode = 30
+	ErrorInvalidSearch                ServiceErrorCode = 31
+	ErrorNoPackages                   ServiceErrorCode = 32
+	ErrorNoRepositories               ServiceErrorCode = 33
+	ErrorInvalidGPGKey                ServiceErrorCode = 34
+	ErrorNoGPGKey                     ServiceErrorCode = 35
+	ErrorSnapshotPackagesChanged      ServiceErrorCode = 36
+	ErrorNoMetadataCache              ServiceErrorCode = 37
+	ErrorRepositoryNotCached          ServiceErrorCode = 38
+	ErrorSnapshotPackagesMissing      ServiceErrorCode = 39
 
 	// Internal errors, these are bugs
 	ErrorFailedToInitializeBlueprint              ServiceErrorCode = 1000
@@ -54,6 +69,8 @@ const (
 	ErrorMalformedOSBuildJobResult                ServiceErrorCode = 1012
 	ErrorGettingDepsolveJobStatus                 ServiceErrorCode = 1013
 	ErrorDepsolveJobCanceled                      ServiceErrorCode = 1014
+	ErrorFailedToDeleteSnapshot                   ServiceErrorCode = 1015
+	ErrorDepsolveTimeout                          ServiceErrorCode = 1016
 
 	// Errors contained within this file
 	ErrorUnspecified          ServiceErrorCode = 10000
@@ -97,6 +114,21 @@ func getServiceErrors() serviceErrors {
 		serviceError{ErrorMethodNotAllowed, http.StatusMethodNotAllowed, "Requested method isn't supported for resource"},
 		serviceError{ErrorNotAcceptable, http.StatusNotAcceptable, "Only 'application/json' content is supported"},
 		serviceError{ErrorNoBaseURLInPayloadRepository, http.StatusBadRequest, "BaseURL must be specified for payload repositories"},
+		serviceError{ErrorInvalidUploadOptions, http.StatusBadRequest, "Invalid upload options"},
+		serviceError{ErrorSnapshotNotFound, http.StatusNotFound, "Snapshot with given name not found"},
+		serviceError{ErrorSnapshotExists, http.StatusConflict, "Snapshot with given name already exists"},
+		serviceError{ErrorSnapshotMismatch, http.StatusBadRequest, "Snapshot was taken for a different distribution, architecture or image type"},
+		serviceError{ErrorInvalidSnapshotName, http.StatusBadRequest, "Invalid snapshot name"},
+		serviceError{ErrorInvalidSnapshotOptions, http.StatusBadRequest, "Supply at most one of snapshot and save_snapshot"},
+		serviceError{ErrorInvalidSearch, http.StatusBadRequest, "Search must be a comma separated list of package names or glob patterns"},
+		serviceError{ErrorNoPackages, http.StatusBadRequest, "At least one package must be given"},
+		serviceError{ErrorNoRepositories, http.StatusBadRequest, "No repositories are configured for the given distribution and architecture"},
+		serviceError{ErrorInvalidGPGKey, http.StatusBadRequest, "GPG keys must be given as ASCII armored key data or http(s) URLs"},
+		serviceError{ErrorNoGPGKey, http.StatusBadRequest, "Repositories with check_gpg enabled must specify their GPG keys"},
+		serviceError{ErrorSnapshotPackagesChanged, http.StatusBadRequest, "The packages requested by the blueprint differ from the ones the snapshot was taken for"},
+		serviceError{ErrorNoMetadataCache, http.StatusBadRequest, "The repository metadata cache cannot be managed"},
+		serviceError{ErrorRepositoryNotCached, http.StatusNotFound, "No repository with given name was used yet"},
+		serviceError{ErrorSnapshotPackagesMissing, http.StatusBadRequest, "Packages of the snapshot are no longer available"},
 
 		serviceError{ErrorFailedToInitializeBlueprint, http.StatusInternalServerError, "Failed to initialize blueprint"},
 		serviceError{ErrorFailedToGenerateManifestSeed, http.StatusInternalServerError, "Failed to generate manifest seed"},
@@ -113,6 +145,8 @@ func getServiceErrors() serviceErrors {
 		serviceError{ErrorMalformedOSBuildJobResult, http.StatusInternalServerError, "OSBuildJobResult does not have expected fields set"},
 		serviceError{ErrorGettingDepsolveJobStatus, http.StatusInternalServerError, "Unable to get depsolve job status"},
 		serviceError{ErrorDepsolveJobCanceled, http.StatusInternalServerError, "Depsolve job was cancelled"},
+		serviceError{ErrorFailedToDeleteSnapshot, http.StatusInternalServerError, "Failed to delete snapshot"},
+		serviceError{ErrorDepsolveTimeout, http.StatusInternalServerError, "Depsolve job did not finish in time"},
 
 		serviceError{ErrorUnspecified, http.StatusInternalServerError, "Unspecified internal error "},
 		serviceError{ErrorNotHTTPError, http.StatusInternalServerError, "Error is not an instance of HTTPError"},
@@ -7,21 +7,42 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/deepmap/oapi-codegen/pkg/runtime"
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
 )
 
 const (
 	BearerScopes = "Bearer.Scopes"
 )
 
+// Defines values for ImageRequestBootType.
+const (
+	ImageRequestBootTypeHybrid ImageRequestBootType = "hybrid"
+
+	ImageRequestBootTypeLegacy ImageRequestBootType = "legacy"
+
+	ImageRequestBootTypeUefi ImageRequestBootType = "uefi"
+)
+
+// Defines values for ImageRequestCompression.
+const (
+	ImageRequestCompressionNone ImageRequestCompression = "none"
+
+	ImageRequestCompressionXz ImageRequestCompression = "xz"
+
+	ImageRequestCompressionZstd ImageRequestCompression = "zstd"
+)
+
 // Defines values for ImageStatusValue.
 const (
 	ImageStatusValueBuilding ImageStatusValue = "building"
@@ -43,6 +64,10 @@ const (
 
 	ImageTypesAzure ImageTypes = "azure"
 
+	ImageTypesAzureGen2 ImageTypes = "azure-gen2"
+
+	ImageTypesAzureRhui ImageTypes = "azure-rhui"
+
 	ImageTypesEdgeCommit ImageTypes = "edge-commit"
 
 	ImageTypesEdgeContainer ImageTypes = "edge-container"
@@ -55,6 +80,8 @@ const (
 
 	ImageTypesImageInstaller ImageTypes = "image-installer"
 
+	ImageTypesMinimalRaw ImageTypes = "minimal-raw"
+
 	ImageTypesVsphere ImageTypes = "vsphere"
 )
 
@@ -78,19 +105,36 @@ const (
 	UploadTypesAzure UploadTypes = "azure"
 
 	UploadTypesGcp UploadTypes = "gcp"
+
+	UploadTypesGenericS3 UploadTypes = "generic.s3"
 )
 
+// Result of copying the AMI to a region. Either ami or error is set.
+type AWSEC2ImageCopy struct {
+	Ami *string `json:"ami,omitempty"`
+
+	// Reason why copying the AMI to the region failed
+	Error  *string `json:"error,omitempty"`
+	Region string  `json:"region"`
+}
+
 // AWSEC2UploadOptions defines model for AWSEC2UploadOptions.
 type AWSEC2UploadOptions struct {
-	Region            string   `json:"region"`
-	ShareWithAccounts []string `json:"share_with_accounts"`
-	SnapshotName      *string  `json:"snapshot_name,omitempty"`
+	// Additional regions the AMI is copied to after it is registered
+	// in the region above. The copies are shared with the same accounts.
+	CopyToRegions     *[]string `json:"copy_to_regions,omitempty"`
+	Region            string    `json:"region"`
+	ShareWithAccounts []string  `json:"share_with_accounts"`
+	SnapshotName      *string   `json:"snapshot_name,omitempty"`
 }
 
 // AWSEC2UploadStatus defines model for AWSEC2UploadStatus.
 type AWSEC2UploadStatus struct {
-	Ami    string `json:"ami"`
-	Region string `json:"region"`
+	Ami string `json:"ami"`
+
+	// Copies of the AMI in the regions listed in copy_to_regions
+	Copies *[]AWSEC2ImageCopy `json:"copies,omitempty"`
+	Region string             `json:"region"`
 }
 
 // AWSS3UploadOptions defines model for AWSS3UploadOptions.
@@ -132,6 +176,23 @@ type AzureUploadStatus struct {
 	ImageName string `json:"image_name"`
 }
 
+// CacheInfo defines model for CacheInfo.
+type CacheInfo struct {
+	// Maximum size of the cache in bytes, 0 if unlimited
+	MaxSize      int64                  `json:"max_size"`
+	Repositories []RepositoryCacheStats `json:"repositories"`
+
+	// Seconds after which unused metadata is removed
+	Ttl int64 `json:"ttl"`
+}
+
+// CacheRefreshRequest defines model for CacheRefreshRequest.
+type CacheRefreshRequest struct {
+	// Names of the repositories whose metadata is refreshed. All
+	// repositories are refreshed if none are given.
+	Repositories *[]string `json:"repositories,omitempty"`
+}
+
 // ComposeId defines model for ComposeId.
 type ComposeId struct {
 	// Embedded struct due to allOf(#/components/schemas/ObjectReference)
@@ -145,6 +206,11 @@ type ComposeMetadata struct {
 	// Embedded struct due to allOf(#/components/schemas/ObjectReference)
 	ObjectReference `yaml:",inline"`
 	// Embedded fields due to inline allOf schema
+	// Size of the built image in bytes, before it was uploaded.
+	// Reflects the install options of the blueprint, like excluding
+	// weak dependencies and documentation.
+	ImageSize *int64 `json:"image_size,omitempty"`
+
 	// ID (hash) of the built commit
 	OstreeCommit *string `json:"ostree_commit,omitempty"`
 
@@ -157,6 +223,18 @@ type ComposeRequest struct {
 	Customizations *Customizations `json:"customizations,omitempty"`
 	Distribution   string          `json:"distribution"`
 	ImageRequest   ImageRequest    `json:"image_request"`
+
+	// Save the depsolved packages of this compose as a new snapshot
+	// with this name. The compose fails if the snapshot cannot be
+	// saved.
+	SaveSnapshot *string `json:"save_snapshot,omitempty"`
+
+	// Name of a snapshot to take the packages from instead of
+	// depsolving. The snapshot must have been taken for the same
+	// distribution, architecture, image type and requested packages.
+	// The compose fails if any of its packages is not available
+	// anymore.
+	Snapshot *string `json:"snapshot,omitempty"`
 }
 
 // ComposeStatus defines model for ComposeStatus.
@@ -175,6 +253,22 @@ type Customizations struct {
 	Users               *[]User       `json:"users,omitempty"`
 }
 
+// DepsolveRequest defines model for DepsolveRequest.
+type DepsolveRequest struct {
+	Architecture string   `json:"architecture"`
+	Distribution string   `json:"distribution"`
+	Packages     []string `json:"packages"`
+
+	// Repositories to depsolve against. Defaults to the repositories
+	// configured for the distribution and architecture.
+	Repositories *[]Repository `json:"repositories,omitempty"`
+}
+
+// DepsolveResult defines model for DepsolveResult.
+type DepsolveResult struct {
+	Packages []SnapshotPackage `json:"packages"`
+}
+
 // Error defines model for Error.
 type Error struct {
 	// Embedded struct due to allOf(#/components/schemas/ObjectReference)
@@ -231,15 +325,54 @@ type GCPUploadStatus struct {
 	ProjectId string `json:"project_id"`
 }
 
+// Upload the image to an S3-compatible object storage other than AWS,
+// e.g. MinIO or Ceph RGW. Usable with the same image types as
+// AWSS3UploadOptions.
+type GenericS3UploadOptions struct {
+	// If not specified, the credentials configured on the worker are used.
+	AccessKeyId *string `json:"access_key_id,omitempty"`
+
+	// Name of an existing bucket.
+	Bucket string `json:"bucket"`
+
+	// URL of the S3-compatible endpoint.
+	Endpoint string `json:"endpoint"`
+
+	// Use path-style instead of virtual-hosted style addressing.
+	PathStyle       *bool   `json:"path_style,omitempty"`
+	Region          string  `json:"region"`
+	SecretAccessKey *string `json:"secret_access_key,omitempty"`
+}
+
 // ImageRequest defines model for ImageRequest.
 type ImageRequest struct {
-	Architecture  string        `json:"architecture"`
+	Architecture string `json:"architecture"`
+
+	// Boot type of the image. Only supported by the minimal-raw
+	// image type, which defaults to hybrid.
+	BootType *ImageRequestBootType `json:"boot_type,omitempty"`
+
+	// Compression of the image. Only supported by the minimal-raw
+	// image type, which defaults to xz.
+	Compression *ImageRequestCompression `json:"compression,omitempty"`
+
+	// The name of a user-defined image type of the distribution is
+	// accepted as well. Such images are uploaded like the image type
+	// they are derived from.
 	ImageType     ImageTypes    `json:"image_type"`
 	Ostree        *OSTree       `json:"ostree,omitempty"`
 	Repositories  []Repository  `json:"repositories"`
 	UploadOptions UploadOptions `json:"upload_options"`
 }
 
+// Boot type of the image. Only supported by the minimal-raw
+// image type, which defaults to hybrid.
+type ImageRequestBootType string
+
+// Compression of the image. Only supported by the minimal-raw
+// image type, which defaults to xz.
+type ImageRequestCompression string
+
 // ImageStatus defines model for ImageStatus.
 type ImageStatus struct {
 	Status       ImageStatusValue `json:"status"`
@@ -249,7 +382,9 @@ type ImageStatus struct {
 // ImageStatusValue defines model for ImageStatusValue.
 type ImageStatusValue string
 
-// ImageTypes defines model for ImageTypes.
+// The name of a user-defined image type of the distribution is
+// accepted as well. Such images are uploaded like the image type
+// they are derived from.
 type ImageTypes string
 
 // List defines model for List.
@@ -273,6 +408,19 @@ type ObjectReference struct {
 	Kind string `json:"kind"`
 }
 
+// PackageInfo defines model for PackageInfo.
+type PackageInfo struct {
+	Arch        string  `json:"arch"`
+	Description *string `json:"description,omitempty"`
+	Epoch       *int    `json:"epoch,omitempty"`
+	License     *string `json:"license,omitempty"`
+	Name        string  `json:"name"`
+	Release     string  `json:"release"`
+	Summary     *string `json:"summary,omitempty"`
+	Url         *string `json:"url,omitempty"`
+	Version     string  `json:"version"`
+}
+
 // PackageMetadata defines model for PackageMetadata.
 type PackageMetadata struct {
 	Arch      string  `json:"arch"`
@@ -281,19 +429,100 @@ type PackageMetadata struct {
 	Release   string  `json:"release"`
 	Sigmd5    string  `json:"sigmd5"`
 	Signature *string `json:"signature,omitempty"`
-	Type      string  `json:"type"`
-	Version   string  `json:"version"`
+
+	// ID of the GPG key which signed the package, as 16 hex digits
+	SignerKeyId *string `json:"signer_key_id,omitempty"`
+	Type        string  `json:"type"`
+	Version     string  `json:"version"`
+}
+
+// PackageSearchResult defines model for PackageSearchResult.
+type PackageSearchResult struct {
+	Packages []PackageInfo `json:"packages"`
 }
 
 // Repository defines model for Repository.
 type Repository struct {
-	Baseurl    *string `json:"baseurl,omitempty"`
-	CheckGpg   *bool   `json:"check_gpg,omitempty"`
-	GpgKey     *string `json:"gpg_key,omitempty"`
-	IgnoreSsl  *bool   `json:"ignore_ssl,omitempty"`
-	Metalink   *string `json:"metalink,omitempty"`
-	Mirrorlist *string `json:"mirrorlist,omitempty"`
-	Rhsm       bool    `json:"rhsm"`
+	Baseurl *string `json:"baseurl,omitempty"`
+
+	// Verify the signatures of all packages from the repository. The
+	// build fails on unsigned packages and packages which were not
+	// signed with one of the keys of the repository.
+	CheckGpg *bool   `json:"check_gpg,omitempty"`
+	GpgKey   *string `json:"gpg_key,omitempty"`
+
+	// GPG keys the packages of the repository can be signed with, in
+	// addition to gpg_key. Keys are given as ASCII armored key data or
+	// as http(s) URLs, in which $releasever and $basearch are
+	// substituted.
+	GpgKeys    *[]string `json:"gpg_keys,omitempty"`
+	IgnoreSsl  *bool     `json:"ignore_ssl,omitempty"`
+	Metalink   *string   `json:"metalink,omitempty"`
+	Mirrorlist *string   `json:"mirrorlist,omitempty"`
+	Rhsm       bool      `json:"rhsm"`
+}
+
+// RepositoryCacheStats defines model for RepositoryCacheStats.
+type RepositoryCacheStats struct {
+	// Size of the cached metadata when the repository was used last
+	Bytes int64 `json:"bytes"`
+
+	// Number of requests which used the cached metadata as it was
+	Hits        int64     `json:"hits"`
+	LastRefresh time.Time `json:"last_refresh"`
+	LastUsed    time.Time `json:"last_used"`
+
+	// Number of requests which downloaded new metadata
+	Misses int64  `json:"misses"`
+	Name   string `json:"name"`
+	Url    string `json:"url"`
+}
+
+// Snapshot defines model for Snapshot.
+type Snapshot struct {
+	// Embedded struct due to allOf(#/components/schemas/ObjectReference)
+	ObjectReference `yaml:",inline"`
+	// Embedded fields due to inline allOf schema
+	Architecture string    `json:"architecture"`
+	Created      time.Time `json:"created"`
+	Distribution string    `json:"distribution"`
+
+	// Name of the image type of the distribution
+	ImageType   string               `json:"image_type"`
+	Name        string               `json:"name"`
+	PackageSets Snapshot_PackageSets `json:"package_sets"`
+
+	// Checksums of the repository metadata, by repository
+	RepoChecksums *Snapshot_RepoChecksums `json:"repo_checksums,omitempty"`
+}
+
+// Snapshot_PackageSets defines model for Snapshot.PackageSets.
+type Snapshot_PackageSets struct {
+	AdditionalProperties map[string][]SnapshotPackage `json:"-"`
+}
+
+// Checksums of the repository metadata, by repository
+type Snapshot_RepoChecksums struct {
+	AdditionalProperties map[string]string `json:"-"`
+}
+
+// SnapshotList defines model for SnapshotList.
+type SnapshotList struct {
+	// Embedded struct due to allOf(#/components/schemas/List)
+	List `yaml:",inline"`
+	// Embedded fields due to inline allOf schema
+	Items []ObjectReference `json:"items"`
+}
+
+// SnapshotPackage defines model for SnapshotPackage.
+type SnapshotPackage struct {
+	Arch           string  `json:"arch"`
+	Checksum       *string `json:"checksum,omitempty"`
+	Epoch          *int    `json:"epoch,omitempty"`
+	Name           string  `json:"name"`
+	Release        string  `json:"release"`
+	RemoteLocation *string `json:"remote_location,omitempty"`
+	Version        string  `json:"version"`
 }
 
 // Subscription defines model for Subscription.
@@ -337,9 +566,15 @@ type Page string
 // Size defines model for size.
 type Size string
 
+// PostCacheRefreshJSONBody defines parameters for PostCacheRefresh.
+type PostCacheRefreshJSONBody CacheRefreshRequest
+
 // PostComposeJSONBody defines parameters for PostCompose.
 type PostComposeJSONBody ComposeRequest
 
+// PostDepsolveJSONBody defines parameters for PostDepsolve.
+type PostDepsolveJSONBody DepsolveRequest
+
 // GetErrorListParams defines parameters for GetErrorList.
 type GetErrorListParams struct {
 	// Page index
@@ -349,11 +584,141 @@ type GetErrorListParams struct {
 	Size *Size `json:"size,omitempty"`
 }
 
+// GetPackagesParams defines parameters for GetPackages.
+type GetPackagesParams struct {
+	// Distribution whose repositories are searched
+	Distribution string `json:"distribution"`
+
+	// Architecture whose repositories are searched
+	Architecture string `json:"architecture"`
+
+	// Comma separated list of package names or glob patterns
+	Search string `json:"search"`
+}
+
+// PostCacheRefreshJSONRequestBody defines body for PostCacheRefresh for application/json ContentType.
+type PostCacheRefreshJSONRequestBody PostCacheRefreshJSONBody
+
 // PostComposeJSONRequestBody defines body for PostCompose for application/json ContentType.
 type PostComposeJSONRequestBody PostComposeJSONBody
 
+// PostDepsolveJSONRequestBody defines body for PostDepsolve for application/json ContentType.
+type PostDepsolveJSONRequestBody PostDepsolveJSONBody
+
+// Getter for additional properties for Snapshot_PackageSets. Returns the specified
+// element and whether it was found
+func (a Snapshot_PackageSets) Get(fieldName string) (value []SnapshotPackage, found bool) {
+	if a.AdditionalProperties != nil {
+		value, found = a.AdditionalProperties[fieldName]
+	}
+	return
+}
+
+// Setter for additional properties for Snapshot_PackageSets
+func (a *Snapshot_PackageSets) Set(fieldName string, value []SnapshotPackage) {
+	if a.AdditionalProperties == nil {
+		a.AdditionalProperties = make(map[string][]SnapshotPackage)
+	}
+	a.AdditionalProperties[fieldName] = value
+}
+
+// Override default JSON handling for Snapshot_PackageSets to handle AdditionalProperties
+func (a *Snapshot_PackageSets) UnmarshalJSON(b []byte) error {
+	object := make(map[string]json.RawMessage)
+	err := json.Unmarshal(b, &object)
+	if err != nil {
+		return err
+	}
+
+	if len(object) != 0 {
+		a.AdditionalProperties = make(map[string][]SnapshotPackage)
+		for fieldName, fieldBuf := range object {
+			var fieldVal []SnapshotPackage
+			err := json.Unmarshal(fieldBuf, &fieldVal)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("error unmarshaling field %s", fieldName))
+			}
+			a.AdditionalProperties[fieldName] = fieldVal
+		}
+	}
+	return nil
+}
+
+// Override default JSON handling for Snapshot_PackageSets to handle AdditionalProperties
+func (a Snapshot_PackageSets) MarshalJSON() ([]byte, error) {
+	var err error
+	object := make(map[string]json.RawMessage)
+
+	for fieldName, field := range a.AdditionalProperties {
+		object[fieldName], err = json.Marshal(field)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("error marshaling '%s'", fieldName))
+		}
+	}
+	return json.Marshal(object)
+}
+
+// Getter for additional properties for Snapshot_RepoChecksums. Returns the specified
+// element and whether it was found
+func (a Snapshot_RepoChecksums) Get(fieldName string) (value string, found bool) {
+	if a.AdditionalProperties != nil {
+		value, found = a.AdditionalProperties[fieldName]
+	}
+	return
+}
+
+// Setter for additional properties for Snapshot_RepoChecksums
+func (a *Snapshot_RepoChecksums) Set(fieldName string, value string) {
+	if a.AdditionalProperties == nil {
+		a.AdditionalProperties = make(map[string]string)
+	}
+	a.AdditionalProperties[fieldName] = value
+}
+
+// Override default JSON handling for Snapshot_RepoChecksums to handle AdditionalProperties
+func (a *Snapshot_RepoChecksums) UnmarshalJSON(b []byte) error {
+	object := make(map[string]json.RawMessage)
+	err := json.Unmarshal(b, &object)
+	if err != nil {
+		return err
+	}
+
+	if len(object) != 0 {
+		a.AdditionalProperties = make(map[string]string)
+		for fieldName, fieldBuf := range object {
+			var fieldVal string
+			err := json.Unmarshal(fieldBuf, &fieldVal)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("error unmarshaling field %s", fieldName))
+			}
+			a.AdditionalProperties[fieldName] = fieldVal
+		}
+	}
+	return nil
+}
+
+// Override default JSON handling for Snapshot_RepoChecksums to handle AdditionalProperties
+func (a Snapshot_RepoChecksums) MarshalJSON() ([]byte, error) {
+	var err error
+	object := make(map[string]json.RawMessage)
+
+	for fieldName, field := range a.AdditionalProperties {
+		object[fieldName], err = json.Marshal(field)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("error marshaling '%s'", fieldName))
+		}
+	}
+	return json.Marshal(object)
+}
+
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// Get the state of the repository metadata cache
+	// (GET /cache)
+	GetCache(ctx echo.Context) error
+	// Refresh the repository metadata
+	// (POST /cache/refresh)
+	PostCacheRefresh(ctx echo.Context) error
 	// Create compose
 	// (POST /compose)
 	PostCompose(ctx echo.Context) error
@@ -363,6 +728,9 @@ type ServerInterface interface {
 	// Get the metadata for a compose.
 	// (GET /composes/{id}/metadata)
 	GetComposeMetadata(ctx echo.Context, id string) error
+	// Depsolve packages
+	// (POST /depsolve)
+	PostDepsolve(ctx echo.Context) error
 	// Get a list of all possible errors
 	// (GET /errors)
 	GetErrorList(ctx echo.Context, params GetErrorListParams) error
@@ -372,6 +740,18 @@ type ServerInterface interface {
 	// Get the openapi spec in json format
 	// (GET /openapi)
 	GetOpenapi(ctx echo.Context) error
+	// Search packages
+	// (GET /packages)
+	GetPackages(ctx echo.Context, params GetPackagesParams) error
+	// List snapshots
+	// (GET /snapshots)
+	GetSnapshotList(ctx echo.Context) error
+	// Delete a snapshot
+	// (DELETE /snapshots/{name})
+	DeleteSnapshot(ctx echo.Context, name string) error
+	// Get a snapshot
+	// (GET /snapshots/{name})
+	GetSnapshot(ctx echo.Context, name string) error
 }
 
 // ServerInterfaceWrapper converts echo contexts to parameters.
@@ -379,6 +759,28 @@ type ServerInterfaceWrapper struct {
 	Handler ServerInterface
 }
 
+// GetCache converts echo context to params.
+func (w *ServerInterfaceWrapper) GetCache(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetCache(ctx)
+	return err
+}
+
+// PostCacheRefresh converts echo context to params.
+func (w *ServerInterfaceWrapper) PostCacheRefresh(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.PostCacheRefresh(ctx)
+	return err
+}
+
 // PostCompose converts echo context to params.
 func (w *ServerInterfaceWrapper) PostCompose(ctx echo.Context) error {
 	var err error
@@ -426,6 +828,17 @@ func (w *ServerInterfaceWrapper) GetComposeMetadata(ctx echo.Context) error {
 	return err
 }
 
+// PostDepsolve converts echo context to params.
+func (w *ServerInterfaceWrapper) PostDepsolve(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.PostDepsolve(ctx)
+	return err
+}
+
 // GetErrorList converts echo context to params.
 func (w *ServerInterfaceWrapper) GetErrorList(ctx echo.Context) error {
 	var err error
@@ -482,6 +895,87 @@ func (w *ServerInterfaceWrapper) GetOpenapi(ctx echo.Context) error {
 	return err
 }
 
+// GetPackages converts echo context to params.
+func (w *ServerInterfaceWrapper) GetPackages(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerScopes, []string{""})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetPackagesParams
+	// ------------- Required query parameter "distribution" -------------
+
+	err = runtime.BindQueryParameter("form", true, true, "distribution", ctx.QueryParams(), &params.Distribution)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter distribution: %s", err))
+	}
+
+	// ------------- Required query parameter "architecture" -------------
+
+	err = runtime.BindQueryParameter("form", true, true, "architecture", ctx.QueryParams(), &params.Architecture)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter architecture: %s", err))
+	}
+
+	// ------------- Required query parameter "search" -------------
+
+	err = runtime.BindQueryParameter("form", true, true, "search", ctx.QueryParams(), &params.Search)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter search: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetPackages(ctx, params)
+	return err
+}
+
+// GetSnapshotList converts echo context to params.
+func (w *ServerInterfaceWrapper) GetSnapshotList(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetSnapshotList(ctx)
+	return err
+}
+
+// DeleteSnapshot converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteSnapshot(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "name" -------------
+	var name string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "name", runtime.ParamLocationPath, ctx.Param("name"), &name)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter name: %s", err))
+	}
+
+	ctx.Set(BearerScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.DeleteSnapshot(ctx, name)
+	return err
+}
+
+// GetSnapshot converts echo context to params.
+func (w *ServerInterfaceWrapper) GetSnapshot(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "name" -------------
+	var name string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "name", runtime.ParamLocationPath, ctx.Param("name"), &name)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter name: %s", err))
+	}
+
+	ctx.Set(BearerScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetSnapshot(ctx, name)
+	return err
+}
+
 // This is a simple interface which specifies echo.Route addition functions which
 // are present on both echo.Echo and echo.Group, since we want to allow using
 // either of them for path registration
@@ -510,78 +1004,126 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 		Handler: si,
 	}
 
+	router.GET(baseURL+"/cache", wrapper.GetCache)
+	router.POST(baseURL+"/cache/refresh", wrapper.PostCacheRefresh)
 	router.POST(baseURL+"/compose", wrapper.PostCompose)
 	router.GET(baseURL+"/composes/:id", wrapper.GetComposeStatus)
 	router.GET(baseURL+"/composes/:id/metadata", wrapper.GetComposeMetadata)
+	router.POST(baseURL+"/depsolve", wrapper.PostDepsolve)
 	router.GET(baseURL+"/errors", wrapper.GetErrorList)
 	router.GET(baseURL+"/errors/:id", wrapper.GetError)
 	router.GET(baseURL+"/openapi", wrapper.GetOpenapi)
+	router.GET(baseURL+"/packages", wrapper.GetPackages)
+	router.GET(baseURL+"/snapshots", wrapper.GetSnapshotList)
+	router.DELETE(baseURL+"/snapshots/:name", wrapper.DeleteSnapshot)
+	router.GET(baseURL+"/snapshots/:name", wrapper.GetSnapshot)
 
 }
 
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/+xbe28bOZL/KkTvAZ7BdUuyHo4jYDDrON6s9yYPWM4s7mJDoNglNTfdZIdkW1ECf/cD",
-	"H93qB2XJO55ZDOB/Ykkkq35VrCpWFZnvAeFZzhkwJYPp9yDHAmegQLhvK9B/Y5BE0FxRzoJp8AGvAFEW",
-	"w9cgDOArzvIUGtPvcFpAMA2Og/v7MKB6zZcCxCYIA4YzPWJmhoEkCWRYL1GbXP8ulaBsZZZJ+s3D+12R",
-	"LUAgvkRUQSYRZQgwSZAjWEdTEqjQDAY78Zi5D+G5LwcN6bN/zi7Ohx/zlOP4vYFm5Rc8B6Go5S9gZTB/",
-	"L1EF0wCKaA1SRcdB2GYRBjLBAuZrqpI5JoQXbkuq1Z+C4+FoPDl5cfpycDwMbsPA6MADtyKOhcAbQ5vh",
-	"XCZcza3AdUzZJipHu6juw0DAl4IKiDUAJ5Mf6221mi/+BURpvnVNzRRWhUdROKNNRDij0YCcjgYvXo5e",
-	"vJhMXk7i8cKnsUequCWM5lvR2AF+NnraXfbrcw/zXYorROr3nToLPclL/1shYI9wNMMrqEym5Yk4A+2H",
-	"KgFUGDIQI7Oghy4Vygqp0AJQweiXQocLM3FF74AhAZIXggBaCV7kvRt2uUSaCaIS8YwqBTFaCp6ZJVoW",
-	"kCpEGAnMYp4hzgAtsIQYcYYw+vjx8jWi8oatgIHACuLeDdvGAmvhBpjPhFJOsHI72BTwFzeC1gkIMFgM",
-	"FSQTXqSxEa6UG7MY6b2UCoTh/3e+RoqjlEqFcJqiko2c3rBEqVxO+/2YE9nLKBFc8qXqEZ71gUWF7JOU",
-	"9rHenr7zrZ/vKKx/Mj9FJKVRihVI9Rf8rXS+uWY0r5gctRSgrREKvbV+L7LbMTfb8fBON7fuANW09+Ka",
-	"FwSzK0fmjeHoi4XFooIwp3EX1OVrDak+7d8AM4ZJfLoYkggvhuNoPD4eRS8HZBKdHA9HgxM4HbyEoQ+d",
-	"AoaZegCXBmEnHYbKmcuSshhRVXqLcVH0gQuF00PsprQZRe8giqkAorjY9JcFi3EGTOFUdkajhK8jxSPN",
-	"OrKQW0qakBewnCxOomMyWkbjGA8ifDIcRoPF4GQwHL2MX8Qv9ga6rca6e9uxwJpX7olcuyJjM3AdEgla",
-	"eGsEfBDOddIk4dIYAE7T98tg+ul78F8ClsE0+Et/m1T1XdrQf28WX8ESBDACwX3YAR03wR4PR6CP+whO",
-	"Xy6i42E8ivB4chKNhycnk8l4PBgMBkEYLLnIsAqmQVEYZe4RLPYIdLsV6S0oHGOFn1IwLpUAmBOeZVR5",
-	"XeaHBMvkx9JzFgVNFXLTPe6XY/IZryztdmpqRmzcpYykRUzZCr27+PXqLKjlSw/J42hUiuhkU/cP6e/K",
-	"HlddkySFVDyj33B11j4E4rw5+z4MYqoVsChUJ90QCaTRqU9R1orFFtJDLC/15BJ+22wa3NuEa+JvHfLJ",
-	"vMKwkhXdvSI4CH6PdnR2uEBni5pQ6nZXy8pzLtVKgHxcRp7jjY5gcwE5l1RxUcp7iI1elYs23mS/FmD3",
-	"UZrV596HQSFd7XcQjo8SxCEOEgYXQnDxlHZBeAxeRetJuJY3ePIdLK1iHg6VhkM1vUXYb0FGyl+odbbD",
-	"JDWzPWZfqv+gfbDa9W1EwwUMKT/yN+cf9hQDi4J8BrU7PcQMwVcqlQ64s+uzd6/Prl6jmeJCB2SSYinR",
-	"K0Oi107O3ZfIcdgZyPyFyHUCtnpQHBUS0JILl27lXCiXnJt6NUY6ShUK0AVbUeYyst4Nu66yM0OoVbvo",
-	"KtdlZG/OP6BccK22EK0TShJdsxQS4htW8n0/c7RsfmfYWyw9pAsdrpDMgdAl1dhcUXPDjoiNoCLCOY1u",
-	"isFgRPSJbj7BEbLKKNkhLGs5pUb9mKJnW7R2ValFtOO11LWSaU3TVKumUq7idf3qqs3p07RdKlVi/Z3G",
-	"hnqZ3PXQDACVWS1JeRH3VpyvUjA5rbSmY9LdflXauGqxrsTQQMyKVNHIIS+nI5JyCVJpmHqSTTNv2A+u",
-	"iinN0xpmtexHrWaScAkM4ULxDCtKcJpu2kqG4hGNnFZ5qVMUviz1YuRG5XSN11BpWrLPfI159m7YBSZJ",
-	"aSRG64QzhamukEtNiTLBcmyQRt5DvxoENo2UCAuY3jCEInSkz4Lpd8gwTWl8fzRFZwyZbwjHsQCpTRAr",
-	"JCAXIHU82vIimgRqidVDf+MCOe2F6AinlMBf3Xe950c9x1mCuKMEzuy6R2KwrB2JXbyzTcRVYrwt/yvO",
-	"c5lz1Vu5ReWaOiRTmjxWG07+ss+hcbVUEGeUSa8OYp5hyqbf7V/N0LgnmhVUAbK/oh9yQTMsNj92maep",
-	"ZWgaNPpUt7uPlVvb1sjW9Y4QF+iohcnvdQ+bJpV2jQ0O2lARZpsbVuq36U2fTPIx7ViFLhmb9nDo5gVh",
-	"YLetq+YgDJyC6z8+IoPb1Rl1h5ivaqzO2KcrW8PAHUfzdvWIJQEWY6aihcA0jkaD0eR4tLc+rJEL91XB",
-	"jYqh29YVJKEKiCpES5yvpyfzk/Huc97+fECqf73JwRRHtsLct+b97FrPMhI/edJtT/s5zw+q75q5Vqcz",
-	"XVddQyst6B22t+W27DKxRxdSv5rrk62AhxFo2HlbvLIIa2K1jLShsCIz0wpCQGohl5imVhU5MF3RGz+j",
-	"qftokdnPZRdWf7v1WFjNbmqs8FqzMf0zHZHiFURV+8F9M4cpiPIHyqTCaWp+WJFc/6vdoPJT87cx607m",
-	"Op/yoipLhuZefabMX8GUF21ugDIFK1uIlZde3RHFFU59Q63NMUzD6obOXozZxeHOCiIMnG957keW3W5F",
-	"/7RvY0Bf69IXCHZebXQZtyrFDoLEQegGG79yd2i920cLS10ZDj6ltFtJ3hjpBQE53zFSng6epD4FLP1j",
-	"kq6yeLJriOEyRu848zwDdyAkPaSKdmHLwN4u28INrRIqjDoq1CJttwzFEpx1bI2qKiJi1hMQJ9i2xbXX",
-	"AlP9mErV14Z3urU8TYfLPpf9Rg9VpD5zJAmQz/NVvqrJu+A8BWyaJqt8Nf8MG7+VrRgXMJcy9a/NQOGU",
-	"ss9+gTKqK3vZW0LMBXaHc4+LVb9c97M+EH6y49FoqMvF4YlW6U/VMbtPOsskdTGoCaLCoId7BJji0vD/",
-	"2W3gT6eRPnxxVuOM9b8nY/uLwfcKS3g/OwCLSGTmU1Q73dLTfC43a/W+Wv5GFL2zPRy3X817cCACVKSH",
-	"akhzLOWai9gHV1vR3GuOXWs8QHrKJF0lrXt/JQoIPZbDxQoz161s8h8OxoPR0Jth6SQZRBdyvWfY09qt",
-	"Id+bNDaQhG0tN5jWVFYT17eTnXYUZ3BAP833NuM+3LumfdG/b0mnX7aXR/e+3TTeHq4I+G8Rv0y/Dpf+",
-	"wBXtQuYRspcrtOiPTyWrZPSQEsEudDWCPwUNy+Opnj93GR6clIqCsV2ZZx1ON/Vcy54cVbmkzUS9VCQ8",
-	"aRvd1MfNGmgbFMyg961Su/rpRFMpkwji4WRy/BKdnZ2dnY/efcPnx+n/vb48fnd9MdG/Xb4Tb/7nQrz9",
-	"X/rfb99+XBd/x1dn/8iufuGX366Wwy+vh/HrybfBq+uv/ZOvPhDdQrmQIPa/utlR0N6aV15ACkHVZqY1",
-	"aFX0CrCwSl+YT38rg/g//nldPhozodnOq+jqU8A+HaNsybsdwJnrUClubjxdp9hWDLaBIntBGKSUALN5",
-	"nXutdpZjkgAa9gaBy5SrfGG9XvewGTaHtFsr+79cnl+8m11Ew96gl6gsNXtIlVHa+9krw95d4QlkWrEI",
-	"57SWsE2DobtcYXpgGox6g96xKRRUYtTUdw1sE8S49NwUnAvAChBGDNbIzQ5RznWORnGabhDhTLorBL5E",
-	"Eu5A4FIXRj2up27e/NmeLhUoBr3E9YfrFzWXcTANPnCpnGiBtQOQ6hWPN/YWyWSIxqPyPKW2/9v/l7sg",
-	"2j4IfPCytnn1e9+0N31821c2Odd7oakNB8dPzf0ytoxbKreDKMESSYWFglhv43gweDL+7u6py/uS2d62",
-	"2+nyJZflf/z78z8rlDaSz8AQlYhaNJb76Pfn/pHhQiVc0G/2liQHobM/VBmnRTL+I5B8ZnzNqn2wSpj8",
-	"ESbwkcHXHIiCGIGegzghhdBuUY+15hgro+yn2/vbMJBFlmFd/pVBowwuel0ZaWT/O43vzSnmu5h8A8pe",
-	"+piT3FxRIndAIy4MxRQ0NEfOXFwZSyFpEYNE6wRUAkJPZtzSKnVo0gCIIe7Gmzegmo8hwsar6k/+F2MV",
-	"YQtWcbQyV6HmtbKOsdvHyu7JVD2+1J8uP/kDottO8Bo8dfCqGoUdC2rq5T8Wu8rA8Ry2nsPWQWHruhV4",
-	"dscv08kp24MPBrJyoqW4pIzKpBW+AMFXTBTSGaf2asoZEqAKwSBGMehKRSLO6i+ry2fb9jb4gXBWtTGf",
-	"A9regLZ9Pdi1ruv6VpavRuzL+HIrn+Pcc5z7c8S5TmzSBo1rhqzjnSEua/GtE2K2D+c6wcUn2XZK31xU",
-	"7WpA1eaZm6zf1fW3Mvis3b5J5kvklPHsZv8ZN7OG/udzMlwZEE5TlHMp6SKFypq2bra/KMLMtpkYqf5f",
-	"j0W2fZe42CBzdPod9bAMoKL7W0/90R98hldb+eyjzz76GB+1a+ukjV9WTdPd5997N8Vv1U2wjpzxVkQZ",
-	"0jpwzzf/jJnDg+LcV1eWvjjz1j2B5HFB7LtdO7fTFsc57Wk+MqHuf8zhnPbtGx3TewcRle+v+3dDk0+0",
-	"mvUKryhbPcRAKryC38jGKJGVTzQrNvvo3N7/fwAAAP//5y+av8k/AAA=",
+	"H4sIAAAAAAAC/+x9e28bN/boVyFmC6S91Uiy/GhioNh1nWzWu80DVtLi3shXoGaOJK5nyCnJsawE/u4/",
+	"HJLzph5OnPYXwP/EkmZIHp73i8ynIBJpJjhwrYLTT0FGJU1Bg3TfFoB/Y1CRZJlmggenwVu6AMJ4DLdB",
+	"L4BbmmYJNF6/oUkOwWlwENzd9QKGY/7IQa6DXsBpik/Mm71ARUtIKQ7R6wx/V1oyvjDDFPvoWft1ns5A",
+	"EjEnTEOqCOMEaLQkbsI6NMUEJTTD4UZ4zLvb4LkrHpqpz34fvzgfXaR0AeciW3fBvASVJxrBjES2ZnxB",
+	"9BLI2asLogWhRMKCCd4nL5hegiQ0ZURIAlIKSZgiCnR/woNekEmRgdTMboimDP+4PQan+EM4jJ4eDn96",
+	"dvjTT8fHz47jo1nQawPfC8zMPiipEpyslmsfmPjRAkrmlCUQ+2a2LzTBylUIVOnwoDvAjPgjZxLi4PRD",
+	"MfqqfE/M/guRxoktit9niaDxGwOwZbEGRhDqqRZTO4/q7vAsjhl+pInbiip3yBRumkFsSDLXIAnT+Cu+",
+	"qDRIiCec8Toa6EzcQJ+8W4IdqgiVQNSSSojJiumleVnRFAiNIpFzrSwdS9x8aCCHZqESuV6aH0aIBsPU",
+	"VpS0Bolb+P8faPjx6tPo7vvQfPrxhx/DD8Pw2dWP3/kI4n6gUtL1JgJBHq7AT6BeYLYzxd1Mi000Rn8I",
+	"DkaHR8cnPz19NjxoAr0TGMVpppZCT63Y1WFK12HxdF+28cO6i5nGmurcw0ufL12WF7rMd25+RyVQ8lyd",
+	"nRRJkM9i/LXFyIZpSrR+J2EenAZ/G1SqeuCU0aCtib6cA1q4RrT0dgjq+HCHnH4ZCPstvomuuUz8Bqa+",
+	"BL7knf9jLmHH5hgiv+TolrlCXeAYIDfTIL1xQJ9caJLmSpMZkJyzP3Io2GPBboATCUrkMgKykCLP+hN+",
+	"MSe4CKookTKNnDOXInUc9UcOSvfQtlAei5QIDmRGFcQEFRd5//7iOWFqwhfAQVINcUszoQAawHwcnoiI",
+	"akfB5gZ/dU/IagkSDCxmFqKWIk9iMqvtm/K4plz7E/4vsULli2JAaJKQYhl1OuFLrTN1OhjEIlL9lEVS",
+	"KDHX/UikA+BhrgZRwgYUyTNwov/3Gwarn81PYZSwMKEalP4b/VjohikuNC0XedJCAHIj5Ehav6Gz5Jga",
+	"cmyndJN0e6CmTYt3Io8ov3TTvDQr+lR1PitBmLK4C9TFcwSp/tpnAHMEx/HT2SgK6Wx0FB4dHRyGz4bR",
+	"cXhyMDocnsDT4TMYeS0RcMr1FrgQCPvSflA5dpkzHhtTbaXFiCh5K6SmyT58U/CMZjcQxkxCpIVcD+Y5",
+	"j2kKXNNEdZ6GS7EKtQhx6dCC3ELScfQTzI9nJ+FBdDgPj2I6DOnJaBQOZ8OT4ejwWfxT/NNORVdhrEvb",
+	"DgfWpHKH5tqkGZuKax9N0IK3NoEPhHMaLeGCz0V36ZTeTv0O/it6y9I8Jfi0YJIIJ0J6z9YaVI8MCZuT",
+	"nCcsZdq4pXMhU6qD04BxfXJUwc24hgVIK7+ZUEwLWWx+H+t6WQxam70gIpXPxGqddHcyhkjwWDnfcrVk",
+	"0ZLkPEeVnIKmMdXUupupuNlvF2120UnQq1DZ2uNVQYFLmEtQy0trInzGuYmZrl5TlWKrXiWrpVDQ2opZ",
+	"CeI+OUuSCW+8TiVULyABOZooKp296zjKaLyEuo9/ifg5RyIquDBKhybJm3lw+mE7kd8Yhr2EOUjgEQR3",
+	"vY6gxE0BORgdAnrAITx9NgsPRvFhSI+OT8Kj0cnJ8fHR0XA4HNbpmecs3i1MsUeIrqotvXKIftCNGQH2",
+	"C+K4JoCznCXaqeZKDGcwFxJQFa+oqivqS5gnEGkbazGuNBp3YSYuWWmW5JBJxnWPJOwaCNxGSR4zvpjw",
+	"FdBrEkMGPAYeGd7hMYlFlBv9jNNYbtlD7IXSEmAaiTRl2muIvl9StfyhuVP3useoZTS6pgufoLy1T6w3",
+	"w7jbDXn94rfLs329eTdHSeoui2/jkI0SHuVKi5R9pKUHuw2I8+bbd70gZoiAWa47TrxcQhI+9SHKspas",
+	"QNq2pIlfCvDRsaE3MC3DwS5r0hvrLMSQKZHcQEwKulg6mtje4IRQRSjhsCLFdBPuInWmjD9dxPP2dcx0",
+	"KNROOH0xhESUc4Ge+oQjaB0XyaLhOMSQcTQcHYQH/tB6444K75FWa2pBNL22+yx3Zxx+lCigMRHzCXcY",
+	"YHxh91EON6HFEhE1A+BmKk7mQpYpigmvk7VHqIyWTEOkcwk9J+u4A+e2G9rUEN2fcC/eKF/b7JyqoEZM",
+	"C03oDWUJnSUw4ZSvUyHhs/DY0psN5mzzXU06Ki/ogbVnOe9ODncg+N0oN88GG9CR4CYodbVUs6GZUHoh",
+	"Qd0vS5PRNary6Re6TL6p617trpnG9XfvekGuQO4Px3sFsgvBncdNfe6UyEb1WZeMpvq7fXoyrRueCqef",
+	"ozI3EFGnOSba/xEJCfcj5HbP7rL2FLVNoUwJXVBUMX3yHOY0T7SqssHViAmPBJ+zRY65z0Kv1Hdt9EYd",
+	"df39s1rbmGi7+DdoVUPpVYPQmJzfLkR7gTl2ytaZ7Z2wNqB5UeTkH0odRSIGL1vgS7SWI9Dd3AZVgnse",
+	"teA3K5Svtyb2Ky6zy1+Z0vvv1Lzt0bYFRfYijcXuLoLYqfyQvzx/uyPxN8uja9hmzDmBW6Y0uoHjd2ev",
+	"n59dPidjLSSa1iihSpFfzBT9diLOfQndChvdK3/SEe0yPkGxzRWU0snSTEjtEnGuaoHGMddAXvAF4y77",
+	"4ky7+WwnauUp0YFy2ZeX529JJgWirediXKZw1XjCi3XfjN1cNkQwy1tY+gSTmkITlUHE5liMKRKYE/7E",
+	"+RYypBkLJ/lweBhhJGU+wRNikVEsh46ebkB9nwRnlaDuohK3aJ/X0lTlnlYsSRA1JXK1qOMXHTaHT1OH",
+	"LFFJ8TuLzexFIqdPxgCkyGBFicjj/kKIRQImf6Us65jU1qAYo1xmuI7EngExzRPNQgd58TqJEqFA6UKl",
+	"25TShH9vP5TsaRmzHPaDKZdhwM8JzbVIqWYRTZJ1G8mQ36Om1EolY+Ak5gVezL7LOhrCa2ZpcrKPfQ17",
+	"9if8BdaFHZMYrEeCa8o4oSWmZBH2uWWMw9snvxkIbHRpEhenE05ISJ6gC3L6CVLKEhbfPTklZ5yYb4TG",
+	"sQSFLEg12kkJChDscq0IpyCtbfXJP4UkDns98oQmLIJ/uO9I8yd9t7ICecMiOLPj7gmDXdpNsWntdB0K",
+	"vTTSlv2DZpnKhO4v3KBiTB0kk4a8Lzbc/ouaBsLVQkGcMq68OIhFShk//WT/4oJGPMk4ZxqI/ZV8n0mW",
+	"Urn+obt4ktgFTTFGgXTBItVubBsjleg9IUKSJy2Y/FK3nTVdJFQvGVO+nvACv91qMcjTDlcEvaDFD/sS",
+	"L+gFlmxdNAe9wCG4/uN90m8birTOiPkyxKWNfbgUtWmawPmn7awdVRHwmHIdziRlcXg4PDw+ONwZX9am",
+	"6+3KeL8EDpJFnnpoU9XZxzV7ZYwGGR+GaPOoZrMEiJ2WOK1PDH2RXTk5+33cm3DoL/rkFeMXb5A7zyFb",
+	"ksuXv/fJe4UxdqsfoYrnFaFqwrtVW2/HSRSBUtNrWPvLOF4JiCTEwDWjiSK1GEFYh2El5DVIk/dFs21X",
+	"7dDwPq7VzO9DmR0r3+TA40ww7pn+/eWvhUVo0qIY0lylsNQp40z0a2Jz+qydAZaJP+LTy6nS68R5cSbc",
+	"Ck7nNFHQa8OmgOD7oXm/lgQiN0zqnCbhUpgMjX3uVDAmhqqVZ0IkQPlnNO6gxokk6GnFErvjhRLRvb20",
+	"QSML+CBR+EwIPbW/tkn9ixDW3BcEd1X5NzxZE5VnTovP1uYhUjilSSjpasIrWSpc3rgWKC/XM8mKDCHP",
+	"U0REAgsarYNekMOcBb3AvhNceUBGpjOE87mj59XDhwb79mMTZC44BL3g9mPQCz4q7QfWqsMCwTvzX+9Q",
+	"/VRZ+V1j3ozf4VtfXrzz5UZsLDIV2V458aY+b/N5K+lQw0oL9M6yVwXbbzKA984u/ma6HasN7jdBwwq3",
+	"t1dkJpuw2oVOP5Uco3KjGlDtUZa4/AtwrIIYuWeJ+2ghs5+LfhD85uOwGt9sjnNN4hxdpTCGOeNFi01D",
+	"vBupKWyEQUWWaRs4riBJ+mScR0s70lYry8YVU6CqGet1BhOul7A2r8Ug2U0V6tVkiK4QG6bhoPgbLoCP",
+	"yi9ymaM6gHgBYVlzct9MrAKy+MGV0cwPiyjDf1FPlm6Q+dt4qyb8QS+4URkGr14kF/mZJutdM+5PFxVt",
+	"vt1qW1FJ7D7RQtPE96jFa2bRXtkfbOvadnBvY7qmFzhV4altz7vZ18HTgTUZA8Ssz25s7BnrLtxKy3Ug",
+	"WDoQurrTj9wNWO8Wi3sFrswKPqS4tKS/AwN11t7JbGgk7TvPIRPRsvakRncMXbjy5yW7br1LdetueiYB",
+	"qlovH/Qheepvi0oxAvSu6adsL7gBqTr+0Ki/u2fHbKIaX8Fqc9HBVUWIegXfT4ydmO0ibxuuOs8UW6Tx",
+	"8aZHnBa+lfcpyM2BQNnP9fLtS3INa+dgmGFxvYTZQ3V7cEKWcEtitmBabW5i3k6m7WRx1ncXdUqU1Mg0",
+	"Bnz0YEWCuhTeq0BQ81+6qWeqwLFyNxyJYt6XEC+pbXtDUwJcD9ACDlD/Pa0UoG2yGQg12CNaiZYQXU8X",
+	"2aLLAL+BZHPrc5aMZNIr2PnRrF43CklrU7WecOMduCKy4CTnjnPKoZTXvljmWoEEwrGg7142Ea/gpcm/",
+	"hnW3c2ndiDhrAdEiW2yIaspnHh/E8btqluk7i5KIcsz61iDtEcYnnLoDCuiCu1X65D84YdkZhRJzNj6/",
+	"uCBUpgJDaRQw03Ml5IRTZTLG36sfyPvLXxVO6xD0nWP2G5AGf98htZG3ce4Jx1qsZjove4H3LyyyBRcS",
+	"pkrVlWkNlylomjB+7efPlEkppOrPIRaSuvxKX8jFoBj3d8Tcz/Z5eDjCjP/oBCH/uTRSu5jVLpI4z6YJ",
+	"RAkDPu5HwLVQZv2/O4z9/DRUWgJNaytT/PfkyP5i4PuFKngz3gMWuVSpD1HtjBm+5jPk3jbErk5Ya1Db",
+	"+7hMI2Wt/XC1BN7m1BV1BZWEKr1fk9WSabXtqJbrxigE18zuA4cq10y237II4NS1FOLy5ZCYagg1S/29",
+	"7DgKQdh/SMqUgvvsMBYr7mIHbD0qNrjftrpukdXSWxzVh7EB+/o6lsuX1nY73PQc+9Xx26IQ2rRxrQPq",
+	"oeren5MYiiRQfR8W+PwWOH/6qX5cYHuw2sg42tM4+zuDziJNFVgJpeV5uLf+4voXtTt4VBcqlqlxHFSe",
+	"bgVhe9QRnBdzeIxrIV89zHtVvwcdePz8XDBDb0czSSOv08Csv4OgwNdf0P7QlZrPb4Rok33/6KWg+32D",
+	"xs+KbSSkQsO0flTp88OHfaK6cauXrV060ezGNsc4r7J51tFk00N8VDMKGVVqJaT3pC2q6alX33fV/R4+",
+	"CUOvedk626llDj7fWMgF5a77sBUiD4+Gh6Mjf8FA3oDsglzvAeyjz1ODfKcJakDSa2O5sWgNZbXt+vyr",
+	"TrVOcNhDUn1HlO96O8eMD+83ZENRceew87f3G+A562jkf3uFVnwJ1tyk90DaniPaheV77L0YgVu/f/K8",
+	"TL/vUxSxA11VxJ907xWZjHrFoLvg3ml4mXO+KddeB+f0UzuLvVJ9hdXyhWVH9yXKimS2f0oFD9rjaJoX",
+	"mmawUizm4cE+h+I7GlmpZQjx6Pj44Bk5Ozs7Oz98/ZGeHyT/7/nFwet3L47xt4vX8uV/XshX/5f9+OrV",
+	"+1X+L3p59u/08ldx8fFyPvrj+Sh+fvxx+Mu728HJ7TYnrV5vBXmwn9ftM822KJtLptdjxKBF0S9ApUX6",
+	"zHz6Z2EI/v37u+KKC6Pe7XvlvGhJ7Bkq5hLG7eNstn1IC2KzNaaNzx3usVWTftBI+NoNB2eZOb436g9d",
+	"7FBFKavVqk/NYxN+u7Fq8OvF+YvX4xfhqD/sL3WaGBoybZD2ZvyLWd619Uti+uQIzVjNYJ8GI9f5yvHB",
+	"aXDYH/YPAltxN2gamOATPy18jQYvQRsv05wx3OZzusOJiAx8A8WWKc0iNeEu/dU4BWdiX8V4VB6dkDhG",
+	"auyeqE4N4VSCY5d1rqpybtWhzWPiUjn/FbMSPNtbYchQtuZexHY3JnNgT5FmgrtwdjQcBqZz2ESH+JFm",
+	"WcKsBzX4r2sKrm5F2XpsqDztaZioW6pD1DQzEUiiowcEwTX9+pe3dCpP8pCUcrqA2MJw8PVhOMv1kmiB",
+	"x3CYIoybNke7+uHXX/09p7leCsk+2hbVDCR6iKTkE4Tk+M+gxXsOtxlEWHm1F96IKMol6rq6QjO2olBl",
+	"H67urmplnVI4Gxy1UTbNvFbcB7VsUSaU9h0/dqVeDre6EjghC3nLVSGfxTUNlXT3bEK2LfOds669MkGE",
+	"EzFZAWyOXmBvF07N/Em7mMWm7QpuMyaBrG3vU1Pg3wql64d/A2tSQOlfRLx+WJlvnS++u7u766iZI3/1",
+	"3pHD5BzLI2aPWuF/l1Y4Gh59fUjO2ilopIexfWvQ35JuctKwSSU5ZWRN/2Y1dG5SUu7kqnu7RzKhbWNl",
+	"ghd2ceUaIMWcmCIPTcruFR4Xhy3M7Wi22Z9JEgMOcQcH/FrDgfaVFEbzpPJd09fVMoeu7jh46NUvYh/t",
+	"3UOypKrwyP40TXRh5b6gdKELH7XQn6yF3vNrLla8pAOLvyXV45SGA76hadTgE4vvdkY7Nulg++lcpgBd",
+	"H5wkAQTNTWdONDHlAhZTkQfTni6kUdy6djLc5CMAL4bwhiWNw9m9xv2TH/xtJuXEFlgsnJszcoybDKpe",
+	"Vtc6untz6vqlfsnjg9/ocfU146sGpjwc1MTLX6a72KPz9Ki27qO23rUUz2b9NUhrHXRbFVnxop1xzjgz",
+	"tw7V1RdeOUMjTTDbhVLNBCcSdC45xO72GVUcWqmuv6hqtdvU2auq4P+o0HYotFeVZ+yN0EpSFseJbdxd",
+	"kPJRzz3quW8ra9VgaFpjZNR3RcZpc2h4CTYl5RLC1SVZRtUl7hB1o3HSqjWiC5034e1WyuK2xeL8QGxv",
+	"EyqgQWdQ2YtEbY7ZHzsWF2x8peCxfVHLXtHj8CssbzqDN6ir7pVUf7qGqqhmEUWc4iyJXqW7Smgfddm3",
+	"lwYvGLLGaahCzHSq5iJ1vJTqUpaOf+LbS/XKILN9XzvfMwd3vqr3UO3Bm00s9KBDxiN3/zWW2rL2t2en",
+	"K0NqDjAIpewxcMdNlZjtzqtQbq0qj8pSlYWsuvNmtibG+/YL6n5BRDnvlwYOh39yGFCS8lFGH2X0PjJq",
+	"x9anNnJZ9nxstn9v3Ct+rm4C66Yz0koYJ4gDdzXQtxh8bN0Ooq9+vs2r0+zxuO510K3rAGnnMsAJr/dT",
+	"m5dqYYhQ9jC3IinV0bK4R7QK+BeJmGHgojVIrmx84qv5M0XmoE3JfLbeErC8hKKjeWfe+Xl9JxbUztXW",
+	"tj0A4kL9tv6DoVZn+T6aeNPBgrteG76zOl4/D75Wq/s+8G06XdGF71ykKSUKEMka4tK2OvI7ugtpaEwK",
+	"Em+A1G5kTxivQXJI/k/Pf7D4q9o132nSTSku5HistfxlIaNF6mPd8dsNBp1aboaCxW3QamfCnBfX/KO/",
+	"Ww7DHzoJDaJA26vdJtwlr1R5vtUWzu1dGD6V2zgT8xWlr7HO1gixwtEj139zXI/krVOwwfSDT8jVd5bp",
+	"E9DQdQafm9/H1X88ttUPqB/aq/1nZZ5wy/y5j5nfdef51b4tdQVcponKbvsxufdXhVYlk3xTWUXkGUIb",
+	"wG+1HaUXLyESMrb/o101vke0WNj2kPJOwvJEqOmS39S+a65YMtc8SHA2aKdh+aZk+OHt3cbm+xoxH1XB",
+	"oyq4RyZUNVjLHrD0SdYrd/2uiPPI3hlt3+2c+qEZ64sMuFoy9z+z0YwN7AVm5mgRyLA4HDO4GQXdgHKs",
+	"6QLjpS0LKI33In7ZMgZfvLgeuFxm1zxXd/8zAAZe04NWeQAA",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
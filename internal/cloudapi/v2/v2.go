@@ -10,8 +10,10 @@ import (
 	"math/big"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/gobwas/glob"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -21,11 +23,12 @@ import (
 	"github.com/osbuild/osbuild-composer/internal/common"
 	"github.com/osbuild/osbuild-composer/internal/distro"
 	"github.com/osbuild/osbuild-composer/internal/distroregistry"
-	"github.com/osbuild/osbuild-composer/internal/jobqueue"
 	osbuild "github.com/osbuild/osbuild-composer/internal/osbuild2"
 	"github.com/osbuild/osbuild-composer/internal/ostree"
 	"github.com/osbuild/osbuild-composer/internal/prometheus"
+	"github.com/osbuild/osbuild-composer/internal/reporegistry"
 	"github.com/osbuild/osbuild-composer/internal/rpmmd"
+	"github.com/osbuild/osbuild-composer/internal/snapshot"
 	"github.com/osbuild/osbuild-composer/internal/target"
 	"github.com/osbuild/osbuild-composer/internal/worker"
 	"github.com/osbuild/osbuild-composer/internal/worker/clienterrors"
@@ -35,7 +38,10 @@ import (
 type Server struct {
 	workers     *worker.Server
 	rpmMetadata rpmmd.RPMMD
+	rpmCache    *rpmmd.CacheManager // nil if the cache cannot be managed
 	distros     *distroregistry.Registry
+	repos       *reporegistry.RepoRegistry // nil if no repositories are configured
+	snapshots   *snapshot.Store
 	awsBucket   string
 }
 
@@ -45,11 +51,14 @@ type apiHandlers struct {
 
 type binder struct{}
 
-func NewServer(workers *worker.Server, rpmMetadata rpmmd.RPMMD, distros *distroregistry.Registry, bucket string) *Server {
+func NewServer(workers *worker.Server, rpmMetadata rpmmd.RPMMD, rpmCache *rpmmd.CacheManager, distros *distroregistry.Registry, repos *reporegistry.RepoRegistry, snapshots *snapshot.Store, bucket string) *Server {
 	server := &Server{
 		workers:     workers,
 		rpmMetadata: rpmMetadata,
+		rpmCache:    rpmCache,
 		distros:     distros,
+		repos:       repos,
+		snapshots:   snapshots,
 		awsBucket:   bucket,
 	}
 	return server
@@ -169,23 +178,77 @@ func (h *apiHandlers) PostCompose(ctx echo.Context) error {
 	if err != nil {
 		return HTTPError(ErrorUnsupportedArchitecture)
 	}
-	imageType, err := arch.GetImageType(imageTypeFromApiImageType(ir.ImageType))
+	// user-defined image types are requested by their name and uploaded
+	// like the image type they are derived from
+	apiImageType := ir.ImageType
+	imageTypeName := imageTypeFromApiImageType(ir.ImageType)
+	if imageTypeName == "" {
+		base, ok := h.server.distros.UserImageTypeBase(distribution.Name(), arch.Name(), string(ir.ImageType))
+		if !ok {
+			return HTTPError(ErrorUnsupportedImageType)
+		}
+		apiImageType, ok = apiImageTypeFromImageType(base)
+		if !ok {
+			return HTTPError(ErrorUnsupportedImageType)
+		}
+		imageTypeName = string(ir.ImageType)
+	}
+	imageType, err := arch.GetImageType(imageTypeName)
 	if err != nil {
 		return HTTPError(ErrorUnsupportedImageType)
 	}
-	repositories := make([]rpmmd.RepoConfig, len(ir.Repositories))
-	for j, repo := range ir.Repositories {
-		repositories[j].RHSM = repo.Rhsm
 
-		if repo.Baseurl != nil {
-			repositories[j].BaseURL = *repo.Baseurl
-		} else if repo.Mirrorlist != nil {
-			repositories[j].MirrorList = *repo.Mirrorlist
-		} else if repo.Metalink != nil {
-			repositories[j].Metalink = *repo.Metalink
-		} else {
-			return HTTPError(ErrorInvalidRepository)
+	if request.Snapshot != nil && request.SaveSnapshot != nil {
+		return HTTPError(ErrorInvalidSnapshotOptions)
+	}
+
+	packageSets := imageType.PackageSets(bp)
+
+	// the worker makes sure the packages of a snapshot are still available
+	// before building
+	var snapshotPackages map[string][]rpmmd.PackageSpec
+	var checkPackages []rpmmd.PackageSpec
+	if request.Snapshot != nil {
+		s := h.server.snapshots.Get(*request.Snapshot)
+		if s == nil {
+			return HTTPError(ErrorSnapshotNotFound)
+		}
+		err = s.Matches(distribution.Name(), arch.Name(), imageType.Name())
+		if err != nil {
+			return HTTPErrorWithInternal(ErrorSnapshotMismatch, err)
+		}
+		err = s.MatchesPackageRequests(packageSets)
+		if err != nil {
+			return HTTPErrorWithInternal(ErrorSnapshotPackagesChanged, err)
 		}
+		// packages of local repositories only exist on this host
+		err = snapshot.CheckLocalPackages(s.Packages())
+		if err != nil {
+			return HTTPErrorWithInternal(ErrorSnapshotPackagesMissing, err)
+		}
+		snapshotPackages = s.PackageSets
+		checkPackages = s.Packages()
+	}
+
+	var saveSnapshot *snapshot.Snapshot
+	if request.SaveSnapshot != nil {
+		if !snapshot.ValidName.MatchString(*request.SaveSnapshot) {
+			return HTTPError(ErrorInvalidSnapshotName)
+		}
+		if h.server.snapshots.Get(*request.SaveSnapshot) != nil {
+			return HTTPError(ErrorSnapshotExists)
+		}
+		saveSnapshot = &snapshot.Snapshot{
+			Name:            *request.SaveSnapshot,
+			Distro:          distribution.Name(),
+			Arch:            arch.Name(),
+			ImageType:       imageType.Name(),
+			PackageRequests: packageSets,
+		}
+	}
+	repositories, err := convertRepositories(ir.Repositories)
+	if err != nil {
+		return err
 	}
 
 	payloadPackageSets := imageType.PayloadPackageSets()
@@ -199,8 +262,9 @@ func (h *apiHandlers) PostCompose(ctx echo.Context) error {
 			} else {
 				return HTTPError(ErrorNoBaseURLInPayloadRepository)
 			}
-			if repo.GpgKey != nil {
-				packageSetsRepositories[packageSetKey][j].GPGKey = *repo.GpgKey
+			packageSetsRepositories[packageSetKey][j].GPGKeys, err = repositoryGPGKeys(repo)
+			if err != nil {
+				return err
 			}
 			if repo.CheckGpg != nil {
 				packageSetsRepositories[packageSetKey][j].CheckGPG = *repo.CheckGpg
@@ -211,20 +275,30 @@ func (h *apiHandlers) PostCompose(ctx echo.Context) error {
 		}
 	}
 
-	packageSets := imageType.PackageSets(bp)
-	depsolveJobID, err := h.server.workers.EnqueueDepsolve(&worker.DepsolveJob{
-		PackageSets:             packageSets,
-		Repos:                   repositories,
-		ModulePlatformID:        distribution.ModulePlatformID(),
-		Arch:                    arch.Name(),
-		Releasever:              distribution.Releasever(),
-		PackageSetsRepositories: packageSetsRepositories,
-	})
-	if err != nil {
-		return HTTPErrorWithInternal(ErrorEnqueueingJob, err)
+	// packages are taken from the snapshot without depsolving
+	var depsolveJobID uuid.UUID
+	if snapshotPackages == nil {
+		depsolveJobID, err = h.server.workers.EnqueueDepsolve(&worker.DepsolveJob{
+			PackageSets:             packageSets,
+			Repos:                   repositories,
+			ModulePlatformID:        distribution.ModulePlatformID(),
+			Arch:                    arch.Name(),
+			Releasever:              distribution.Releasever(),
+			PackageSetsRepositories: packageSetsRepositories,
+			CacheRefresh:            h.server.cacheRefresh(repositories, packageSetsRepositories),
+		})
+		if err != nil {
+			return HTTPErrorWithInternal(ErrorEnqueueingJob, err)
+		}
 	}
 
 	imageOptions := distro.ImageOptions{Size: imageType.Size(0)}
+	if ir.BootType != nil {
+		imageOptions.BootType = distro.BootType(*ir.BootType)
+	}
+	if ir.Compression != nil {
+		imageOptions.Compression = distro.Compression(*ir.Compression)
+	}
 	if request.Customizations != nil && request.Customizations.Subscription != nil {
 		imageOptions.Subscription = &distro.SubscriptionImageOptions{
 			Organization:  request.Customizations.Subscription.Organization,
@@ -281,7 +355,7 @@ func (h *apiHandlers) PostCompose(ctx echo.Context) error {
 
 	var irTarget *target.Target
 	/* oneOf is not supported by the openapi generator so marshal and unmarshal the uploadrequest based on the type */
-	switch ir.ImageType {
+	switch apiImageType {
 	case ImageTypesAws:
 		var awsUploadOptions AWSEC2UploadOptions
 		jsonUploadOptions, err := json.Marshal(ir.UploadOptions)
@@ -298,13 +372,22 @@ func (h *apiHandlers) PostCompose(ctx echo.Context) error {
 		// guaranteed to be unique as well. If users are ever allowed to name their images,
 		// an extra tag should be added.
 		key := fmt.Sprintf("composer-api-%s", uuid.New().String())
-		t := target.NewAWSTarget(&target.AWSTargetOptions{
-			Filename:          imageType.Filename(),
+		awsTargetOptions := &target.AWSTargetOptions{
+			Filename:          distro.ImageFilename(imageType, imageOptions),
 			Region:            awsUploadOptions.Region,
 			Bucket:            h.server.awsBucket,
 			Key:               key,
 			ShareWithAccounts: awsUploadOptions.ShareWithAccounts,
-		})
+		}
+		if awsUploadOptions.CopyToRegions != nil {
+			for _, region := range *awsUploadOptions.CopyToRegions {
+				if !target.IsValidAWSRegion(region) {
+					return HTTPError(ErrorInvalidUploadOptions)
+				}
+			}
+			awsTargetOptions.CopyToRegions = *awsUploadOptions.CopyToRegions
+		}
+		t := target.NewAWSTarget(awsTargetOptions)
 		if awsUploadOptions.SnapshotName != nil {
 			t.ImageName = *awsUploadOptions.SnapshotName
 		} else {
@@ -318,24 +401,62 @@ func (h *apiHandlers) PostCompose(ctx echo.Context) error {
 		fallthrough
 	case ImageTypesImageInstaller:
 		fallthrough
+	case ImageTypesMinimalRaw:
+		fallthrough
 	case ImageTypesEdgeInstaller:
 		fallthrough
 	case ImageTypesEdgeContainer:
 		fallthrough
 	case ImageTypesEdgeCommit:
-		var awsS3UploadOptions AWSS3UploadOptions
 		jsonUploadOptions, err := json.Marshal(ir.UploadOptions)
 		if err != nil {
 			return HTTPError(ErrorJSONMarshallingError)
 		}
-		err = json.Unmarshal(jsonUploadOptions, &awsS3UploadOptions)
+
+		// Generic S3 upload options are distinguished from the AWS S3 ones
+		// by the presence of the endpoint
+		var genericS3UploadOptions GenericS3UploadOptions
+		err = json.Unmarshal(jsonUploadOptions, &genericS3UploadOptions)
 		if err != nil {
 			return HTTPError(ErrorJSONUnMarshallingError)
 		}
 
 		key := fmt.Sprintf("composer-api-%s", uuid.New().String())
+		if genericS3UploadOptions.Endpoint != "" {
+			if genericS3UploadOptions.Bucket == "" {
+				return HTTPError(ErrorInvalidUploadOptions)
+			}
+			options := &target.GenericS3TargetOptions{
+				AWSS3TargetOptions: target.AWSS3TargetOptions{
+					Filename: distro.ImageFilename(imageType, imageOptions),
+					Region:   genericS3UploadOptions.Region,
+					Bucket:   genericS3UploadOptions.Bucket,
+					Key:      key,
+				},
+				Endpoint: genericS3UploadOptions.Endpoint,
+			}
+			if genericS3UploadOptions.AccessKeyId != nil && genericS3UploadOptions.SecretAccessKey != nil {
+				options.AccessKeyID = *genericS3UploadOptions.AccessKeyId
+				options.SecretAccessKey = *genericS3UploadOptions.SecretAccessKey
+			}
+			if genericS3UploadOptions.PathStyle != nil {
+				options.PathStyle = *genericS3UploadOptions.PathStyle
+			}
+			t := target.NewGenericS3Target(options)
+			t.ImageName = key
+
+			irTarget = t
+			break
+		}
+
+		var awsS3UploadOptions AWSS3UploadOptions
+		err = json.Unmarshal(jsonUploadOptions, &awsS3UploadOptions)
+		if err != nil {
+			return HTTPError(ErrorJSONUnMarshallingError)
+		}
+
 		t := target.NewAWSS3Target(&target.AWSS3TargetOptions{
-			Filename: imageType.Filename(),
+			Filename: distro.ImageFilename(imageType, imageOptions),
 			Region:   awsS3UploadOptions.Region,
 			Bucket:   h.server.awsBucket,
 			Key:      key,
@@ -361,7 +482,7 @@ func (h *apiHandlers) PostCompose(ctx echo.Context) error {
 
 		object := fmt.Sprintf("composer-api-%s", uuid.New().String())
 		t := target.NewGCPTarget(&target.GCPTargetOptions{
-			Filename:          imageType.Filename(),
+			Filename:          distro.ImageFilename(imageType, imageOptions),
 			Region:            gcpUploadOptions.Region,
 			Os:                "", // not exposed in cloudapi for now
 			Bucket:            gcpUploadOptions.Bucket,
@@ -376,7 +497,7 @@ func (h *apiHandlers) PostCompose(ctx echo.Context) error {
 		}
 
 		irTarget = t
-	case ImageTypesAzure:
+	case ImageTypesAzure, ImageTypesAzureGen2, ImageTypesAzureRhui:
 		var azureUploadOptions AzureUploadOptions
 		jsonUploadOptions, err := json.Marshal(ir.UploadOptions)
 		if err != nil {
@@ -386,13 +507,18 @@ func (h *apiHandlers) PostCompose(ctx echo.Context) error {
 		if err != nil {
 			return HTTPError(ErrorJSONUnMarshallingError)
 		}
-		t := target.NewAzureImageTarget(&target.AzureImageTargetOptions{
-			Filename:       imageType.Filename(),
+		azureTargetOptions := &target.AzureImageTargetOptions{
+			Filename:       distro.ImageFilename(imageType, imageOptions),
 			TenantID:       azureUploadOptions.TenantId,
 			Location:       azureUploadOptions.Location,
 			SubscriptionID: azureUploadOptions.SubscriptionId,
 			ResourceGroup:  azureUploadOptions.ResourceGroup,
-		})
+		}
+		// Gen2 images boot only with UEFI and must be registered as such
+		if apiImageType == ImageTypesAzureGen2 {
+			azureTargetOptions.HyperVGeneration = "V2"
+		}
+		t := target.NewAzureImageTarget(azureTargetOptions)
 
 		if azureUploadOptions.ImageName != nil {
 			t.ImageName = *azureUploadOptions.ImageName
@@ -406,7 +532,12 @@ func (h *apiHandlers) PostCompose(ctx echo.Context) error {
 		return HTTPError(ErrorUnsupportedImageType)
 	}
 
-	manifestJobID, err := h.server.workers.EnqueueManifestJobByID(&worker.ManifestJobByID{}, depsolveJobID)
+	var manifestJobID uuid.UUID
+	if snapshotPackages != nil {
+		manifestJobID, err = h.server.workers.EnqueueManifestJob(&worker.ManifestJobByID{})
+	} else {
+		manifestJobID, err = h.server.workers.EnqueueManifestJobByID(&worker.ManifestJobByID{}, depsolveJobID)
+	}
 	if err != nil {
 		return HTTPErrorWithInternal(ErrorEnqueueingJob, err)
 	}
@@ -418,6 +549,7 @@ func (h *apiHandlers) PostCompose(ctx echo.Context) error {
 			Build:   imageType.BuildPipelines(),
 			Payload: imageType.PayloadPipelines(),
 		},
+		CheckPackages: checkPackages,
 	}, manifestJobID)
 	if err != nil {
 		return HTTPErrorWithInternal(ErrorEnqueueingJob, err)
@@ -427,30 +559,26 @@ func (h *apiHandlers) PostCompose(ctx echo.Context) error {
 	manifestJobContext, manifestCancel := context.WithTimeout(context.Background(), time.Minute*5)
 
 	// start 1 goroutine which requests datajob type
-	go func(workers *worker.Server, manifestJobID uuid.UUID, b *blueprint.Customizations, options distro.ImageOptions, repos []rpmmd.RepoConfig, seed int64, depsolveJobID uuid.UUID) {
+	go func(workers *worker.Server, manifestJobID uuid.UUID, b *blueprint.Customizations, options distro.ImageOptions, repos []rpmmd.RepoConfig, seed int64, depsolveJobID uuid.UUID, snapshotPackages map[string][]rpmmd.PackageSpec, saveSnapshot *snapshot.Snapshot) {
 		defer manifestCancel()
-		// wait until job is in a pending state
-		var token uuid.UUID
-		var dynArgs []json.RawMessage
-		var err error
-		for {
-			_, token, _, _, dynArgs, err = workers.RequestJobById(context.Background(), "", manifestJobID)
-			if err == jobqueue.ErrNotPending {
-				logrus.Debugf("Manifest job %v not pending, waiting for depsolve job to finish", manifestJobID)
-				time.Sleep(time.Millisecond * 50)
-				select {
-				case <-manifestJobContext.Done():
-					logrus.Warnf("Manifest job %v's dependencies took longer than 5 minutes to finish, returning to avoid dangling routines", manifestJobID)
-					return
-				default:
-					continue
-				}
+		// the manifest job is pending once the depsolve job it depends on
+		// has finished
+		if snapshotPackages == nil {
+			err := workers.WaitForJob(manifestJobContext, depsolveJobID)
+			if err == context.DeadlineExceeded {
+				logrus.Warnf("Manifest job %v's dependencies took longer than 5 minutes to finish, returning to avoid dangling routines", manifestJobID)
+				return
 			}
 			if err != nil {
-				logrus.Errorf("Error requesting manifest job: %v", err)
+				logrus.Errorf("Error waiting for the dependencies of manifest job %v: %v", manifestJobID, err)
 				return
 			}
-			break
+		}
+
+		_, token, _, _, dynArgs, err := workers.RequestJobById(manifestJobContext, "", manifestJobID)
+		if err != nil {
+			logrus.Errorf("Error requesting manifest job: %v", err)
+			return
 		}
 
 		var jobResult *worker.ManifestJobByIDResult = &worker.ManifestJobByIDResult{
@@ -473,37 +601,57 @@ func (h *apiHandlers) PostCompose(ctx echo.Context) error {
 			}
 		}()
 
-		if len(dynArgs) == 0 {
-			reason := "No dynamic arguments"
-			jobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorNoDynamicArgs, reason)
-			return
-		}
+		var packageSets map[string][]rpmmd.PackageSpec
+		if snapshotPackages != nil {
+			packageSets = snapshotPackages
+		} else {
+			if len(dynArgs) == 0 {
+				reason := "No dynamic arguments"
+				jobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorNoDynamicArgs, reason)
+				return
+			}
 
-		var depsolveResults worker.DepsolveJobResult
-		err = json.Unmarshal(dynArgs[0], &depsolveResults)
-		if err != nil {
-			reason := "Error parsing dynamic arguments"
-			jobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorParsingDynamicArgs, reason)
-			return
-		}
+			var depsolveResults worker.DepsolveJobResult
+			err = json.Unmarshal(dynArgs[0], &depsolveResults)
+			if err != nil {
+				reason := "Error parsing dynamic arguments"
+				jobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorParsingDynamicArgs, reason)
+				return
+			}
 
-		_, _, err = workers.JobStatus(depsolveJobID, &depsolveResults)
-		if err != nil {
-			reason := "Error reading depsolve status"
-			jobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorReadingJobStatus, reason)
-			return
-		}
+			_, _, err = workers.JobStatus(depsolveJobID, &depsolveResults)
+			if err != nil {
+				reason := "Error reading depsolve status"
+				jobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorReadingJobStatus, reason)
+				return
+			}
+			h.server.mergeCacheStats(depsolveResults.CacheStats)
 
-		if jobErr := depsolveResults.JobError; jobErr != nil {
-			if jobErr.ID == clienterrors.ErrorDNFDepsolveError || jobErr.ID == clienterrors.ErrorDNFMarkingError {
-				jobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorDepsolveDependency, "Error in depsolve job dependency input, bad package set requested")
+			if jobErr := depsolveResults.JobError; jobErr != nil {
+				if jobErr.ID == clienterrors.ErrorDNFDepsolveError || jobErr.ID == clienterrors.ErrorDNFMarkingError {
+					jobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorDepsolveDependency, "Error in depsolve job dependency input, bad package set requested")
+					return
+				}
+				jobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorDepsolveDependency, "Error in depsolve job dependency")
 				return
 			}
-			jobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorDepsolveDependency, "Error in depsolve job dependency")
-			return
+
+			packageSets = depsolveResults.PackageSpecs
+			if saveSnapshot != nil {
+				saveSnapshot.Created = time.Now()
+				saveSnapshot.RepoChecksums = depsolveResults.RepoChecksums
+				saveSnapshot.PackageSets = packageSets
+				err = h.server.snapshots.Push(saveSnapshot)
+				if err != nil {
+					reason := fmt.Sprintf("Error saving snapshot %s: %v", saveSnapshot.Name, err)
+					jobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorSnapshotSave, reason)
+					return
+				}
+			}
 		}
 
-		manifest, err := imageType.Manifest(b, options, repos, depsolveResults.PackageSpecs, seed)
+		repos = rpmmd.ResolveGPGKeys(repos, imageType.Arch().Name(), imageType.Arch().Distro().Releasever())
+		manifest, err := imageType.Manifest(b, options, repos, packageSets, seed)
 		if err != nil {
 			reason := "Error generating manifest"
 			jobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorManifestGeneration, reason)
@@ -511,7 +659,7 @@ func (h *apiHandlers) PostCompose(ctx echo.Context) error {
 		}
 
 		jobResult.Manifest = manifest
-	}(h.server.workers, manifestJobID, blueprintCustoms, imageOptions, repositories, manifestSeed, depsolveJobID)
+	}(h.server.workers, manifestJobID, blueprintCustoms, imageOptions, repositories, manifestSeed, depsolveJobID, snapshotPackages, saveSnapshot)
 
 	return ctx.JSON(http.StatusCreated, &ComposeId{
 		ObjectReference: ObjectReference{
@@ -531,12 +679,18 @@ func imageTypeFromApiImageType(it ImageTypes) string {
 		return "vhd"
 	case ImageTypesAzure:
 		return "vhd"
+	case ImageTypesAzureGen2:
+		return "azure-gen2"
+	case ImageTypesAzureRhui:
+		return "azure-rhui"
 	case ImageTypesGuestImage:
 		return "qcow2"
 	case ImageTypesVsphere:
 		return "vmdk"
 	case ImageTypesImageInstaller:
 		return "image-installer"
+	case ImageTypesMinimalRaw:
+		return "minimal-raw"
 	case ImageTypesEdgeCommit:
 		return "rhel-edge-commit"
 	case ImageTypesEdgeContainer:
@@ -547,6 +701,36 @@ func imageTypeFromApiImageType(it ImageTypes) string {
 	return ""
 }
 
+// apiImageTypeFromImageType returns the API image type which is uploaded like
+// the image type name, for user-defined image types derived from it
+func apiImageTypeFromImageType(name string) (ImageTypes, bool) {
+	switch name {
+	case "ami":
+		return ImageTypesAws, true
+	case "vhd":
+		return ImageTypesAzure, true
+	case "azure-gen2":
+		return ImageTypesAzureGen2, true
+	case "azure-rhui":
+		return ImageTypesAzureRhui, true
+	case "qcow2":
+		return ImageTypesGuestImage, true
+	case "vmdk":
+		return ImageTypesVsphere, true
+	case "image-installer":
+		return ImageTypesImageInstaller, true
+	case "minimal-raw":
+		return ImageTypesMinimalRaw, true
+	case "rhel-edge-commit":
+		return ImageTypesEdgeCommit, true
+	case "rhel-edge-container":
+		return ImageTypesEdgeContainer, true
+	case "rhel-edge-installer":
+		return ImageTypesEdgeInstaller, true
+	}
+	return "", false
+}
+
 func (h *apiHandlers) GetComposeStatus(ctx echo.Context, id string) error {
 	jobId, err := uuid.Parse(id)
 	if err != nil {
@@ -574,16 +758,41 @@ func (h *apiHandlers) GetComposeStatus(ctx echo.Context, id string) error {
 		case "org.osbuild.aws":
 			uploadType = UploadTypesAws
 			awsOptions := tr.Options.(*target.AWSTargetResultOptions)
-			uploadOptions = AWSEC2UploadStatus{
+			awsStatus := AWSEC2UploadStatus{
 				Ami:    awsOptions.Ami,
 				Region: awsOptions.Region,
 			}
+			if len(awsOptions.Copies) > 0 {
+				var copies []AWSEC2ImageCopy
+				for _, c := range awsOptions.Copies {
+					imageCopy := AWSEC2ImageCopy{
+						Region: c.Region,
+					}
+					if c.Ami != "" {
+						ami := c.Ami
+						imageCopy.Ami = &ami
+					}
+					if c.Error != "" {
+						copyErr := c.Error
+						imageCopy.Error = &copyErr
+					}
+					copies = append(copies, imageCopy)
+				}
+				awsStatus.Copies = &copies
+			}
+			uploadOptions = awsStatus
 		case "org.osbuild.aws.s3":
 			uploadType = UploadTypesAwsS3
 			awsOptions := tr.Options.(*target.AWSS3TargetResultOptions)
 			uploadOptions = AWSS3UploadStatus{
 				Url: awsOptions.URL,
 			}
+		case "org.osbuild.generic.s3":
+			uploadType = UploadTypesGenericS3
+			s3Options := tr.Options.(*target.GenericS3TargetResultOptions)
+			uploadOptions = AWSS3UploadStatus{
+				Url: s3Options.URL,
+			}
 		case "org.osbuild.gcp":
 			uploadType = UploadTypesGcp
 			gcpOptions := tr.Options.(*target.GCPTargetResultOptions)
@@ -720,6 +929,11 @@ func (h *apiHandlers) GetComposeMetadata(ctx echo.Context, id string) error {
 		resp.OstreeCommit = &ostreeCommitMetadata.Compose.OSTreeCommit
 	}
 
+	if result.ImageSize != 0 {
+		imageSize := int64(result.ImageSize)
+		resp.ImageSize = &imageSize
+	}
+
 	return ctx.JSON(200, resp)
 }
 
@@ -729,17 +943,457 @@ func stagesToPackageMetadata(stages []osbuild.RPMStageMetadata) []PackageMetadat
 		for _, rpm := range md.Packages {
 			packages = append(packages,
 				PackageMetadata{
-					Type:      "rpm",
-					Name:      rpm.Name,
-					Version:   rpm.Version,
-					Release:   rpm.Release,
-					Epoch:     rpm.Epoch,
-					Arch:      rpm.Arch,
-					Sigmd5:    rpm.SigMD5,
-					Signature: rpmmd.PackageMetadataToSignature(rpm),
+					Type:        "rpm",
+					Name:        rpm.Name,
+					Version:     rpm.Version,
+					Release:     rpm.Release,
+					Epoch:       rpm.Epoch,
+					Arch:        rpm.Arch,
+					Sigmd5:      rpm.SigMD5,
+					Signature:   rpmmd.PackageMetadataToSignature(rpm),
+					SignerKeyId: rpmmd.PackageMetadataToSignerKeyID(rpm),
 				},
 			)
 		}
 	}
 	return packages
 }
+
+func (h *apiHandlers) GetSnapshotList(ctx echo.Context) error {
+	names := h.server.snapshots.List()
+
+	items := make([]ObjectReference, 0, len(names))
+	for _, name := range names {
+		items = append(items, ObjectReference{
+			Href: fmt.Sprintf("/api/image-builder-composer/v2/snapshots/%s", name),
+			Id:   name,
+			Kind: "Snapshot",
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, SnapshotList{
+		List: List{
+			Kind:  "SnapshotList",
+			Page:  0,
+			Size:  len(items),
+			Total: len(items),
+		},
+		Items: items,
+	})
+}
+
+func (h *apiHandlers) GetSnapshot(ctx echo.Context, name string) error {
+	s := h.server.snapshots.Get(name)
+	if s == nil {
+		return HTTPError(ErrorSnapshotNotFound)
+	}
+
+	packageSets := make(map[string][]SnapshotPackage, len(s.PackageSets))
+	for setName, packages := range s.PackageSets {
+		packageSets[setName] = snapshotPackages(packages)
+	}
+
+	apiSnapshot := Snapshot{
+		ObjectReference: ObjectReference{
+			Href: fmt.Sprintf("/api/image-builder-composer/v2/snapshots/%s", s.Name),
+			Id:   s.Name,
+			Kind: "Snapshot",
+		},
+		Name:         s.Name,
+		Created:      s.Created,
+		Distribution: s.Distro,
+		Architecture: s.Arch,
+		ImageType:    s.ImageType,
+		PackageSets: Snapshot_PackageSets{
+			AdditionalProperties: packageSets,
+		},
+	}
+	if len(s.RepoChecksums) > 0 {
+		apiSnapshot.RepoChecksums = &Snapshot_RepoChecksums{
+			AdditionalProperties: s.RepoChecksums,
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, apiSnapshot)
+}
+
+func (h *apiHandlers) DeleteSnapshot(ctx echo.Context, name string) error {
+	err := h.server.snapshots.Delete(name)
+	if err == snapshot.ErrNotFound {
+		return HTTPError(ErrorSnapshotNotFound)
+	} else if err != nil {
+		return HTTPErrorWithInternal(ErrorFailedToDeleteSnapshot, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func convertRepositories(repos []Repository) ([]rpmmd.RepoConfig, error) {
+	repositories := make([]rpmmd.RepoConfig, len(repos))
+	for j, repo := range repos {
+		repositories[j].RHSM = repo.Rhsm
+
+		if repo.Baseurl != nil {
+			repositories[j].BaseURL = *repo.Baseurl
+		} else if repo.Mirrorlist != nil {
+			repositories[j].MirrorList = *repo.Mirrorlist
+		} else if repo.Metalink != nil {
+			repositories[j].Metalink = *repo.Metalink
+		} else {
+			return nil, HTTPError(ErrorInvalidRepository)
+		}
+
+		var err error
+		repositories[j].GPGKeys, err = repositoryGPGKeys(repo)
+		if err != nil {
+			return nil, err
+		}
+		if repo.CheckGpg != nil {
+			repositories[j].CheckGPG = *repo.CheckGpg
+		}
+	}
+	return repositories, nil
+}
+
+// repositoryGPGKeys returns all GPG keys of repo. Keys are given as key data
+// or http(s) URLs, the service does not read keys from its own filesystem.
+func repositoryGPGKeys(repo Repository) ([]string, error) {
+	var keys []string
+	if repo.GpgKey != nil {
+		keys = append(keys, *repo.GpgKey)
+	}
+	if repo.GpgKeys != nil {
+		keys = append(keys, *repo.GpgKeys...)
+	}
+	for _, key := range keys {
+		if !rpmmd.IsInlineGPGKey(key) && !rpmmd.IsGPGKeyURL(key) {
+			return nil, HTTPError(ErrorInvalidGPGKey)
+		}
+	}
+	if repo.CheckGpg != nil && *repo.CheckGpg && len(keys) == 0 {
+		return nil, HTTPError(ErrorNoGPGKey)
+	}
+	return keys, nil
+}
+
+func snapshotPackages(packages []rpmmd.PackageSpec) []SnapshotPackage {
+	set := make([]SnapshotPackage, 0, len(packages))
+	for _, pkg := range packages {
+		epoch := int(pkg.Epoch)
+		p := SnapshotPackage{
+			Name:    pkg.Name,
+			Epoch:   &epoch,
+			Version: pkg.Version,
+			Release: pkg.Release,
+			Arch:    pkg.Arch,
+		}
+		if pkg.Checksum != "" {
+			p.Checksum = common.StringToPtr(pkg.Checksum)
+		}
+		if pkg.RemoteLocation != "" {
+			p.RemoteLocation = common.StringToPtr(pkg.RemoteLocation)
+		}
+		set = append(set, p)
+	}
+	return set
+}
+
+// depsolveTimeout limits how long the package search and depsolve requests
+// wait for their job to be picked up and finished by a worker
+const depsolveTimeout = 5 * time.Minute
+
+// depsolvePackageSet is the name of the package set depsolved for
+// POST /depsolve requests
+const depsolvePackageSet = "packages"
+
+// configuredRepositories returns the repositories configured in composer
+// for the distribution and architecture
+func (h *apiHandlers) configuredRepositories(distribution distro.Distro, arch distro.Arch) ([]rpmmd.RepoConfig, error) {
+	if h.server.repos == nil {
+		return nil, HTTPError(ErrorNoRepositories)
+	}
+	repos, err := h.server.repos.ReposByArchName(distribution.Name(), arch.Name(), false)
+	if err != nil {
+		return nil, HTTPErrorWithInternal(ErrorNoRepositories, err)
+	}
+	return repos, nil
+}
+
+// cacheRefresh returns the refresh requests of the metadata cache for a
+// depsolve or search job using repos and packageSetsRepositories
+func (s *Server) cacheRefresh(repos []rpmmd.RepoConfig, packageSetsRepositories map[string][]rpmmd.RepoConfig) map[string]time.Time {
+	if s.rpmCache == nil {
+		return nil
+	}
+	all := append([]rpmmd.RepoConfig{}, repos...)
+	for _, r := range packageSetsRepositories {
+		all = append(all, r...)
+	}
+	return s.rpmCache.RefreshRequests(all)
+}
+
+// mergeCacheStats adds the statistics of the metadata cache of a worker,
+// which are reported with the result of depsolve and search jobs
+func (s *Server) mergeCacheStats(stats []rpmmd.RepoCacheStats) {
+	if s.rpmCache != nil {
+		s.rpmCache.Merge(stats)
+	}
+}
+
+func (h *apiHandlers) GetCache(ctx echo.Context) error {
+	if h.server.rpmCache == nil {
+		return HTTPError(ErrorNoMetadataCache)
+	}
+
+	config := h.server.rpmCache.Config()
+	stats := h.server.rpmCache.Stats()
+	repos := make([]RepositoryCacheStats, 0, len(stats))
+	for _, s := range stats {
+		repos = append(repos, RepositoryCacheStats{
+			Name:        s.Name,
+			Url:         s.URL,
+			Hits:        int64(s.Hits),
+			Misses:      int64(s.Misses),
+			Bytes:       s.Bytes,
+			LastUsed:    s.LastUsed,
+			LastRefresh: s.LastRefresh,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, CacheInfo{
+		Ttl:          int64(config.TTL.Seconds()),
+		MaxSize:      config.MaxSize,
+		Repositories: repos,
+	})
+}
+
+func (h *apiHandlers) PostCacheRefresh(ctx echo.Context) error {
+	if h.server.rpmCache == nil {
+		return HTTPError(ErrorNoMetadataCache)
+	}
+
+	var request CacheRefreshRequest
+	if ctx.Request().ContentLength != 0 {
+		err := ctx.Bind(&request)
+		if err != nil {
+			return err
+		}
+	}
+
+	if request.Repositories == nil || len(*request.Repositories) == 0 {
+		h.server.rpmCache.Refresh()
+		return ctx.NoContent(http.StatusNoContent)
+	}
+
+	for _, name := range *request.Repositories {
+		err := h.server.rpmCache.RefreshRepo(name)
+		if err == rpmmd.ErrRepoNotCached {
+			return HTTPErrorWithInternal(ErrorRepositoryNotCached, fmt.Errorf("repository %s", name))
+		} else if err != nil {
+			return HTTPErrorWithInternal(ErrorNoMetadataCache, err)
+		}
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// waitForJob waits for the job to finish and reads its result. The job is
+// canceled when it does not finish within depsolveTimeout or when the
+// request is canceled.
+func (h *apiHandlers) waitForJob(ctx echo.Context, jobID uuid.UUID, result interface{}) error {
+	waitCtx, cancel := context.WithTimeout(ctx.Request().Context(), depsolveTimeout)
+	defer cancel()
+
+	err := h.server.workers.WaitForJob(waitCtx, jobID)
+	if err != nil && waitCtx.Err() == nil {
+		return HTTPErrorWithInternal(ErrorGettingDepsolveJobStatus, err)
+	}
+	if err != nil {
+		cancelErr := h.server.workers.Cancel(jobID)
+		if cancelErr != nil {
+			logrus.Errorf("Error canceling job %v: %v", jobID, cancelErr)
+		}
+		if ctx.Request().Context().Err() != nil {
+			return HTTPErrorWithInternal(ErrorDepsolveJobCanceled, ctx.Request().Context().Err())
+		}
+		return HTTPError(ErrorDepsolveTimeout)
+	}
+
+	status, _, err := h.server.workers.JobStatus(jobID, result)
+	if err != nil {
+		return HTTPErrorWithInternal(ErrorGettingDepsolveJobStatus, err)
+	}
+	if status.Canceled {
+		return HTTPError(ErrorDepsolveJobCanceled)
+	}
+	return nil
+}
+
+// dnfJobError returns the error of a finished depsolve or search job. Errors
+// caused by the requested packages are returned as bad requests.
+func dnfJobError(jobErr *clienterrors.Error) error {
+	if jobErr.ID == clienterrors.ErrorDNFDepsolveError || jobErr.ID == clienterrors.ErrorDNFMarkingError {
+		return HTTPErrorWithInternal(ErrorDNFError, fmt.Errorf("%s", jobErr.Reason))
+	}
+	return HTTPErrorWithInternal(ErrorFailedToDepsolve, fmt.Errorf("%s", jobErr.Reason))
+}
+
+// runDepsolveJob enqueues job and waits for it to finish.
+func (h *apiHandlers) runDepsolveJob(ctx echo.Context, job *worker.DepsolveJob) (*worker.DepsolveJobResult, error) {
+	job.CacheRefresh = h.server.cacheRefresh(job.Repos, job.PackageSetsRepositories)
+	jobID, err := h.server.workers.EnqueueDepsolve(job)
+	if err != nil {
+		return nil, HTTPErrorWithInternal(ErrorEnqueueingJob, err)
+	}
+
+	var result worker.DepsolveJobResult
+	err = h.waitForJob(ctx, jobID, &result)
+	if err != nil {
+		return nil, err
+	}
+	h.server.mergeCacheStats(result.CacheStats)
+	if result.JobError != nil {
+		return nil, dnfJobError(result.JobError)
+	}
+
+	return &result, nil
+}
+
+// runSearchJob enqueues job and waits for it to finish.
+func (h *apiHandlers) runSearchJob(ctx echo.Context, job *worker.SearchJob) (*worker.SearchJobResult, error) {
+	job.CacheRefresh = h.server.cacheRefresh(job.Repos, nil)
+	jobID, err := h.server.workers.EnqueueSearch(job)
+	if err != nil {
+		return nil, HTTPErrorWithInternal(ErrorEnqueueingJob, err)
+	}
+
+	var result worker.SearchJobResult
+	err = h.waitForJob(ctx, jobID, &result)
+	if err != nil {
+		return nil, err
+	}
+	h.server.mergeCacheStats(result.CacheStats)
+	if result.JobError != nil {
+		return nil, dnfJobError(result.JobError)
+	}
+
+	return &result, nil
+}
+
+func (h *apiHandlers) GetPackages(ctx echo.Context, params GetPackagesParams) error {
+	distribution := h.server.distros.GetDistro(params.Distribution)
+	if distribution == nil {
+		return HTTPError(ErrorUnsupportedDistribution)
+	}
+	arch, err := distribution.GetArch(params.Architecture)
+	if err != nil {
+		return HTTPError(ErrorUnsupportedArchitecture)
+	}
+
+	var patterns []string
+	for _, pattern := range strings.Split(params.Search, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := glob.Compile(pattern); err != nil {
+			return HTTPErrorWithInternal(ErrorInvalidSearch, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	if len(patterns) == 0 {
+		return HTTPError(ErrorInvalidSearch)
+	}
+
+	repos, err := h.configuredRepositories(distribution, arch)
+	if err != nil {
+		return err
+	}
+
+	result, err := h.runSearchJob(ctx, &worker.SearchJob{
+		Patterns:         patterns,
+		Repos:            repos,
+		ModulePlatformID: distribution.ModulePlatformID(),
+		Arch:             arch.Name(),
+		Releasever:       distribution.Releasever(),
+	})
+	if err != nil {
+		return err
+	}
+
+	packages := make([]PackageInfo, 0, len(result.Packages))
+	for _, pkg := range result.Packages {
+		epoch := int(pkg.Epoch)
+		p := PackageInfo{
+			Name:    pkg.Name,
+			Epoch:   &epoch,
+			Version: pkg.Version,
+			Release: pkg.Release,
+			Arch:    pkg.Arch,
+		}
+		if pkg.Summary != "" {
+			p.Summary = common.StringToPtr(pkg.Summary)
+		}
+		if pkg.Description != "" {
+			p.Description = common.StringToPtr(pkg.Description)
+		}
+		if pkg.URL != "" {
+			p.Url = common.StringToPtr(pkg.URL)
+		}
+		if pkg.License != "" {
+			p.License = common.StringToPtr(pkg.License)
+		}
+		packages = append(packages, p)
+	}
+
+	return ctx.JSON(http.StatusOK, PackageSearchResult{
+		Packages: packages,
+	})
+}
+
+func (h *apiHandlers) PostDepsolve(ctx echo.Context) error {
+	var request DepsolveRequest
+	err := ctx.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	distribution := h.server.distros.GetDistro(request.Distribution)
+	if distribution == nil {
+		return HTTPError(ErrorUnsupportedDistribution)
+	}
+	arch, err := distribution.GetArch(request.Architecture)
+	if err != nil {
+		return HTTPError(ErrorUnsupportedArchitecture)
+	}
+
+	if len(request.Packages) == 0 {
+		return HTTPError(ErrorNoPackages)
+	}
+
+	var repos []rpmmd.RepoConfig
+	if request.Repositories != nil {
+		repos, err = convertRepositories(*request.Repositories)
+	} else {
+		repos, err = h.configuredRepositories(distribution, arch)
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := h.runDepsolveJob(ctx, &worker.DepsolveJob{
+		PackageSets: map[string]rpmmd.PackageSet{
+			depsolvePackageSet: {Include: request.Packages},
+		},
+		Repos:            repos,
+		ModulePlatformID: distribution.ModulePlatformID(),
+		Arch:             arch.Name(),
+		Releasever:       distribution.Releasever(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, DepsolveResult{
+		Packages: snapshotPackages(result.PackageSpecs[depsolvePackageSet]),
+	})
+}
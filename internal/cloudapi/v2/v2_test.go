@@ -7,15 +7,22 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
 	v2 "github.com/osbuild/osbuild-composer/internal/cloudapi/v2"
+	"github.com/osbuild/osbuild-composer/internal/distro"
 	"github.com/osbuild/osbuild-composer/internal/distro/test_distro"
 	distro_mock "github.com/osbuild/osbuild-composer/internal/mocks/distro"
 	rpmmd_mock "github.com/osbuild/osbuild-composer/internal/mocks/rpmmd"
+	"github.com/osbuild/osbuild-composer/internal/reporegistry"
 	"github.com/osbuild/osbuild-composer/internal/rpmmd"
+	"github.com/osbuild/osbuild-composer/internal/snapshot"
+	"github.com/osbuild/osbuild-composer/internal/target"
 	"github.com/osbuild/osbuild-composer/internal/test"
 	"github.com/osbuild/osbuild-composer/internal/worker"
 	"github.com/osbuild/osbuild-composer/internal/worker/clienterrors"
@@ -29,20 +36,66 @@ func newV2Server(t *testing.T, dir string) (*v2.Server, *worker.Server, context.
 	distros, err := distro_mock.NewDefaultRegistry()
 	require.NoError(t, err)
 	require.NotNil(t, distros)
+	err = distros.AddImageTypes([]distro.ImageTypeDefinition{
+		{Name: "custom-vhd", Base: test_distro.TestImageTypeVhd, Arches: []string{test_distro.TestArch3Name}},
+	})
+	require.NoError(t, err)
+
+	snapshots, err := snapshot.NewStore(nil)
+	require.NoError(t, err)
 
-	v2Server := v2.NewServer(rpmFixture.Workers, rpm, distros, "image-builder.service")
+	repos := reporegistry.NewFromDistrosRepoConfigs(rpmmd.DistrosRepoConfigs{
+		test_distro.TestDistroName: {
+			test_distro.TestArchName: {{Name: "test-repo", BaseURL: "http://example.com/test/os"}},
+		},
+	})
+
+	v2Server := v2.NewServer(rpmFixture.Workers, rpm, rpmmd.NewCacheManager(filepath.Join(dir, "rpmmd"), rpmmd.DefaultCacheConfig), distros, repos, snapshots, "image-builder.service")
 	require.NotNil(t, v2Server)
 
-	// start a routine which just completes depsolve jobs
+	// start a routine which just completes depsolve and search jobs
 	depsolveContext, cancel := context.WithCancel(context.Background())
 	go func() {
 		for {
-			_, token, _, _, _, err := rpmFixture.Workers.RequestJob(context.Background(), test_distro.TestDistroName, []string{"depsolve"})
+			_, token, jobType, rawArgs, _, err := rpmFixture.Workers.RequestJob(context.Background(), test_distro.TestDistroName, []string{"depsolve", "search"})
 			if err != nil {
 				continue
 			}
-			rawMsg, err := json.Marshal(&worker.DepsolveJobResult{PackageSpecs: map[string][]rpmmd.PackageSpec{"build": []rpmmd.PackageSpec{rpmmd.PackageSpec{Name: "pkg1"}}}, Error: "", ErrorType: worker.ErrorType("")})
-			require.NoError(t, err)
+			var rawMsg json.RawMessage
+			if jobType == "search" {
+				var args worker.SearchJob
+				require.NoError(t, json.Unmarshal(rawArgs, &args))
+				result := worker.SearchJobResult{
+					Packages: rpmmd.PackageList{{Name: "pkg1", Summary: "A package", Version: "1.0", Release: "1.fc30", Arch: "x86_64"}},
+				}
+				// report a miss for every repository the composer asked to refresh
+				for _, repo := range args.Repos {
+					stats := rpmmd.RepoCacheStats{
+						Name:        repo.Name,
+						URL:         repo.BaseURL,
+						Bytes:       1024,
+						LastUsed:    time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC),
+						LastRefresh: time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC),
+					}
+					if _, ok := args.CacheRefresh[repo.BaseURL]; ok {
+						stats.Misses = 1
+					} else {
+						stats.Hits = 1
+					}
+					result.CacheStats = append(result.CacheStats, stats)
+				}
+				rawMsg, err = json.Marshal(&result)
+				require.NoError(t, err)
+			} else {
+				var args worker.DepsolveJob
+				require.NoError(t, json.Unmarshal(rawArgs, &args))
+				result := worker.DepsolveJobResult{PackageSpecs: map[string][]rpmmd.PackageSpec{"build": []rpmmd.PackageSpec{rpmmd.PackageSpec{Name: "pkg1"}}}, Error: "", ErrorType: worker.ErrorType("")}
+				for name := range args.PackageSets {
+					result.PackageSpecs[name] = []rpmmd.PackageSpec{{Name: "pkg1", Version: "1.0", Release: "1.fc30", Arch: "x86_64"}}
+				}
+				rawMsg, err = json.Marshal(&result)
+				require.NoError(t, err)
+			}
 			err = rpmFixture.Workers.FinishJob(token, rawMsg)
 			if err != nil {
 				return
@@ -528,6 +581,109 @@ func TestComposeCustomizations(t *testing.T) {
 	}`, "id")
 }
 
+func TestComposeGPGKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osbuild-composer-test-api-v2-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	srv, _, cancel := newV2Server(t, dir)
+	defer cancel()
+
+	// keys are fetched from URLs when the manifest is created
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "POST", "/api/image-builder-composer/v2/compose", fmt.Sprintf(`
+	{
+		"distribution": "%s",
+		"image_request":{
+			"architecture": "%s",
+			"image_type": "aws",
+			"repositories": [{
+				"baseurl": "somerepo.org",
+				"check_gpg": true,
+				"gpg_key": "https://somerepo.org/RPM-GPG-KEY-$releasever"
+			}],
+			"upload_options": {
+				"region": "eu-central-1"
+			}
+		 }
+	}`, test_distro.TestDistroName, test_distro.TestArch3Name), http.StatusCreated, `
+	{
+		"href": "/api/image-builder-composer/v2/compose",
+		"kind": "ComposeId"
+	}`, "id")
+
+	// but never read from the filesystem of the service
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "POST", "/api/image-builder-composer/v2/compose", fmt.Sprintf(`
+	{
+		"distribution": "%s",
+		"image_request":{
+			"architecture": "%s",
+			"image_type": "aws",
+			"repositories": [{
+				"baseurl": "somerepo.org",
+				"check_gpg": true,
+				"gpg_keys": ["file:///etc/pki/rpm-gpg/RPM-GPG-KEY"]
+			}],
+			"upload_options": {
+				"region": "eu-central-1"
+			}
+		 }
+	}`, test_distro.TestDistroName, test_distro.TestArch3Name), http.StatusBadRequest, `
+	{
+		"href": "/api/image-builder-composer/v2/errors/34",
+		"id": "34",
+		"kind": "Error",
+		"code": "IMAGE-BUILDER-COMPOSER-34",
+		"reason": "GPG keys must be given as ASCII armored key data or http(s) URLs"
+	}`, "operation_id")
+
+	// check_gpg without any keys
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "POST", "/api/image-builder-composer/v2/compose", fmt.Sprintf(`
+	{
+		"distribution": "%s",
+		"image_request":{
+			"architecture": "%s",
+			"image_type": "aws",
+			"repositories": [{
+				"baseurl": "somerepo.org",
+				"check_gpg": true
+			}],
+			"upload_options": {
+				"region": "eu-central-1"
+			}
+		 }
+	}`, test_distro.TestDistroName, test_distro.TestArch3Name), http.StatusBadRequest, `
+	{
+		"href": "/api/image-builder-composer/v2/errors/35",
+		"id": "35",
+		"kind": "Error",
+		"code": "IMAGE-BUILDER-COMPOSER-35",
+		"reason": "Repositories with check_gpg enabled must specify their GPG keys"
+	}`, "operation_id")
+
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "POST", "/api/image-builder-composer/v2/compose", fmt.Sprintf(`
+	{
+		"distribution": "%s",
+		"image_request":{
+			"architecture": "%s",
+			"image_type": "aws",
+			"repositories": [{
+				"baseurl": "somerepo.org",
+				"check_gpg": true,
+				"gpg_keys": [
+					"-----BEGIN PGP PUBLIC KEY BLOCK-----\n\nkey one\n-----END PGP PUBLIC KEY BLOCK-----\n",
+					"-----BEGIN PGP PUBLIC KEY BLOCK-----\n\nkey two\n-----END PGP PUBLIC KEY BLOCK-----\n"
+				]
+			}],
+			"upload_options": {
+				"region": "eu-central-1"
+			}
+		 }
+	}`, test_distro.TestDistroName, test_distro.TestArch3Name), http.StatusCreated, `
+	{
+		"href": "/api/image-builder-composer/v2/compose",
+		"kind": "ComposeId"
+	}`, "id")
+}
+
 func TestImageTypes(t *testing.T) {
 	dir, err := ioutil.TempDir("", "osbuild-composer-test-api-v2-")
 	require.NoError(t, err)
@@ -713,4 +869,709 @@ func TestImageTypes(t *testing.T) {
 		"href": "/api/image-builder-composer/v2/compose",
 		"kind": "ComposeId"
 	}`, "id")
+
+	// other image types of the distribution cannot be requested by name
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "POST", "/api/image-builder-composer/v2/compose", fmt.Sprintf(`
+	{
+		"distribution": "%s",
+		"image_request":{
+			"architecture": "%s",
+			"image_type": "%s",
+			"repositories": [{
+				"baseurl": "somerepo.org",
+				"rhsm": false
+			}],
+			"upload_options": {
+				"region": "eu-central-1"
+			}
+		 }
+	}`, test_distro.TestDistroName, test_distro.TestArch3Name, test_distro.TestImageTypeAmi), http.StatusBadRequest, `
+	{
+		"href": "/api/image-builder-composer/v2/errors/6",
+		"id": "6",
+		"kind": "Error",
+		"code": "IMAGE-BUILDER-COMPOSER-6",
+		"reason": "Unsupported image type"
+	}`, "operation_id")
+}
+
+func TestComposeUserImageType(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osbuild-composer-test-api-v2-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	srv, wrksrv, cancel := newV2Server(t, dir)
+	defer cancel()
+
+	// user-defined image types are uploaded like their base image type
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "POST", "/api/image-builder-composer/v2/compose", fmt.Sprintf(`
+	{
+		"distribution": "%s",
+		"image_request":{
+			"architecture": "%s",
+			"image_type": "custom-vhd",
+			"repositories": [{
+				"baseurl": "somerepo.org",
+				"rhsm": false
+			}],
+			"upload_options": {
+				"tenant_id": "tenant",
+				"subscription_id": "subscription",
+				"resource_group": "group",
+				"location": "westeurope"
+			}
+		 }
+	}`, test_distro.TestDistroName, test_distro.TestArch3Name), http.StatusCreated, `
+	{
+		"href": "/api/image-builder-composer/v2/compose",
+		"kind": "ComposeId"
+	}`, "id")
+
+	_, _, jobType, args, _, err := wrksrv.RequestJob(context.Background(), test_distro.TestArch3Name, []string{"osbuild"})
+	require.NoError(t, err)
+	require.Equal(t, "osbuild", jobType)
+
+	var osbuildJob worker.OSBuildJob
+	err = json.Unmarshal(args, &osbuildJob)
+	require.NoError(t, err)
+	require.Len(t, osbuildJob.Targets, 1)
+	require.Equal(t, "org.osbuild.azure.image", osbuildJob.Targets[0].Name)
+
+	// but only for the architectures they are defined for
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "POST", "/api/image-builder-composer/v2/compose", fmt.Sprintf(`
+	{
+		"distribution": "%s",
+		"image_request":{
+			"architecture": "%s",
+			"image_type": "custom-vhd",
+			"repositories": [{
+				"baseurl": "somerepo.org",
+				"rhsm": false
+			}],
+			"upload_options": {
+				"tenant_id": "tenant",
+				"subscription_id": "subscription",
+				"resource_group": "group",
+				"location": "westeurope"
+			}
+		 }
+	}`, test_distro.TestDistroName, test_distro.TestArchName), http.StatusBadRequest, `
+	{
+		"href": "/api/image-builder-composer/v2/errors/6",
+		"id": "6",
+		"kind": "Error",
+		"code": "IMAGE-BUILDER-COMPOSER-6",
+		"reason": "Unsupported image type"
+	}`, "operation_id")
+}
+
+func TestComposeCompression(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osbuild-composer-test-api-v2-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	srv, wrksrv, cancel := newV2Server(t, dir)
+	defer cancel()
+
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "POST", "/api/image-builder-composer/v2/compose", fmt.Sprintf(`
+	{
+		"distribution": "%s",
+		"image_request":{
+			"architecture": "%s",
+			"image_type": "%s",
+			"compression": "zstd",
+			"repositories": [{
+				"baseurl": "somerepo.org",
+				"rhsm": false
+			}],
+			"upload_options": {
+				"region": "eu-central-1"
+			}
+		 }
+	}`, test_distro.TestDistroName, test_distro.TestArch3Name, string(v2.ImageTypesGuestImage)), http.StatusCreated, `
+	{
+		"href": "/api/image-builder-composer/v2/compose",
+		"kind": "ComposeId"
+	}`, "id")
+
+	_, _, jobType, args, _, err := wrksrv.RequestJob(context.Background(), test_distro.TestArch3Name, []string{"osbuild"})
+	require.NoError(t, err)
+	require.Equal(t, "osbuild", jobType)
+
+	var osbuildJob worker.OSBuildJob
+	err = json.Unmarshal(args, &osbuildJob)
+	require.NoError(t, err)
+	require.Len(t, osbuildJob.Targets, 1)
+	options, ok := osbuildJob.Targets[0].Options.(*target.AWSS3TargetOptions)
+	require.True(t, ok)
+	require.Equal(t, "test.img.zst", options.Filename)
+}
+
+func TestComposeGenericS3(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osbuild-composer-test-api-v2-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	srv, wrksrv, cancel := newV2Server(t, dir)
+	defer cancel()
+
+	// endpoint without a bucket
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "POST", "/api/image-builder-composer/v2/compose", fmt.Sprintf(`
+	{
+		"distribution": "%s",
+		"image_request":{
+			"architecture": "%s",
+			"image_type": "%s",
+			"repositories": [{
+				"baseurl": "somerepo.org",
+				"rhsm": false
+			}],
+			"upload_options": {
+				"endpoint": "https://minio.example.com:9000",
+				"region": "us-east-1"
+			}
+		 }
+	}`, test_distro.TestDistroName, test_distro.TestArch3Name, string(v2.ImageTypesGuestImage)), http.StatusBadRequest, `
+	{
+		"href": "/api/image-builder-composer/v2/errors/25",
+		"id": "25",
+		"kind": "Error",
+		"code": "IMAGE-BUILDER-COMPOSER-25",
+		"reason": "Invalid upload options"
+	}`, "operation_id")
+
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "POST", "/api/image-builder-composer/v2/compose", fmt.Sprintf(`
+	{
+		"distribution": "%s",
+		"image_request":{
+			"architecture": "%s",
+			"image_type": "%s",
+			"repositories": [{
+				"baseurl": "somerepo.org",
+				"rhsm": false
+			}],
+			"upload_options": {
+				"endpoint": "https://minio.example.com:9000",
+				"region": "us-east-1",
+				"bucket": "images",
+				"access_key_id": "access",
+				"secret_access_key": "secret",
+				"path_style": true
+			}
+		 }
+	}`, test_distro.TestDistroName, test_distro.TestArch3Name, string(v2.ImageTypesGuestImage)), http.StatusCreated, `
+	{
+		"href": "/api/image-builder-composer/v2/compose",
+		"kind": "ComposeId"
+	}`, "id")
+
+	jobId, token, jobType, args, _, err := wrksrv.RequestJob(context.Background(), test_distro.TestArch3Name, []string{"osbuild"})
+	require.NoError(t, err)
+	require.Equal(t, "osbuild", jobType)
+
+	var osbuildJob worker.OSBuildJob
+	err = json.Unmarshal(args, &osbuildJob)
+	require.NoError(t, err)
+	require.Len(t, osbuildJob.Targets, 1)
+	require.Equal(t, "org.osbuild.generic.s3", osbuildJob.Targets[0].Name)
+	options, ok := osbuildJob.Targets[0].Options.(*target.GenericS3TargetOptions)
+	require.True(t, ok)
+	require.Equal(t, "https://minio.example.com:9000", options.Endpoint)
+	require.Equal(t, "images", options.Bucket)
+	require.Equal(t, "access", options.AccessKeyID)
+	require.Equal(t, "secret", options.SecretAccessKey)
+	require.True(t, options.PathStyle)
+
+	res, err := json.Marshal(&worker.OSBuildJobResult{
+		Success:      true,
+		UploadStatus: "success",
+		TargetResults: []*target.TargetResult{
+			target.NewGenericS3TargetResult(&target.GenericS3TargetResultOptions{URL: "https://minio.example.com:9000/images/image.qcow2"}),
+		},
+	})
+	require.NoError(t, err)
+
+	err = wrksrv.FinishJob(token, res)
+	require.NoError(t, err)
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "GET", fmt.Sprintf("/api/image-builder-composer/v2/composes/%v", jobId), ``, http.StatusOK, fmt.Sprintf(`
+	{
+		"href": "/api/image-builder-composer/v2/composes/%v",
+		"kind": "ComposeStatus",
+		"id": "%v",
+		"image_status": {
+			"status": "success",
+			"upload_status": {
+				"status": "success",
+				"type": "generic.s3",
+				"options": {
+					"url": "https://minio.example.com:9000/images/image.qcow2"
+				}
+			}
+		}
+	}`, jobId, jobId))
+}
+
+func TestComposeAWSCopyToRegions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osbuild-composer-test-api-v2-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	srv, wrksrv, cancel := newV2Server(t, dir)
+	defer cancel()
+
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "POST", "/api/image-builder-composer/v2/compose", fmt.Sprintf(`
+	{
+		"distribution": "%s",
+		"image_request":{
+			"architecture": "%s",
+			"image_type": "%s",
+			"repositories": [{
+				"baseurl": "somerepo.org",
+				"rhsm": false
+			}],
+			"upload_options": {
+				"region": "eu-central-1",
+				"share_with_accounts": ["123456789012"],
+				"copy_to_regions": ["us-east-1", "ap-southeast-2"]
+			}
+		 }
+	}`, test_distro.TestDistroName, test_distro.TestArch3Name, string(v2.ImageTypesAws)), http.StatusCreated, `
+	{
+		"href": "/api/image-builder-composer/v2/compose",
+		"kind": "ComposeId"
+	}`, "id")
+
+	jobId, token, jobType, args, _, err := wrksrv.RequestJob(context.Background(), test_distro.TestArch3Name, []string{"osbuild"})
+	require.NoError(t, err)
+	require.Equal(t, "osbuild", jobType)
+
+	var osbuildJob worker.OSBuildJob
+	err = json.Unmarshal(args, &osbuildJob)
+	require.NoError(t, err)
+	require.Len(t, osbuildJob.Targets, 1)
+	options, ok := osbuildJob.Targets[0].Options.(*target.AWSTargetOptions)
+	require.True(t, ok)
+	require.Equal(t, "eu-central-1", options.Region)
+	require.Equal(t, []string{"us-east-1", "ap-southeast-2"}, options.CopyToRegions)
+
+	res, err := json.Marshal(&worker.OSBuildJobResult{
+		Success:      true,
+		UploadStatus: "success",
+		TargetResults: []*target.TargetResult{
+			target.NewAWSTargetResult(&target.AWSTargetResultOptions{
+				Ami:    "ami-1",
+				Region: "eu-central-1",
+				Copies: []target.AWSImageCopy{
+					{Ami: "ami-2", Region: "us-east-1"},
+					{Ami: "ami-3", Region: "ap-southeast-2"},
+				},
+			}),
+		},
+	})
+	require.NoError(t, err)
+
+	err = wrksrv.FinishJob(token, res)
+	require.NoError(t, err)
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "GET", fmt.Sprintf("/api/image-builder-composer/v2/composes/%v", jobId), ``, http.StatusOK, fmt.Sprintf(`
+	{
+		"href": "/api/image-builder-composer/v2/composes/%v",
+		"kind": "ComposeStatus",
+		"id": "%v",
+		"image_status": {
+			"status": "success",
+			"upload_status": {
+				"status": "success",
+				"type": "aws",
+				"options": {
+					"ami": "ami-1",
+					"region": "eu-central-1",
+					"copies": [
+						{"ami": "ami-2", "region": "us-east-1"},
+						{"ami": "ami-3", "region": "ap-southeast-2"}
+					]
+				}
+			}
+		}
+	}`, jobId, jobId))
+}
+
+func TestComposeAWSCopyToRegionsInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osbuild-composer-test-api-v2-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	srv, _, cancel := newV2Server(t, dir)
+	defer cancel()
+
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "POST", "/api/image-builder-composer/v2/compose", fmt.Sprintf(`
+	{
+		"distribution": "%s",
+		"image_request":{
+			"architecture": "%s",
+			"image_type": "%s",
+			"repositories": [{
+				"baseurl": "somerepo.org",
+				"rhsm": false
+			}],
+			"upload_options": {
+				"region": "eu-central-1",
+				"copy_to_regions": ["us-east-1", "../../evil"]
+			}
+		 }
+	}`, test_distro.TestDistroName, test_distro.TestArch3Name, string(v2.ImageTypesAws)), http.StatusBadRequest, `
+	{
+		"href": "/api/image-builder-composer/v2/errors/25",
+		"id": "25",
+		"kind": "Error",
+		"code": "IMAGE-BUILDER-COMPOSER-25",
+		"reason": "Invalid upload options"
+	}`, "operation_id")
+}
+
+func TestComposeAWSCopyToRegionsPartialFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osbuild-composer-test-api-v2-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	srv, wrksrv, cancel := newV2Server(t, dir)
+	defer cancel()
+
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "POST", "/api/image-builder-composer/v2/compose", fmt.Sprintf(`
+	{
+		"distribution": "%s",
+		"image_request":{
+			"architecture": "%s",
+			"image_type": "%s",
+			"repositories": [{
+				"baseurl": "somerepo.org",
+				"rhsm": false
+			}],
+			"upload_options": {
+				"region": "eu-central-1",
+				"copy_to_regions": ["us-east-1", "ap-southeast-2"]
+			}
+		 }
+	}`, test_distro.TestDistroName, test_distro.TestArch3Name, string(v2.ImageTypesAws)), http.StatusCreated, `
+	{
+		"href": "/api/image-builder-composer/v2/compose",
+		"kind": "ComposeId"
+	}`, "id")
+
+	jobId, token, _, _, _, err := wrksrv.RequestJob(context.Background(), test_distro.TestArch3Name, []string{"osbuild"})
+	require.NoError(t, err)
+
+	jobResult := worker.OSBuildJobResult{
+		UploadStatus: "failure",
+		TargetResults: []*target.TargetResult{
+			target.NewAWSTargetResult(&target.AWSTargetResultOptions{
+				Ami:    "ami-1",
+				Region: "eu-central-1",
+				Copies: []target.AWSImageCopy{
+					{Ami: "ami-2", Region: "us-east-1"},
+					{Region: "ap-southeast-2", Error: "quota exceeded"},
+				},
+			}),
+		},
+	}
+	jobResult.JobError = clienterrors.WorkerClientError(clienterrors.ErrorImportingImage, "copying the AMI failed in ap-southeast-2: quota exceeded")
+	res, err := json.Marshal(&jobResult)
+	require.NoError(t, err)
+
+	err = wrksrv.FinishJob(token, res)
+	require.NoError(t, err)
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "GET", fmt.Sprintf("/api/image-builder-composer/v2/composes/%v", jobId), ``, http.StatusOK, fmt.Sprintf(`
+	{
+		"href": "/api/image-builder-composer/v2/composes/%v",
+		"kind": "ComposeStatus",
+		"id": "%v",
+		"image_status": {
+			"status": "failure",
+			"upload_status": {
+				"status": "failure",
+				"type": "aws",
+				"options": {
+					"ami": "ami-1",
+					"region": "eu-central-1",
+					"copies": [
+						{"ami": "ami-2", "region": "us-east-1"},
+						{"region": "ap-southeast-2", "error": "quota exceeded"}
+					]
+				}
+			}
+		}
+	}`, jobId, jobId))
+}
+
+func TestComposeSnapshots(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osbuild-composer-test-api-v2-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	srv, wrksrv, cancel := newV2Server(t, dir)
+	defer cancel()
+	handler := srv.Handler("/api/image-builder-composer/v2")
+
+	composeRequest := func(imageType v2.ImageTypes, snapshotOption string) string {
+		return fmt.Sprintf(`
+		{
+			"distribution": "%s",
+			"image_request":{
+				"architecture": "%s",
+				"image_type": "%s",
+				"repositories": [{
+					"baseurl": "somerepo.org",
+					"rhsm": false
+				}],
+				"upload_options": {
+					"region": "eu-central-1"
+				}
+			},
+			%s
+		}`, test_distro.TestDistroName, test_distro.TestArch3Name, string(imageType), snapshotOption)
+	}
+
+	test.TestRoute(t, handler, false, "POST", "/api/image-builder-composer/v2/compose", composeRequest(v2.ImageTypesAws, `"save_snapshot": "snap1"`), http.StatusCreated, `
+	{
+		"href": "/api/image-builder-composer/v2/compose",
+		"kind": "ComposeId"
+	}`, "id")
+
+	// the snapshot is saved once the depsolve job has finished
+	require.Eventually(t, func() bool {
+		resp := test.SendHTTP(handler, false, "GET", "/api/image-builder-composer/v2/snapshots/snap1", ``)
+		return resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 50*time.Millisecond)
+
+	test.TestRoute(t, handler, false, "GET", "/api/image-builder-composer/v2/snapshots/snap1", ``, http.StatusOK, fmt.Sprintf(`
+	{
+		"href": "/api/image-builder-composer/v2/snapshots/snap1",
+		"kind": "Snapshot",
+		"id": "snap1",
+		"name": "snap1",
+		"distribution": "%s",
+		"architecture": "%s",
+		"image_type": "%s",
+		"package_sets": {
+			"build": [{"name": "pkg1", "epoch": 0, "version": "", "release": "", "arch": ""}]
+		}
+	}`, test_distro.TestDistroName, test_distro.TestArch3Name, test_distro.TestImageTypeAmi), "created")
+
+	test.TestRoute(t, handler, false, "GET", "/api/image-builder-composer/v2/snapshots", ``, http.StatusOK, `
+	{
+		"kind": "SnapshotList",
+		"page": 0,
+		"size": 1,
+		"total": 1,
+		"items": [{
+			"href": "/api/image-builder-composer/v2/snapshots/snap1",
+			"kind": "Snapshot",
+			"id": "snap1"
+		}]
+	}`)
+
+	// snapshots are never overwritten
+	test.TestRoute(t, handler, false, "POST", "/api/image-builder-composer/v2/compose", composeRequest(v2.ImageTypesAws, `"save_snapshot": "snap1"`), http.StatusConflict, `
+	{
+		"href": "/api/image-builder-composer/v2/errors/27",
+		"id": "27",
+		"kind": "Error",
+		"code": "IMAGE-BUILDER-COMPOSER-27",
+		"reason": "Snapshot with given name already exists"
+	}`, "operation_id")
+
+	test.TestRoute(t, handler, false, "POST", "/api/image-builder-composer/v2/compose", composeRequest(v2.ImageTypesAws, `"snapshot": "snap2"`), http.StatusNotFound, `
+	{
+		"href": "/api/image-builder-composer/v2/errors/26",
+		"id": "26",
+		"kind": "Error",
+		"code": "IMAGE-BUILDER-COMPOSER-26",
+		"reason": "Snapshot with given name not found"
+	}`, "operation_id")
+
+	test.TestRoute(t, handler, false, "POST", "/api/image-builder-composer/v2/compose", composeRequest(v2.ImageTypesGuestImage, `"snapshot": "snap1"`), http.StatusBadRequest, `
+	{
+		"href": "/api/image-builder-composer/v2/errors/28",
+		"id": "28",
+		"kind": "Error",
+		"code": "IMAGE-BUILDER-COMPOSER-28",
+		"reason": "Snapshot was taken for a different distribution, architecture or image type"
+	}`, "operation_id")
+
+	// the worker checks whether the packages of the snapshot are still
+	// available before building
+	resp := test.SendHTTP(handler, false, "POST", "/api/image-builder-composer/v2/compose", composeRequest(v2.ImageTypesAws, `"snapshot": "snap1"`))
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var composeID v2.ComposeId
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&composeID))
+	var job worker.OSBuildJob
+	_, _, _, err = wrksrv.Job(uuid.MustParse(composeID.Id), &job)
+	require.NoError(t, err)
+	require.Equal(t, []rpmmd.PackageSpec{{Name: "pkg1"}}, job.CheckPackages)
+
+	resp = test.SendHTTP(handler, false, "DELETE", "/api/image-builder-composer/v2/snapshots/snap1", ``)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	test.TestRoute(t, handler, false, "GET", "/api/image-builder-composer/v2/snapshots/snap1", ``, http.StatusNotFound, `
+	{
+		"href": "/api/image-builder-composer/v2/errors/26",
+		"id": "26",
+		"kind": "Error",
+		"code": "IMAGE-BUILDER-COMPOSER-26",
+		"reason": "Snapshot with given name not found"
+	}`, "operation_id")
+}
+
+func TestPackages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osbuild-composer-test-api-v2-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	srv, _, cancel := newV2Server(t, dir)
+	defer cancel()
+
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "GET", fmt.Sprintf("/api/image-builder-composer/v2/packages?distribution=%s&architecture=%s&search=pkg*,tmux", test_distro.TestDistroName, test_distro.TestArchName), ``, http.StatusOK, `
+	{
+		"packages": [{
+			"name": "pkg1",
+			"epoch": 0,
+			"version": "1.0",
+			"release": "1.fc30",
+			"arch": "x86_64",
+			"summary": "A package"
+		}]
+	}`)
+
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "GET", fmt.Sprintf("/api/image-builder-composer/v2/packages?distribution=%s&architecture=%s&search=,", test_distro.TestDistroName, test_distro.TestArchName), ``, http.StatusBadRequest, `
+	{
+		"href": "/api/image-builder-composer/v2/errors/31",
+		"id": "31",
+		"kind": "Error",
+		"code": "IMAGE-BUILDER-COMPOSER-31",
+		"reason": "Search must be a comma separated list of package names or glob patterns"
+	}`, "operation_id")
+
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "GET", fmt.Sprintf("/api/image-builder-composer/v2/packages?distribution=%s&architecture=%s&search=pkg*", test_distro.TestDistroName, test_distro.TestArch2Name), ``, http.StatusBadRequest, `
+	{
+		"href": "/api/image-builder-composer/v2/errors/33",
+		"id": "33",
+		"kind": "Error",
+		"code": "IMAGE-BUILDER-COMPOSER-33",
+		"reason": "No repositories are configured for the given distribution and architecture"
+	}`, "operation_id")
+}
+
+func TestDepsolve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osbuild-composer-test-api-v2-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	srv, _, cancel := newV2Server(t, dir)
+	defer cancel()
+
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "POST", "/api/image-builder-composer/v2/depsolve", fmt.Sprintf(`
+	{
+		"distribution": "%s",
+		"architecture": "%s",
+		"packages": ["pkg1"]
+	}`, test_distro.TestDistroName, test_distro.TestArchName), http.StatusOK, `
+	{
+		"packages": [{
+			"name": "pkg1",
+			"epoch": 0,
+			"version": "1.0",
+			"release": "1.fc30",
+			"arch": "x86_64"
+		}]
+	}`)
+
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "POST", "/api/image-builder-composer/v2/depsolve", fmt.Sprintf(`
+	{
+		"distribution": "%s",
+		"architecture": "%s",
+		"packages": []
+	}`, test_distro.TestDistroName, test_distro.TestArchName), http.StatusBadRequest, `
+	{
+		"href": "/api/image-builder-composer/v2/errors/32",
+		"id": "32",
+		"kind": "Error",
+		"code": "IMAGE-BUILDER-COMPOSER-32",
+		"reason": "At least one package must be given"
+	}`, "operation_id")
+
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "POST", "/api/image-builder-composer/v2/depsolve", fmt.Sprintf(`
+	{
+		"distribution": "%s",
+		"architecture": "%s",
+		"packages": ["pkg1"],
+		"repositories": [{"rhsm": false}]
+	}`, test_distro.TestDistroName, test_distro.TestArchName), http.StatusBadRequest, `
+	{
+		"href": "/api/image-builder-composer/v2/errors/7",
+		"id": "7",
+		"kind": "Error",
+		"code": "IMAGE-BUILDER-COMPOSER-7",
+		"reason": "Must specify baseurl, mirrorlist, or metalink"
+	}`, "operation_id")
+}
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osbuild-composer-test-api-v2-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	srv, _, cancel := newV2Server(t, dir)
+	defer cancel()
+
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "GET", "/api/image-builder-composer/v2/cache", ``, http.StatusOK, `
+	{
+		"ttl": 86400,
+		"max_size": 0,
+		"repositories": []
+	}`)
+
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "POST", "/api/image-builder-composer/v2/cache/refresh", `
+	{
+		"repositories": ["test-repo"]
+	}`, http.StatusNotFound, `
+	{
+		"href": "/api/image-builder-composer/v2/errors/38",
+		"id": "38",
+		"kind": "Error",
+		"code": "IMAGE-BUILDER-COMPOSER-38",
+		"reason": "No repository with given name was used yet"
+	}`, "operation_id")
+
+	search := fmt.Sprintf("/api/image-builder-composer/v2/packages?distribution=%s&architecture=%s&search=pkg1", test_distro.TestDistroName, test_distro.TestArchName)
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "GET", search, ``, http.StatusOK, `{"packages": []}`, "packages")
+
+	// the statistics reported by the worker are merged into the cache of the composer
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "GET", "/api/image-builder-composer/v2/cache", ``, http.StatusOK, `
+	{
+		"ttl": 86400,
+		"max_size": 0,
+		"repositories": [{
+			"name": "test-repo",
+			"url": "http://example.com/test/os",
+			"hits": 1,
+			"misses": 0,
+			"bytes": 1024,
+			"last_used": "2021-11-01T00:00:00Z",
+			"last_refresh": "2021-10-01T00:00:00Z"
+		}]
+	}`)
+
+	resp := test.SendHTTP(srv.Handler("/api/image-builder-composer/v2"), false, "POST", "/api/image-builder-composer/v2/cache/refresh", `
+	{
+		"repositories": ["test-repo"]
+	}`)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// the refresh request is passed to the worker with the next job
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "GET", search, ``, http.StatusOK, `{"packages": []}`, "packages")
+	test.TestRoute(t, srv.Handler("/api/image-builder-composer/v2"), false, "GET", "/api/image-builder-composer/v2/cache", ``, http.StatusOK, `
+	{
+		"ttl": 86400,
+		"max_size": 0,
+		"repositories": [{
+			"name": "test-repo",
+			"url": "http://example.com/test/os",
+			"hits": 1,
+			"misses": 1,
+			"bytes": 1024,
+			"last_used": "2021-11-01T00:00:00Z",
+			"last_refresh": "2021-10-01T00:00:00Z"
+		}]
+	}`)
+
+	resp = test.SendHTTP(srv.Handler("/api/image-builder-composer/v2"), false, "POST", "/api/image-builder-composer/v2/cache/refresh", ``)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
 }
@@ -13,38 +13,38 @@ import (
 
 type PartitionTable struct {
 	// Size of the disk.
-	Size uint64
-	UUID string
+	Size uint64 `json:"size,omitempty"`
+	UUID string `json:"uuid,omitempty"`
 	// Partition table type, e.g. dos, gpt.
-	Type       string
-	Partitions []Partition
+	Type       string      `json:"type"`
+	Partitions []Partition `json:"partitions"`
 }
 
 type Partition struct {
-	Start    uint64
-	Size     uint64
-	Type     string
-	Bootable bool
+	Start    uint64 `json:"start,omitempty"`
+	Size     uint64 `json:"size"`
+	Type     string `json:"type,omitempty"`
+	Bootable bool   `json:"bootable,omitempty"`
 	// ID of the partition, dos doesn't use traditional UUIDs, therefore this
 	// is just a string.
-	UUID string
+	UUID string `json:"uuid,omitempty"`
 	// If nil, the partition is raw; It doesn't contain a filesystem.
-	Filesystem *Filesystem
+	Filesystem *Filesystem `json:"filesystem,omitempty"`
 }
 
 type Filesystem struct {
-	Type string
+	Type string `json:"type"`
 	// ID of the filesystem, vfat doesn't use traditional UUIDs, therefore this
 	// is just a string.
-	UUID       string
-	Label      string
-	Mountpoint string
+	UUID       string `json:"uuid,omitempty"`
+	Label      string `json:"label,omitempty"`
+	Mountpoint string `json:"mountpoint,omitempty"`
 	// The fourth field of fstab(5); fs_mntops
-	FSTabOptions string
+	FSTabOptions string `json:"fstab_options,omitempty"`
 	// The fifth field of fstab(5); fs_freq
-	FSTabFreq uint64
+	FSTabFreq uint64 `json:"fstab_freq,omitempty"`
 	// The sixth field of fstab(5); fs_passno
-	FSTabPassNo uint64
+	FSTabPassNo uint64 `json:"fstab_passno,omitempty"`
 }
 
 // Converts PartitionTable to osbuild.QEMUAssemblerOptions that encode
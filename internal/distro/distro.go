@@ -33,6 +33,27 @@ const (
 	HybridBootType BootType = "hybrid"
 )
 
+// Compression of the image file
+type Compression string
+
+const (
+	UnsetCompression Compression = ""
+	NoCompression    Compression = "none"
+	XzCompression    Compression = "xz"
+	ZstdCompression  Compression = "zstd"
+)
+
+// Extension returns the file name extension of files compressed with c.
+func (c Compression) Extension() string {
+	switch c {
+	case XzCompression:
+		return ".xz"
+	case ZstdCompression:
+		return ".zst"
+	}
+	return ""
+}
+
 // A Distro represents composer's notion of what a given distribution is.
 type Distro interface {
 	// Returns the name of the distro.
@@ -126,6 +147,37 @@ type ImageOptions struct {
 	OSTree       OSTreeImageOptions
 	Size         uint64
 	Subscription *SubscriptionImageOptions
+	// Replace the boot type and the compression of the image type, if it
+	// supports selecting them
+	BootType    BootType
+	Compression Compression
+}
+
+// ImageFilename returns the file name of the image of type t built with the
+// options, which depends on the compression selected in them.
+func ImageFilename(t ImageType, options ImageOptions) string {
+	filename := t.Filename()
+	if options.Compression == UnsetCompression {
+		return filename
+	}
+	for _, c := range []Compression{XzCompression, ZstdCompression} {
+		filename = strings.TrimSuffix(filename, c.Extension())
+	}
+	return filename + options.Compression.Extension()
+}
+
+// ImageMIMEType returns the MIME type of the image of type t built with the
+// options, which depends on the compression selected in them.
+func ImageMIMEType(t ImageType, options ImageOptions) string {
+	switch options.Compression {
+	case NoCompression:
+		return "application/octet-stream"
+	case XzCompression:
+		return "application/xz"
+	case ZstdCompression:
+		return "application/zstd"
+	}
+	return t.MIMEType()
 }
 
 // The OSTreeImageOptions specify ostree-specific image options
@@ -0,0 +1,160 @@
+package distro_test_common
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// curlSourceSchema is the schema of the options of the org.osbuild.curl
+// source, as defined in sources/org.osbuild.curl of osbuild, which rejects
+// manifests with any other item properties
+const curlSourceSchema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "definitions": {
+    "item": {
+      "description": "The files to fetch indexed their content checksum",
+      "type": "object",
+      "additionalProperties": false,
+      "patternProperties": {
+        "(md5|sha1|sha256|sha384|sha512):[0-9a-f]{32,128}": {
+          "oneOf": [
+            {
+              "type": "string",
+              "description": "URL to download the file from."
+            },
+            {
+              "type": "object",
+              "additionalProperties": false,
+              "required": ["url"],
+              "properties": {
+                "url": {
+                  "type": "string",
+                  "description": "URL to download the file from."
+                },
+                "insecure": {
+                  "type": "boolean",
+                  "description": "Skip the verification step for secure connections and proceed without checking"
+                },
+                "secrets": {
+                  "type": "object",
+                  "additionalProperties": false,
+                  "required": ["name"],
+                  "properties": {
+                    "name": {
+                      "type": "string",
+                      "description": "Name of the secrets provider."
+                    }
+                  }
+                }
+              }
+            }
+          ]
+        }
+      }
+    }
+  },
+  "properties": {
+    "items": {"$ref": "#/definitions/item"},
+    "urls": {"$ref": "#/definitions/item"}
+  },
+  "oneOf": [
+    {"required": ["items"]},
+    {"required": ["urls"]}
+  ]
+}`
+
+// jsonSchema is the subset of JSON schema used by the osbuild source schemas
+type jsonSchema struct {
+	Type                 string                 `json:"type"`
+	Ref                  string                 `json:"$ref"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	PatternProperties    map[string]*jsonSchema `json:"patternProperties"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	OneOf                []*jsonSchema          `json:"oneOf"`
+	Definitions          map[string]*jsonSchema `json:"definitions"`
+}
+
+// ValidateCurlSource validates the options of an org.osbuild.curl source
+// against the schema osbuild checks them with
+func ValidateCurlSource(source json.RawMessage) error {
+	var schema jsonSchema
+	if err := json.Unmarshal([]byte(curlSourceSchema), &schema); err != nil {
+		panic(err)
+	}
+	var value interface{}
+	if err := json.Unmarshal(source, &value); err != nil {
+		return err
+	}
+	return schema.validate(&schema, value, "")
+}
+
+func (s *jsonSchema) validate(root *jsonSchema, value interface{}, path string) error {
+	if s.Ref != "" {
+		var name string
+		if _, err := fmt.Sscanf(s.Ref, "#/definitions/%s", &name); err != nil || root.Definitions[name] == nil {
+			return fmt.Errorf("%s: unknown reference %q", path, s.Ref)
+		}
+		return root.Definitions[name].validate(root, value, path)
+	}
+
+	if len(s.OneOf) > 0 {
+		matches := 0
+		for _, sub := range s.OneOf {
+			if sub.validate(root, value, path) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return fmt.Errorf("%s: %d instead of exactly one of the alternatives match", path, matches)
+		}
+	}
+
+	switch s.Type {
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: not a string", path)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: not a boolean", path)
+		}
+	case "object":
+		if _, ok := value.(map[string]interface{}); !ok {
+			return fmt.Errorf("%s: not an object", path)
+		}
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			return fmt.Errorf("%s: missing required property %q", path, name)
+		}
+	}
+	for name, v := range object {
+		matched := false
+		if sub, ok := s.Properties[name]; ok {
+			matched = true
+			if err := sub.validate(root, v, path+"/"+name); err != nil {
+				return err
+			}
+		}
+		for pattern, sub := range s.PatternProperties {
+			if regexp.MustCompile(pattern).MatchString(name) {
+				matched = true
+				if err := sub.validate(root, v, path+"/"+name); err != nil {
+					return err
+				}
+			}
+		}
+		if !matched && s.AdditionalProperties != nil && !*s.AdditionalProperties {
+			return fmt.Errorf("%s: additional property %q is not allowed", path, name)
+		}
+	}
+	return nil
+}
@@ -56,12 +56,16 @@ func TestDistro_Manifest(t *testing.T, pipelinePath string, prefix string, regis
 
 		repos := make([]rpmmd.RepoConfig, len(tt.ComposeRequest.Repositories))
 		for i, repo := range tt.ComposeRequest.Repositories {
+			var gpgKeys []string
+			if repo.GPGKey != "" {
+				gpgKeys = []string{repo.GPGKey}
+			}
 			repos[i] = rpmmd.RepoConfig{
 				Name:       fmt.Sprintf("repo-%d", i),
 				BaseURL:    repo.BaseURL,
 				Metalink:   repo.Metalink,
 				MirrorList: repo.MirrorList,
-				GPGKey:     repo.GPGKey,
+				GPGKeys:    gpgKeys,
 				CheckGPG:   repo.CheckGPG,
 			}
 		}
@@ -115,6 +119,17 @@ func TestDistro_Manifest(t *testing.T, pipelinePath string, prefix string, regis
 				t.Errorf("distro.Manifest() error = %v", err)
 				return
 			}
+			if err == nil {
+				var sources struct {
+					Sources struct {
+						Curl json.RawMessage `json:"org.osbuild.curl"`
+					} `json:"sources"`
+				}
+				require.NoError(t, json.Unmarshal(got, &sources))
+				if sources.Sources.Curl != nil {
+					require.NoError(t, ValidateCurlSource(sources.Sources.Curl), "Test case file: %s", fileName)
+				}
+			}
 			if tt.Manifest != nil {
 				var expected, actual interface{}
 				err = json.Unmarshal(tt.Manifest, &expected)
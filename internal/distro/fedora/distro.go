@@ -0,0 +1,972 @@
+package fedora
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/osbuild/osbuild-composer/internal/blueprint"
+	"github.com/osbuild/osbuild-composer/internal/disk"
+	"github.com/osbuild/osbuild-composer/internal/distro"
+	osbuild "github.com/osbuild/osbuild-composer/internal/osbuild2"
+	"github.com/osbuild/osbuild-composer/internal/rpmmd"
+)
+
+const (
+	// package set names
+
+	// build package set name
+	buildPkgsKey = "build"
+
+	// main/common os image package set name
+	osPkgsKey = "packages"
+
+	// container package set name
+	containerPkgsKey = "container"
+
+	// installer package set name
+	installerPkgsKey = "installer"
+
+	// blueprint package set name
+	blueprintPkgsKey = "blueprint"
+)
+
+var mountpointAllowList = []string{
+	"/", "/var", "/opt", "/srv", "/usr", "/app", "/data", "/home",
+}
+
+type distribution struct {
+	name               string
+	product            string
+	osVersion          string
+	releaseVersion     string
+	modulePlatformID   string
+	vendor             string
+	ostreeRefTmpl      string
+	isolabelTmpl       string
+	runner             string
+	arches             map[string]distro.Arch
+	defaultImageConfig *distro.ImageConfig
+}
+
+// Fedora OS image configuration defaults
+var defaultDistroImageConfig = &distro.ImageConfig{
+	Timezone: "UTC",
+	Locale:   "en_US",
+}
+
+// distribution objects without the arches > image types
+var distroMap = map[string]distribution{
+	"fedora-34": {
+		name:               "fedora-34",
+		product:            "Fedora",
+		osVersion:          "34",
+		releaseVersion:     "34",
+		modulePlatformID:   "platform:f34",
+		vendor:             "fedora",
+		ostreeRefTmpl:      "fedora/34/%s/iot",
+		isolabelTmpl:       "Fedora-34-BaseOS-%s",
+		runner:             "org.osbuild.fedora34",
+		defaultImageConfig: defaultDistroImageConfig,
+	},
+	"fedora-35": {
+		name:               "fedora-35",
+		product:            "Fedora",
+		osVersion:          "35",
+		releaseVersion:     "35",
+		modulePlatformID:   "platform:f35",
+		vendor:             "fedora",
+		ostreeRefTmpl:      "fedora/35/%s/iot",
+		isolabelTmpl:       "Fedora-35-BaseOS-%s",
+		runner:             "org.osbuild.fedora35",
+		defaultImageConfig: defaultDistroImageConfig,
+	},
+	"fedora-36": {
+		name:               "fedora-36",
+		product:            "Fedora",
+		osVersion:          "36",
+		releaseVersion:     "36",
+		modulePlatformID:   "platform:f36",
+		vendor:             "fedora",
+		ostreeRefTmpl:      "fedora/36/%s/iot",
+		isolabelTmpl:       "Fedora-36-BaseOS-%s",
+		runner:             "org.osbuild.fedora36",
+		defaultImageConfig: defaultDistroImageConfig,
+	},
+}
+
+func (d *distribution) Name() string {
+	return d.name
+}
+
+func (d *distribution) Releasever() string {
+	return d.releaseVersion
+}
+
+func (d *distribution) ModulePlatformID() string {
+	return d.modulePlatformID
+}
+
+func (d *distribution) OSTreeRef() string {
+	return d.ostreeRefTmpl
+}
+
+func (d *distribution) ListArches() []string {
+	archNames := make([]string, 0, len(d.arches))
+	for name := range d.arches {
+		archNames = append(archNames, name)
+	}
+	sort.Strings(archNames)
+	return archNames
+}
+
+func (d *distribution) GetArch(name string) (distro.Arch, error) {
+	arch, exists := d.arches[name]
+	if !exists {
+		return nil, errors.New("invalid architecture: " + name)
+	}
+	return arch, nil
+}
+
+func (d *distribution) addArches(arches ...architecture) {
+	if d.arches == nil {
+		d.arches = map[string]distro.Arch{}
+	}
+
+	// Do not make copies of architectures, as opposed to image types,
+	// because architecture definitions are not used by more than a single
+	// distro definition.
+	for idx := range arches {
+		d.arches[arches[idx].name] = &arches[idx]
+	}
+}
+
+func (d *distribution) getDefaultImageConfig() *distro.ImageConfig {
+	return d.defaultImageConfig
+}
+
+type architecture struct {
+	distro           *distribution
+	name             string
+	imageTypes       map[string]distro.ImageType
+	imageTypeAliases map[string]string
+	legacy           string
+	bootType         distro.BootType
+}
+
+func (a *architecture) Name() string {
+	return a.name
+}
+
+func (a *architecture) ListImageTypes() []string {
+	itNames := make([]string, 0, len(a.imageTypes))
+	for name := range a.imageTypes {
+		itNames = append(itNames, name)
+	}
+	sort.Strings(itNames)
+	return itNames
+}
+
+func (a *architecture) GetImageType(name string) (distro.ImageType, error) {
+	t, exists := a.imageTypes[name]
+	if !exists {
+		aliasForName, exists := a.imageTypeAliases[name]
+		if !exists {
+			return nil, errors.New("invalid image type: " + name)
+		}
+		t, exists = a.imageTypes[aliasForName]
+		if !exists {
+			panic(fmt.Sprintf("image type '%s' is an alias to a non-existing image type '%s'", name, aliasForName))
+		}
+	}
+	return t, nil
+}
+
+func (a *architecture) addImageTypes(imageTypes ...imageType) {
+	if a.imageTypes == nil {
+		a.imageTypes = map[string]distro.ImageType{}
+	}
+	for idx := range imageTypes {
+		it := imageTypes[idx]
+		it.arch = a
+		a.imageTypes[it.name] = &it
+		for _, alias := range it.nameAliases {
+			if a.imageTypeAliases == nil {
+				a.imageTypeAliases = map[string]string{}
+			}
+			if existingAliasFor, exists := a.imageTypeAliases[alias]; exists {
+				panic(fmt.Sprintf("image type alias '%s' for '%s' is already defined for another image type '%s'", alias, it.name, existingAliasFor))
+			}
+			a.imageTypeAliases[alias] = it.name
+		}
+	}
+}
+
+// DeriveImageType adds a user-defined image type, which is derived from
+// one of the built-in image types of the architecture.
+func (a *architecture) DeriveImageType(def distro.ImageTypeDefinition) error {
+	if _, err := a.GetImageType(def.Name); err == nil {
+		return fmt.Errorf("image type %q already exists", def.Name)
+	}
+	base, err := a.GetImageType(def.Base)
+	if err != nil {
+		return err
+	}
+
+	it := *base.(*imageType)
+	it.baseName = it.builtinName()
+	it.name = def.Name
+	it.nameAliases = nil
+	if def.Filename != "" {
+		it.filename = def.Filename
+	}
+	if def.DefaultSize != 0 {
+		it.defaultSize = def.DefaultSize
+	}
+	it.defaultImageConfig = def.DeriveImageConfig(it.getDefaultImageConfig())
+
+	if ps := def.PackageSet(); len(ps.Include) > 0 || len(ps.Exclude) > 0 {
+		packageSets := make(map[string]packageSetFunc, len(it.packageSets)+1)
+		for name, getter := range it.packageSets {
+			packageSets[name] = getter
+		}
+		basePackages := packageSets[osPkgsKey]
+		packageSets[osPkgsKey] = func(t *imageType) rpmmd.PackageSet {
+			if basePackages == nil {
+				return ps
+			}
+			return basePackages(t).Append(ps)
+		}
+		it.packageSets = packageSets
+	}
+
+	if def.PartitionTable != nil {
+		if _, exists := it.basePartitionTables[a.name]; !exists {
+			return fmt.Errorf("image type %q has no partition table", def.Base)
+		}
+		it.basePartitionTables = distro.BasePartitionTableMap{a.name: *def.PartitionTable}
+	}
+
+	a.addImageTypes(it)
+	return nil
+}
+
+func (a *architecture) Distro() distro.Distro {
+	return a.distro
+}
+
+type pipelinesFunc func(t *imageType, customizations *blueprint.Customizations, options distro.ImageOptions, repos []rpmmd.RepoConfig, packageSetSpecs map[string][]rpmmd.PackageSpec, rng *rand.Rand) ([]osbuild.Pipeline, error)
+
+type packageSetFunc func(t *imageType) rpmmd.PackageSet
+
+type imageType struct {
+	arch               *architecture
+	name               string
+	nameAliases        []string
+	baseName           string // built-in image type this one is derived from
+	filename           string
+	mimeType           string
+	packageSets        map[string]packageSetFunc
+	defaultImageConfig *distro.ImageConfig
+	kernelOptions      string
+	defaultSize        uint64
+	buildPipelines     []string
+	payloadPipelines   []string
+	exports            []string
+	pipelines          pipelinesFunc
+
+	// bootISO: installable ISO
+	bootISO bool
+	// liveISO: bootable ISO running the OS from a squashfs image
+	liveISO bool
+	// boot type and compression can be selected in the image options
+	selectableBootType    bool
+	selectableCompression bool
+	// rpmOstree: edge/ostree
+	rpmOstree bool
+	// bootable image
+	bootable bool
+	// If set to a value, it is preferred over the architecture value
+	bootType distro.BootType
+	// List of valid arches for the image type
+	basePartitionTables distro.BasePartitionTableMap
+}
+
+func (t *imageType) Name() string {
+	return t.name
+}
+
+// builtinName returns the name of the built-in image type t is, or is
+// derived from.
+func (t *imageType) builtinName() string {
+	if t.baseName != "" {
+		return t.baseName
+	}
+	return t.name
+}
+
+func (t *imageType) Arch() distro.Arch {
+	return t.arch
+}
+
+func (t *imageType) Filename() string {
+	return t.filename
+}
+
+func (t *imageType) MIMEType() string {
+	return t.mimeType
+}
+
+func (t *imageType) OSTreeRef() string {
+	d := t.arch.distro
+	if t.rpmOstree {
+		return fmt.Sprintf(d.ostreeRefTmpl, t.arch.name)
+	}
+	return ""
+}
+
+func (t *imageType) Size(size uint64) uint64 {
+	const MegaByte = 1024 * 1024
+	// Microsoft Azure requires vhd images to be rounded up to the nearest MB
+	if t.builtinName() == "vhd" && size%MegaByte != 0 {
+		size = (size/MegaByte + 1) * MegaByte
+	}
+	if size == 0 {
+		size = t.defaultSize
+	}
+	return size
+}
+
+func (t *imageType) getPackages(name string) rpmmd.PackageSet {
+	getter := t.packageSets[name]
+	if getter == nil {
+		return rpmmd.PackageSet{}
+	}
+
+	return getter(t)
+}
+
+func (t *imageType) PackageSets(bp blueprint.Blueprint) map[string]rpmmd.PackageSet {
+	// merge package sets that appear in the image type with the package sets
+	// of the same name from the distro and arch
+	mergedSets := make(map[string]rpmmd.PackageSet)
+
+	imageSets := t.packageSets
+
+	for name := range imageSets {
+		mergedSets[name] = t.getPackages(name)
+	}
+
+	if _, hasPackages := imageSets[osPkgsKey]; !hasPackages {
+		// should this be possible??
+		mergedSets[osPkgsKey] = rpmmd.PackageSet{}
+	}
+
+	// every image type must define a 'build' package set
+	if _, hasBuild := imageSets[buildPkgsKey]; !hasBuild {
+		panic(fmt.Sprintf("'%s' image type has no '%s' package set defined", t.name, buildPkgsKey))
+	}
+
+	// blueprint packages
+	bpPackages := bp.GetPackages()
+	timezone, _ := bp.Customizations.GetTimezoneSettings()
+	if timezone != nil {
+		bpPackages = append(bpPackages, "chrony")
+	}
+
+	// depsolve bp packages separately
+	// bp packages aren't restricted by exclude lists
+	// the module streams and install options apply to both the OS and the
+	// blueprint packages, so that they are installed the same way
+	enabledModules, disabledModules := bp.Customizations.GetModuleSpecs()
+	bpOptions := rpmmd.PackageSet{
+		EnabledModules:  enabledModules,
+		DisabledModules: disabledModules,
+		ExcludeWeakDeps: !*bp.Customizations.GetInstall().WeakDeps,
+	}
+	mergedSets[blueprintPkgsKey] = rpmmd.PackageSet{Include: bpPackages}.Append(bpOptions)
+	kernel := bp.Customizations.GetKernel().Name
+
+	// add bp kernel to main OS package set to avoid duplicate kernels
+	mergedSets[osPkgsKey] = mergedSets[osPkgsKey].Append(rpmmd.PackageSet{Include: []string{kernel}}).Append(bpOptions)
+	return mergedSets
+
+}
+
+func (t *imageType) BuildPipelines() []string {
+	return t.buildPipelines
+}
+
+func (t *imageType) PayloadPipelines() []string {
+	return t.payloadPipelines
+}
+
+func (t *imageType) PayloadPackageSets() []string {
+	return []string{blueprintPkgsKey}
+}
+
+func (t *imageType) Exports() []string {
+	if len(t.exports) > 0 {
+		return t.exports
+	}
+	return []string{"assembler"}
+}
+
+// getBootType returns the BootType which should be used for this particular
+// combination of architecture and image type.
+func (t *imageType) getBootType() distro.BootType {
+	bootType := t.arch.bootType
+	if t.bootType != distro.UnsetBootType {
+		bootType = t.bootType
+	}
+	return bootType
+}
+
+func (t *imageType) supportsUEFI() bool {
+	bootType := t.getBootType()
+	if bootType == distro.HybridBootType || bootType == distro.UEFIBootType {
+		return true
+	}
+	return false
+}
+
+func (t *imageType) getPartitionTable(
+	mountpoints []blueprint.FilesystemCustomization,
+	options distro.ImageOptions,
+	rng *rand.Rand,
+) (disk.PartitionTable, error) {
+	archName := t.arch.Name()
+
+	basePartitionTable, exists := t.basePartitionTables[archName]
+
+	if !exists {
+		return basePartitionTable, fmt.Errorf("unknown arch: " + archName)
+	}
+
+	return disk.CreatePartitionTable(mountpoints, options.Size, basePartitionTable, rng), nil
+}
+
+func (t *imageType) getDefaultImageConfig() *distro.ImageConfig {
+	// ensure that image always returns non-nil default config
+	imageConfig := t.defaultImageConfig
+	if imageConfig == nil {
+		imageConfig = &distro.ImageConfig{}
+	}
+	return imageConfig.InheritFrom(t.arch.distro.getDefaultImageConfig())
+}
+
+// local type for ostree commit metadata used to define commit sources
+type ostreeCommit struct {
+	Checksum string
+	URL      string
+}
+
+func (t *imageType) Manifest(customizations *blueprint.Customizations,
+	options distro.ImageOptions,
+	repos []rpmmd.RepoConfig,
+	packageSpecSets map[string][]rpmmd.PackageSpec,
+	seed int64) (distro.Manifest, error) {
+
+	if err := t.checkOptions(customizations, options); err != nil {
+		return distro.Manifest{}, err
+	}
+
+	source := rand.NewSource(seed)
+	// math/rand is good enough in this case
+	/* #nosec G404 */
+	rng := rand.New(source)
+
+	pipelines, err := t.pipelines(t, customizations, options, repos, packageSpecSets, rng)
+	if err != nil {
+		return distro.Manifest{}, err
+	}
+
+	// flatten spec sets for sources
+	allPackageSpecs := make([]rpmmd.PackageSpec, 0)
+	for _, specs := range packageSpecSets {
+		allPackageSpecs = append(allPackageSpecs, specs...)
+	}
+
+	var commits []ostreeCommit
+	if options.OSTree.Parent != "" && options.OSTree.URL != "" {
+		commits = []ostreeCommit{{Checksum: options.OSTree.Parent, URL: options.OSTree.URL}}
+	}
+	sources, err := t.sources(allPackageSpecs, commits)
+	if err != nil {
+		return distro.Manifest{}, err
+	}
+	return json.Marshal(
+		osbuild.Manifest{
+			Version:   "2",
+			Pipelines: pipelines,
+			Sources:   sources,
+		},
+	)
+}
+
+func (t *imageType) sources(packages []rpmmd.PackageSpec, ostreeCommits []ostreeCommit) (osbuild.Sources, error) {
+	sources := osbuild.Sources{}
+	if err := distro.AddPackageSources(sources, packages); err != nil {
+		return nil, err
+	}
+
+	ostree := &osbuild.OSTreeSource{
+		Items: make(map[string]osbuild.OSTreeSourceItem),
+	}
+	for _, commit := range ostreeCommits {
+		item := new(osbuild.OSTreeSourceItem)
+		item.Remote.URL = commit.URL
+		ostree.Items[commit.Checksum] = *item
+	}
+	if len(ostree.Items) > 0 {
+		sources["org.osbuild.ostree"] = ostree
+	}
+	return sources, nil
+}
+
+func isMountpointAllowed(mountpoint string) bool {
+	for _, allowed := range mountpointAllowList {
+		match, _ := path.Match(allowed, mountpoint)
+		if match {
+			return true
+		}
+		// ensure that only clean mountpoints
+		// are valid
+		if strings.Contains(mountpoint, "//") {
+			return false
+		}
+		match = strings.HasPrefix(mountpoint, allowed+"/")
+		if allowed != "/" && match {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOptions checks the validity and compatibility of options and customizations for the image type.
+func (t *imageType) checkOptions(customizations *blueprint.Customizations, options distro.ImageOptions) error {
+	if t.bootISO && t.rpmOstree {
+		if options.OSTree.Parent == "" {
+			return fmt.Errorf("boot ISO image type %q requires specifying a URL from which to retrieve the OSTree commit", t.name)
+		}
+
+		if t.builtinName() == "edge-simplified-installer" {
+			if err := customizations.CheckAllowed("InstallationDevice"); err != nil {
+				return fmt.Errorf("boot ISO image type %q contains unsupported blueprint customizations: %v", t.name, err)
+			}
+			if customizations.GetInstallationDevice() == "" {
+				return fmt.Errorf("boot ISO image type %q requires specifying an installation device to install to", t.name)
+			}
+		} else if customizations != nil {
+			return fmt.Errorf("boot ISO image type %q does not support blueprint customizations", t.name)
+		}
+	}
+
+	if options.BootType != distro.UnsetBootType {
+		if !t.selectableBootType {
+			return fmt.Errorf("image type %q does not support selecting the boot type", t.name)
+		}
+		switch options.BootType {
+		case distro.LegacyBootType, distro.HybridBootType:
+			if t.arch.legacy == "" {
+				return fmt.Errorf("boot type %q is not supported on %s", options.BootType, t.arch.name)
+			}
+		case distro.UEFIBootType:
+		default:
+			return fmt.Errorf("unknown boot type %q", options.BootType)
+		}
+	}
+
+	if options.Compression != distro.UnsetCompression {
+		if !t.selectableCompression {
+			return fmt.Errorf("image type %q does not support selecting the compression", t.name)
+		}
+		switch options.Compression {
+		case distro.NoCompression, distro.XzCompression, distro.ZstdCompression:
+		default:
+			return fmt.Errorf("unknown compression %q", options.Compression)
+		}
+	}
+
+	if t.builtinName() == "edge-raw-image" && options.OSTree.Parent == "" {
+		return fmt.Errorf("edge raw images require specifying a URL from which to retrieve the OSTree commit")
+	}
+
+	if kernelOpts := customizations.GetKernel(); kernelOpts.Append != "" && t.rpmOstree {
+		return fmt.Errorf("kernel boot parameter customizations are not supported for ostree types")
+	}
+
+	mountpoints := customizations.GetFilesystems()
+
+	if mountpoints != nil && t.rpmOstree {
+		return fmt.Errorf("Custom mountpoints are not supported for ostree types")
+	}
+
+	invalidMountpoints := []string{}
+	for _, m := range mountpoints {
+		if m.Mountpoint == "/usr" && m.MinSize < 2147483648 {
+			m.MinSize = 2147483648
+		}
+		if !isMountpointAllowed(m.Mountpoint) {
+			invalidMountpoints = append(invalidMountpoints, m.Mountpoint)
+		}
+	}
+
+	if len(invalidMountpoints) > 0 {
+		return fmt.Errorf("The following custom mountpoints are not supported %+q", invalidMountpoints)
+	}
+
+	return nil
+}
+
+// NewF34 creates a new Fedora 34 distro object, defining the supported
+// architectures and image types
+func NewF34() distro.Distro {
+	return newDistro("fedora-34")
+}
+
+func NewF35() distro.Distro {
+	return newDistro("fedora-35")
+}
+
+func NewF36() distro.Distro {
+	return newDistro("fedora-36")
+}
+
+// NewHostDistro creates a distro object for the Fedora release composer
+// runs on, overriding its name, module platform ID and ostree ref
+func NewHostDistro(name, modulePlatformID, ostreeRef string) distro.Distro {
+	hd := newDistro(name).(*distribution)
+	hd.modulePlatformID = modulePlatformID
+	hd.ostreeRefTmpl = ostreeRef
+	return hd
+}
+
+func newDistro(distroName string) distro.Distro {
+	const GigaByte = 1024 * 1024 * 1024
+
+	rd := distroMap[distroName]
+
+	// Architecture definitions
+	x86_64 := architecture{
+		name:     distro.X86_64ArchName,
+		distro:   &rd,
+		legacy:   "i386-pc",
+		bootType: distro.HybridBootType,
+	}
+
+	aarch64 := architecture{
+		name:     distro.Aarch64ArchName,
+		distro:   &rd,
+		bootType: distro.UEFIBootType,
+	}
+
+	// Shared Services
+	edgeServices := []string{
+		"NetworkManager.service", "firewalld.service", "rngd.service", "sshd.service",
+		"zezere_ignition.timer", "zezere_ignition_banner.service",
+		"greenboot-grub2-set-counter", "greenboot-grub2-set-success", "greenboot-healthcheck", "greenboot-rpm-ostree-grub2-check-fallback",
+		"greenboot-status", "greenboot-task-runner", "redboot-auto-reboot", "redboot-task-runner",
+		"parsec", "dbus-parsec",
+	}
+
+	cloudInitServices := []string{
+		"cloud-init.service",
+		"cloud-config.service",
+		"cloud-final.service",
+		"cloud-init-local.service",
+	}
+
+	// Image Definitions
+	edgeCommitImgType := imageType{
+		name:        "edge-commit",
+		nameAliases: []string{"fedora-iot-commit"},
+		filename:    "commit.tar",
+		mimeType:    "application/x-tar",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: edgeBuildPackageSet,
+			osPkgsKey:    edgeCommitPackageSet,
+		},
+		defaultImageConfig: &distro.ImageConfig{
+			EnabledServices: edgeServices,
+		},
+		rpmOstree:        true,
+		pipelines:        edgeCommitPipelines,
+		buildPipelines:   []string{"build"},
+		payloadPipelines: []string{"ostree-tree", "ostree-commit", "commit-archive"},
+		exports:          []string{"commit-archive"},
+	}
+
+	edgeOCIImgType := imageType{
+		name:        "edge-container",
+		nameAliases: []string{"fedora-iot-container"},
+		filename:    "container.tar",
+		mimeType:    "application/x-tar",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: edgeBuildPackageSet,
+			osPkgsKey:    edgeCommitPackageSet,
+			containerPkgsKey: func(t *imageType) rpmmd.PackageSet {
+				return rpmmd.PackageSet{
+					Include: []string{"nginx"},
+				}
+			},
+		},
+		defaultImageConfig: &distro.ImageConfig{
+			EnabledServices: edgeServices,
+		},
+		rpmOstree:        true,
+		bootISO:          false,
+		pipelines:        edgeContainerPipelines,
+		buildPipelines:   []string{"build"},
+		payloadPipelines: []string{"ostree-tree", "ostree-commit", "container-tree", "container"},
+		exports:          []string{containerPkgsKey},
+	}
+
+	edgeRawImgType := imageType{
+		name:        "edge-raw-image",
+		nameAliases: []string{"fedora-iot-raw-image"},
+		filename:    "image.raw.xz",
+		mimeType:    "application/xz",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: edgeRawImageBuildPackageSet,
+		},
+		defaultSize:         10 * GigaByte,
+		rpmOstree:           true,
+		bootable:            true,
+		bootISO:             false,
+		pipelines:           edgeRawImagePipelines,
+		buildPipelines:      []string{"build"},
+		payloadPipelines:    []string{"image-tree", "image", "archive"},
+		exports:             []string{"archive"},
+		basePartitionTables: edgeBasePartitionTables,
+	}
+
+	edgeInstallerImgType := imageType{
+		name:        "edge-installer",
+		nameAliases: []string{"fedora-iot-installer"},
+		filename:    "installer.iso",
+		mimeType:    "application/x-iso9660-image",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey:     edgeInstallerBuildPackageSet,
+			osPkgsKey:        edgeCommitPackageSet,
+			installerPkgsKey: edgeInstallerPackageSet,
+		},
+		defaultImageConfig: &distro.ImageConfig{
+			EnabledServices: edgeServices,
+		},
+		rpmOstree:        true,
+		bootISO:          true,
+		pipelines:        edgeInstallerPipelines,
+		buildPipelines:   []string{"build"},
+		payloadPipelines: []string{"anaconda-tree", "bootiso-tree", "bootiso"},
+		exports:          []string{"bootiso"},
+	}
+
+	edgeSimplifiedInstallerImgType := imageType{
+		name:        "edge-simplified-installer",
+		nameAliases: []string{"fedora-iot-simplified-installer"},
+		filename:    "simplified-installer.iso",
+		mimeType:    "application/x-iso9660-image",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey:     edgeInstallerBuildPackageSet,
+			installerPkgsKey: edgeSimplifiedInstallerPackageSet,
+		},
+		defaultImageConfig: &distro.ImageConfig{
+			EnabledServices: edgeServices,
+		},
+		defaultSize:         10 * GigaByte,
+		rpmOstree:           true,
+		bootable:            true,
+		bootISO:             true,
+		pipelines:           edgeSimplifiedInstallerPipelines,
+		buildPipelines:      []string{"build"},
+		payloadPipelines:    []string{"image-tree", "image", "archive", "coi-tree", "efiboot-tree", "bootiso-tree", "bootiso"},
+		exports:             []string{"bootiso"},
+		basePartitionTables: edgeBasePartitionTables,
+	}
+
+	qcow2ImgType := imageType{
+		name:     "qcow2",
+		filename: "disk.qcow2",
+		mimeType: "application/x-qemu-disk",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: distroBuildPackageSet,
+			osPkgsKey:    qcow2CommonPackageSet,
+		},
+		defaultImageConfig: &distro.ImageConfig{
+			EnabledServices: cloudInitServices,
+			DefaultTarget:   "multi-user.target",
+		},
+		kernelOptions:       "ro net.ifnames=0",
+		bootable:            true,
+		defaultSize:         2 * GigaByte,
+		pipelines:           qcow2Pipelines,
+		buildPipelines:      []string{"build"},
+		payloadPipelines:    []string{"os", "image", "qcow2"},
+		exports:             []string{"qcow2"},
+		basePartitionTables: defaultBasePartitionTables,
+	}
+
+	vhdImgType := imageType{
+		name:     "vhd",
+		filename: "disk.vhd",
+		mimeType: "application/x-vhd",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: distroBuildPackageSet,
+			osPkgsKey:    vhdCommonPackageSet,
+		},
+		defaultImageConfig: &distro.ImageConfig{
+			EnabledServices: []string{
+				"sshd",
+				"waagent", // needed to run in Azure
+			},
+			DisabledServices: []string{
+				"proc-sys-fs-binfmt_misc.mount",
+				"loadmodules.service",
+			},
+			DefaultTarget: "multi-user.target",
+		},
+		// These kernel parameters are required by Azure documentation
+		kernelOptions:       "ro biosdevname=0 rootdelay=300 console=ttyS0 earlyprintk=ttyS0 net.ifnames=0",
+		bootable:            true,
+		defaultSize:         2 * GigaByte,
+		pipelines:           vhdPipelines,
+		buildPipelines:      []string{"build"},
+		payloadPipelines:    []string{"os", "image", "vpc"},
+		exports:             []string{"vpc"},
+		basePartitionTables: defaultBasePartitionTables,
+	}
+
+	vmdkImgType := imageType{
+		name:     "vmdk",
+		filename: "disk.vmdk",
+		mimeType: "application/x-vmdk",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: distroBuildPackageSet,
+			osPkgsKey:    vmdkCommonPackageSet,
+		},
+		defaultImageConfig: &distro.ImageConfig{
+			EnabledServices: cloudInitServices,
+		},
+		kernelOptions:       "ro net.ifnames=0",
+		bootable:            true,
+		defaultSize:         2 * GigaByte,
+		pipelines:           vmdkPipelines,
+		buildPipelines:      []string{"build"},
+		payloadPipelines:    []string{"os", "image", "vmdk"},
+		exports:             []string{"vmdk"},
+		basePartitionTables: defaultBasePartitionTables,
+	}
+
+	openstackImgType := imageType{
+		name:     "openstack",
+		filename: "disk.qcow2",
+		mimeType: "application/x-qemu-disk",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: distroBuildPackageSet,
+			osPkgsKey:    openstackCommonPackageSet,
+		},
+		defaultImageConfig: &distro.ImageConfig{
+			EnabledServices: cloudInitServices,
+		},
+		kernelOptions:       "ro net.ifnames=0",
+		bootable:            true,
+		defaultSize:         2 * GigaByte,
+		pipelines:           openstackPipelines,
+		buildPipelines:      []string{"build"},
+		payloadPipelines:    []string{"os", "image", "qcow2"},
+		exports:             []string{"qcow2"},
+		basePartitionTables: defaultBasePartitionTables,
+	}
+
+	amiImgType := imageType{
+		name:     "ami",
+		filename: "image.raw",
+		mimeType: "application/octet-stream",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: ec2BuildPackageSet,
+			osPkgsKey:    ec2CommonPackageSet,
+		},
+		defaultImageConfig: &distro.ImageConfig{
+			EnabledServices: []string{
+				"cloud-init.service",
+			},
+		},
+		kernelOptions:       "ro no_timer_check console=ttyS0,115200n8 console=tty1 biosdevname=0 net.ifnames=0 console=ttyS0,115200",
+		bootable:            true,
+		defaultSize:         6 * GigaByte,
+		pipelines:           amiPipelines,
+		buildPipelines:      []string{"build"},
+		payloadPipelines:    []string{"os", "image"},
+		exports:             []string{"image"},
+		basePartitionTables: defaultBasePartitionTables,
+	}
+
+	imageInstallerImgType := imageType{
+		name:     "image-installer",
+		filename: "installer.iso",
+		mimeType: "application/x-iso9660-image",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey:     anacondaBuildPackageSet,
+			osPkgsKey:        bareMetalPackageSet,
+			installerPkgsKey: anacondaPackageSet,
+		},
+		rpmOstree:        false,
+		bootISO:          true,
+		bootable:         true,
+		pipelines:        tarInstallerPipelines,
+		buildPipelines:   []string{"build"},
+		payloadPipelines: []string{"os", "anaconda-tree", "bootiso-tree", "bootiso"},
+		exports:          []string{"bootiso"},
+	}
+
+	liveISOImgType := imageType{
+		name:     "live-iso",
+		filename: "live.iso",
+		mimeType: "application/x-iso9660-image",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: anacondaBuildPackageSet,
+			osPkgsKey:    liveISOPackageSet,
+		},
+		liveISO:          true,
+		bootable:         true,
+		defaultSize:      4 * GigaByte,
+		pipelines:        liveISOPipelines,
+		buildPipelines:   []string{"build"},
+		payloadPipelines: []string{"os", "bootiso-tree", "bootiso"},
+		exports:          []string{"bootiso"},
+	}
+
+	minimalRawImgType := imageType{
+		name:     "minimal-raw",
+		filename: "disk.raw.xz",
+		mimeType: "application/xz",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: minimalRawBuildPackageSet,
+			osPkgsKey:    minimalRawPackageSet,
+		},
+		defaultImageConfig: &distro.ImageConfig{
+			DefaultTarget: "multi-user.target",
+		},
+		kernelOptions:         "ro",
+		bootable:              true,
+		selectableBootType:    true,
+		selectableCompression: true,
+		defaultSize:           2 * GigaByte,
+		pipelines:             minimalRawPipelines,
+		buildPipelines:        []string{"build"},
+		payloadPipelines:      []string{"os", "image", "archive"},
+		exports:               []string{"archive"},
+		basePartitionTables:   defaultBasePartitionTables,
+	}
+
+	x86_64.addImageTypes(qcow2ImgType, vhdImgType, vmdkImgType, openstackImgType, amiImgType, imageInstallerImgType, edgeCommitImgType, edgeInstallerImgType, edgeOCIImgType, edgeRawImgType, edgeSimplifiedInstallerImgType, liveISOImgType, minimalRawImgType)
+	aarch64.addImageTypes(qcow2ImgType, openstackImgType, amiImgType, edgeCommitImgType, edgeInstallerImgType, edgeOCIImgType, edgeRawImgType, edgeSimplifiedInstallerImgType, liveISOImgType, minimalRawImgType)
+
+	rd.addArches(x86_64, aarch64)
+	return &rd
+}
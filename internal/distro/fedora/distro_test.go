@@ -0,0 +1,255 @@
+package fedora_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/osbuild-composer/internal/blueprint"
+	"github.com/osbuild/osbuild-composer/internal/distro"
+	"github.com/osbuild/osbuild-composer/internal/distro/distro_test_common"
+	"github.com/osbuild/osbuild-composer/internal/distro/fedora"
+)
+
+type fedoraFamilyDistro struct {
+	name   string
+	distro distro.Distro
+}
+
+var fedoraFamilyDistros = []fedoraFamilyDistro{
+	{
+		name:   "fedora-34",
+		distro: fedora.NewF34(),
+	},
+	{
+		name:   "fedora-35",
+		distro: fedora.NewF35(),
+	},
+	{
+		name:   "fedora-36",
+		distro: fedora.NewF36(),
+	},
+}
+
+func TestFilenameFromType(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		mimeType string
+	}{
+		{
+			name:     "ami",
+			filename: "image.raw",
+			mimeType: "application/octet-stream",
+		},
+		{
+			name:     "qcow2",
+			filename: "disk.qcow2",
+			mimeType: "application/x-qemu-disk",
+		},
+		{
+			name:     "openstack",
+			filename: "disk.qcow2",
+			mimeType: "application/x-qemu-disk",
+		},
+		{
+			name:     "vhd",
+			filename: "disk.vhd",
+			mimeType: "application/x-vhd",
+		},
+		{
+			name:     "vmdk",
+			filename: "disk.vmdk",
+			mimeType: "application/x-vmdk",
+		},
+		{
+			name:     "edge-commit",
+			filename: "commit.tar",
+			mimeType: "application/x-tar",
+		},
+		{
+			name:     "edge-container",
+			filename: "container.tar",
+			mimeType: "application/x-tar",
+		},
+		{
+			name:     "edge-raw-image",
+			filename: "image.raw.xz",
+			mimeType: "application/xz",
+		},
+		{
+			name:     "edge-installer",
+			filename: "installer.iso",
+			mimeType: "application/x-iso9660-image",
+		},
+		{
+			name:     "edge-simplified-installer",
+			filename: "simplified-installer.iso",
+			mimeType: "application/x-iso9660-image",
+		},
+		{
+			name:     "image-installer",
+			filename: "installer.iso",
+			mimeType: "application/x-iso9660-image",
+		},
+		{
+			name:     "live-iso",
+			filename: "live.iso",
+			mimeType: "application/x-iso9660-image",
+		},
+		{
+			name:     "minimal-raw",
+			filename: "disk.raw.xz",
+			mimeType: "application/xz",
+		},
+	}
+	for _, dist := range fedoraFamilyDistros {
+		t.Run(dist.name, func(t *testing.T) {
+			arch, err := dist.distro.GetArch("x86_64")
+			require.NoError(t, err)
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					imgType, err := arch.GetImageType(tt.name)
+					require.NoError(t, err)
+					assert.Equal(t, tt.filename, imgType.Filename())
+					assert.Equal(t, tt.mimeType, imgType.MIMEType())
+				})
+			}
+			_, err = arch.GetImageType("foobar")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestImageTypeAliases(t *testing.T) {
+	aliases := map[string]string{
+		"fedora-iot-commit":               "edge-commit",
+		"fedora-iot-container":            "edge-container",
+		"fedora-iot-raw-image":            "edge-raw-image",
+		"fedora-iot-installer":            "edge-installer",
+		"fedora-iot-simplified-installer": "edge-simplified-installer",
+	}
+	for _, dist := range fedoraFamilyDistros {
+		t.Run(dist.name, func(t *testing.T) {
+			for _, archName := range dist.distro.ListArches() {
+				arch, err := dist.distro.GetArch(archName)
+				require.NoError(t, err)
+				for alias, name := range aliases {
+					imgType, err := arch.GetImageType(alias)
+					require.NoErrorf(t, err, "arch: %s, alias: %s", archName, alias)
+					assert.Equal(t, name, imgType.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestArchitecture_ListImageTypes(t *testing.T) {
+	imgMap := map[string][]string{
+		"x86_64": {
+			"qcow2",
+			"openstack",
+			"vhd",
+			"vmdk",
+			"ami",
+			"edge-commit",
+			"edge-container",
+			"edge-installer",
+			"edge-raw-image",
+			"edge-simplified-installer",
+			"image-installer",
+			"live-iso",
+			"minimal-raw",
+		},
+		"aarch64": {
+			"qcow2",
+			"openstack",
+			"ami",
+			"edge-commit",
+			"edge-container",
+			"edge-installer",
+			"edge-raw-image",
+			"edge-simplified-installer",
+			"live-iso",
+			"minimal-raw",
+		},
+	}
+
+	for _, dist := range fedoraFamilyDistros {
+		t.Run(dist.name, func(t *testing.T) {
+			for archName, imgNames := range imgMap {
+				arch, err := dist.distro.GetArch(archName)
+				require.NoError(t, err)
+				require.ElementsMatch(t, imgNames, arch.ListImageTypes())
+			}
+		})
+	}
+}
+
+// Check that Manifest() function returns an error for unsupported
+// configurations.
+func TestDistro_ManifestError(t *testing.T) {
+	bp := blueprint.Blueprint{
+		Customizations: &blueprint.Customizations{
+			Kernel: &blueprint.KernelCustomization{
+				Append: "debug",
+			},
+		},
+	}
+
+	for _, dist := range fedoraFamilyDistros {
+		for _, archName := range dist.distro.ListArches() {
+			arch, _ := dist.distro.GetArch(archName)
+			for _, imgTypeName := range arch.ListImageTypes() {
+				imgType, _ := arch.GetImageType(imgTypeName)
+				imgOpts := distro.ImageOptions{
+					Size: imgType.Size(0),
+				}
+				_, err := imgType.Manifest(bp.Customizations, imgOpts, nil, nil, 0)
+				if imgTypeName == "edge-commit" || imgTypeName == "edge-container" {
+					assert.EqualError(t, err, "kernel boot parameter customizations are not supported for ostree types")
+				} else if imgTypeName == "edge-raw-image" {
+					assert.EqualError(t, err, "edge raw images require specifying a URL from which to retrieve the OSTree commit")
+				} else if imgTypeName == "edge-installer" || imgTypeName == "edge-simplified-installer" {
+					assert.EqualError(t, err, fmt.Sprintf("boot ISO image type \"%s\" requires specifying a URL from which to retrieve the OSTree commit", imgTypeName))
+				} else {
+					assert.NoError(t, err)
+				}
+			}
+		}
+	}
+}
+
+func TestFedora_ListArches(t *testing.T) {
+	for _, dist := range fedoraFamilyDistros {
+		assert.Equal(t, []string{"aarch64", "x86_64"}, dist.distro.ListArches())
+	}
+}
+
+func TestFedora_Name(t *testing.T) {
+	for _, dist := range fedoraFamilyDistros {
+		assert.Equal(t, dist.name, dist.distro.Name())
+	}
+}
+
+func TestFedora_ModulePlatformID(t *testing.T) {
+	assert.Equal(t, "platform:f34", fedora.NewF34().ModulePlatformID())
+	assert.Equal(t, "platform:f35", fedora.NewF35().ModulePlatformID())
+	assert.Equal(t, "platform:f36", fedora.NewF36().ModulePlatformID())
+}
+
+func TestFedora_OSTreeRef(t *testing.T) {
+	arch, err := fedora.NewF35().GetArch("aarch64")
+	require.NoError(t, err)
+	imgType, err := arch.GetImageType("edge-commit")
+	require.NoError(t, err)
+	assert.Equal(t, "fedora/35/aarch64/iot", imgType.OSTreeRef())
+}
+
+func TestFedora_KernelOption(t *testing.T) {
+	for _, dist := range fedoraFamilyDistros {
+		distro_test_common.TestDistro_KernelOption(t, dist.distro)
+	}
+}
@@ -0,0 +1,862 @@
+package fedora
+
+// This file defines package sets that are used by more than one image type.
+
+import (
+	"fmt"
+
+	"github.com/osbuild/osbuild-composer/internal/distro"
+	"github.com/osbuild/osbuild-composer/internal/rpmmd"
+)
+
+// BUILD PACKAGE SETS
+
+// distro-wide build package set
+func distroBuildPackageSet(t *imageType) rpmmd.PackageSet {
+	ps := rpmmd.PackageSet{
+		Include: []string{
+			"dnf",
+			"dosfstools",
+			"e2fsprogs",
+			"glibc",
+			"lorax-templates-generic",
+			"policycoreutils",
+			"python3-iniparse",
+			"qemu-img",
+			"selinux-policy-targeted",
+			"systemd",
+			"tar",
+			"xfsprogs",
+			"xz",
+		},
+	}
+
+	if t.arch.Name() == distro.X86_64ArchName {
+		ps = ps.Append(x8664BuildPackageSet(t))
+	}
+
+	return ps
+}
+
+// x86_64 build package set
+func x8664BuildPackageSet(t *imageType) rpmmd.PackageSet {
+	return rpmmd.PackageSet{
+		Include: []string{
+			"grub2-pc",
+		},
+	}
+}
+
+// common ec2 image build package set
+func ec2BuildPackageSet(t *imageType) rpmmd.PackageSet {
+	return distroBuildPackageSet(t).Append(
+		rpmmd.PackageSet{
+			Include: []string{
+				"python3-pyyaml",
+			},
+		})
+}
+
+// common edge image build package set
+func edgeBuildPackageSet(t *imageType) rpmmd.PackageSet {
+	return distroBuildPackageSet(t).Append(
+		rpmmd.PackageSet{
+			Include: []string{
+				"rpm-ostree",
+			},
+		})
+}
+
+func edgeRawImageBuildPackageSet(t *imageType) rpmmd.PackageSet {
+	return edgeBuildPackageSet(t).Append(
+		bootPackageSet(t),
+	)
+}
+
+// installer boot package sets, needed for booting and
+// also in the build host
+
+func anacondaBootPackageSet(t *imageType) rpmmd.PackageSet {
+	ps := rpmmd.PackageSet{}
+
+	grubCommon := rpmmd.PackageSet{
+		Include: []string{
+			"grub2-tools",
+			"grub2-tools-extra",
+			"grub2-tools-minimal",
+		},
+	}
+
+	efiCommon := rpmmd.PackageSet{
+		Include: []string{
+			"efibootmgr",
+		},
+	}
+
+	switch t.arch.Name() {
+	case distro.X86_64ArchName:
+		ps = ps.Append(grubCommon)
+		ps = ps.Append(efiCommon)
+		ps = ps.Append(rpmmd.PackageSet{
+			Include: []string{
+				"grub2-efi-ia32-cdboot",
+				"grub2-efi-x64",
+				"grub2-efi-x64-cdboot",
+				"grub2-pc",
+				"grub2-pc-modules",
+				"shim-ia32",
+				"shim-x64",
+				"syslinux",
+				"syslinux-nonlinux",
+			},
+		})
+	case distro.Aarch64ArchName:
+		ps = ps.Append(grubCommon)
+		ps = ps.Append(efiCommon)
+		ps = ps.Append(rpmmd.PackageSet{
+			Include: []string{
+				"grub2-efi-aa64-cdboot",
+				"grub2-efi-aa64",
+				"shim-aa64",
+			},
+		})
+
+	default:
+		panic(fmt.Sprintf("unsupported arch: %s", t.arch.Name()))
+	}
+
+	return ps
+}
+
+func installerBuildPackageSet(t *imageType) rpmmd.PackageSet {
+	return distroBuildPackageSet(t).Append(
+		rpmmd.PackageSet{
+			Include: []string{
+				"isomd5sum",
+				"xorriso",
+			},
+		})
+}
+
+func anacondaBuildPackageSet(t *imageType) rpmmd.PackageSet {
+	ps := rpmmd.PackageSet{
+		Include: []string{
+			"squashfs-tools",
+		},
+	}
+
+	ps = ps.Append(installerBuildPackageSet(t))
+	ps = ps.Append(anacondaBootPackageSet(t))
+
+	return ps
+}
+
+func edgeInstallerBuildPackageSet(t *imageType) rpmmd.PackageSet {
+	return anacondaBuildPackageSet(t).Append(
+		edgeBuildPackageSet(t),
+	)
+}
+
+// BOOT PACKAGE SETS
+
+func bootPackageSet(t *imageType) rpmmd.PackageSet {
+	if !t.bootable {
+		return rpmmd.PackageSet{}
+	}
+
+	var addLegacyBootPkg bool
+	var addUEFIBootPkg bool
+
+	switch bt := t.getBootType(); bt {
+	case distro.LegacyBootType:
+		addLegacyBootPkg = true
+	case distro.UEFIBootType:
+		addUEFIBootPkg = true
+	case distro.HybridBootType:
+		addLegacyBootPkg = true
+		addUEFIBootPkg = true
+	default:
+		panic(fmt.Sprintf("unsupported boot type: %q", bt))
+	}
+
+	ps := rpmmd.PackageSet{}
+
+	switch t.arch.Name() {
+	case distro.X86_64ArchName:
+		if addLegacyBootPkg {
+			ps = ps.Append(x8664LegacyBootPackageSet(t))
+		}
+		if addUEFIBootPkg {
+			ps = ps.Append(x8664UEFIBootPackageSet(t))
+		}
+
+	case distro.Aarch64ArchName:
+		ps = ps.Append(aarch64UEFIBootPackageSet(t))
+
+	default:
+		panic(fmt.Sprintf("unsupported boot arch: %s", t.arch.Name()))
+	}
+
+	return ps
+}
+
+// x86_64 Legacy arch-specific boot package set
+func x8664LegacyBootPackageSet(t *imageType) rpmmd.PackageSet {
+	return rpmmd.PackageSet{
+		Include: []string{
+			"dracut-config-generic",
+			"grub2-pc",
+		},
+	}
+}
+
+// x86_64 UEFI arch-specific boot package set
+func x8664UEFIBootPackageSet(t *imageType) rpmmd.PackageSet {
+	return rpmmd.PackageSet{
+		Include: []string{
+			"dracut-config-generic",
+			"efibootmgr",
+			"grub2-efi-x64",
+			"shim-x64",
+		},
+	}
+}
+
+// aarch64 UEFI arch-specific boot package set
+func aarch64UEFIBootPackageSet(t *imageType) rpmmd.PackageSet {
+	return rpmmd.PackageSet{
+		Include: []string{
+			"dracut-config-generic",
+			"efibootmgr",
+			"grub2-efi-aa64",
+			"grub2-tools",
+			"shim-aa64",
+		},
+	}
+}
+
+// OS package sets
+
+func qcow2CommonPackageSet(t *imageType) rpmmd.PackageSet {
+	return rpmmd.PackageSet{
+		Include: []string{
+			"@Fedora Cloud Server",
+			"chrony",
+			"systemd-udev",
+			"selinux-policy-targeted",
+			"langpacks-en",
+		},
+		Exclude: []string{
+			"dracut-config-rescue",
+			"etables",
+			"firewalld",
+			"geolite2-city",
+			"geolite2-country",
+			"gobject-introspection",
+			"plymouth",
+			"zram-generator-defaults",
+		},
+	}.Append(bootPackageSet(t))
+}
+
+func vhdCommonPackageSet(t *imageType) rpmmd.PackageSet {
+	return rpmmd.PackageSet{
+		Include: []string{
+			"@Core",
+			"chrony",
+			"selinux-policy-targeted",
+			"langpacks-en",
+			"net-tools",
+			"ntfsprogs",
+			"WALinuxAgent",
+			"libxcrypt-compat",
+			"initscripts",
+			"glibc-all-langpacks",
+		},
+		Exclude: []string{
+			"dracut-config-rescue",
+			"geolite2-city",
+			"geolite2-country",
+			"zram-generator-defaults",
+		},
+	}.Append(bootPackageSet(t))
+}
+
+func vmdkCommonPackageSet(t *imageType) rpmmd.PackageSet {
+	return rpmmd.PackageSet{
+		Include: []string{
+			"@Fedora Cloud Server",
+			"chrony",
+			"systemd-udev",
+			"selinux-policy-targeted",
+			"langpacks-en",
+			"open-vm-tools",
+		},
+		Exclude: []string{
+			"dracut-config-rescue",
+			"etables",
+			"firewalld",
+			"geolite2-city",
+			"geolite2-country",
+			"gobject-introspection",
+			"plymouth",
+			"zram-generator-defaults",
+		},
+	}.Append(bootPackageSet(t))
+}
+
+func openstackCommonPackageSet(t *imageType) rpmmd.PackageSet {
+	return rpmmd.PackageSet{
+		Include: []string{
+			"@Core",
+			"chrony",
+			"selinux-policy-targeted",
+			"spice-vdagent",
+			"qemu-guest-agent",
+			"xen-libs",
+			"langpacks-en",
+			"cloud-init",
+			"libdrm",
+		},
+		Exclude: []string{
+			"dracut-config-rescue",
+			"geolite2-city",
+			"geolite2-country",
+			"zram-generator-defaults",
+		},
+	}.Append(bootPackageSet(t))
+}
+
+func ec2CommonPackageSet(t *imageType) rpmmd.PackageSet {
+	return rpmmd.PackageSet{
+		Include: []string{
+			"@Core",
+			"chrony",
+			"selinux-policy-targeted",
+			"langpacks-en",
+			"libxcrypt-compat",
+			"xfsprogs",
+			"cloud-init",
+			"checkpolicy",
+			"net-tools",
+		},
+		Exclude: []string{
+			"dracut-config-rescue",
+			"geolite2-city",
+			"geolite2-country",
+			"zram-generator-defaults",
+		},
+	}.Append(bootPackageSet(t))
+}
+
+// edge commit OS package set
+func edgeCommitPackageSet(t *imageType) rpmmd.PackageSet {
+	ps := rpmmd.PackageSet{
+		Include: []string{
+			"fedora-release-iot",
+			"glibc",
+			"glibc-minimal-langpack",
+			"nss-altfiles",
+			"sssd-client",
+			"libsss_sudo",
+			"shadow-utils",
+			"dracut-config-generic",
+			"dracut-network",
+			"rpm-ostree",
+			"polkit",
+			"lvm2",
+			"cryptsetup",
+			"pinentry",
+			"keyutils",
+			"cracklib-dicts",
+			"e2fsprogs",
+			"xfsprogs",
+			"dosfstools",
+			"gnupg2",
+			"basesystem",
+			"python3",
+			"bash",
+			"xz",
+			"gzip",
+			"coreutils",
+			"which",
+			"curl",
+			"firewalld",
+			"iptables",
+			"NetworkManager",
+			"NetworkManager-wifi",
+			"NetworkManager-wwan",
+			"wpa_supplicant",
+			"iwd",
+			"tpm2-pkcs11",
+			"dnsmasq",
+			"traceroute",
+			"hostname",
+			"iproute",
+			"iputils",
+			"openssh-clients",
+			"openssh-server",
+			"passwd",
+			"policycoreutils",
+			"procps-ng",
+			"rootfiles",
+			"rpm",
+			"selinux-policy-targeted",
+			"setup",
+			"sudo",
+			"systemd",
+			"util-linux",
+			"vim-minimal",
+			"less",
+			"tar",
+			"fwupd",
+			"usbguard",
+			"greenboot",
+			"greenboot-grub2",
+			"greenboot-rpm-ostree-grub2",
+			"greenboot-reboot",
+			"greenboot-status",
+			"ignition",
+			"zezere-ignition",
+			"rsync",
+			"attr",
+			"ima-evm-utils",
+			"bash-completion",
+			"tmux",
+			"screen",
+			"policycoreutils-python-utils",
+			"setools-console",
+			"audit",
+			"rng-tools",
+			"chrony",
+			"bluez",
+			"bluez-libs",
+			"bluez-mesh",
+			"kernel-tools",
+			"libgpiod-utils",
+			"podman",
+			"container-selinux",
+			"skopeo",
+			"criu",
+			"slirp4netns",
+			"fuse-overlayfs",
+			"clevis",
+			"clevis-dracut",
+			"clevis-luks",
+			"clevis-pin-tpm2",
+			"parsec",
+			"dbus-parsec",
+		},
+	}
+
+	ps = ps.Append(bootPackageSet(t))
+
+	switch t.arch.Name() {
+	case distro.X86_64ArchName:
+		ps = ps.Append(x8664EdgeCommitPackageSet(t))
+
+	case distro.Aarch64ArchName:
+		ps = ps.Append(aarch64EdgeCommitPackageSet(t))
+	}
+
+	return ps
+
+}
+
+func x8664EdgeCommitPackageSet(t *imageType) rpmmd.PackageSet {
+	return rpmmd.PackageSet{
+		Include: []string{
+			"grub2",
+			"grub2-efi-x64",
+			"efibootmgr",
+			"shim-x64",
+			"microcode_ctl",
+			"iwl1000-firmware",
+			"iwl100-firmware",
+			"iwl105-firmware",
+			"iwl135-firmware",
+			"iwl2000-firmware",
+			"iwl2030-firmware",
+			"iwl3160-firmware",
+			"iwl5000-firmware",
+			"iwl5150-firmware",
+			"iwl6000-firmware",
+			"iwl6050-firmware",
+			"iwl7260-firmware",
+		},
+	}
+}
+
+func aarch64EdgeCommitPackageSet(t *imageType) rpmmd.PackageSet {
+	return rpmmd.PackageSet{
+		Include: []string{
+			"grub2-efi-aa64",
+			"efibootmgr",
+			"shim-aa64",
+			"iwl7260-firmware",
+		},
+	}
+}
+
+func bareMetalPackageSet(t *imageType) rpmmd.PackageSet {
+	return rpmmd.PackageSet{
+		Include: []string{
+			"@Core",
+			"chrony",
+			"cockpit-system",
+			"cockpit-ws",
+			"dnf-utils",
+			"dosfstools",
+			"firewalld",
+			"iwl1000-firmware",
+			"iwl100-firmware",
+			"iwl105-firmware",
+			"iwl135-firmware",
+			"iwl2000-firmware",
+			"iwl2030-firmware",
+			"iwl3160-firmware",
+			"iwl5000-firmware",
+			"iwl5150-firmware",
+			"iwl6000g2a-firmware",
+			"iwl6000g2b-firmware",
+			"iwl6050-firmware",
+			"iwl7260-firmware",
+			"langpacks-en",
+			"lvm2",
+			"net-tools",
+			"nfs-utils",
+			"policycoreutils",
+			"qemu-guest-agent",
+			"rsync",
+			"selinux-policy-targeted",
+			"tar",
+		},
+		Exclude: []string{
+			"dracut-config-rescue",
+			"geolite2-city",
+			"geolite2-country",
+			"zram-generator-defaults",
+		},
+	}.Append(bootPackageSet(t)).Append(distroBuildPackageSet(t))
+}
+
+// MINIMAL RAW PACKAGE SETS
+
+func minimalRawBuildPackageSet(t *imageType) rpmmd.PackageSet {
+	return distroBuildPackageSet(t).Append(
+		rpmmd.PackageSet{
+			Include: []string{
+				"zstd",
+			},
+		})
+}
+
+// a minimal OS booting on bare-metal and embedded devices
+func minimalRawPackageSet(t *imageType) rpmmd.PackageSet {
+	return rpmmd.PackageSet{
+		Include: []string{
+			"@core",
+			"dracut-config-generic",
+			"NetworkManager-wifi",
+			"selinux-policy-targeted",
+		},
+		Exclude: []string{
+			"dracut-config-rescue",
+		},
+	}.Append(bootPackageSet(t))
+}
+
+// LIVE ISO PACKAGE SET
+
+// the OS of live images, which boots from the squashfs image on the ISO
+func liveISOPackageSet(t *imageType) rpmmd.PackageSet {
+	return bareMetalPackageSet(t).Append(anacondaBootPackageSet(t)).Append(
+		rpmmd.PackageSet{
+			Include: []string{
+				"dracut-live",
+			},
+		})
+}
+
+// INSTALLER PACKAGE SET
+
+func installerPackageSet(t *imageType) rpmmd.PackageSet {
+	ps := rpmmd.PackageSet{
+		Include: []string{
+			"anaconda-dracut",
+			"curl",
+			"dracut-config-generic",
+			"dracut-network",
+			"hostname",
+			"iwl100-firmware",
+			"iwl1000-firmware",
+			"iwl105-firmware",
+			"iwl135-firmware",
+			"iwl2000-firmware",
+			"iwl2030-firmware",
+			"iwl3160-firmware",
+			"iwl5000-firmware",
+			"iwl5150-firmware",
+			"iwl6050-firmware",
+			"iwl7260-firmware",
+			"kernel",
+			"less",
+			"nfs-utils",
+			"openssh-clients",
+			"ostree",
+			"plymouth",
+			"prefixdevname",
+			"rng-tools",
+			"rpcbind",
+			"selinux-policy-targeted",
+			"systemd",
+			"tar",
+			"xfsprogs",
+			"xz",
+		},
+	}
+
+	switch t.arch.Name() {
+	case distro.X86_64ArchName:
+		ps = ps.Append(rpmmd.PackageSet{
+			Include: []string{
+				"biosdevname",
+			},
+		})
+	}
+
+	return ps
+}
+
+func anacondaPackageSet(t *imageType) rpmmd.PackageSet {
+
+	// common installer packages
+	ps := installerPackageSet(t)
+
+	ps = ps.Append(rpmmd.PackageSet{
+		Include: []string{
+			"aajohan-comfortaa-fonts",
+			"abattis-cantarell-fonts",
+			"alsa-firmware",
+			"alsa-tools-firmware",
+			"anaconda",
+			"anaconda-dracut",
+			"anaconda-install-env-deps",
+			"anaconda-widgets",
+			"audit",
+			"bind-utils",
+			"biosdevname",
+			"bitmap-fangsongti-fonts",
+			"bzip2",
+			"cryptsetup",
+			"curl",
+			"dbus-x11",
+			"dejavu-sans-fonts",
+			"dejavu-sans-mono-fonts",
+			"device-mapper-persistent-data",
+			"dmidecode",
+			"dnf",
+			"dracut-config-generic",
+			"dracut-network",
+			"efibootmgr",
+			"ethtool",
+			"ftp",
+			"gdb-gdbserver",
+			"gdisk",
+			"glibc-all-langpacks",
+			"gnome-kiosk",
+			"google-noto-sans-cjk-ttc-fonts",
+			"grub2-efi-ia32-cdboot",
+			"grub2-efi-x64-cdboot",
+			"grub2-tools",
+			"grub2-tools-efi",
+			"grub2-tools-extra",
+			"grub2-tools-minimal",
+			"grubby",
+			"gsettings-desktop-schemas",
+			"hdparm",
+			"hexedit",
+			"hostname",
+			"initscripts",
+			"ipmitool",
+			"iwl1000-firmware",
+			"iwl100-firmware",
+			"iwl105-firmware",
+			"iwl135-firmware",
+			"iwl2000-firmware",
+			"iwl2030-firmware",
+			"iwl3160-firmware",
+			"iwl5000-firmware",
+			"iwl5150-firmware",
+			"iwl6000g2a-firmware",
+			"iwl6000g2b-firmware",
+			"iwl6050-firmware",
+			"iwl7260-firmware",
+			"jomolhari-fonts",
+			"kacst-farsi-fonts",
+			"kacst-qurn-fonts",
+			"kbd",
+			"kbd-misc",
+			"kdump-anaconda-addon",
+			"kernel",
+			"khmeros-base-fonts",
+			"less",
+			"libblockdev-lvm-dbus",
+			"libibverbs",
+			"libreport-plugin-bugzilla",
+			"libreport-plugin-reportuploader",
+			"librsvg2",
+			"linux-firmware",
+			"lklug-fonts",
+			"lohit-assamese-fonts",
+			"lohit-bengali-fonts",
+			"lohit-devanagari-fonts",
+			"lohit-gujarati-fonts",
+			"lohit-gurmukhi-fonts",
+			"lohit-kannada-fonts",
+			"lohit-odia-fonts",
+			"lohit-tamil-fonts",
+			"lohit-telugu-fonts",
+			"lsof",
+			"madan-fonts",
+			"memtest86+",
+			"mtr",
+			"mt-st",
+			"net-tools",
+			"nfs-utils",
+			"nmap-ncat",
+			"nm-connection-editor",
+			"nss-tools",
+			"openssh-clients",
+			"openssh-server",
+			"ostree",
+			"pciutils",
+			"perl-interpreter",
+			"pigz",
+			"plymouth",
+			"prefixdevname",
+			"python3-pyatspi",
+			"rdma-core",
+			"rng-tools",
+			"rpcbind",
+			"rpm-ostree",
+			"rsync",
+			"rsyslog",
+			"selinux-policy-targeted",
+			"sg3_utils",
+			"shim-ia32",
+			"shim-x64",
+			"sil-abyssinica-fonts",
+			"sil-padauk-fonts",
+			"sil-scheherazade-fonts",
+			"smartmontools",
+			"smc-meera-fonts",
+			"spice-vdagent",
+			"strace",
+			"syslinux",
+			"systemd",
+			"tar",
+			"thai-scalable-waree-fonts",
+			"tigervnc-server-minimal",
+			"tigervnc-server-module",
+			"udisks2",
+			"udisks2-iscsi",
+			"usbutils",
+			"vim-minimal",
+			"volume_key",
+			"wget",
+			"xfsdump",
+			"xfsprogs",
+			"xorg-x11-drivers",
+			"xorg-x11-fonts-misc",
+			"xorg-x11-server-utils",
+			"xorg-x11-server-Xorg",
+			"xorg-x11-xauth",
+			"xz",
+		},
+	})
+
+	ps = ps.Append(anacondaBootPackageSet(t))
+
+	switch t.arch.Name() {
+	case distro.X86_64ArchName:
+		ps = ps.Append(rpmmd.PackageSet{
+			Include: []string{
+				"biosdevname",
+				"dmidecode",
+				"memtest86+",
+			},
+		})
+
+	case distro.Aarch64ArchName:
+		ps = ps.Append(rpmmd.PackageSet{
+			Include: []string{
+				"dmidecode",
+			},
+		})
+
+	default:
+		panic(fmt.Sprintf("unsupported arch: %s", t.arch.Name()))
+	}
+
+	return ps
+}
+
+func edgeInstallerPackageSet(t *imageType) rpmmd.PackageSet {
+	return anacondaPackageSet(t)
+}
+
+func edgeSimplifiedInstallerPackageSet(t *imageType) rpmmd.PackageSet {
+	// common installer packages
+	ps := installerPackageSet(t)
+
+	ps = ps.Append(rpmmd.PackageSet{
+		Include: []string{
+			"attr",
+			"basesystem",
+			"binutils",
+			"bsdtar",
+			"cloud-utils-growpart",
+			"coreos-installer",
+			"coreos-installer-dracut",
+			"coreutils",
+			"device-mapper-multipath",
+			"dnsmasq",
+			"dosfstools",
+			"dracut-live",
+			"e2fsprogs",
+			"fcoe-utils",
+			"gzip",
+			"ima-evm-utils",
+			"iproute",
+			"iptables",
+			"iputils",
+			"iscsi-initiator-utils",
+			"keyutils",
+			"lldpad",
+			"lvm2",
+			"passwd",
+			"policycoreutils",
+			"policycoreutils-python-utils",
+			"procps-ng",
+			"rootfiles",
+			"setools-console",
+			"sudo",
+			"traceroute",
+			"util-linux",
+		},
+	})
+
+	switch t.arch.Name() {
+
+	case distro.X86_64ArchName:
+		ps = ps.Append(x8664EdgeCommitPackageSet(t))
+	case distro.Aarch64ArchName:
+		ps = ps.Append(aarch64EdgeCommitPackageSet(t))
+
+	default:
+		panic(fmt.Sprintf("unsupported arch: %s", t.arch.Name()))
+	}
+
+	return ps
+}
@@ -21,18 +21,6 @@ const releaseVersion = "33"
 const modulePlatformID = "platform:f33"
 const ostreeRef = "fedora/33/%s/iot"
 
-const f34Name = "fedora-34"
-const f34modulePlatformID = "platform:f34"
-const f34ostreeRef = "fedora/34/%s/iot"
-
-const f35Name = "fedora-35"
-const f35modulePlatformID = "platform:f35"
-const f35ostreeRef = "fedora/35/%s/iot"
-
-const f36Name = "fedora-36"
-const f36modulePlatformID = "platform:f36"
-const f36ostreeRef = "fedora/36/%s/iot"
-
 type distribution struct {
 	name             string
 	modulePlatformID string
@@ -311,6 +299,10 @@ func sources(packages []rpmmd.PackageSpec) *osbuild.Sources {
 
 func (t *imageType) pipeline(c *blueprint.Customizations, options distro.ImageOptions, repos []rpmmd.RepoConfig, packageSpecs, buildPackageSpecs []rpmmd.PackageSpec) (*osbuild.Pipeline, error) {
 
+	if len(c.GetModuleStreams()) > 0 {
+		return nil, fmt.Errorf("module stream customizations are not supported")
+	}
+
 	if kernelOpts := c.GetKernel(); kernelOpts != nil && kernelOpts.Append != "" && t.rpmOstree {
 		return nil, fmt.Errorf("kernel boot parameter customizations are not supported for ostree types")
 	}
@@ -436,10 +428,7 @@ func (t *imageType) kernelCmdlineStageOptions() *osbuild.KernelCmdlineStageOptio
 func (t *imageType) rpmStageOptions(arch architecture, repos []rpmmd.RepoConfig, specs []rpmmd.PackageSpec) *osbuild.RPMStageOptions {
 	var gpgKeys []string
 	for _, repo := range repos {
-		if repo.GPGKey == "" {
-			continue
-		}
-		gpgKeys = append(gpgKeys, repo.GPGKey)
+		gpgKeys = append(gpgKeys, repo.GPGKeys...)
 	}
 
 	var packages []osbuild.RPMPackage
@@ -646,18 +635,6 @@ func New() distro.Distro {
 	return newDistro(defaultName, modulePlatformID, ostreeRef)
 }
 
-func NewF34() distro.Distro {
-	return newDistro(f34Name, f34modulePlatformID, f34ostreeRef)
-}
-
-func NewF35() distro.Distro {
-	return newDistro(f35Name, f35modulePlatformID, f35ostreeRef)
-}
-
-func NewF36() distro.Distro {
-	return newDistro(f36Name, f36modulePlatformID, f36ostreeRef)
-}
-
 func NewHostDistro(name, modulePlatformID, ostreeRef string) distro.Distro {
 	return newDistro(name, modulePlatformID, ostreeRef)
 }
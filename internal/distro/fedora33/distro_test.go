@@ -406,6 +406,23 @@ func TestDistro_CustomFileSystemManifestError(t *testing.T) {
 	}
 }
 
+func TestDistro_ModuleStreamsManifestError(t *testing.T) {
+	f33distro := fedora33.New()
+	bp := blueprint.Blueprint{
+		Customizations: &blueprint.Customizations{
+			ModuleStreams: []blueprint.ModuleStreamCustomization{{Name: "nodejs", Stream: "14"}},
+		},
+	}
+	for _, archName := range f33distro.ListArches() {
+		arch, _ := f33distro.GetArch(archName)
+		for _, imgTypeName := range arch.ListImageTypes() {
+			imgType, _ := arch.GetImageType(imgTypeName)
+			_, err := imgType.Manifest(bp.Customizations, distro.ImageOptions{}, nil, nil, 0)
+			assert.EqualError(t, err, "module stream customizations are not supported")
+		}
+	}
+}
+
 func TestDistro_TestRootMountPoint(t *testing.T) {
 	f33distro := fedora33.New()
 	bp := blueprint.Blueprint{
@@ -11,31 +11,35 @@ const (
 
 // ImageConfig represents a (default) configuration applied to the image
 type ImageConfig struct {
-	Timezone            string
-	TimeSynchronization *osbuild2.ChronyStageOptions
-	Locale              string
-	Keyboard            *osbuild2.KeymapStageOptions
-	EnabledServices     []string
-	DisabledServices    []string
-	DefaultTarget       string
-	Sysconfig           []*osbuild2.SysconfigStageOptions
+	Timezone            string                            `json:"timezone,omitempty"`
+	TimeSynchronization *osbuild2.ChronyStageOptions      `json:"time_synchronization,omitempty"`
+	Locale              string                            `json:"locale,omitempty"`
+	Keyboard            *osbuild2.KeymapStageOptions      `json:"keyboard,omitempty"`
+	EnabledServices     []string                          `json:"enabled_services,omitempty"`
+	DisabledServices    []string                          `json:"disabled_services,omitempty"`
+	DefaultTarget       string                            `json:"default_target,omitempty"`
+	Sysconfig           []*osbuild2.SysconfigStageOptions `json:"sysconfig,omitempty"`
 
 	// for RHSM configuration, we need to potentially distinguish the case
 	// when the user want the image to be subscribed on first boot and when not
-	RHSMConfig map[RHSMSubscriptionStatus]*osbuild2.RHSMStageOptions
+	RHSMConfig map[RHSMSubscriptionStatus]*osbuild2.RHSMStageOptions `json:"rhsm_config,omitempty"`
 
-	SystemdLogind []*osbuild2.SystemdLogindStageOptions
-	CloudInit     []*osbuild2.CloudInitStageOptions
-	Modprobe      []*osbuild2.ModprobeStageOptions
-	DracutConf    []*osbuild2.DracutConfStageOptions
-	SystemdUnit   []*osbuild2.SystemdUnitStageOptions
-	Authselect    *osbuild2.AuthselectStageOptions
-	SELinuxConfig *osbuild2.SELinuxConfigStageOptions
-	Tuned         *osbuild2.TunedStageOptions
-	Tmpfilesd     []*osbuild2.TmpfilesdStageOptions
-	PamLimitsConf []*osbuild2.PamLimitsConfStageOptions
-	Sysctld       []*osbuild2.SysctldStageOptions
-	DNFConfig     []*osbuild2.DNFConfigStageOptions
+	SystemdLogind []*osbuild2.SystemdLogindStageOptions `json:"systemd_logind,omitempty"`
+	CloudInit     []*osbuild2.CloudInitStageOptions     `json:"cloud_init,omitempty"`
+	Modprobe      []*osbuild2.ModprobeStageOptions      `json:"modprobe,omitempty"`
+	DracutConf    []*osbuild2.DracutConfStageOptions    `json:"dracut_conf,omitempty"`
+	SystemdUnit   []*osbuild2.SystemdUnitStageOptions   `json:"systemd_unit,omitempty"`
+	Authselect    *osbuild2.AuthselectStageOptions      `json:"authselect,omitempty"`
+	SELinuxConfig *osbuild2.SELinuxConfigStageOptions   `json:"selinux_config,omitempty"`
+	Tuned         *osbuild2.TunedStageOptions           `json:"tuned,omitempty"`
+	Tmpfilesd     []*osbuild2.TmpfilesdStageOptions     `json:"tmpfilesd,omitempty"`
+	PamLimitsConf []*osbuild2.PamLimitsConfStageOptions `json:"pam_limits_conf,omitempty"`
+	Sysctld       []*osbuild2.SysctldStageOptions       `json:"sysctld,omitempty"`
+	DNFConfig     []*osbuild2.DNFConfigStageOptions     `json:"dnf_config,omitempty"`
+	WSLConfig     *osbuild2.WSLConfStageOptions         `json:"wsl_config,omitempty"`
+	WAAgentConfig *osbuild2.WAAgentConfStageOptions     `json:"waagent_config,omitempty"`
+	UdevRules     []*osbuild2.UdevRulesStageOptions     `json:"udev_rules,omitempty"`
+	Sudoers       []*osbuild2.SudoersStageOptions       `json:"sudoers,omitempty"`
 }
 
 // InheritFrom inherits unset values from the provided parent configuration and
@@ -106,6 +110,18 @@ func (c *ImageConfig) InheritFrom(parentConfig *ImageConfig) *ImageConfig {
 		if finalConfig.DNFConfig == nil {
 			finalConfig.DNFConfig = parentConfig.DNFConfig
 		}
+		if finalConfig.WSLConfig == nil {
+			finalConfig.WSLConfig = parentConfig.WSLConfig
+		}
+		if finalConfig.WAAgentConfig == nil {
+			finalConfig.WAAgentConfig = parentConfig.WAAgentConfig
+		}
+		if finalConfig.UdevRules == nil {
+			finalConfig.UdevRules = parentConfig.UdevRules
+		}
+		if finalConfig.Sudoers == nil {
+			finalConfig.Sudoers = parentConfig.Sudoers
+		}
 	}
 	return &finalConfig
 }
@@ -0,0 +1,172 @@
+package distro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/osbuild/osbuild-composer/internal/disk"
+	"github.com/osbuild/osbuild-composer/internal/rpmmd"
+)
+
+// ImageTypeDefinition describes a user-defined image type, which is derived
+// from one of the built-in image types and overrides some of its defaults.
+type ImageTypeDefinition struct {
+	Name string `json:"name"`
+	// Name of the built-in image type this one is derived from
+	Base string `json:"base"`
+
+	// Distributions and architectures to define the image type for. All of
+	// them which have the base image type if empty.
+	Distros []string `json:"distros,omitempty"`
+	Arches  []string `json:"arches,omitempty"`
+
+	Filename    string `json:"filename,omitempty"`
+	DefaultSize uint64 `json:"default_size,omitempty"`
+
+	// Packages installed in addition to, and excluded from, the packages of
+	// the base image type
+	Packages        []string `json:"packages,omitempty"`
+	ExcludePackages []string `json:"exclude_packages,omitempty"`
+
+	// Services enabled and disabled in addition to those of the base image
+	// type
+	EnabledServices  []string `json:"enabled_services,omitempty"`
+	DisabledServices []string `json:"disabled_services,omitempty"`
+
+	// Overrides the values which are set in the image configuration of the
+	// base image type
+	ImageConfig *ImageConfig `json:"image_config,omitempty"`
+
+	// Replaces the partition table of the base image type. Sizes are in
+	// 512-byte sectors and the root partition grows to fill the image.
+	PartitionTable *disk.PartitionTable `json:"partition_table,omitempty"`
+}
+
+// ImageTypeDeriver is implemented by architectures which support image types
+// derived from their built-in ones.
+type ImageTypeDeriver interface {
+	// DeriveImageType adds the image type described by def to the
+	// architecture.
+	DeriveImageType(def ImageTypeDefinition) error
+}
+
+var imageTypeNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+func (def *ImageTypeDefinition) validate() error {
+	if !imageTypeNameRegex.MatchString(def.Name) {
+		return fmt.Errorf("invalid image type name %q", def.Name)
+	}
+	if def.Base == "" {
+		return fmt.Errorf("image type %q has no base image type", def.Name)
+	}
+	if def.PartitionTable != nil && def.PartitionTable.RootPartition() == nil {
+		return fmt.Errorf("the partition table of image type %q has no root partition", def.Name)
+	}
+	return nil
+}
+
+// PackageSet returns the packages which are installed in addition to, or
+// excluded from, the packages of the base image type.
+func (def *ImageTypeDefinition) PackageSet() rpmmd.PackageSet {
+	return rpmmd.PackageSet{
+		Include: def.Packages,
+		Exclude: def.ExcludePackages,
+	}
+}
+
+// DeriveImageConfig returns the image configuration of the derived image
+// type, given the configuration of the base image type.
+func (def *ImageTypeDefinition) DeriveImageConfig(base *ImageConfig) *ImageConfig {
+	config := &ImageConfig{}
+	if def.ImageConfig != nil {
+		config = def.ImageConfig
+	}
+	derived := config.InheritFrom(base)
+
+	if len(def.EnabledServices) > 0 {
+		derived.EnabledServices = append(append([]string{}, derived.EnabledServices...), def.EnabledServices...)
+	}
+	if len(def.DisabledServices) > 0 {
+		derived.DisabledServices = append(append([]string{}, derived.DisabledServices...), def.DisabledServices...)
+	}
+	return derived
+}
+
+// UnmarshalImageTypeDefinition reads an image type definition in YAML or
+// JSON format.
+func UnmarshalImageTypeDefinition(data []byte) (*ImageTypeDefinition, error) {
+	// YAML is a superset of JSON, convert it to JSON to use the json tags
+	var raw interface{}
+	err := yaml.Unmarshal(data, &raw)
+	if err != nil {
+		return nil, err
+	}
+	data, err = json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var def ImageTypeDefinition
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&def)
+	if err != nil {
+		return nil, err
+	}
+
+	err = def.validate()
+	if err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+// LoadImageTypeDefinitions reads all image type definitions (*.yaml, *.yml
+// and *.json files) from dir, sorted by file name. A missing directory
+// contains no definitions.
+func LoadImageTypeDefinitions(dir string) ([]ImageTypeDefinition, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var defs []ImageTypeDefinition
+	names := make(map[string]string)
+	for _, entry := range entries {
+		switch filepath.Ext(entry.Name()) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		if entry.IsDir() {
+			continue
+		}
+
+		filename := filepath.Join(dir, entry.Name())
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		def, err := UnmarshalImageTypeDefinition(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		if other, exists := names[def.Name]; exists {
+			return nil, fmt.Errorf("%s: image type %q is already defined in %s", filename, def.Name, other)
+		}
+		names[def.Name] = filename
+		defs = append(defs, *def)
+	}
+	return defs, nil
+}
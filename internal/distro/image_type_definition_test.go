@@ -0,0 +1,117 @@
+package distro
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/osbuild-composer/internal/osbuild2"
+)
+
+const qcow2AgentYAML = `
+name: qcow2-agent
+base: qcow2
+distros: [rhel-90]
+default_size: 8589934592
+packages:
+  - example-agent
+exclude_packages:
+  - rng-tools
+enabled_services:
+  - example-agent.service
+image_config:
+  timezone: Europe/Prague
+  tuned:
+    profiles: [throughput-performance]
+partition_table:
+  type: gpt
+  partitions:
+    - size: 2048
+      bootable: true
+      type: 21686148-6449-6E6F-744E-656564454649
+    - size: 1048576
+      filesystem:
+        type: xfs
+        mountpoint: /boot
+    - filesystem:
+        type: xfs
+        mountpoint: /
+`
+
+func TestUnmarshalImageTypeDefinition(t *testing.T) {
+	def, err := UnmarshalImageTypeDefinition([]byte(qcow2AgentYAML))
+	require.NoError(t, err)
+
+	assert.Equal(t, "qcow2-agent", def.Name)
+	assert.Equal(t, "qcow2", def.Base)
+	assert.Equal(t, []string{"rhel-90"}, def.Distros)
+	assert.Equal(t, uint64(8589934592), def.DefaultSize)
+	assert.Equal(t, []string{"example-agent"}, def.PackageSet().Include)
+	assert.Equal(t, []string{"rng-tools"}, def.PackageSet().Exclude)
+	assert.Equal(t, "Europe/Prague", def.ImageConfig.Timezone)
+	assert.Equal(t, []string{"throughput-performance"}, def.ImageConfig.Tuned.Profiles)
+	require.Len(t, def.PartitionTable.Partitions, 3)
+	assert.Equal(t, "/", def.PartitionTable.RootPartition().Filesystem.Mountpoint)
+
+	// JSON is valid YAML
+	def, err = UnmarshalImageTypeDefinition([]byte(`{"name": "vmdk-small", "base": "vmdk", "default_size": 2147483648}`))
+	require.NoError(t, err)
+	assert.Equal(t, "vmdk-small", def.Name)
+	assert.Equal(t, uint64(2147483648), def.DefaultSize)
+
+	for _, invalid := range []string{
+		`{"name": "Not Valid", "base": "qcow2"}`,
+		`{"name": "no-base"}`,
+		`{"name": "typo", "base": "qcow2", "pakages": ["vim"]}`,
+		`{"name": "no-root", "base": "qcow2", "partition_table": {"type": "gpt", "partitions": [{"size": 2048}]}}`,
+		`name: [`,
+	} {
+		_, err = UnmarshalImageTypeDefinition([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestDeriveImageConfig(t *testing.T) {
+	def := ImageTypeDefinition{
+		EnabledServices: []string{"example-agent.service"},
+		ImageConfig: &ImageConfig{
+			Tuned: osbuild2.NewTunedStageOptions("throughput-performance"),
+		},
+	}
+	base := &ImageConfig{
+		Timezone:        "UTC",
+		EnabledServices: []string{"sshd"},
+	}
+
+	config := def.DeriveImageConfig(base)
+	assert.Equal(t, "UTC", config.Timezone)
+	assert.Equal(t, []string{"sshd", "example-agent.service"}, config.EnabledServices)
+	assert.Equal(t, []string{"throughput-performance"}, config.Tuned.Profiles)
+	// the base configuration is left alone
+	assert.Equal(t, []string{"sshd"}, base.EnabledServices)
+	assert.Nil(t, def.ImageConfig.EnabledServices)
+}
+
+func TestLoadImageTypeDefinitions(t *testing.T) {
+	defs, err := LoadImageTypeDefinitions(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, defs)
+
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "20-vmdk.json"), []byte(`{"name": "vmdk-small", "base": "vmdk"}`), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "10-qcow2.yaml"), []byte(qcow2AgentYAML), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README"), []byte("not a definition"), 0600))
+
+	defs, err = LoadImageTypeDefinitions(dir)
+	require.NoError(t, err)
+	require.Len(t, defs, 2)
+	assert.Equal(t, "qcow2-agent", defs[0].Name)
+	assert.Equal(t, "vmdk-small", defs[1].Name)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "30-vmdk.yml"), []byte("name: vmdk-small\nbase: qcow2\n"), 0600))
+	_, err = LoadImageTypeDefinitions(dir)
+	assert.EqualError(t, err, filepath.Join(dir, "30-vmdk.yml")+`: image type "vmdk-small" is already defined in `+filepath.Join(dir, "20-vmdk.json"))
+}
@@ -627,6 +627,33 @@ func TestDistro_CustomFileSystemManifestError(t *testing.T) {
 	}
 }
 
+func TestDistro_ModuleStreamsManifestError(t *testing.T) {
+	bp := blueprint.Blueprint{
+		Customizations: &blueprint.Customizations{
+			ModuleStreams: []blueprint.ModuleStreamCustomization{{Name: "nodejs", Stream: "14"}},
+		},
+	}
+	for _, dist := range rhelFamilyDistros {
+		t.Run(dist.name, func(t *testing.T) {
+			d := dist.distro
+			for _, archName := range d.ListArches() {
+				arch, _ := d.GetArch(archName)
+				for _, imgTypeName := range arch.ListImageTypes() {
+					if (archName == "s390x" && imgTypeName == "tar") || imgTypeName == "rhel-edge-installer" {
+						continue
+					}
+					imgType, _ := arch.GetImageType(imgTypeName)
+					imgOpts := distro.ImageOptions{
+						Size: imgType.Size(0),
+					}
+					_, err := imgType.Manifest(bp.Customizations, imgOpts, nil, nil, 0)
+					assert.EqualError(t, err, "module stream customizations are not supported")
+				}
+			}
+		})
+	}
+}
+
 func TestDistro_TestRootMountPoint(t *testing.T) {
 	bp := blueprint.Blueprint{
 		Customizations: &blueprint.Customizations{
@@ -160,11 +160,15 @@ func (t *imageTypeS2) Manifest(c *blueprint.Customizations,
 		commit := ostreeCommit{Checksum: options.OSTree.Parent, URL: options.OSTree.URL}
 		commits = []ostreeCommit{commit}
 	}
+	sources, err := t.sources(allPackageSpecs, commits)
+	if err != nil {
+		return distro.Manifest{}, err
+	}
 	return json.Marshal(
 		osbuild.Manifest{
 			Version:   "2",
 			Pipelines: pipelines,
-			Sources:   t.sources(allPackageSpecs, commits),
+			Sources:   sources,
 		},
 	)
 }
@@ -175,23 +179,10 @@ type ostreeCommit struct {
 	URL      string
 }
 
-func (t *imageTypeS2) sources(packages []rpmmd.PackageSpec, ostreeCommits []ostreeCommit) osbuild.Sources {
+func (t *imageTypeS2) sources(packages []rpmmd.PackageSpec, ostreeCommits []ostreeCommit) (osbuild.Sources, error) {
 	sources := osbuild.Sources{}
-	curl := &osbuild.CurlSource{
-		Items: make(map[string]osbuild.CurlSourceItem),
-	}
-	for _, pkg := range packages {
-		item := new(osbuild.URLWithSecrets)
-		item.URL = pkg.RemoteLocation
-		if pkg.Secrets == "org.osbuild.rhsm" {
-			item.Secrets = &osbuild.URLSecrets{
-				Name: "org.osbuild.rhsm",
-			}
-		}
-		curl.Items[pkg.Checksum] = item
-	}
-	if len(curl.Items) > 0 {
-		sources["org.osbuild.curl"] = curl
+	if err := distro.AddPackageSources(sources, packages); err != nil {
+		return nil, err
 	}
 
 	ostree := &osbuild.OSTreeSource{
@@ -205,7 +196,7 @@ func (t *imageTypeS2) sources(packages []rpmmd.PackageSpec, ostreeCommits []ostr
 	if len(ostree.Items) > 0 {
 		sources["org.osbuild.ostree"] = ostree
 	}
-	return sources
+	return sources, nil
 }
 
 func (t *imageTypeS2) pipelines(customizations *blueprint.Customizations, options distro.ImageOptions, repos []rpmmd.RepoConfig, packageSetSpecs map[string][]rpmmd.PackageSpec, rng *rand.Rand) ([]osbuild.Pipeline, error) {
@@ -219,6 +210,10 @@ func (t *imageTypeS2) pipelines(customizations *blueprint.Customizations, option
 		}
 	}
 
+	if len(customizations.GetModuleStreams()) > 0 {
+		return nil, fmt.Errorf("module stream customizations are not supported")
+	}
+
 	if kernelOpts := customizations.GetKernel(); kernelOpts.Append != "" && t.rpmOstree {
 		return nil, fmt.Errorf("kernel boot parameter customizations are not supported for ostree types")
 	}
@@ -496,10 +491,26 @@ func (t *imageTypeS2) rpmStageInputs(specs []rpmmd.PackageSpec) *osbuild.RPMStag
 	return &osbuild.RPMStageInputs{Packages: stageInput}
 }
 
-func pkgRefs(specs []rpmmd.PackageSpec) osbuild.RPMStageReferences {
-	refs := make([]string, len(specs))
-	for idx, pkg := range specs {
-		refs[idx] = pkg.Checksum
+// pkgRefs returns the references of the packages, with options requesting
+// signature verification if this is enabled for any of them.
+func pkgRefs(specs []rpmmd.PackageSpec) osbuild.References {
+	checkGPG := false
+	for _, pkg := range specs {
+		checkGPG = checkGPG || pkg.CheckGPG
+	}
+	if !checkGPG {
+		refs := make(osbuild.RPMStageReferences, len(specs))
+		for idx, pkg := range specs {
+			refs[idx] = pkg.Checksum
+		}
+		return refs
+	}
+
+	refs := make(osbuild.RPMStageReferencesWithOptions, len(specs))
+	for _, pkg := range specs {
+		refs[pkg.Checksum] = osbuild.RPMStageReferenceOptions{
+			Metadata: osbuild.RPMStageReferenceMetadata{CheckGPG: pkg.CheckGPG},
+		}
 	}
 	return refs
 }
@@ -518,10 +529,7 @@ func (t *imageTypeS2) ostreePullStageInputs(origin, source, commitRef string) *o
 func (t *imageTypeS2) rpmStageOptions(repos []rpmmd.RepoConfig) *osbuild.RPMStageOptions {
 	var gpgKeys []string
 	for _, repo := range repos {
-		if repo.GPGKey == "" {
-			continue
-		}
-		gpgKeys = append(gpgKeys, repo.GPGKey)
+		gpgKeys = append(gpgKeys, repo.GPGKeys...)
 	}
 
 	return &osbuild.RPMStageOptions{
@@ -266,11 +266,19 @@ func (t *imageType) PackageSets(bp blueprint.Blueprint) map[string]rpmmd.Package
 
 	// depsolve bp packages separately
 	// bp packages aren't restricted by exclude lists
-	mergedSets[blueprintPkgsKey] = rpmmd.PackageSet{Include: bpPackages}
+	// the module streams and install options apply to both the OS and the
+	// blueprint packages, so that they are installed the same way
+	enabledModules, disabledModules := bp.Customizations.GetModuleSpecs()
+	bpOptions := rpmmd.PackageSet{
+		EnabledModules:  enabledModules,
+		DisabledModules: disabledModules,
+		ExcludeWeakDeps: !*bp.Customizations.GetInstall().WeakDeps,
+	}
+	mergedSets[blueprintPkgsKey] = rpmmd.PackageSet{Include: bpPackages}.Append(bpOptions)
 	kernel := bp.Customizations.GetKernel().Name
 
 	// add bp kernel to main OS package set to avoid duplicate kernels
-	mergedSets[osPkgsKey] = mergedSets[osPkgsKey].Append(rpmmd.PackageSet{Include: []string{kernel}})
+	mergedSets[osPkgsKey] = mergedSets[osPkgsKey].Append(rpmmd.PackageSet{Include: []string{kernel}}).Append(bpOptions)
 	return mergedSets
 
 }
@@ -361,32 +369,23 @@ func (t *imageType) Manifest(customizations *blueprint.Customizations,
 	if options.OSTree.Parent != "" && options.OSTree.URL != "" {
 		commits = []ostreeCommit{{Checksum: options.OSTree.Parent, URL: options.OSTree.URL}}
 	}
+	sources, err := t.sources(allPackageSpecs, commits)
+	if err != nil {
+		return distro.Manifest{}, err
+	}
 	return json.Marshal(
 		osbuild.Manifest{
 			Version:   "2",
 			Pipelines: pipelines,
-			Sources:   t.sources(allPackageSpecs, commits),
+			Sources:   sources,
 		},
 	)
 }
 
-func (t *imageType) sources(packages []rpmmd.PackageSpec, ostreeCommits []ostreeCommit) osbuild.Sources {
+func (t *imageType) sources(packages []rpmmd.PackageSpec, ostreeCommits []ostreeCommit) (osbuild.Sources, error) {
 	sources := osbuild.Sources{}
-	curl := &osbuild.CurlSource{
-		Items: make(map[string]osbuild.CurlSourceItem),
-	}
-	for _, pkg := range packages {
-		item := new(osbuild.URLWithSecrets)
-		item.URL = pkg.RemoteLocation
-		if pkg.Secrets == "org.osbuild.rhsm" {
-			item.Secrets = &osbuild.URLSecrets{
-				Name: "org.osbuild.rhsm",
-			}
-		}
-		curl.Items[pkg.Checksum] = item
-	}
-	if len(curl.Items) > 0 {
-		sources["org.osbuild.curl"] = curl
+	if err := distro.AddPackageSources(sources, packages); err != nil {
+		return nil, err
 	}
 
 	ostree := &osbuild.OSTreeSource{
@@ -400,7 +399,7 @@ func (t *imageType) sources(packages []rpmmd.PackageSpec, ostreeCommits []ostree
 	if len(ostree.Items) > 0 {
 		sources["org.osbuild.ostree"] = ostree
 	}
-	return sources
+	return sources, nil
 }
 
 func isMountpointAllowed(mountpoint string) bool {
@@ -23,10 +23,26 @@ func rpmStageInputs(specs []rpmmd.PackageSpec) *osbuild.RPMStageInputs {
 	return &osbuild.RPMStageInputs{Packages: stageInput}
 }
 
-func pkgRefs(specs []rpmmd.PackageSpec) osbuild.RPMStageReferences {
-	refs := make([]string, len(specs))
-	for idx, pkg := range specs {
-		refs[idx] = pkg.Checksum
+// pkgRefs returns the references of the packages, with options requesting
+// signature verification if this is enabled for any of them.
+func pkgRefs(specs []rpmmd.PackageSpec) osbuild.References {
+	checkGPG := false
+	for _, pkg := range specs {
+		checkGPG = checkGPG || pkg.CheckGPG
+	}
+	if !checkGPG {
+		refs := make(osbuild.RPMStageReferences, len(specs))
+		for idx, pkg := range specs {
+			refs[idx] = pkg.Checksum
+		}
+		return refs
+	}
+
+	refs := make(osbuild.RPMStageReferencesWithOptions, len(specs))
+	for _, pkg := range specs {
+		refs[pkg.Checksum] = osbuild.RPMStageReferenceOptions{
+			Metadata: osbuild.RPMStageReferenceMetadata{CheckGPG: pkg.CheckGPG},
+		}
 	}
 	return refs
 }
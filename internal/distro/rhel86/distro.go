@@ -13,6 +13,7 @@ import (
 	"github.com/osbuild/osbuild-composer/internal/common"
 	"github.com/osbuild/osbuild-composer/internal/disk"
 	"github.com/osbuild/osbuild-composer/internal/distro"
+	"github.com/osbuild/osbuild-composer/internal/distro/vagrant"
 	osbuild "github.com/osbuild/osbuild-composer/internal/osbuild2"
 	"github.com/osbuild/osbuild-composer/internal/rpmmd"
 )
@@ -211,6 +212,55 @@ func (a *architecture) addImageTypes(imageTypes ...imageType) {
 	}
 }
 
+// DeriveImageType adds a user-defined image type, which is derived from
+// one of the built-in image types of the architecture.
+func (a *architecture) DeriveImageType(def distro.ImageTypeDefinition) error {
+	if _, err := a.GetImageType(def.Name); err == nil {
+		return fmt.Errorf("image type %q already exists", def.Name)
+	}
+	base, err := a.GetImageType(def.Base)
+	if err != nil {
+		return err
+	}
+
+	it := *base.(*imageType)
+	it.baseName = it.builtinName()
+	it.name = def.Name
+	it.nameAliases = nil
+	if def.Filename != "" {
+		it.filename = def.Filename
+	}
+	if def.DefaultSize != 0 {
+		it.defaultSize = def.DefaultSize
+	}
+	it.defaultImageConfig = def.DeriveImageConfig(it.getDefaultImageConfig())
+
+	if ps := def.PackageSet(); len(ps.Include) > 0 || len(ps.Exclude) > 0 {
+		packageSets := make(map[string]packageSetFunc, len(it.packageSets)+1)
+		for name, getter := range it.packageSets {
+			packageSets[name] = getter
+		}
+		basePackages := packageSets[osPkgsKey]
+		packageSets[osPkgsKey] = func(t *imageType) rpmmd.PackageSet {
+			if basePackages == nil {
+				return ps
+			}
+			return basePackages(t).Append(ps)
+		}
+		it.packageSets = packageSets
+	}
+
+	if def.PartitionTable != nil {
+		if _, exists := it.basePartitionTables[a.name]; !exists {
+			return fmt.Errorf("image type %q has no partition table", def.Base)
+		}
+		it.basePartitionTables = distro.BasePartitionTableMap{a.name: *def.PartitionTable}
+	}
+
+	a.addImageTypes(it)
+	return nil
+}
+
 func (a *architecture) Distro() distro.Distro {
 	return a.distro
 }
@@ -223,6 +273,7 @@ type imageType struct {
 	arch               *architecture
 	name               string
 	nameAliases        []string
+	baseName           string // built-in image type this one is derived from
 	filename           string
 	mimeType           string
 	packageSets        map[string]packageSetFunc
@@ -236,10 +287,17 @@ type imageType struct {
 
 	// bootISO: installable ISO
 	bootISO bool
+	// liveISO: bootable ISO running the OS from a squashfs image
+	liveISO bool
+	// boot type and compression can be selected in the image options
+	selectableBootType    bool
+	selectableCompression bool
 	// rpmOstree: edge/ostree
 	rpmOstree bool
 	// bootable image
 	bootable bool
+	// image without a kernel, which runs on the one of its host
+	noKernel bool
 	// If set to a value, it is preferred over the architecture value
 	bootType distro.BootType
 	// List of valid arches for the image type
@@ -250,6 +308,15 @@ func (t *imageType) Name() string {
 	return t.name
 }
 
+// builtinName returns the name of the built-in image type t is, or is
+// derived from.
+func (t *imageType) builtinName() string {
+	if t.baseName != "" {
+		return t.baseName
+	}
+	return t.name
+}
+
 func (t *imageType) Arch() distro.Arch {
 	return t.arch
 }
@@ -273,7 +340,7 @@ func (t *imageType) OSTreeRef() string {
 func (t *imageType) Size(size uint64) uint64 {
 	const MegaByte = 1024 * 1024
 	// Microsoft Azure requires vhd images to be rounded up to the nearest MB
-	if t.name == "vhd" && size%MegaByte != 0 {
+	if t.builtinName() == "vhd" && size%MegaByte != 0 {
 		size = (size/MegaByte + 1) * MegaByte
 	}
 	if size == 0 {
@@ -321,11 +388,22 @@ func (t *imageType) PackageSets(bp blueprint.Blueprint) map[string]rpmmd.Package
 
 	// depsolve bp packages separately
 	// bp packages aren't restricted by exclude lists
-	mergedSets[blueprintPkgsKey] = rpmmd.PackageSet{Include: bpPackages}
+	// the module streams and install options apply to both the OS and the
+	// blueprint packages, so that they are installed the same way
+	enabledModules, disabledModules := bp.Customizations.GetModuleSpecs()
+	bpOptions := rpmmd.PackageSet{
+		EnabledModules:  enabledModules,
+		DisabledModules: disabledModules,
+		ExcludeWeakDeps: !*bp.Customizations.GetInstall().WeakDeps,
+	}
+	mergedSets[blueprintPkgsKey] = rpmmd.PackageSet{Include: bpPackages}.Append(bpOptions)
 	kernel := bp.Customizations.GetKernel().Name
 
 	// add bp kernel to main OS package set to avoid duplicate kernels
-	mergedSets[osPkgsKey] = mergedSets[osPkgsKey].Append(rpmmd.PackageSet{Include: []string{kernel}})
+	if !t.noKernel {
+		mergedSets[osPkgsKey] = mergedSets[osPkgsKey].Append(rpmmd.PackageSet{Include: []string{kernel}})
+	}
+	mergedSets[osPkgsKey] = mergedSets[osPkgsKey].Append(bpOptions)
 	return mergedSets
 
 }
@@ -359,6 +437,19 @@ func (t *imageType) getBootType() distro.BootType {
 	return bootType
 }
 
+// withBootType returns a copy of the image type, whose architecture boots
+// with the given boot type instead of its default one.
+func (t *imageType) withBootType(bootType distro.BootType) *imageType {
+	arch := *t.arch
+	arch.bootType = bootType
+	if bootType == distro.UEFIBootType {
+		arch.legacy = ""
+	}
+	imgType := *t
+	imgType.arch = &arch
+	return &imgType
+}
+
 func (t *imageType) supportsUEFI() bool {
 	bootType := t.getBootType()
 	if bootType == distro.HybridBootType || bootType == distro.UEFIBootType {
@@ -428,32 +519,23 @@ func (t *imageType) Manifest(customizations *blueprint.Customizations,
 	if options.OSTree.Parent != "" && options.OSTree.URL != "" {
 		commits = []ostreeCommit{{Checksum: options.OSTree.Parent, URL: options.OSTree.URL}}
 	}
+	sources, err := t.sources(allPackageSpecs, commits)
+	if err != nil {
+		return distro.Manifest{}, err
+	}
 	return json.Marshal(
 		osbuild.Manifest{
 			Version:   "2",
 			Pipelines: pipelines,
-			Sources:   t.sources(allPackageSpecs, commits),
+			Sources:   sources,
 		},
 	)
 }
 
-func (t *imageType) sources(packages []rpmmd.PackageSpec, ostreeCommits []ostreeCommit) osbuild.Sources {
+func (t *imageType) sources(packages []rpmmd.PackageSpec, ostreeCommits []ostreeCommit) (osbuild.Sources, error) {
 	sources := osbuild.Sources{}
-	curl := &osbuild.CurlSource{
-		Items: make(map[string]osbuild.CurlSourceItem),
-	}
-	for _, pkg := range packages {
-		item := new(osbuild.URLWithSecrets)
-		item.URL = pkg.RemoteLocation
-		if pkg.Secrets == "org.osbuild.rhsm" {
-			item.Secrets = &osbuild.URLSecrets{
-				Name: "org.osbuild.rhsm",
-			}
-		}
-		curl.Items[pkg.Checksum] = item
-	}
-	if len(curl.Items) > 0 {
-		sources["org.osbuild.curl"] = curl
+	if err := distro.AddPackageSources(sources, packages); err != nil {
+		return nil, err
 	}
 
 	ostree := &osbuild.OSTreeSource{
@@ -467,7 +549,7 @@ func (t *imageType) sources(packages []rpmmd.PackageSpec, ostreeCommits []ostree
 	if len(ostree.Items) > 0 {
 		sources["org.osbuild.ostree"] = ostree
 	}
-	return sources
+	return sources, nil
 }
 
 func isMountpointAllowed(mountpoint string) bool {
@@ -496,7 +578,7 @@ func (t *imageType) checkOptions(customizations *blueprint.Customizations, optio
 			return fmt.Errorf("boot ISO image type %q requires specifying a URL from which to retrieve the OSTree commit", t.name)
 		}
 
-		if t.name == "edge-simplified-installer" {
+		if t.builtinName() == "edge-simplified-installer" {
 			if err := customizations.CheckAllowed("InstallationDevice"); err != nil {
 				return fmt.Errorf("boot ISO image type %q contains unsupported blueprint customizations: %v", t.name, err)
 			}
@@ -508,7 +590,37 @@ func (t *imageType) checkOptions(customizations *blueprint.Customizations, optio
 		}
 	}
 
-	if t.name == "edge-raw-image" && options.OSTree.Parent == "" {
+	if options.BootType != distro.UnsetBootType {
+		if !t.selectableBootType {
+			return fmt.Errorf("image type %q does not support selecting the boot type", t.name)
+		}
+		switch options.BootType {
+		case distro.LegacyBootType, distro.HybridBootType:
+			if t.arch.legacy == "" {
+				return fmt.Errorf("boot type %q is not supported on %s", options.BootType, t.arch.name)
+			}
+		case distro.UEFIBootType:
+		default:
+			return fmt.Errorf("unknown boot type %q", options.BootType)
+		}
+	}
+
+	if options.Compression != distro.UnsetCompression {
+		if !t.selectableCompression {
+			return fmt.Errorf("image type %q does not support selecting the compression", t.name)
+		}
+		switch options.Compression {
+		case distro.NoCompression, distro.XzCompression, distro.ZstdCompression:
+		default:
+			return fmt.Errorf("unknown compression %q", options.Compression)
+		}
+	}
+
+	if customizations.GetContainer() != nil && t.builtinName() != "container" {
+		return fmt.Errorf("image type %q does not support container customizations", t.name)
+	}
+
+	if t.builtinName() == "edge-raw-image" && options.OSTree.Parent == "" {
 		return fmt.Errorf("edge raw images require specifying a URL from which to retrieve the OSTree commit")
 	}
 
@@ -772,6 +884,212 @@ func newDistro(distroName string) distro.Distro {
 		basePartitionTables: defaultBasePartitionTables,
 	}
 
+	// config shared by the Azure images
+	azureImageConfig := &distro.ImageConfig{
+		Timezone: "Etc/UTC",
+		Locale:   "en_US.UTF-8",
+		Keyboard: &osbuild.KeymapStageOptions{
+			Keymap: "us",
+			X11Keymap: &osbuild.X11KeymapOptions{
+				Layouts: []string{"us"},
+			},
+		},
+		Sysconfig: []*osbuild.SysconfigStageOptions{
+			{
+				Kernel: &osbuild.SysconfigKernelOptions{
+					UpdateDefault: true,
+					DefaultKernel: "kernel-core",
+				},
+				Network: &osbuild.SysconfigNetworkOptions{
+					Networking: true,
+					NoZeroConf: true,
+				},
+			},
+		},
+		EnabledServices: []string{
+			"sshd",
+			"waagent",
+			"cloud-init",
+			"cloud-init-local",
+			"cloud-config",
+			"cloud-final",
+		},
+		DefaultTarget: "multi-user.target",
+		Modprobe: []*osbuild.ModprobeStageOptions{
+			{
+				Filename: "blacklist-amdgpu.conf",
+				Commands: osbuild.ModprobeConfigCmdList{
+					osbuild.NewModprobeConfigCmdBlacklist("amdgpu"),
+				},
+			},
+			{
+				Filename: "blacklist-floppy.conf",
+				Commands: osbuild.ModprobeConfigCmdList{
+					osbuild.NewModprobeConfigCmdBlacklist("floppy"),
+				},
+			},
+			{
+				Filename: "blacklist-intel-cstate.conf",
+				Commands: osbuild.ModprobeConfigCmdList{
+					osbuild.NewModprobeConfigCmdBlacklist("intel_cstate"),
+				},
+			},
+			{
+				Filename: "blacklist-nouveau.conf",
+				Commands: osbuild.ModprobeConfigCmdList{
+					osbuild.NewModprobeConfigCmdBlacklist("nouveau"),
+					osbuild.NewModprobeConfigCmdBlacklist("lbm-nouveau"),
+				},
+			},
+			{
+				Filename: "blacklist-skylake-edac.conf",
+				Commands: osbuild.ModprobeConfigCmdList{
+					osbuild.NewModprobeConfigCmdBlacklist("skx_edac"),
+				},
+			},
+		},
+		CloudInit: []*osbuild.CloudInitStageOptions{
+			{
+				Filename: "10-azure-kvp.cfg",
+				Config: osbuild.CloudInitConfigFile{
+					Reporting: &osbuild.CloudInitConfigReporting{
+						Logging: &osbuild.CloudInitConfigReportingHandlers{
+							Type: "log",
+						},
+						Telemetry: &osbuild.CloudInitConfigReportingHandlers{
+							Type: "hyperv",
+						},
+					},
+				},
+			},
+			{
+				Filename: "91-azure_datasource.cfg",
+				Config: osbuild.CloudInitConfigFile{
+					Datasource: &osbuild.CloudInitConfigDatasource{
+						Azure: &osbuild.CloudInitConfigDatasourceAzure{
+							ApplyNetworkConfig: false,
+						},
+					},
+					DatasourceList: []string{
+						"Azure",
+					},
+				},
+			},
+		},
+		DracutConf: []*osbuild.DracutConfStageOptions{
+			{
+				Filename: "hv.conf",
+				Config: osbuild.DracutConfigFile{
+					AddDrivers: []string{
+						"hv_vmbus",
+						"hv_netvsc",
+						"hv_storvsc",
+					},
+				},
+			},
+		},
+		// the provisioning and the resource disk are handled by cloud-init
+		WAAgentConfig: &osbuild.WAAgentConfStageOptions{
+			Config: osbuild.WAAgentConfig{
+				ProvisioningUseCloudInit: common.BoolToPtr(true),
+				ProvisioningEnabled:      common.BoolToPtr(false),
+				RDFormat:                 common.BoolToPtr(false),
+				RDEnableSwap:             common.BoolToPtr(false),
+			},
+		},
+		UdevRules: []*osbuild.UdevRulesStageOptions{
+			{
+				Filename: "/etc/udev/rules.d/68-azure-sriov-nm-unmanaged.rules",
+				Rules: osbuild.UdevRules{
+					osbuild.UdevRuleComment{
+						Comment: []string{
+							"Accelerated Networking on Azure exposes a new SRIOV interface to the VM.",
+							"This interface is transparently bonded to the synthetic interface,",
+							"so NetworkManager should just ignore any SRIOV interfaces.",
+						},
+					},
+					osbuild.UdevRuleKV{
+						{Key: "SUBSYSTEM", Op: "==", Val: "net"},
+						{Key: "DRIVERS", Op: "==", Val: "hv_pci"},
+						{Key: "ACTION", Op: "==", Val: "add"},
+						{Key: "ENV", Arg: "NM_UNMANAGED", Op: "=", Val: "1"},
+					},
+				},
+			},
+		},
+	}
+
+	// RHUI enabled Azure images get their content from the RHUI of Azure, so
+	// the repositories of subscription-manager are not managed by default
+	azureRhuiImageConfig := &distro.ImageConfig{
+		RHSMConfig: map[distro.RHSMSubscriptionStatus]*osbuild.RHSMStageOptions{
+			distro.RHSMConfigNoSubscription: {
+				DnfPlugins: &osbuild.RHSMStageOptionsDnfPlugins{
+					SubscriptionManager: &osbuild.RHSMStageOptionsDnfPlugin{
+						Enabled: false,
+					},
+				},
+				SubMan: &osbuild.RHSMStageOptionsSubMan{
+					Rhsmcertd: &osbuild.SubManConfigRHSMCERTDSection{
+						AutoRegistration: common.BoolToPtr(true),
+					},
+					Rhsm: &osbuild.SubManConfigRHSMSection{
+						ManageRepos: common.BoolToPtr(false),
+					},
+				},
+			},
+			distro.RHSMConfigWithSubscription: {
+				SubMan: &osbuild.RHSMStageOptionsSubMan{
+					Rhsmcertd: &osbuild.SubManConfigRHSMCERTDSection{
+						AutoRegistration: common.BoolToPtr(true),
+					},
+					// do not disable the redhat.repo management if the user
+					// explicitly request the system to be subscribed
+				},
+			},
+		},
+	}
+	azureRhuiImageConfig = azureRhuiImageConfig.InheritFrom(azureImageConfig)
+
+	azureRhuiImgType := imageType{
+		name:     "azure-rhui",
+		filename: "disk.vhd",
+		mimeType: "application/x-vhd",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: distroBuildPackageSet,
+			osPkgsKey:    azureRhuiPackageSet,
+		},
+		defaultImageConfig:  azureRhuiImageConfig,
+		kernelOptions:       "ro console=tty1 console=ttyS0 earlyprintk=ttyS0 rootdelay=300",
+		bootable:            true,
+		defaultSize:         4 * GigaByte,
+		pipelines:           vhdPipelines,
+		buildPipelines:      []string{"build"},
+		payloadPipelines:    []string{"os", "image", "vpc"},
+		exports:             []string{"vpc"},
+		basePartitionTables: defaultBasePartitionTables,
+	}
+
+	azureGen2ImgType := imageType{
+		name:     "azure-gen2",
+		filename: "disk.vhd",
+		mimeType: "application/x-vhd",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: distroBuildPackageSet,
+			osPkgsKey:    azureCommonPackageSet,
+		},
+		defaultImageConfig:  azureImageConfig,
+		kernelOptions:       "ro console=tty1 console=ttyS0 earlyprintk=ttyS0 rootdelay=300",
+		bootable:            true,
+		bootType:            distro.UEFIBootType,
+		defaultSize:         4 * GigaByte,
+		pipelines:           azureGen2Pipelines,
+		buildPipelines:      []string{"build"},
+		payloadPipelines:    []string{"os", "image", "vpc"},
+		exports:             []string{"vpc"},
+		basePartitionTables: azureGen2BasePartitionTables,
+	}
+
 	vmdkImgType := imageType{
 		name:     "vmdk",
 		filename: "disk.vmdk",
@@ -1223,6 +1541,215 @@ func newDistro(distroName string) distro.Distro {
 		basePartitionTables: ec2BasePartitionTables,
 	}
 
+	// config shared by the Oracle, Alibaba and IBM cloud images
+	cloudImageConfig := &distro.ImageConfig{
+		Timezone: "UTC",
+		EnabledServices: []string{
+			"sshd",
+			"NetworkManager",
+			"cloud-init",
+			"cloud-init-local",
+			"cloud-config",
+			"cloud-final",
+		},
+		DefaultTarget: "multi-user.target",
+		Modprobe: []*osbuild.ModprobeStageOptions{
+			{
+				Filename: "blacklist-nouveau.conf",
+				Commands: osbuild.ModprobeConfigCmdList{
+					osbuild.NewModprobeConfigCmdBlacklist("nouveau"),
+				},
+			},
+		},
+	}
+
+	ociImageConfig := &distro.ImageConfig{
+		TimeSynchronization: &osbuild.ChronyStageOptions{
+			Servers: []osbuild.ChronyConfigServer{
+				{
+					Hostname: "169.254.169.254",
+					Prefer:   common.BoolToPtr(true),
+					Iburst:   common.BoolToPtr(true),
+				},
+			},
+		},
+		EnabledServices: append(cloudImageConfig.EnabledServices, "iscsid"),
+		CloudInit: []*osbuild.CloudInitStageOptions{
+			{
+				Filename: "10-oci.cfg",
+				Config: osbuild.CloudInitConfigFile{
+					DatasourceList: []string{"Oracle", "None"},
+					SystemInfo: &osbuild.CloudInitConfigSystemInfo{
+						DefaultUser: &osbuild.CloudInitConfigDefaultUser{
+							Name: "opc",
+						},
+					},
+				},
+			},
+		},
+		DracutConf: []*osbuild.DracutConfStageOptions{
+			{
+				Filename: "oci.conf",
+				Config: osbuild.DracutConfigFile{
+					AddModules: []string{"iscsi"},
+					AddDrivers: []string{
+						"nvme",
+						"virtio_blk",
+						"virtio_net",
+						"virtio_pci",
+						"virtio_scsi",
+					},
+				},
+			},
+		},
+	}
+	ociImageConfig = ociImageConfig.InheritFrom(cloudImageConfig)
+
+	ociImgType := imageType{
+		name:     "oci",
+		filename: "disk.qcow2",
+		mimeType: "application/x-qemu-disk",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: distroBuildPackageSet,
+			osPkgsKey:    ociPackageSet,
+		},
+		defaultImageConfig:  ociImageConfig,
+		kernelOptions:       "console=tty0 console=ttyS0,115200n8 net.ifnames=0 rd.iscsi.ibft=1 rd.iscsi.firmware=1",
+		bootable:            true,
+		defaultSize:         10 * GigaByte,
+		pipelines:           qcow2Pipelines,
+		buildPipelines:      []string{"build"},
+		payloadPipelines:    []string{"os", "image", "qcow2"},
+		exports:             []string{"qcow2"},
+		basePartitionTables: defaultBasePartitionTables,
+	}
+
+	alibabaImageConfig := &distro.ImageConfig{
+		TimeSynchronization: &osbuild.ChronyStageOptions{
+			Servers: []osbuild.ChronyConfigServer{
+				{
+					Hostname: "ntp.cloud.aliyuncs.com",
+					Prefer:   common.BoolToPtr(true),
+					Iburst:   common.BoolToPtr(true),
+				},
+			},
+		},
+		EnabledServices: append(cloudImageConfig.EnabledServices,
+			"nm-cloud-setup.service",
+			"nm-cloud-setup.timer",
+		),
+		CloudInit: []*osbuild.CloudInitStageOptions{
+			{
+				Filename: "10-alibaba.cfg",
+				Config: osbuild.CloudInitConfigFile{
+					DatasourceList: []string{"AliYun", "None"},
+				},
+			},
+		},
+		DracutConf: []*osbuild.DracutConfStageOptions{
+			{
+				Filename: "alibaba.conf",
+				Config: osbuild.DracutConfigFile{
+					AddDrivers: []string{
+						"nvme",
+						"virtio_blk",
+						"virtio_net",
+						"virtio_pci",
+					},
+				},
+			},
+		},
+		SystemdUnit: []*osbuild.SystemdUnitStageOptions{
+			{
+				Unit:   "nm-cloud-setup.service",
+				Dropin: "10-rh-enable-for-aliyun.conf",
+				Config: osbuild.SystemdServiceUnitDropin{
+					Service: &osbuild.SystemdUnitServiceSection{
+						Environment: "NM_CLOUD_SETUP_ALIYUN=yes",
+					},
+				},
+			},
+		},
+	}
+	alibabaImageConfig = alibabaImageConfig.InheritFrom(cloudImageConfig)
+
+	alibabaImgType := imageType{
+		name:     "alibaba",
+		filename: "disk.qcow2",
+		mimeType: "application/x-qemu-disk",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: distroBuildPackageSet,
+			osPkgsKey:    alibabaPackageSet,
+		},
+		defaultImageConfig:  alibabaImageConfig,
+		kernelOptions:       "console=tty0 console=ttyS0,115200n8 net.ifnames=0",
+		bootable:            true,
+		defaultSize:         10 * GigaByte,
+		pipelines:           qcow2Pipelines,
+		buildPipelines:      []string{"build"},
+		payloadPipelines:    []string{"os", "image", "qcow2"},
+		exports:             []string{"qcow2"},
+		basePartitionTables: defaultBasePartitionTables,
+	}
+
+	ibmCloudImageConfig := &distro.ImageConfig{
+		TimeSynchronization: &osbuild.ChronyStageOptions{
+			Servers: []osbuild.ChronyConfigServer{
+				{
+					Hostname: "161.26.0.6",
+					Prefer:   common.BoolToPtr(true),
+					Iburst:   common.BoolToPtr(true),
+				},
+			},
+		},
+		CloudInit: []*osbuild.CloudInitStageOptions{
+			{
+				Filename: "10-ibm-cloud.cfg",
+				Config: osbuild.CloudInitConfigFile{
+					DatasourceList: []string{"ConfigDrive", "NoCloud", "None"},
+					SystemInfo: &osbuild.CloudInitConfigSystemInfo{
+						DefaultUser: &osbuild.CloudInitConfigDefaultUser{
+							Name: "vpcuser",
+						},
+					},
+				},
+			},
+		},
+		DracutConf: []*osbuild.DracutConfStageOptions{
+			{
+				Filename: "ibm-cloud.conf",
+				Config: osbuild.DracutConfigFile{
+					AddDrivers: []string{
+						"virtio_blk",
+						"virtio_net",
+						"virtio_pci",
+						"virtio_scsi",
+					},
+				},
+			},
+		},
+	}
+	ibmCloudImageConfig = ibmCloudImageConfig.InheritFrom(cloudImageConfig)
+
+	ibmCloudImgType := imageType{
+		name:     "ibm-cloud",
+		filename: "disk.qcow2",
+		mimeType: "application/x-qemu-disk",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: distroBuildPackageSet,
+			osPkgsKey:    ibmCloudPackageSet,
+		},
+		defaultImageConfig:  ibmCloudImageConfig,
+		kernelOptions:       "console=tty0 console=ttyS0,115200n8 net.ifnames=0",
+		bootable:            true,
+		defaultSize:         10 * GigaByte,
+		pipelines:           qcow2Pipelines,
+		buildPipelines:      []string{"build"},
+		payloadPipelines:    []string{"os", "image", "qcow2"},
+		exports:             []string{"qcow2"},
+		basePartitionTables: defaultBasePartitionTables,
+	}
+
 	tarImgType := imageType{
 		name:     "tar",
 		filename: "root.tar.xz",
@@ -1259,14 +1786,143 @@ func newDistro(distroName string) distro.Distro {
 		exports:          []string{"bootiso"},
 	}
 
-	x86_64.addImageTypes(qcow2ImgType, vhdImgType, vmdkImgType, openstackImgType, amiImgTypeX86_64, tarImgType, tarInstallerImgTypeX86_64, edgeCommitImgType, edgeInstallerImgType, edgeOCIImgType, edgeRawImgType, edgeSimplifiedInstallerImgType)
-	aarch64.addImageTypes(qcow2ImgType, openstackImgType, amiImgTypeAarch64, tarImgType, edgeCommitImgType, edgeInstallerImgType, edgeOCIImgType, edgeRawImgType, edgeSimplifiedInstallerImgType)
+	liveISOImgType := imageType{
+		name:     "live-iso",
+		filename: "live.iso",
+		mimeType: "application/x-iso9660-image",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: anacondaBuildPackageSet,
+			osPkgsKey:    liveISOPackageSet,
+		},
+		liveISO:          true,
+		bootable:         true,
+		defaultSize:      4 * GigaByte,
+		pipelines:        liveISOPipelines,
+		buildPipelines:   []string{"build"},
+		payloadPipelines: []string{"os", "bootiso-tree", "bootiso"},
+		exports:          []string{"bootiso"},
+	}
+
+	minimalRawImgType := imageType{
+		name:     "minimal-raw",
+		filename: "disk.raw.xz",
+		mimeType: "application/xz",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: minimalRawBuildPackageSet,
+			osPkgsKey:    minimalRawPackageSet,
+		},
+		defaultImageConfig: &distro.ImageConfig{
+			DefaultTarget: "multi-user.target",
+		},
+		kernelOptions:         "ro",
+		bootable:              true,
+		selectableBootType:    true,
+		selectableCompression: true,
+		defaultSize:           2 * GigaByte,
+		pipelines:             minimalRawPipelines,
+		buildPipelines:        []string{"build"},
+		payloadPipelines:      []string{"os", "image", "archive"},
+		exports:               []string{"archive"},
+		basePartitionTables:   defaultBasePartitionTables,
+	}
+
+	wslImgType := imageType{
+		name:     "wsl",
+		filename: "image.tar.gz",
+		mimeType: "application/x-tar",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: distroBuildPackageSet,
+			osPkgsKey:    wslPackageSet,
+		},
+		defaultImageConfig: &distro.ImageConfig{
+			Locale: "C.UTF-8",
+			WSLConfig: &osbuild.WSLConfStageOptions{
+				Boot: &osbuild.WSLConfBootOptions{
+					Systemd: true,
+				},
+			},
+		},
+		noKernel:         true,
+		pipelines:        tarPipelines,
+		buildPipelines:   []string{"build"},
+		payloadPipelines: []string{"os", "root-tar"},
+		exports:          []string{"root-tar"},
+	}
+
+	containerImgType := imageType{
+		name:     "container",
+		filename: "container.tar",
+		mimeType: "application/x-tar",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: distroBuildPackageSet,
+			osPkgsKey:    containerPackageSet,
+		},
+		defaultImageConfig: &distro.ImageConfig{
+			Locale: "C.UTF-8",
+		},
+		noKernel:         true,
+		pipelines:        containerPipelines,
+		buildPipelines:   []string{"build"},
+		payloadPipelines: []string{"os", "container"},
+		exports:          []string{"container"},
+	}
+
+	vagrantImageConfig := vagrant.ImageConfig()
+
+	vagrantLibvirtImageConfig := &distro.ImageConfig{
+		EnabledServices: []string{"sshd", "qemu-guest-agent"},
+	}
+	vagrantLibvirtImageConfig = vagrantLibvirtImageConfig.InheritFrom(vagrantImageConfig)
+
+	vagrantLibvirtImgType := imageType{
+		name:          "vagrant-libvirt",
+		filename:      "vagrant-libvirt.box",
+		mimeType:      "application/x-tar",
+		kernelOptions: "console=tty0 console=ttyS0,115200n8 no_timer_check net.ifnames=0 crashkernel=auto",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: distroBuildPackageSet,
+			osPkgsKey:    vagrantPackageSet,
+		},
+		defaultImageConfig:  vagrantLibvirtImageConfig,
+		bootable:            true,
+		defaultSize:         10 * GigaByte,
+		pipelines:           vagrantLibvirtPipelines,
+		buildPipelines:      []string{"build"},
+		payloadPipelines:    []string{"os", "image", "qcow2", "vagrant", "archive"},
+		exports:             []string{"archive"},
+		basePartitionTables: defaultBasePartitionTables,
+	}
+
+	vagrantVirtualBoxImgType := imageType{
+		name:          "vagrant-virtualbox",
+		filename:      "vagrant-virtualbox.box",
+		mimeType:      "application/x-tar",
+		kernelOptions: "console=tty0 console=ttyS0,115200n8 no_timer_check net.ifnames=0 crashkernel=auto",
+		packageSets: map[string]packageSetFunc{
+			buildPkgsKey: distroBuildPackageSet,
+			osPkgsKey:    vagrantPackageSet,
+		},
+		defaultImageConfig:  vagrantImageConfig,
+		bootable:            true,
+		defaultSize:         10 * GigaByte,
+		pipelines:           vagrantVirtualBoxPipelines,
+		buildPipelines:      []string{"build"},
+		payloadPipelines:    []string{"os", "image", "vmdk", "vagrant", "archive"},
+		exports:             []string{"archive"},
+		basePartitionTables: defaultBasePartitionTables,
+	}
+
+	x86_64.addImageTypes(qcow2ImgType, vhdImgType, azureGen2ImgType, vmdkImgType, openstackImgType, amiImgTypeX86_64, tarImgType, tarInstallerImgTypeX86_64, edgeCommitImgType, edgeInstallerImgType, edgeOCIImgType, edgeRawImgType, edgeSimplifiedInstallerImgType, liveISOImgType, minimalRawImgType, wslImgType, containerImgType, ociImgType, alibabaImgType, ibmCloudImgType, vagrantLibvirtImgType, vagrantVirtualBoxImgType)
+	aarch64.addImageTypes(qcow2ImgType, openstackImgType, amiImgTypeAarch64, tarImgType, edgeCommitImgType, edgeInstallerImgType, edgeOCIImgType, edgeRawImgType, edgeSimplifiedInstallerImgType, liveISOImgType, minimalRawImgType, wslImgType, containerImgType, ociImgType, alibabaImgType, vagrantLibvirtImgType)
 	ppc64le.addImageTypes(qcow2ImgType, tarImgType)
 	s390x.addImageTypes(qcow2ImgType, tarImgType)
 
 	if rd.isRHEL() {
 		// add ec2 image types to RHEL distro only
 		x86_64.addImageTypes(ec2ImgTypeX86_64, ec2HaImgTypeX86_64, ec2SapImgTypeX86_64)
+
+		// add RHUI enabled azure image type to RHEL distro only
+		x86_64.addImageTypes(azureRhuiImgType)
 		aarch64.addImageTypes(ec2ImgTypeAarch64)
 
 		// add s390x to RHEL distro only
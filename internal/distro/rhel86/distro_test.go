@@ -1,6 +1,7 @@
 package rhel86_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
@@ -9,9 +10,11 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/osbuild/osbuild-composer/internal/blueprint"
+	"github.com/osbuild/osbuild-composer/internal/common"
 	"github.com/osbuild/osbuild-composer/internal/distro"
 	"github.com/osbuild/osbuild-composer/internal/distro/distro_test_common"
 	"github.com/osbuild/osbuild-composer/internal/distro/rhel86"
+	"github.com/osbuild/osbuild-composer/internal/rpmmd"
 )
 
 type rhelFamilyDistro struct {
@@ -96,6 +99,22 @@ func TestFilenameFromType(t *testing.T) {
 				mimeType: "application/x-vhd",
 			},
 		},
+		{
+			name: "azure-rhui",
+			args: args{"azure-rhui"},
+			want: wantResult{
+				filename: "disk.vhd",
+				mimeType: "application/x-vhd",
+			},
+		},
+		{
+			name: "azure-gen2",
+			args: args{"azure-gen2"},
+			want: wantResult{
+				filename: "disk.vhd",
+				mimeType: "application/x-vhd",
+			},
+		},
 		{
 			name: "vmdk",
 			args: args{"vmdk"},
@@ -120,6 +139,78 @@ func TestFilenameFromType(t *testing.T) {
 				mimeType: "application/x-iso9660-image",
 			},
 		},
+		{
+			name: "live-iso",
+			args: args{"live-iso"},
+			want: wantResult{
+				filename: "live.iso",
+				mimeType: "application/x-iso9660-image",
+			},
+		},
+		{
+			name: "minimal-raw",
+			args: args{"minimal-raw"},
+			want: wantResult{
+				filename: "disk.raw.xz",
+				mimeType: "application/xz",
+			},
+		},
+		{
+			name: "wsl",
+			args: args{"wsl"},
+			want: wantResult{
+				filename: "image.tar.gz",
+				mimeType: "application/x-tar",
+			},
+		},
+		{
+			name: "container",
+			args: args{"container"},
+			want: wantResult{
+				filename: "container.tar",
+				mimeType: "application/x-tar",
+			},
+		},
+		{
+			name: "oci",
+			args: args{"oci"},
+			want: wantResult{
+				filename: "disk.qcow2",
+				mimeType: "application/x-qemu-disk",
+			},
+		},
+		{
+			name: "alibaba",
+			args: args{"alibaba"},
+			want: wantResult{
+				filename: "disk.qcow2",
+				mimeType: "application/x-qemu-disk",
+			},
+		},
+		{
+			name: "ibm-cloud",
+			args: args{"ibm-cloud"},
+			want: wantResult{
+				filename: "disk.qcow2",
+				mimeType: "application/x-qemu-disk",
+			},
+		},
+		{
+			name: "vagrant-libvirt",
+			args: args{"vagrant-libvirt"},
+			want: wantResult{
+				filename: "vagrant-libvirt.box",
+				mimeType: "application/x-tar",
+			},
+		},
+		{
+			name: "vagrant-virtualbox",
+			args: args{"vagrant-virtualbox"},
+			want: wantResult{
+				filename: "vagrant-virtualbox.box",
+				mimeType: "application/x-tar",
+			},
+		},
 		{
 			name: "edge-commit",
 			args: args{"edge-commit"},
@@ -265,6 +356,8 @@ func TestImageType_Name(t *testing.T) {
 				"qcow2",
 				"openstack",
 				"vhd",
+				"azure-rhui",
+				"azure-gen2",
 				"vmdk",
 				"ami",
 				"ec2",
@@ -275,6 +368,15 @@ func TestImageType_Name(t *testing.T) {
 				"edge-installer",
 				"tar",
 				"image-installer",
+				"live-iso",
+				"minimal-raw",
+				"wsl",
+				"container",
+				"oci",
+				"alibaba",
+				"ibm-cloud",
+				"vagrant-libvirt",
+				"vagrant-virtualbox",
 			},
 		},
 		{
@@ -287,6 +389,13 @@ func TestImageType_Name(t *testing.T) {
 				"edge-commit",
 				"edge-container",
 				"tar",
+				"live-iso",
+				"minimal-raw",
+				"wsl",
+				"container",
+				"oci",
+				"alibaba",
+				"vagrant-libvirt",
 			},
 		},
 		{
@@ -450,6 +559,8 @@ func TestArchitecture_ListImageTypes(t *testing.T) {
 				"qcow2",
 				"openstack",
 				"vhd",
+				"azure-rhui",
+				"azure-gen2",
 				"vmdk",
 				"ami",
 				"ec2",
@@ -462,6 +573,15 @@ func TestArchitecture_ListImageTypes(t *testing.T) {
 				"edge-simplified-installer",
 				"tar",
 				"image-installer",
+				"live-iso",
+				"minimal-raw",
+				"wsl",
+				"container",
+				"oci",
+				"alibaba",
+				"ibm-cloud",
+				"vagrant-libvirt",
+				"vagrant-virtualbox",
 			},
 		},
 		{
@@ -477,6 +597,13 @@ func TestArchitecture_ListImageTypes(t *testing.T) {
 				"edge-simplified-installer",
 				"edge-raw-image",
 				"tar",
+				"live-iso",
+				"minimal-raw",
+				"wsl",
+				"container",
+				"oci",
+				"alibaba",
+				"vagrant-libvirt",
 			},
 		},
 		{
@@ -790,3 +917,98 @@ func TestDistro_CustomUsrPartitionNotLargeEnough(t *testing.T) {
 		}
 	}
 }
+
+func TestDistro_ModuleStreams(t *testing.T) {
+	bp := blueprint.Blueprint{
+		Customizations: &blueprint.Customizations{
+			ModuleStreams: []blueprint.ModuleStreamCustomization{
+				{Name: "postgresql", Stream: "13", Profiles: []string{"server"}},
+				{Name: "nodejs", State: "disabled"},
+			},
+		},
+	}
+	arch, err := rhel86.New().GetArch("x86_64")
+	require.NoError(t, err)
+	imgType, err := arch.GetImageType("qcow2")
+	require.NoError(t, err)
+
+	packageSets := imgType.PackageSets(bp)
+	for _, name := range []string{"packages", "blueprint"} {
+		assert.Equal(t, []string{"postgresql:13"}, packageSets[name].EnabledModules)
+		assert.Equal(t, []string{"nodejs"}, packageSets[name].DisabledModules)
+	}
+	assert.Contains(t, packageSets["blueprint"].Include, "@postgresql:13/server")
+	assert.Empty(t, packageSets["build"].EnabledModules)
+
+	manifest, err := imgType.Manifest(bp.Customizations, distro.ImageOptions{}, nil, nil, 0)
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), `{"type":"org.osbuild.dnf.module-config","options":{"conf":{"name":"postgresql","stream":"13","state":"enabled","profiles":["server"]}}}`)
+	assert.Contains(t, string(manifest), `{"type":"org.osbuild.dnf.module-config","options":{"conf":{"name":"nodejs","stream":"","state":"disabled","profiles":[]}}}`)
+}
+
+func TestDistro_InstallOptions(t *testing.T) {
+	bp := blueprint.Blueprint{
+		Customizations: &blueprint.Customizations{
+			Install: &blueprint.InstallCustomization{
+				WeakDeps:     common.BoolToPtr(false),
+				ExcludeDocs:  true,
+				InstallLangs: []string{"en_US"},
+			},
+		},
+	}
+	arch, err := rhel86.New().GetArch("x86_64")
+	require.NoError(t, err)
+	imgType, err := arch.GetImageType("qcow2")
+	require.NoError(t, err)
+
+	packageSets := imgType.PackageSets(bp)
+	assert.True(t, packageSets["packages"].ExcludeWeakDeps)
+	assert.True(t, packageSets["blueprint"].ExcludeWeakDeps)
+	assert.False(t, packageSets["build"].ExcludeWeakDeps)
+
+	manifest, err := imgType.Manifest(bp.Customizations, distro.ImageOptions{}, nil, nil, 0)
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), `"exclude":{"docs":true},"install_langs":["en_US"]`)
+}
+
+func TestDistro_CurlSourceSecrets(t *testing.T) {
+	arch, err := rhel86.New().GetArch("x86_64")
+	require.NoError(t, err)
+	imgType, err := arch.GetImageType("qcow2")
+	require.NoError(t, err)
+
+	packages := []rpmmd.PackageSpec{
+		{
+			Name:           "public",
+			RemoteLocation: "https://example.com/public.rpm",
+			Checksum:       "sha256:" + strings.Repeat("1", 64),
+		},
+		{
+			Name:           "subscribed",
+			RemoteLocation: "https://cdn.example.com/subscribed.rpm",
+			Checksum:       "sha256:" + strings.Repeat("2", 64),
+			Secrets:        "org.osbuild.rhsm",
+		},
+		{
+			Name:           "internal",
+			RemoteLocation: "https://internal.example.com/internal.rpm",
+			Checksum:       "sha256:" + strings.Repeat("3", 64),
+			Secrets:        "org.osbuild.mtls",
+		},
+	}
+	manifest, err := imgType.Manifest(nil, distro.ImageOptions{Size: imgType.Size(0)}, nil, map[string][]rpmmd.PackageSpec{"packages": packages}, 0)
+	require.NoError(t, err)
+
+	var parsed struct {
+		Sources struct {
+			Curl json.RawMessage `json:"org.osbuild.curl"`
+		} `json:"sources"`
+	}
+	require.NoError(t, json.Unmarshal(manifest, &parsed))
+	require.NoError(t, distro_test_common.ValidateCurlSource(parsed.Sources.Curl))
+	assert.Contains(t, string(parsed.Sources.Curl), `{"url":"https://internal.example.com/internal.rpm","secrets":{"name":"org.osbuild.mtls"}}`)
+
+	// items with options osbuild doesn't know about are rejected
+	invalid := fmt.Sprintf(`{"items":{"sha256:%s":{"url":"https://internal.example.com/internal.rpm","options":{"proxy":"http://proxy:3128"}}}}`, strings.Repeat("3", 64))
+	assert.Error(t, distro_test_common.ValidateCurlSource(json.RawMessage(invalid)))
+}
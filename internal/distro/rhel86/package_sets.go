@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/osbuild/osbuild-composer/internal/distro"
+	"github.com/osbuild/osbuild-composer/internal/distro/vagrant"
 	"github.com/osbuild/osbuild-composer/internal/rpmmd"
 )
 
@@ -311,6 +312,20 @@ func vhdCommonPackageSet(t *imageType) rpmmd.PackageSet {
 	}.Append(bootPackageSet(t))
 }
 
+// Azure image package set
+func azureCommonPackageSet(t *imageType) rpmmd.PackageSet {
+	return vhdCommonPackageSet(t).Append(rpmmd.PackageSet{
+		Include: []string{"hyperv-daemons"},
+	})
+}
+
+// RHUI enabled Azure image package set
+func azureRhuiPackageSet(t *imageType) rpmmd.PackageSet {
+	return azureCommonPackageSet(t).Append(rpmmd.PackageSet{
+		Include: []string{"rhui-azure-rhel8"},
+	})
+}
+
 func vmdkCommonPackageSet(t *imageType) rpmmd.PackageSet {
 	return rpmmd.PackageSet{
 		Include: []string{
@@ -341,6 +356,46 @@ func openstackCommonPackageSet(t *imageType) rpmmd.PackageSet {
 
 }
 
+// common package set of the Oracle, Alibaba and IBM cloud images
+func cloudCommonPackageSet(t *imageType) rpmmd.PackageSet {
+	return rpmmd.PackageSet{
+		Include: []string{
+			"@core", "authselect-compat", "chrony", "cloud-init",
+			"cloud-utils-growpart", "dhcp-client", "dracut-config-generic",
+			"dracut-norescue", "gdisk", "langpacks-en", "NetworkManager",
+			"redhat-release", "redhat-release-eula", "rsync", "tar",
+			"yum-utils",
+		},
+		Exclude: []string{
+			"aic94xx-firmware", "alsa-firmware", "alsa-tools-firmware",
+			"biosdevname", "dracut-config-rescue", "firewalld", "iprutils",
+			"ivtv-firmware", "plymouth",
+		},
+	}.Append(bootPackageSet(t)).Append(distroSpecificPackageSet(t))
+}
+
+// Oracle Cloud Infrastructure image package set
+func ociPackageSet(t *imageType) rpmmd.PackageSet {
+	return cloudCommonPackageSet(t).Append(rpmmd.PackageSet{
+		// boot volumes of bare metal instances are attached via iSCSI
+		Include: []string{"iscsi-initiator-utils"},
+	})
+}
+
+// Alibaba Cloud image package set
+func alibabaPackageSet(t *imageType) rpmmd.PackageSet {
+	return cloudCommonPackageSet(t).Append(rpmmd.PackageSet{
+		Include: []string{"NetworkManager-cloud-setup"},
+	})
+}
+
+// IBM Cloud image package set
+func ibmCloudPackageSet(t *imageType) rpmmd.PackageSet {
+	return cloudCommonPackageSet(t).Append(rpmmd.PackageSet{
+		Include: []string{"qemu-guest-agent"},
+	})
+}
+
 func ec2CommonPackageSet(t *imageType) rpmmd.PackageSet {
 	return rpmmd.PackageSet{
 		Include: []string{
@@ -533,6 +588,132 @@ func distroSpecificPackageSet(t *imageType) rpmmd.PackageSet {
 	return rpmmd.PackageSet{}
 }
 
+// VAGRANT PACKAGE SET
+
+// packages of Vagrant boxes, which vagrant logs in to and syncs folders with
+// (rsync and the qemu-guest-agent of the libvirt provider are in the qcow2
+// package set)
+func vagrantPackageSet(t *imageType) rpmmd.PackageSet {
+	return qcow2CommonPackageSet(t).Append(
+		rpmmd.PackageSet{
+			Include: vagrant.Packages,
+		})
+}
+
+// WSL PACKAGE SET
+
+// a container-like userspace for the Windows Subsystem for Linux, which
+// provides the kernel
+func wslPackageSet(t *imageType) rpmmd.PackageSet {
+	ps := rpmmd.PackageSet{
+		Include: []string{
+			"bash",
+			"ca-certificates",
+			"coreutils-single",
+			"dnf",
+			"filesystem",
+			"findutils",
+			"glibc-minimal-langpack",
+			"hostname",
+			"iproute",
+			"less",
+			"passwd",
+			"procps-ng",
+			"redhat-release",
+			"rootfiles",
+			"selinux-policy-targeted",
+			"shadow-utils",
+			"sudo",
+			"systemd",
+			"tar",
+			"util-linux",
+			"vim-minimal",
+		},
+		Exclude: []string{
+			"grub2-*",
+			"kernel*",
+			"linux-firmware*",
+		},
+	}
+
+	// Ensure to not pull in subscription-manager on non-RHEL distro
+	if t.arch.distro.isRHEL() {
+		ps = ps.Append(rpmmd.PackageSet{
+			Include: []string{
+				"subscription-manager",
+			},
+		})
+	}
+
+	return ps
+}
+
+// minimal package set of container base images, similar to the UBI ones
+func containerPackageSet(t *imageType) rpmmd.PackageSet {
+	return rpmmd.PackageSet{
+		Include: []string{
+			"bash",
+			"ca-certificates",
+			"coreutils-single",
+			"crypto-policies-scripts",
+			"dnf",
+			"findutils",
+			"glibc-minimal-langpack",
+			"gzip",
+			"redhat-release",
+			"rootfiles",
+			"selinux-policy-targeted",
+			"shadow-utils",
+			"tar",
+			"vim-minimal",
+		},
+		Exclude: []string{
+			"dracut*",
+			"grub2-*",
+			"kernel*",
+			"linux-firmware*",
+		},
+	}
+}
+
+// MINIMAL RAW PACKAGE SETS
+
+func minimalRawBuildPackageSet(t *imageType) rpmmd.PackageSet {
+	return distroBuildPackageSet(t).Append(
+		rpmmd.PackageSet{
+			Include: []string{
+				"zstd",
+			},
+		})
+}
+
+// a minimal OS booting on bare-metal and embedded devices
+func minimalRawPackageSet(t *imageType) rpmmd.PackageSet {
+	return rpmmd.PackageSet{
+		Include: []string{
+			"@core",
+			"dracut-config-generic",
+			"NetworkManager-wifi",
+			"selinux-policy-targeted",
+		},
+		Exclude: []string{
+			"dracut-config-rescue",
+		},
+	}.Append(bootPackageSet(t))
+}
+
+// LIVE ISO PACKAGE SET
+
+// the OS of live images, which boots from the squashfs image on the ISO
+func liveISOPackageSet(t *imageType) rpmmd.PackageSet {
+	return bareMetalPackageSet(t).Append(anacondaBootPackageSet(t)).Append(
+		rpmmd.PackageSet{
+			Include: []string{
+				"dracut-live",
+			},
+		})
+}
+
 // INSTALLER PACKAGE SET
 
 func installerPackageSet(t *imageType) rpmmd.PackageSet {
@@ -182,6 +182,16 @@ var ec2BasePartitionTables = distro.BasePartitionTableMap{
 	},
 }
 
+// Azure Gen2 virtual machines boot only with UEFI, so the BIOS boot partition
+// of x86_64 is left out
+var azureGen2BasePartitionTables = distro.BasePartitionTableMap{
+	distro.X86_64ArchName: disk.PartitionTable{
+		UUID:       defaultBasePartitionTables[distro.X86_64ArchName].UUID,
+		Type:       "gpt",
+		Partitions: defaultBasePartitionTables[distro.X86_64ArchName].Partitions[1:],
+	},
+}
+
 var edgeBasePartitionTables = distro.BasePartitionTableMap{
 	distro.X86_64ArchName: disk.PartitionTable{
 		UUID: "D209C89E-EA5E-4FBD-B161-B461CCE297E0",
@@ -11,6 +11,7 @@ import (
 	"github.com/osbuild/osbuild-composer/internal/common"
 	"github.com/osbuild/osbuild-composer/internal/disk"
 	"github.com/osbuild/osbuild-composer/internal/distro"
+	"github.com/osbuild/osbuild-composer/internal/distro/vagrant"
 	osbuild "github.com/osbuild/osbuild-composer/internal/osbuild2"
 	"github.com/osbuild/osbuild-composer/internal/rpmmd"
 )
@@ -81,6 +82,11 @@ func vhdPipelines(t *imageType, customizations *blueprint.Customizations, option
 	return pipelines, nil
 }
 
+func azureGen2Pipelines(t *imageType, customizations *blueprint.Customizations, options distro.ImageOptions, repos []rpmmd.RepoConfig, packageSetSpecs map[string][]rpmmd.PackageSpec, rng *rand.Rand) ([]osbuild.Pipeline, error) {
+	// Gen2 virtual machines boot only with UEFI
+	return vhdPipelines(t.withBootType(distro.UEFIBootType), customizations, options, repos, packageSetSpecs, rng)
+}
+
 func vmdkPipelines(t *imageType, customizations *blueprint.Customizations, options distro.ImageOptions, repos []rpmmd.RepoConfig, packageSetSpecs map[string][]rpmmd.PackageSpec, rng *rand.Rand) ([]osbuild.Pipeline, error) {
 	pipelines := make([]osbuild.Pipeline, 0)
 	pipelines = append(pipelines, *buildPipeline(repos, packageSetSpecs[buildPkgsKey], t.arch.distro.runner))
@@ -193,11 +199,24 @@ func tarPipelines(t *imageType, customizations *blueprint.Customizations, option
 		Name:  "root-tar",
 		Build: "name:build",
 	}
-	tarPipeline.AddStage(tarStage("os", "root.tar.xz"))
+	tarPipeline.AddStage(tarStage("os", t.Filename()))
 	pipelines = append(pipelines, tarPipeline)
 	return pipelines, nil
 }
 
+func containerPipelines(t *imageType, customizations *blueprint.Customizations, options distro.ImageOptions, repos []rpmmd.RepoConfig, packageSetSpecs map[string][]rpmmd.PackageSpec, rng *rand.Rand) ([]osbuild.Pipeline, error) {
+	pipelines := make([]osbuild.Pipeline, 0)
+	pipelines = append(pipelines, *buildPipeline(repos, packageSetSpecs[buildPkgsKey], t.arch.distro.runner))
+
+	treePipeline, err := osPipeline(t, repos, packageSetSpecs[osPkgsKey], packageSetSpecs[blueprintPkgsKey], customizations, options, nil)
+	if err != nil {
+		return nil, err
+	}
+	pipelines = append(pipelines, *treePipeline)
+	pipelines = append(pipelines, *containerBasePipeline(t, customizations.GetContainer()))
+	return pipelines, nil
+}
+
 //makeISORootPath return a path that can be used to address files and folders in
 //the root of the iso
 func makeISORootPath(p string) string {
@@ -259,6 +278,116 @@ func tarInstallerPipelines(t *imageType, customizations *blueprint.Customization
 	return pipelines, nil
 }
 
+func vagrantLibvirtPipelines(t *imageType, customizations *blueprint.Customizations, options distro.ImageOptions, repos []rpmmd.RepoConfig, packageSetSpecs map[string][]rpmmd.PackageSpec, rng *rand.Rand) ([]osbuild.Pipeline, error) {
+	return vagrantPipelines(t, customizations, options, repos, packageSetSpecs, rng, osbuild.VagrantProviderLibvirt)
+}
+
+func vagrantVirtualBoxPipelines(t *imageType, customizations *blueprint.Customizations, options distro.ImageOptions, repos []rpmmd.RepoConfig, packageSetSpecs map[string][]rpmmd.PackageSpec, rng *rand.Rand) ([]osbuild.Pipeline, error) {
+	return vagrantPipelines(t, customizations, options, repos, packageSetSpecs, rng, osbuild.VagrantProviderVirtualBox)
+}
+
+func vagrantPipelines(t *imageType, customizations *blueprint.Customizations, options distro.ImageOptions, repos []rpmmd.RepoConfig, packageSetSpecs map[string][]rpmmd.PackageSpec, rng *rand.Rand, provider osbuild.VagrantProvider) ([]osbuild.Pipeline, error) {
+	pipelines := make([]osbuild.Pipeline, 0)
+	pipelines = append(pipelines, *buildPipeline(repos, packageSetSpecs[buildPkgsKey], t.arch.distro.runner))
+
+	partitionTable, err := t.getPartitionTable(customizations.GetFilesystems(), options, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	treePipeline, err := osPipeline(t, repos, packageSetSpecs[osPkgsKey], packageSetSpecs[blueprintPkgsKey], vagrant.Customizations(customizations), options, &partitionTable)
+	if err != nil {
+		return nil, err
+	}
+	pipelines = append(pipelines, *treePipeline)
+
+	diskfile := "disk.img"
+	kernelVer := kernelVerStr(packageSetSpecs[blueprintPkgsKey], customizations.GetKernel().Name, t.Arch().Name())
+	imagePipeline := liveImagePipeline(treePipeline.Name, diskfile, &partitionTable, t.arch, kernelVer)
+	pipelines = append(pipelines, *imagePipeline)
+
+	var boxPipeline *osbuild.Pipeline
+	var boxfile string
+	switch provider {
+	case osbuild.VagrantProviderLibvirt:
+		boxfile = "box.img"
+		boxPipeline = qemuPipeline(imagePipeline.Name, diskfile, boxfile, "qcow2", "1.1")
+	case osbuild.VagrantProviderVirtualBox:
+		boxfile = "box.vmdk"
+		boxPipeline = qemuPipeline(imagePipeline.Name, diskfile, boxfile, "vmdk", "")
+	}
+	pipelines = append(pipelines, *boxPipeline)
+	pipelines = append(pipelines, *vagrant.Pipeline(boxPipeline.Name, boxfile, provider))
+
+	archivePipeline := osbuild.Pipeline{
+		Name:  "archive",
+		Build: "name:build",
+	}
+	archivePipeline.AddStage(tarStage("vagrant", t.Filename()))
+	pipelines = append(pipelines, archivePipeline)
+
+	return pipelines, nil
+}
+
+func minimalRawPipelines(t *imageType, customizations *blueprint.Customizations, options distro.ImageOptions, repos []rpmmd.RepoConfig, packageSetSpecs map[string][]rpmmd.PackageSpec, rng *rand.Rand) ([]osbuild.Pipeline, error) {
+	// the boot type selected in the image options replaces the one of the
+	// architecture
+	if options.BootType != distro.UnsetBootType {
+		t = t.withBootType(options.BootType)
+	}
+
+	pipelines := make([]osbuild.Pipeline, 0)
+	pipelines = append(pipelines, *buildPipeline(repos, packageSetSpecs[buildPkgsKey], t.arch.distro.runner))
+
+	partitionTable, err := t.getPartitionTable(customizations.GetFilesystems(), options, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	treePipeline, err := osPipeline(t, repos, packageSetSpecs[osPkgsKey], packageSetSpecs[blueprintPkgsKey], customizations, options, &partitionTable)
+	if err != nil {
+		return nil, err
+	}
+	pipelines = append(pipelines, *treePipeline)
+
+	diskfile := "disk.raw"
+	kernelVer := kernelVerStr(packageSetSpecs[blueprintPkgsKey], customizations.GetKernel().Name, t.Arch().Name())
+	imagePipeline := liveImagePipeline(treePipeline.Name, diskfile, &partitionTable, t.arch, kernelVer)
+	pipelines = append(pipelines, *imagePipeline)
+
+	filename := distro.ImageFilename(t, options)
+	switch options.Compression {
+	case distro.NoCompression:
+		pipelines = append(pipelines, *copyArchivePipeline(imagePipeline.Name, diskfile, filename))
+	case distro.ZstdCompression:
+		pipelines = append(pipelines, *zstdArchivePipeline(imagePipeline.Name, diskfile, filename))
+	default:
+		pipelines = append(pipelines, *xzArchivePipeline(imagePipeline.Name, diskfile, filename))
+	}
+
+	return pipelines, nil
+}
+
+func liveISOPipelines(t *imageType, customizations *blueprint.Customizations, options distro.ImageOptions, repos []rpmmd.RepoConfig, packageSetSpecs map[string][]rpmmd.PackageSpec, rng *rand.Rand) ([]osbuild.Pipeline, error) {
+	pipelines := make([]osbuild.Pipeline, 0)
+	pipelines = append(pipelines, *buildPipeline(repos, packageSetSpecs[buildPkgsKey], t.arch.distro.runner))
+
+	treePipeline, err := osPipeline(t, repos, packageSetSpecs[osPkgsKey], packageSetSpecs[blueprintPkgsKey], customizations, options, nil)
+	if err != nil {
+		return nil, err
+	}
+	pipelines = append(pipelines, *treePipeline)
+
+	archName := t.arch.name
+	d := t.arch.distro
+	kernelVer := kernelVerStr(packageSetSpecs[osPkgsKey], customizations.GetKernel().Name, archName)
+	isolabel := fmt.Sprintf(d.isolabelTmpl, archName)
+	stageOptions := liveBootISOMonoStageOptions(kernelVer, archName, d.vendor, d.product, d.osVersion, isolabel, customizations.GetKernel().Append, t.Size(options.Size))
+	pipelines = append(pipelines, *liveISOTreePipeline(stageOptions))
+	pipelines = append(pipelines, *bootISOPipeline(t.Filename(), d.isolabelTmpl, archName, archName == distro.X86_64ArchName))
+	return pipelines, nil
+}
+
 func edgeCorePipelines(t *imageType, customizations *blueprint.Customizations, options distro.ImageOptions, repos []rpmmd.RepoConfig, packageSetSpecs map[string][]rpmmd.PackageSpec) ([]osbuild.Pipeline, error) {
 	pipelines := make([]osbuild.Pipeline, 0)
 	pipelines = append(pipelines, *buildPipeline(repos, packageSetSpecs[buildPkgsKey], t.arch.distro.runner))
@@ -373,7 +502,12 @@ func osPipeline(t *imageType,
 		p.AddStage(osbuild.NewOSTreePasswdStage("org.osbuild.source", options.OSTree.Parent))
 	}
 
-	p.AddStage(osbuild.NewRPMStage(rpmStageOptions(repos), rpmStageInputs(packages)))
+	p.AddStage(osbuild.NewRPMStage(osRPMStageOptions(repos, c.GetInstall()), rpmStageInputs(packages)))
+
+	// keep the module streams of the blueprint on updates
+	for _, module := range c.GetModuleStreams() {
+		p.AddStage(osbuild.NewDNFModuleConfigStage(dnfModuleConfigStageOptions(module)))
+	}
 
 	// If the /boot is on a separate partition, the prefix for the BLS stage must be ""
 	if pt == nil || pt.BootPartition() == nil {
@@ -515,6 +649,22 @@ func osPipeline(t *imageType,
 		p.AddStage(osbuild.NewDNFConfigStage(dnfConfig))
 	}
 
+	if wslConfig := imageConfig.WSLConfig; wslConfig != nil {
+		p.AddStage(osbuild.NewWSLConfStage(wslConfStageOptions(wslConfig, c.GetUsers())))
+	}
+
+	if waagentConfig := imageConfig.WAAgentConfig; waagentConfig != nil {
+		p.AddStage(osbuild.NewWAAgentConfStage(waagentConfig))
+	}
+
+	for _, udevRules := range imageConfig.UdevRules {
+		p.AddStage(osbuild.NewUdevRulesStage(udevRules))
+	}
+
+	for _, sudoersConfig := range imageConfig.Sudoers {
+		p.AddStage(osbuild.NewSudoersStage(sudoersConfig))
+	}
+
 	if pt != nil {
 		p = prependKernelCmdlineStage(p, t, pt)
 		p.AddStage(osbuild.NewFSTabStage(pt.FSTabStageOptionsV2()))
@@ -522,6 +672,11 @@ func osPipeline(t *imageType,
 		p.AddStage(bootloaderConfigStage(t, *pt, c.GetKernel(), kernelVer, false, false))
 	}
 
+	if t.liveISO {
+		kernelVer := kernelVerStr(packages, c.GetKernel().Name, t.Arch().Name())
+		p.AddStage(osbuild.NewDracutStage(liveDracutStageOptions(kernelVer)))
+	}
+
 	p.AddStage(osbuild.NewSELinuxStage(selinuxStageOptions(false)))
 
 	if t.rpmOstree {
@@ -616,6 +771,39 @@ func containerPipeline(t *imageType, nginxConfigPath, listenPort string) *osbuil
 	return p
 }
 
+// containerBasePipeline wraps the os tree in an OCI archive, configured by the
+// container customization of the blueprint
+func containerBasePipeline(t *imageType, c *blueprint.ContainerCustomization) *osbuild.Pipeline {
+	p := new(osbuild.Pipeline)
+	p.Name = "container"
+	p.Build = "name:build"
+	options := &osbuild.OCIArchiveStageOptions{
+		Architecture: t.arch.Name(),
+		Filename:     t.Filename(),
+	}
+	if c != nil {
+		if c.Architecture != "" {
+			options.Architecture = c.Architecture
+		}
+		options.Config = &osbuild.OCIArchiveConfig{
+			Entrypoint:   c.Entrypoint,
+			Cmd:          c.Cmd,
+			Env:          c.Env,
+			ExposedPorts: c.ExposedPorts,
+			User:         c.User,
+			Labels:       c.Labels,
+			WorkingDir:   c.WorkingDir,
+		}
+	}
+	baseInput := new(osbuild.OCIArchiveStageInput)
+	baseInput.Type = "org.osbuild.tree"
+	baseInput.Origin = "org.osbuild.pipeline"
+	baseInput.References = []string{"name:os"}
+	inputs := &osbuild.OCIArchiveStageInputs{Base: baseInput}
+	p.AddStage(osbuild.NewOCIArchiveStage(options, inputs))
+	return p
+}
+
 func ostreePayloadStages(options distro.ImageOptions, ostreeRepoPath string) []*osbuild.Stage {
 	stages := make([]*osbuild.Stage, 0)
 
@@ -901,7 +1089,7 @@ func bootISOTreePipeline(kernelVer, arch, vendor, product, osVersion, isolabel s
 	p.Name = "bootiso-tree"
 	p.Build = "name:build"
 
-	p.AddStage(osbuild.NewBootISOMonoStage(bootISOMonoStageOptions(kernelVer, arch, vendor, product, osVersion, isolabel), bootISOMonoStageInputs()))
+	p.AddStage(osbuild.NewBootISOMonoStage(bootISOMonoStageOptions(kernelVer, arch, vendor, product, osVersion, isolabel), bootISOMonoStageInputs("anaconda-tree")))
 	p.AddStage(osbuild.NewKickstartStage(ksOptions))
 	p.AddStage(osbuild.NewDiscinfoStage(discinfoStageOptions(arch)))
 
@@ -911,6 +1099,19 @@ func bootISOTreePipeline(kernelVer, arch, vendor, product, osVersion, isolabel s
 
 	return p
 }
+
+// liveISOTreePipeline assembles the tree of a live ISO, which boots the
+// "os" tree from a squashfs image
+func liveISOTreePipeline(options *osbuild.BootISOMonoStageOptions) *osbuild.Pipeline {
+	p := new(osbuild.Pipeline)
+	p.Name = "bootiso-tree"
+	p.Build = "name:build"
+
+	p.AddStage(osbuild.NewBootISOMonoStage(options, bootISOMonoStageInputs("os")))
+
+	return p
+}
+
 func bootISOPipeline(filename, isolabel, arch string, isolinux bool) *osbuild.Pipeline {
 	p := new(osbuild.Pipeline)
 	p.Name = "bootiso"
@@ -957,6 +1158,41 @@ func xzArchivePipeline(inputPipelineName, inputFilename, outputFilename string)
 	return p
 }
 
+func zstdArchivePipeline(inputPipelineName, inputFilename, outputFilename string) *osbuild.Pipeline {
+	p := new(osbuild.Pipeline)
+	p.Name = "archive"
+	p.Build = "name:build"
+
+	p.AddStage(osbuild.NewZstdStage(
+		osbuild.NewZstdStageOptions(outputFilename),
+		osbuild.NewFilesInputs(osbuild.NewFilesInputReferencesPipeline(inputPipelineName, inputFilename)),
+	))
+
+	return p
+}
+
+// copyArchivePipeline exports the file as it is, in place of a compressed
+// archive
+func copyArchivePipeline(inputPipelineName, inputFilename, outputFilename string) *osbuild.Pipeline {
+	p := new(osbuild.Pipeline)
+	p.Name = "archive"
+	p.Build = "name:build"
+
+	p.AddStage(osbuild.NewCopyStageSimple(
+		&osbuild.CopyStageOptions{
+			Paths: []osbuild.CopyStagePath{
+				{
+					From: fmt.Sprintf("input://file/%s", inputFilename),
+					To:   fmt.Sprintf("tree:///%s", outputFilename),
+				},
+			},
+		},
+		osbuild.NewFilesInputs(osbuild.NewFilesInputReferencesPipeline(inputPipelineName, inputFilename)),
+	))
+
+	return p
+}
+
 // mkfsStages generates a list of org.osbuild.mkfs.* stages based on a
 // partition table description for a single device node
 func mkfsStages(pt *disk.PartitionTable, device *osbuild.Device) []*osbuild.Stage {
@@ -24,10 +24,7 @@ const (
 func rpmStageOptions(repos []rpmmd.RepoConfig) *osbuild.RPMStageOptions {
 	var gpgKeys []string
 	for _, repo := range repos {
-		if repo.GPGKey == "" {
-			continue
-		}
-		gpgKeys = append(gpgKeys, repo.GPGKey)
+		gpgKeys = append(gpgKeys, repo.GPGKeys...)
 	}
 
 	return &osbuild.RPMStageOptions{
@@ -35,6 +32,30 @@ func rpmStageOptions(repos []rpmmd.RepoConfig) *osbuild.RPMStageOptions {
 	}
 }
 
+// osRPMStageOptions returns the options of the rpm stage installing the OS
+// packages, which follow the install customization of the blueprint.
+func osRPMStageOptions(repos []rpmmd.RepoConfig, install *blueprint.InstallCustomization) *osbuild.RPMStageOptions {
+	options := rpmStageOptions(repos)
+	if install.ExcludeDocs {
+		options.Exclude = &osbuild.Exclude{Docs: true}
+	}
+	options.InstallLangs = install.InstallLangs
+	return options
+}
+
+func dnfModuleConfigStageOptions(module blueprint.ModuleStreamCustomization) *osbuild.DNFModuleConfigStageOptions {
+	conf := &osbuild.DNFModuleConfig{
+		Name:     module.Name,
+		Stream:   module.Stream,
+		State:    module.State,
+		Profiles: module.Profiles,
+	}
+	if conf.Profiles == nil {
+		conf.Profiles = []string{}
+	}
+	return &osbuild.DNFModuleConfigStageOptions{Conf: conf}
+}
+
 // selinuxStageOptions returns the options for the org.osbuild.selinux stage.
 // Setting the argument to 'true' relabels the '/usr/bin/cp' and '/usr/bin/tar'
 // binaries with 'install_exec_t'. This should be set in the build root.
@@ -307,6 +328,39 @@ func bootISOMonoStageOptions(kernelVer, arch, vendor, product, osVersion, isolab
 	}
 }
 
+// liveBootISOMonoStageOptions returns the options of a live ISO, whose root
+// file system of rootfsSize bytes is found by the dmsquash-live dracut module
+// using the ISO label.
+func liveBootISOMonoStageOptions(kernelVer, arch, vendor, product, osVersion, isolabel, kernelOpts string, rootfsSize uint64) *osbuild.BootISOMonoStageOptions {
+	options := bootISOMonoStageOptions(kernelVer, arch, vendor, product, osVersion, isolabel)
+	options.KernelOpts = fmt.Sprintf("root=live:CDLABEL=%s rd.live.image", isolabel)
+	if kernelOpts != "" {
+		options.KernelOpts += " " + kernelOpts
+	}
+	// the size of the root file system is in MiB
+	options.RootFS.Size = int(rootfsSize / (1024 * 1024))
+	return options
+}
+
+func liveDracutStageOptions(kernelVer string) *osbuild.DracutStageOptions {
+	return &osbuild.DracutStageOptions{
+		Kernel:     []string{kernelVer},
+		AddModules: []string{"dmsquash-live"},
+	}
+}
+
+// wslConfStageOptions returns the WSL configuration, in which the first user
+// of the blueprint is logged in by default, unless set otherwise.
+func wslConfStageOptions(config *osbuild.WSLConfStageOptions, users []blueprint.UserCustomization) *osbuild.WSLConfStageOptions {
+	options := *config
+	if options.User == nil && len(users) > 0 {
+		options.User = &osbuild.WSLConfUserOptions{
+			Default: users[0].Name,
+		}
+	}
+	return &options
+}
+
 func grubISOStageOptions(installDevice, kernelVer, arch, vendor, product, osVersion, isolabel string) *osbuild.GrubISOStageOptions {
 	var architectures []string
 
@@ -9,9 +9,12 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/osbuild/osbuild-composer/internal/blueprint"
+	"github.com/osbuild/osbuild-composer/internal/common"
+	"github.com/osbuild/osbuild-composer/internal/disk"
 	"github.com/osbuild/osbuild-composer/internal/distro"
 	"github.com/osbuild/osbuild-composer/internal/distro/distro_test_common"
 	"github.com/osbuild/osbuild-composer/internal/distro/rhel90"
+	osbuild "github.com/osbuild/osbuild-composer/internal/osbuild2"
 )
 
 type rhelFamilyDistro struct {
@@ -96,6 +99,22 @@ func TestFilenameFromType(t *testing.T) {
 				mimeType: "application/x-vhd",
 			},
 		},
+		{
+			name: "azure-rhui",
+			args: args{"azure-rhui"},
+			want: wantResult{
+				filename: "disk.vhd",
+				mimeType: "application/x-vhd",
+			},
+		},
+		{
+			name: "azure-gen2",
+			args: args{"azure-gen2"},
+			want: wantResult{
+				filename: "disk.vhd",
+				mimeType: "application/x-vhd",
+			},
+		},
 		{
 			name: "vmdk",
 			args: args{"vmdk"},
@@ -120,6 +139,78 @@ func TestFilenameFromType(t *testing.T) {
 				mimeType: "application/x-iso9660-image",
 			},
 		},
+		{
+			name: "live-iso",
+			args: args{"live-iso"},
+			want: wantResult{
+				filename: "live.iso",
+				mimeType: "application/x-iso9660-image",
+			},
+		},
+		{
+			name: "minimal-raw",
+			args: args{"minimal-raw"},
+			want: wantResult{
+				filename: "disk.raw.xz",
+				mimeType: "application/xz",
+			},
+		},
+		{
+			name: "wsl",
+			args: args{"wsl"},
+			want: wantResult{
+				filename: "image.tar.gz",
+				mimeType: "application/x-tar",
+			},
+		},
+		{
+			name: "container",
+			args: args{"container"},
+			want: wantResult{
+				filename: "container.tar",
+				mimeType: "application/x-tar",
+			},
+		},
+		{
+			name: "oci",
+			args: args{"oci"},
+			want: wantResult{
+				filename: "disk.qcow2",
+				mimeType: "application/x-qemu-disk",
+			},
+		},
+		{
+			name: "alibaba",
+			args: args{"alibaba"},
+			want: wantResult{
+				filename: "disk.qcow2",
+				mimeType: "application/x-qemu-disk",
+			},
+		},
+		{
+			name: "ibm-cloud",
+			args: args{"ibm-cloud"},
+			want: wantResult{
+				filename: "disk.qcow2",
+				mimeType: "application/x-qemu-disk",
+			},
+		},
+		{
+			name: "vagrant-libvirt",
+			args: args{"vagrant-libvirt"},
+			want: wantResult{
+				filename: "vagrant-libvirt.box",
+				mimeType: "application/x-tar",
+			},
+		},
+		{
+			name: "vagrant-virtualbox",
+			args: args{"vagrant-virtualbox"},
+			want: wantResult{
+				filename: "vagrant-virtualbox.box",
+				mimeType: "application/x-tar",
+			},
+		},
 		{
 			name: "edge-commit",
 			args: args{"edge-commit"},
@@ -265,6 +356,8 @@ func TestImageType_Name(t *testing.T) {
 				"qcow2",
 				"openstack",
 				"vhd",
+				"azure-rhui",
+				"azure-gen2",
 				"vmdk",
 				"ami",
 				"ec2",
@@ -275,6 +368,15 @@ func TestImageType_Name(t *testing.T) {
 				"edge-installer",
 				"tar",
 				"image-installer",
+				"live-iso",
+				"minimal-raw",
+				"wsl",
+				"container",
+				"oci",
+				"alibaba",
+				"ibm-cloud",
+				"vagrant-libvirt",
+				"vagrant-virtualbox",
 			},
 		},
 		{
@@ -287,6 +389,13 @@ func TestImageType_Name(t *testing.T) {
 				"edge-commit",
 				"edge-container",
 				"tar",
+				"live-iso",
+				"minimal-raw",
+				"wsl",
+				"container",
+				"oci",
+				"alibaba",
+				"vagrant-libvirt",
 			},
 		},
 		{
@@ -450,6 +559,8 @@ func TestArchitecture_ListImageTypes(t *testing.T) {
 				"qcow2",
 				"openstack",
 				"vhd",
+				"azure-rhui",
+				"azure-gen2",
 				"vmdk",
 				"ami",
 				"ec2",
@@ -462,6 +573,15 @@ func TestArchitecture_ListImageTypes(t *testing.T) {
 				"edge-simplified-installer",
 				"tar",
 				"image-installer",
+				"live-iso",
+				"minimal-raw",
+				"wsl",
+				"container",
+				"oci",
+				"alibaba",
+				"ibm-cloud",
+				"vagrant-libvirt",
+				"vagrant-virtualbox",
 			},
 		},
 		{
@@ -477,6 +597,13 @@ func TestArchitecture_ListImageTypes(t *testing.T) {
 				"edge-simplified-installer",
 				"edge-raw-image",
 				"tar",
+				"live-iso",
+				"minimal-raw",
+				"wsl",
+				"container",
+				"oci",
+				"alibaba",
+				"vagrant-libvirt",
 			},
 		},
 		{
@@ -790,3 +917,277 @@ func TestDistro_CustomUsrPartitionNotLargeEnough(t *testing.T) {
 		}
 	}
 }
+
+func TestDistro_DeriveImageType(t *testing.T) {
+	r9distro := rhel90.New()
+	arch, err := r9distro.GetArch("x86_64")
+	require.NoError(t, err)
+
+	def := distro.ImageTypeDefinition{
+		Name:        "vhd-tuned",
+		Base:        "vhd",
+		DefaultSize: 8 * 1024 * 1024 * 1024,
+		ImageConfig: &distro.ImageConfig{
+			Tuned: osbuild.NewTunedStageOptions("throughput-performance"),
+		},
+	}
+	require.NoError(t, arch.(distro.ImageTypeDeriver).DeriveImageType(def))
+	require.Error(t, arch.(distro.ImageTypeDeriver).DeriveImageType(def))
+
+	imgType, err := arch.GetImageType("vhd-tuned")
+	require.NoError(t, err)
+	assert.Equal(t, "disk.vhd", imgType.Filename())
+	assert.Equal(t, uint64(8*1024*1024*1024), imgType.Size(0))
+	// sizes are still rounded up like the ones of vhd images
+	assert.Equal(t, uint64(2*1024*1024), imgType.Size(1024*1024+1))
+
+	manifest, err := imgType.Manifest(nil, distro.ImageOptions{Size: imgType.Size(0)}, nil, nil, 0)
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), `"type":"org.osbuild.tuned"`)
+
+	// the base image type is not changed
+	baseType, err := arch.GetImageType("vhd")
+	require.NoError(t, err)
+	manifest, err = baseType.Manifest(nil, distro.ImageOptions{Size: baseType.Size(0)}, nil, nil, 0)
+	require.NoError(t, err)
+	assert.NotContains(t, string(manifest), `"type":"org.osbuild.tuned"`)
+}
+
+func TestDistro_LiveISO(t *testing.T) {
+	r9distro := rhel90.New()
+	arch, err := r9distro.GetArch("x86_64")
+	require.NoError(t, err)
+	imgType, err := arch.GetImageType("live-iso")
+	require.NoError(t, err)
+
+	customizations := &blueprint.Customizations{
+		Hostname: common.StringToPtr("kiosk"),
+		Kernel: &blueprint.KernelCustomization{
+			Append: "quiet",
+		},
+	}
+	manifest, err := imgType.Manifest(customizations, distro.ImageOptions{Size: imgType.Size(0)}, nil, nil, 0)
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), `"type":"org.osbuild.hostname"`)
+	assert.Contains(t, string(manifest), `"add_modules":["dmsquash-live"]`)
+	assert.Contains(t, string(manifest), `"kernel_opts":"root=live:CDLABEL=RHEL-9-0-0-BaseOS-x86_64 rd.live.image quiet"`)
+	assert.Contains(t, string(manifest), `"references":["name:os"]`)
+}
+
+func TestDistro_MinimalRaw(t *testing.T) {
+	r9distro := rhel90.New()
+	x86_64, err := r9distro.GetArch("x86_64")
+	require.NoError(t, err)
+	imgType, err := x86_64.GetImageType("minimal-raw")
+	require.NoError(t, err)
+
+	tests := []struct {
+		options  distro.ImageOptions
+		filename string
+		mimeType string
+		stages   []string
+		absent   []string
+	}{
+		{
+			options:  distro.ImageOptions{},
+			filename: "disk.raw.xz",
+			mimeType: "application/xz",
+			stages:   []string{"org.osbuild.xz", "org.osbuild.grub2.inst"},
+		},
+		{
+			options:  distro.ImageOptions{BootType: distro.UEFIBootType, Compression: distro.ZstdCompression},
+			filename: "disk.raw.zst",
+			mimeType: "application/zstd",
+			stages:   []string{"org.osbuild.zstd"},
+			absent:   []string{"org.osbuild.grub2.inst"},
+		},
+		{
+			options:  distro.ImageOptions{BootType: distro.LegacyBootType, Compression: distro.NoCompression},
+			filename: "disk.raw",
+			mimeType: "application/octet-stream",
+			stages:   []string{"org.osbuild.copy", "org.osbuild.grub2.inst"},
+			absent:   []string{"org.osbuild.xz", "org.osbuild.zstd"},
+		},
+	}
+	for _, tt := range tests {
+		tt.options.Size = imgType.Size(0)
+		assert.Equal(t, tt.filename, distro.ImageFilename(imgType, tt.options))
+		assert.Equal(t, tt.mimeType, distro.ImageMIMEType(imgType, tt.options))
+		manifest, err := imgType.Manifest(nil, tt.options, nil, nil, 0)
+		require.NoError(t, err)
+		assert.Contains(t, string(manifest), fmt.Sprintf(`"filename":"%s"`, tt.filename))
+		for _, stage := range tt.stages {
+			assert.Contains(t, string(manifest), fmt.Sprintf(`"type":"%s"`, stage))
+		}
+		for _, stage := range tt.absent {
+			assert.NotContains(t, string(manifest), fmt.Sprintf(`"type":"%s"`, stage))
+		}
+	}
+
+	_, err = imgType.Manifest(nil, distro.ImageOptions{Compression: "lz4"}, nil, nil, 0)
+	assert.EqualError(t, err, `unknown compression "lz4"`)
+
+	aarch64, err := r9distro.GetArch("aarch64")
+	require.NoError(t, err)
+	imgType, err = aarch64.GetImageType("minimal-raw")
+	require.NoError(t, err)
+	_, err = imgType.Manifest(nil, distro.ImageOptions{BootType: distro.LegacyBootType}, nil, nil, 0)
+	assert.EqualError(t, err, `boot type "legacy" is not supported on aarch64`)
+
+	imgType, err = x86_64.GetImageType("qcow2")
+	require.NoError(t, err)
+	_, err = imgType.Manifest(nil, distro.ImageOptions{BootType: distro.UEFIBootType}, nil, nil, 0)
+	assert.EqualError(t, err, `image type "qcow2" does not support selecting the boot type`)
+}
+
+func TestDistro_WSL(t *testing.T) {
+	r9distro := rhel90.New()
+	arch, err := r9distro.GetArch("x86_64")
+	require.NoError(t, err)
+	imgType, err := arch.GetImageType("wsl")
+	require.NoError(t, err)
+
+	bp := blueprint.Blueprint{
+		Customizations: &blueprint.Customizations{
+			User: []blueprint.UserCustomization{
+				{Name: "developer"},
+				{Name: "admin"},
+			},
+		},
+	}
+	packageSets := imgType.PackageSets(bp)
+	assert.NotContains(t, packageSets["packages"].Include, "kernel")
+
+	manifest, err := imgType.Manifest(bp.Customizations, distro.ImageOptions{}, nil, nil, 0)
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), `{"type":"org.osbuild.wsl.conf","options":{"boot":{"systemd":true},"user":{"default":"developer"}}}`)
+	assert.Contains(t, string(manifest), `"filename":"image.tar.gz"`)
+	assert.NotContains(t, string(manifest), `"type":"org.osbuild.grub2"`)
+}
+
+func TestDistro_Container(t *testing.T) {
+	r9distro := rhel90.New()
+	arch, err := r9distro.GetArch("aarch64")
+	require.NoError(t, err)
+	imgType, err := arch.GetImageType("container")
+	require.NoError(t, err)
+
+	bp := blueprint.Blueprint{}
+	packageSets := imgType.PackageSets(bp)
+	assert.NotContains(t, packageSets["packages"].Include, "kernel")
+
+	manifest, err := imgType.Manifest(nil, distro.ImageOptions{}, nil, nil, 0)
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), `"options":{"architecture":"aarch64","filename":"container.tar"}`)
+	assert.Contains(t, string(manifest), `"references":["name:os"]`)
+	assert.NotContains(t, string(manifest), `"type":"org.osbuild.grub2"`)
+
+	customizations := &blueprint.Customizations{
+		Container: &blueprint.ContainerCustomization{
+			Entrypoint:   []string{"/usr/bin/bash", "-c"},
+			Env:          []string{"LANG=C.UTF-8"},
+			ExposedPorts: []string{"8080/tcp"},
+			Labels:       map[string]string{"name": "base"},
+			Architecture: "arm64",
+		},
+	}
+	manifest, err = imgType.Manifest(customizations, distro.ImageOptions{}, nil, nil, 0)
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), `"options":{"architecture":"arm64","filename":"container.tar","config":{"Entrypoint":["/usr/bin/bash","-c"],"Env":["LANG=C.UTF-8"],"ExposedPorts":["8080/tcp"],"Labels":{"name":"base"}}}`)
+
+	// container customizations are rejected for other image types
+	imgType, err = arch.GetImageType("qcow2")
+	require.NoError(t, err)
+	_, err = imgType.Manifest(customizations, distro.ImageOptions{Size: imgType.Size(0)}, nil, nil, 0)
+	assert.EqualError(t, err, `image type "qcow2" does not support container customizations`)
+}
+
+func TestDistro_CloudProviders(t *testing.T) {
+	r9distro := rhel90.New()
+	arch, err := r9distro.GetArch("x86_64")
+	require.NoError(t, err)
+
+	tests := []struct {
+		imgType    string
+		ntpServer  string
+		datasource string
+	}{
+		{"oci", "169.254.169.254", `["Oracle","None"]`},
+		{"alibaba", "ntp.cloud.aliyuncs.com", `["AliYun","None"]`},
+		{"ibm-cloud", "161.26.0.6", `["ConfigDrive","NoCloud","None"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.imgType, func(t *testing.T) {
+			imgType, err := arch.GetImageType(tt.imgType)
+			require.NoError(t, err)
+
+			manifest, err := imgType.Manifest(nil, distro.ImageOptions{Size: imgType.Size(0)}, nil, nil, 0)
+			require.NoError(t, err)
+			assert.Contains(t, string(manifest), fmt.Sprintf(`"hostname":"%s"`, tt.ntpServer))
+			assert.Contains(t, string(manifest), fmt.Sprintf(`"datasource_list":%s`, tt.datasource))
+			assert.Contains(t, string(manifest), fmt.Sprintf(`"filename":"%s.conf"`, tt.imgType))
+			assert.Contains(t, string(manifest), `"blacklist","modulename":"nouveau"`)
+		})
+	}
+}
+
+func TestDistro_AzureGen2(t *testing.T) {
+	r9distro := rhel90.New()
+	arch, err := r9distro.GetArch("x86_64")
+	require.NoError(t, err)
+	imgType, err := arch.GetImageType("azure-gen2")
+	require.NoError(t, err)
+
+	packageSets := imgType.PackageSets(blueprint.Blueprint{})
+	assert.Contains(t, packageSets["packages"].Include, "shim-x64")
+	assert.NotContains(t, packageSets["packages"].Include, "grub2-pc")
+
+	manifest, err := imgType.Manifest(nil, distro.ImageOptions{Size: imgType.Size(0)}, nil, nil, 0)
+	require.NoError(t, err)
+	assert.NotContains(t, string(manifest), `"type":"org.osbuild.grub2.inst"`)
+	assert.NotContains(t, string(manifest), `"legacy":"i386-pc"`)
+	assert.NotContains(t, string(manifest), disk.BIOSBootPartitionGUID)
+	assert.Contains(t, string(manifest), `"kernel_opts":"ro console=tty1 console=ttyS0 earlyprintk=ttyS0 rootdelay=300"`)
+	assert.Contains(t, string(manifest), `{"type":"org.osbuild.waagent.conf","options":{"config":{"Provisioning.UseCloudInit":true,"Provisioning.Enabled":false,"ResourceDisk.Format":false,"ResourceDisk.EnableSwap":false}}}`)
+	assert.Contains(t, string(manifest), `{"key":{"name":"ENV","arg":"NM_UNMANAGED"},"op":"=","val":"1"}`)
+	assert.Contains(t, string(manifest), `"add_drivers":["hv_vmbus","hv_netvsc","hv_storvsc"]`)
+
+	// the vhd image type keeps booting with both BIOS and UEFI
+	imgType, err = arch.GetImageType("vhd")
+	require.NoError(t, err)
+	manifest, err = imgType.Manifest(nil, distro.ImageOptions{Size: imgType.Size(0)}, nil, nil, 0)
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), `"type":"org.osbuild.grub2.inst"`)
+}
+
+func TestDistro_Vagrant(t *testing.T) {
+	r9distro := rhel90.New()
+	arch, err := r9distro.GetArch("x86_64")
+	require.NoError(t, err)
+
+	for _, provider := range []string{"libvirt", "virtualbox"} {
+		imgType, err := arch.GetImageType("vagrant-" + provider)
+		require.NoError(t, err)
+
+		manifest, err := imgType.Manifest(nil, distro.ImageOptions{Size: imgType.Size(0)}, nil, nil, 0)
+		require.NoError(t, err)
+		assert.Contains(t, string(manifest), `"type":"org.osbuild.vagrant","inputs":{"image":`)
+		assert.Contains(t, string(manifest), fmt.Sprintf(`"options":{"provider":"%s"}`, provider))
+		assert.Contains(t, string(manifest), `"type":"org.osbuild.sudoers","options":{"filename":"vagrant","config":["vagrant ALL=(ALL) NOPASSWD: ALL"]}`)
+		assert.NotContains(t, string(manifest), `"type":"org.osbuild.tmpfilesd"`)
+		assert.Contains(t, string(manifest), `"key":"ssh-rsa AAAAB3NzaC1yc2EAAAABIwAAAQEA6NF8`)
+		assert.Contains(t, string(manifest), fmt.Sprintf(`"filename":"vagrant-%s.box"`, provider))
+	}
+
+	// the vagrant user of the blueprint is kept
+	imgType, err := arch.GetImageType("vagrant-libvirt")
+	require.NoError(t, err)
+	customizations := &blueprint.Customizations{
+		User: []blueprint.UserCustomization{
+			{Name: "vagrant", Key: common.StringToPtr("ssh-ed25519 AAAA")},
+		},
+	}
+	manifest, err := imgType.Manifest(customizations, distro.ImageOptions{Size: imgType.Size(0)}, nil, nil, 0)
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), `"vagrant":{"key":"ssh-ed25519 AAAA"}`)
+}
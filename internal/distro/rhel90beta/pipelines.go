@@ -717,7 +717,13 @@ func osPipeline(repos []rpmmd.RepoConfig, packages []rpmmd.PackageSpec, bpPackag
 	p.Name = "os"
 	p.Build = "name:build"
 	packages = append(packages, bpPackages...)
-	p.AddStage(osbuild.NewRPMStage(rpmStageOptions(repos), rpmStageInputs(packages)))
+	p.AddStage(osbuild.NewRPMStage(osRPMStageOptions(repos, c.GetInstall()), rpmStageInputs(packages)))
+
+	// keep the module streams of the blueprint on updates
+	for _, module := range c.GetModuleStreams() {
+		p.AddStage(osbuild.NewDNFModuleConfigStage(dnfModuleConfigStageOptions(module)))
+	}
+
 	p.AddStage(osbuild.NewFixBLSStage(&osbuild.FixBLSStageOptions{}))
 	language, keyboard := c.GetPrimaryLocale()
 	if language != nil {
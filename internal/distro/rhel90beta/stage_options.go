@@ -21,10 +21,7 @@ const (
 func rpmStageOptions(repos []rpmmd.RepoConfig) *osbuild.RPMStageOptions {
 	var gpgKeys []string
 	for _, repo := range repos {
-		if repo.GPGKey == "" {
-			continue
-		}
-		gpgKeys = append(gpgKeys, repo.GPGKey)
+		gpgKeys = append(gpgKeys, repo.GPGKeys...)
 	}
 
 	return &osbuild.RPMStageOptions{
@@ -32,6 +29,30 @@ func rpmStageOptions(repos []rpmmd.RepoConfig) *osbuild.RPMStageOptions {
 	}
 }
 
+// osRPMStageOptions returns the options of the rpm stage installing the OS
+// packages, which follow the install customization of the blueprint.
+func osRPMStageOptions(repos []rpmmd.RepoConfig, install *blueprint.InstallCustomization) *osbuild.RPMStageOptions {
+	options := rpmStageOptions(repos)
+	if install.ExcludeDocs {
+		options.Exclude = &osbuild.Exclude{Docs: true}
+	}
+	options.InstallLangs = install.InstallLangs
+	return options
+}
+
+func dnfModuleConfigStageOptions(module blueprint.ModuleStreamCustomization) *osbuild.DNFModuleConfigStageOptions {
+	conf := &osbuild.DNFModuleConfig{
+		Name:     module.Name,
+		Stream:   module.Stream,
+		State:    module.State,
+		Profiles: module.Profiles,
+	}
+	if conf.Profiles == nil {
+		conf.Profiles = []string{}
+	}
+	return &osbuild.DNFModuleConfigStageOptions{Conf: conf}
+}
+
 // selinuxStageOptions returns the options for the org.osbuild.selinux stage.
 // Setting the argument to 'true' relabels the '/usr/bin/cp' and '/usr/bin/tar'
 // binaries with 'install_exec_t'. This should be set in the build root.
@@ -0,0 +1,50 @@
+package distro
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	osbuild "github.com/osbuild/osbuild-composer/internal/osbuild2"
+	"github.com/osbuild/osbuild-composer/internal/rpmmd"
+)
+
+// CurlSourceItem returns the item of the curl source downloading pkg. Only
+// the name of the secrets provider is passed, osbuild resolves the secrets
+// on the worker.
+func CurlSourceItem(pkg rpmmd.PackageSpec) osbuild.CurlSourceItem {
+	item := &osbuild.URLWithSecrets{
+		URL: pkg.RemoteLocation,
+	}
+	if pkg.Secrets != "" {
+		item.Secrets = &osbuild.URLSecrets{
+			Name: pkg.Secrets,
+		}
+	}
+	return item
+}
+
+// AddPackageSources adds the sources of packages to sources. Packages with a
+// local path are embedded into the manifest by an inline source, all others
+// are downloaded by a curl source.
+func AddPackageSources(sources osbuild.Sources, packages []rpmmd.PackageSpec) error {
+	curl := &osbuild.CurlSource{Items: make(map[string]osbuild.CurlSourceItem)}
+	inline := &osbuild.InlineSource{Items: make(map[string]osbuild.InlineSourceItem)}
+	for _, pkg := range packages {
+		if pkg.Path == "" {
+			curl.Items[pkg.Checksum] = CurlSourceItem(pkg)
+			continue
+		}
+		data, err := ioutil.ReadFile(pkg.Path)
+		if err != nil {
+			return fmt.Errorf("cannot embed package %s: %v", pkg.Name, err)
+		}
+		inline.Items[pkg.Checksum] = osbuild.NewInlineSourceItem(data)
+	}
+	if len(curl.Items) > 0 {
+		sources["org.osbuild.curl"] = curl
+	}
+	if len(inline.Items) > 0 {
+		sources["org.osbuild.inline"] = inline
+	}
+	return nil
+}
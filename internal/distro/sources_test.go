@@ -0,0 +1,58 @@
+package distro
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	osbuild "github.com/osbuild/osbuild-composer/internal/osbuild2"
+	"github.com/osbuild/osbuild-composer/internal/rpmmd"
+)
+
+func TestCurlSourceItem(t *testing.T) {
+	assert.Equal(t, &osbuild.URLWithSecrets{
+		URL: "https://example.com/a.rpm",
+	}, CurlSourceItem(rpmmd.PackageSpec{RemoteLocation: "https://example.com/a.rpm"}))
+
+	assert.Equal(t, &osbuild.URLWithSecrets{
+		URL:     "https://cdn.example.com/b.rpm",
+		Secrets: &osbuild.URLSecrets{Name: "org.osbuild.rhsm"},
+	}, CurlSourceItem(rpmmd.PackageSpec{RemoteLocation: "https://cdn.example.com/b.rpm", Secrets: "org.osbuild.rhsm"}))
+
+	assert.Equal(t, &osbuild.URLWithSecrets{
+		URL:     "https://internal.example.com/c.rpm",
+		Secrets: &osbuild.URLSecrets{Name: "org.osbuild.mtls"},
+	}, CurlSourceItem(rpmmd.PackageSpec{RemoteLocation: "https://internal.example.com/c.rpm", Secrets: "org.osbuild.mtls"}))
+}
+
+func TestAddPackageSources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "local.rpm")
+	require.NoError(t, ioutil.WriteFile(path, []byte("rpm"), 0600))
+
+	sources := osbuild.Sources{}
+	err := AddPackageSources(sources, []rpmmd.PackageSpec{
+		{Name: "remote", RemoteLocation: "https://example.com/remote.rpm", Checksum: "sha256:01"},
+		{Name: "local", RemoteLocation: "file://" + path, Path: path, Checksum: "sha256:02"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, osbuild.Sources{
+		"org.osbuild.curl": &osbuild.CurlSource{
+			Items: map[string]osbuild.CurlSourceItem{
+				"sha256:01": &osbuild.URLWithSecrets{URL: "https://example.com/remote.rpm"},
+			},
+		},
+		"org.osbuild.inline": &osbuild.InlineSource{
+			Items: map[string]osbuild.InlineSourceItem{
+				"sha256:02": {Encoding: "base64", Data: "cnBt"},
+			},
+		},
+	}, sources)
+
+	err = AddPackageSources(osbuild.Sources{}, []rpmmd.PackageSpec{
+		{Name: "missing", Path: filepath.Join(t.TempDir(), "missing.rpm"), Checksum: "sha256:03"},
+	})
+	assert.Error(t, err)
+}
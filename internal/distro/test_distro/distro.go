@@ -141,6 +141,16 @@ func (a *TestArch) addImageTypes(imageTypes ...TestImageType) {
 	}
 }
 
+// DeriveImageType adds an image type named after def, which behaves like the
+// base image type.
+func (a *TestArch) DeriveImageType(def distro.ImageTypeDefinition) error {
+	if _, err := a.GetImageType(def.Base); err != nil {
+		return err
+	}
+	a.addImageTypes(TestImageType{name: def.Name})
+	return nil
+}
+
 // TestImageType
 
 func (t *TestImageType) Name() string {
@@ -0,0 +1,82 @@
+// Package vagrant provides the configuration shared by the Vagrant box image
+// types of the distributions.
+package vagrant
+
+import (
+	"github.com/osbuild/osbuild-composer/internal/blueprint"
+	"github.com/osbuild/osbuild-composer/internal/distro"
+	osbuild "github.com/osbuild/osbuild-composer/internal/osbuild2"
+)
+
+const (
+	// User is the user vagrant logs in as
+	User = "vagrant"
+
+	// PasswordHash is the crypted well-known password "vagrant". It is
+	// crypted up front to keep the manifests reproducible.
+	PasswordHash = "$6$vagrantsalt$tx6cR8EhN6G4kZRctafCOQJHk2AIXYKB5lE1Kdne2mx.OJ8g5fp.ybguoF7MTC7OaU4rxLaxJN2jJF7aSua5d0"
+
+	// InsecureKey is the well-known key, which vagrant replaces on the
+	// first login
+	InsecureKey = "ssh-rsa AAAAB3NzaC1yc2EAAAABIwAAAQEA6NF8iallvQVp22WDkTkyrtvp9eWW6A8YVr+kz4TjGYe7gHzIw+niNltGEFHzD8+v1I2YJ6oXevct1YeS0o9HZyN1Q9qgCgzUFtdOKLv6IedplqoPkcmF0aYet2PkEDo3MlTBckFXPITAMzF8dJSIFo9D8HfdOV0IAdx4O7PtixWKn5y2hMNG0zQPyUecp4pzC6kivAIhyfHilFR61RGL+GPXQ2MWZWFYbAGjyiYJnAmCP3NOTd0jMZEnDkbUvxhMmBYSdETk1rRgm+R4LOzFUGaHqHDLKLX+FIPKcF96hrucXzcWyLbIbEgE98OHlnVYCzRdK8jlqm8tehUc9c9WhQ== vagrant insecure public key"
+)
+
+// Packages are the packages vagrant needs to log in to the box and to sync
+// folders with it
+var Packages = []string{
+	"openssh-server",
+	"sudo",
+}
+
+// ImageConfig returns the image configuration of Vagrant boxes, which lets
+// the vagrant user log in over ssh and run any command with sudo
+func ImageConfig() *distro.ImageConfig {
+	return &distro.ImageConfig{
+		DefaultTarget:   "multi-user.target",
+		EnabledServices: []string{"sshd"},
+		Sudoers: []*osbuild.SudoersStageOptions{
+			{
+				Filename: User,
+				Config:   []string{User + " ALL=(ALL) NOPASSWD: ALL"},
+			},
+		},
+	}
+}
+
+// Customizations adds the vagrant user, which vagrant logs in as with its
+// insecure key, to the customizations, unless they define it already
+func Customizations(c *blueprint.Customizations) *blueprint.Customizations {
+	for _, user := range c.GetUsers() {
+		if user.Name == User {
+			return c
+		}
+	}
+
+	customizations := blueprint.Customizations{}
+	if c != nil {
+		customizations = *c
+	}
+	password := PasswordHash
+	key := InsecureKey
+	customizations.User = append(append([]blueprint.UserCustomization{}, customizations.User...), blueprint.UserCustomization{
+		Name:     User,
+		Password: &password,
+		Key:      &key,
+	})
+	return &customizations
+}
+
+// Pipeline returns the pipeline, which assembles the box of the provider
+// from the image in the input pipeline
+func Pipeline(inputPipelineName, inputFilename string, provider osbuild.VagrantProvider) *osbuild.Pipeline {
+	p := new(osbuild.Pipeline)
+	p.Name = "vagrant"
+	p.Build = "name:build"
+
+	p.AddStage(osbuild.NewVagrantStage(
+		&osbuild.VagrantStageOptions{Provider: provider},
+		osbuild.NewVagrantStageInputs(osbuild.NewFilesInputReferencesPipeline(inputPipelineName, inputFilename)),
+	))
+
+	return p
+}
@@ -0,0 +1,37 @@
+package vagrant
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/osbuild-composer/internal/blueprint"
+	"github.com/osbuild/osbuild-composer/internal/common"
+	"github.com/osbuild/osbuild-composer/internal/crypt"
+)
+
+func TestCustomizations(t *testing.T) {
+	// the vagrant user is added with the insecure key
+	c := Customizations(nil)
+	require.Len(t, c.GetUsers(), 1)
+	assert.Equal(t, User, c.GetUsers()[0].Name)
+	assert.Equal(t, InsecureKey, *c.GetUsers()[0].Key)
+	assert.True(t, crypt.PasswordIsCrypted(*c.GetUsers()[0].Password))
+
+	// next to the users of the blueprint, which are not modified
+	bp := &blueprint.Customizations{
+		User: []blueprint.UserCustomization{{Name: "user"}},
+	}
+	c = Customizations(bp)
+	require.Len(t, c.GetUsers(), 2)
+	assert.Equal(t, "user", c.GetUsers()[0].Name)
+	assert.Equal(t, User, c.GetUsers()[1].Name)
+	assert.Len(t, bp.GetUsers(), 1)
+
+	// the vagrant user of the blueprint is kept
+	bp = &blueprint.Customizations{
+		User: []blueprint.UserCustomization{{Name: User, Key: common.StringToPtr("ssh-ed25519 AAAA")}},
+	}
+	assert.Equal(t, bp, Customizations(bp))
+}
@@ -3,9 +3,14 @@ package distroregistry
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
+	"unicode"
+
+	"github.com/gobwas/glob"
 
 	"github.com/osbuild/osbuild-composer/internal/distro"
+	"github.com/osbuild/osbuild-composer/internal/distro/fedora"
 	"github.com/osbuild/osbuild-composer/internal/distro/fedora33"
 	"github.com/osbuild/osbuild-composer/internal/distro/rhel8"
 	"github.com/osbuild/osbuild-composer/internal/distro/rhel84"
@@ -19,9 +24,9 @@ import (
 // Note that this is a constant, do not write to this array.
 var supportedDistros = []supportedDistro{
 	{fedora33.New, fedora33.NewHostDistro},
-	{fedora33.NewF34, fedora33.NewHostDistro},
-	{fedora33.NewF35, fedora33.NewHostDistro},
-	{fedora33.NewF36, fedora33.NewHostDistro},
+	{fedora.NewF34, fedora.NewHostDistro},
+	{fedora.NewF35, fedora.NewHostDistro},
+	{fedora.NewF36, fedora.NewHostDistro},
 	{rhel8.New, rhel8.NewHostDistro},
 	{rhel84.New, rhel84.NewHostDistro},
 	{rhel85.New, rhel85.NewHostDistro},
@@ -39,7 +44,12 @@ type supportedDistro struct {
 
 type Registry struct {
 	distros    map[string]distro.Distro
+	aliases    map[string]string
 	hostDistro distro.Distro
+
+	// base image types of the user-defined image types, by distro,
+	// architecture and name
+	userImageTypes map[string]string
 }
 
 func New(hostDistro distro.Distro, distros ...distro.Distro) (*Registry, error) {
@@ -92,7 +102,13 @@ func NewDefault() *Registry {
 	return registry
 }
 
+// GetDistro returns the distro with the given name or alias, or nil if
+// there is no such distro.
 func (r *Registry) GetDistro(name string) distro.Distro {
+	if target, ok := r.aliases[name]; ok {
+		name = target
+	}
+
 	d, ok := r.distros[name]
 	if !ok {
 		return nil
@@ -111,6 +127,100 @@ func (r *Registry) List() []string {
 	return list
 }
 
+// SetAliases replaces the distro aliases of the Registry. Each alias maps to
+// either the name of a distro or a glob pattern, such as "rhel-8*", which
+// resolves to the newest matching distro that is not a beta release. Aliases
+// must not have the name of a distro.
+func (r *Registry) SetAliases(aliases map[string]string) error {
+	resolved := make(map[string]string, len(aliases))
+
+	names := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+
+	for _, alias := range names {
+		target := aliases[alias]
+		if alias == "" {
+			return fmt.Errorf("distro alias for %q has an empty name", target)
+		}
+		if _, exists := r.distros[alias]; exists {
+			return fmt.Errorf("distro alias %q has the name of a distro", alias)
+		}
+		if _, isAlias := aliases[target]; isAlias {
+			return fmt.Errorf("distro alias %q: target %q is an alias itself", alias, target)
+		}
+
+		if _, ok := r.distros[target]; ok {
+			resolved[alias] = target
+			continue
+		}
+
+		pattern, err := glob.Compile(target)
+		if err != nil {
+			return fmt.Errorf("distro alias %q: invalid pattern %q: %v", alias, target, err)
+		}
+		newest := ""
+		for name := range r.distros {
+			if strings.HasSuffix(name, "-beta") {
+				continue
+			}
+			if pattern.Match(name) && (newest == "" || versionLess(newest, name)) {
+				newest = name
+			}
+		}
+		if newest == "" {
+			return fmt.Errorf("distro alias %q: no distribution matches %q", alias, target)
+		}
+		resolved[alias] = newest
+	}
+
+	r.aliases = resolved
+	return nil
+}
+
+// Aliases returns a map of all distro aliases to the names of the distros
+// they resolve to.
+func (r *Registry) Aliases() map[string]string {
+	aliases := make(map[string]string, len(r.aliases))
+	for alias, target := range r.aliases {
+		aliases[alias] = target
+	}
+	return aliases
+}
+
+// versionLess compares two distro names, treating runs of digits as numbers,
+// so that e.g. "rhel-8" < "rhel-86" < "rhel-90" and "fedora-9" < "fedora-36".
+func versionLess(a, b string) bool {
+	for a != "" && b != "" {
+		var chunkA, chunkB string
+		chunkA, a = nextVersionChunk(a)
+		chunkB, b = nextVersionChunk(b)
+		if chunkA == chunkB {
+			continue
+		}
+
+		numA, errA := strconv.Atoi(chunkA)
+		numB, errB := strconv.Atoi(chunkB)
+		if errA == nil && errB == nil {
+			return numA < numB
+		}
+		return chunkA < chunkB
+	}
+	return len(a) < len(b)
+}
+
+// nextVersionChunk splits off the leading run of either digits or non-digits.
+func nextVersionChunk(s string) (string, string) {
+	isDigit := unicode.IsDigit(rune(s[0]))
+	i := 1
+	for i < len(s) && unicode.IsDigit(rune(s[i])) == isDigit {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
 func mangleHostDistroName(name string, isBeta, isStream bool) string {
 	hostDistroName := name
 	if strings.HasPrefix(hostDistroName, "rhel-8") {
@@ -134,3 +244,82 @@ func mangleHostDistroName(name string, isBeta, isStream bool) string {
 func (r *Registry) FromHost() distro.Distro {
 	return r.hostDistro
 }
+
+// AddImageTypes adds the user-defined image types to the distributions and
+// architectures they are defined for. An image type defined for all
+// distributions is only added to those which have its base image type and
+// support deriving image types from it.
+func (r *Registry) AddImageTypes(defs []distro.ImageTypeDefinition) error {
+	distros := make([]distro.Distro, 0, len(r.distros)+1)
+	for _, name := range r.List() {
+		distros = append(distros, r.distros[name])
+	}
+	if r.hostDistro != nil {
+		distros = append(distros, r.hostDistro)
+	}
+
+	for _, def := range defs {
+		for _, name := range def.Distros {
+			if r.GetDistro(name) == nil {
+				return fmt.Errorf("image type %q: unknown distribution %q", def.Name, name)
+			}
+		}
+
+		added := 0
+		for _, d := range distros {
+			if len(def.Distros) > 0 && !contains(def.Distros, d.Name()) {
+				continue
+			}
+			for _, archName := range d.ListArches() {
+				if len(def.Arches) > 0 && !contains(def.Arches, archName) {
+					continue
+				}
+				arch, _ := d.GetArch(archName)
+				if _, err := arch.GetImageType(def.Base); err != nil {
+					continue
+				}
+				deriver, ok := arch.(distro.ImageTypeDeriver)
+				if !ok {
+					if len(def.Distros) > 0 {
+						return fmt.Errorf("image type %q: %s does not support user-defined image types", def.Name, d.Name())
+					}
+					continue
+				}
+				err := deriver.DeriveImageType(def)
+				if err != nil {
+					return fmt.Errorf("image type %q: %s/%s: %v", def.Name, d.Name(), archName, err)
+				}
+				if r.userImageTypes == nil {
+					r.userImageTypes = make(map[string]string)
+				}
+				r.userImageTypes[userImageTypeKey(d.Name(), archName, def.Name)] = def.Base
+				added++
+			}
+		}
+		if added == 0 {
+			return fmt.Errorf("image type %q: no distribution supports deriving it from %q", def.Name, def.Base)
+		}
+	}
+	return nil
+}
+
+// UserImageTypeBase returns the name of the image type the user-defined
+// image type name of the distro and arch is derived from, and whether there
+// is such a user-defined image type.
+func (r *Registry) UserImageTypeBase(distroName, archName, name string) (string, bool) {
+	base, ok := r.userImageTypes[userImageTypeKey(distroName, archName, name)]
+	return base, ok
+}
+
+func userImageTypeKey(distroName, archName, name string) string {
+	return distroName + "/" + archName + "/" + name
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
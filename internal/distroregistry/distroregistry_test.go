@@ -5,8 +5,10 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/osbuild/osbuild-composer/internal/blueprint"
 	"github.com/osbuild/osbuild-composer/internal/distro"
 	"github.com/osbuild/osbuild-composer/internal/distro/rhel8"
+	"github.com/osbuild/osbuild-composer/internal/distro/rhel90"
 )
 
 // Test that all distros are registered properly and that Registry.List() works.
@@ -119,3 +121,112 @@ func TestRegistry_FromHost(t *testing.T) {
 		require.Equal(t, gotDistro.Name(), mangledName)
 	})
 }
+
+func TestRegistry_AddImageTypes(t *testing.T) {
+	newRegistry := func() *Registry {
+		reg, err := New(nil, rhel8.New(), rhel90.New())
+		require.NoError(t, err)
+		return reg
+	}
+
+	reg := newRegistry()
+	err := reg.AddImageTypes([]distro.ImageTypeDefinition{
+		{
+			Name:            "qcow2-agent",
+			Base:            "qcow2",
+			Arches:          []string{"x86_64"},
+			Packages:        []string{"example-agent"},
+			EnabledServices: []string{"example-agent.service"},
+		},
+	})
+	require.NoError(t, err)
+
+	arch, err := reg.GetDistro("rhel-90").GetArch("x86_64")
+	require.NoError(t, err)
+	require.Contains(t, arch.ListImageTypes(), "qcow2-agent")
+	imageType, err := arch.GetImageType("qcow2-agent")
+	require.NoError(t, err)
+	require.Equal(t, "qcow2-agent", imageType.Name())
+	require.Equal(t, "disk.qcow2", imageType.Filename())
+	require.Contains(t, imageType.PackageSets(blueprint.Blueprint{})["packages"].Include, "example-agent")
+	base, ok := reg.UserImageTypeBase("rhel-90", "x86_64", "qcow2-agent")
+	require.True(t, ok)
+	require.Equal(t, "qcow2", base)
+	_, ok = reg.UserImageTypeBase("rhel-90", "x86_64", "qcow2")
+	require.False(t, ok)
+
+	// only derived for the requested arches
+	arch, err = reg.GetDistro("rhel-90").GetArch("aarch64")
+	require.NoError(t, err)
+	require.NotContains(t, arch.ListImageTypes(), "qcow2-agent")
+
+	// rhel-8 does not support user-defined image types
+	arch, err = reg.GetDistro("rhel-8").GetArch("x86_64")
+	require.NoError(t, err)
+	require.NotContains(t, arch.ListImageTypes(), "qcow2-agent")
+
+	for _, def := range []distro.ImageTypeDefinition{
+		{Name: "no-base", Base: "toucan"},
+		{Name: "qcow2-rhel8", Base: "qcow2", Distros: []string{"rhel-8"}},
+		{Name: "qcow2-toucan", Base: "qcow2", Distros: []string{"toucan-os"}},
+		{Name: "vmdk", Base: "qcow2"},
+	} {
+		require.Error(t, newRegistry().AddImageTypes([]distro.ImageTypeDefinition{def}), def.Name)
+	}
+}
+
+func TestRegistry_SetAliases(t *testing.T) {
+	distros := NewDefault()
+
+	err := distros.SetAliases(map[string]string{
+		"rhel":            "rhel-*",
+		"rhel-9":          "rhel-9*",
+		"fedora":          "fedora-*",
+		"centos-stream-8": "centos-8",
+	})
+	require.NoError(t, err)
+
+	// the patterns resolve to the newest distro which is not a beta release,
+	// rhel-90-beta matches rhel-9* and sorts after rhel-90
+	require.Equal(t, map[string]string{
+		"rhel":            "rhel-90",
+		"rhel-9":          "rhel-90",
+		"fedora":          "fedora-36",
+		"centos-stream-8": "centos-8",
+	}, distros.Aliases())
+
+	require.Equal(t, "rhel-90", distros.GetDistro("rhel-9").Name())
+	require.Equal(t, "fedora-36", distros.GetDistro("fedora").Name())
+	require.Equal(t, "centos-8", distros.GetDistro("centos-stream-8").Name())
+	require.Equal(t, "rhel-8", distros.GetDistro("rhel-8").Name())
+	require.Equal(t, "rhel-90-beta", distros.GetDistro("rhel-90-beta").Name())
+
+	// aliases are not listed as distros
+	require.NotContains(t, distros.List(), "rhel-9")
+	require.Contains(t, distros.List(), "rhel-90")
+
+	for name, aliases := range map[string]map[string]string{
+		"unknown distro":  {"toucan": "toucan-os"},
+		"no match":        {"toucan": "toucan-*"},
+		"invalid pattern": {"rhel": "rhel-[8"},
+		"alias of alias":  {"rhel": "rhel-8", "rhel-8": "rhel-86"},
+		"self reference":  {"rhel-86": "rhel-86"},
+		"distro name":     {"rhel-8": "rhel-8*"},
+		"empty name":      {"": "rhel-86"},
+	} {
+		require.Error(t, NewDefault().SetAliases(aliases), name)
+	}
+
+	// a failed call keeps the previous aliases
+	require.Error(t, distros.SetAliases(map[string]string{"toucan": "toucan-os"}))
+	require.Equal(t, "rhel-90", distros.GetDistro("rhel-9").Name())
+}
+
+func TestVersionLess(t *testing.T) {
+	require.True(t, versionLess("rhel-8", "rhel-86"))
+	require.True(t, versionLess("rhel-86", "rhel-90"))
+	require.True(t, versionLess("fedora-9", "fedora-36"))
+	require.True(t, versionLess("fedora-36", "fedora-100"))
+	require.False(t, versionLess("rhel-90", "rhel-86"))
+	require.False(t, versionLess("rhel-86", "rhel-86"))
+}
@@ -22,6 +22,24 @@ const (
 	ComposeStatusValueSuccess ComposeStatusValue = "success"
 )
 
+// Defines values for ImageRequestBootType.
+const (
+	ImageRequestBootTypeHybrid ImageRequestBootType = "hybrid"
+
+	ImageRequestBootTypeLegacy ImageRequestBootType = "legacy"
+
+	ImageRequestBootTypeUefi ImageRequestBootType = "uefi"
+)
+
+// Defines values for ImageRequestCompression.
+const (
+	ImageRequestCompressionNone ImageRequestCompression = "none"
+
+	ImageRequestCompressionXz ImageRequestCompression = "xz"
+
+	ImageRequestCompressionZstd ImageRequestCompression = "zstd"
+)
+
 // Defines values for ImageStatusValue.
 const (
 	ImageStatusValueBuilding ImageStatusValue = "building"
@@ -76,11 +94,19 @@ type ComposeStatusValue string
 
 // ImageRequest defines model for ImageRequest.
 type ImageRequest struct {
-	Architecture string       `json:"architecture"`
-	ImageType    string       `json:"image_type"`
-	Repositories []Repository `json:"repositories"`
+	Architecture string                   `json:"architecture"`
+	BootType     *ImageRequestBootType    `json:"boot_type,omitempty"`
+	Compression  *ImageRequestCompression `json:"compression,omitempty"`
+	ImageType    string                   `json:"image_type"`
+	Repositories []Repository             `json:"repositories"`
 }
 
+// ImageRequestBootType defines model for ImageRequest.BootType.
+type ImageRequestBootType string
+
+// ImageRequestCompression defines model for ImageRequest.Compression.
+type ImageRequestCompression string
+
 // ImageStatus defines model for ImageStatus.
 type ImageStatus struct {
 	Status ImageStatusValue `json:"status"`
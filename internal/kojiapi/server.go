@@ -113,7 +113,7 @@ func (h *apiHandlers) PostCompose(ctx echo.Context) error {
 		for j, repo := range ir.Repositories {
 			repositories[j].BaseURL = repo.Baseurl
 			if repo.Gpgkey != nil {
-				repositories[j].GPGKey = *repo.Gpgkey
+				repositories[j].GPGKeys = []string{*repo.Gpgkey}
 			}
 		}
 		bp := &blueprint.Blueprint{}
@@ -132,14 +132,22 @@ func (h *apiHandlers) PostCompose(ctx echo.Context) error {
 			packageSpecSets[name] = packageSpecs
 		}
 
-		manifest, err := imageType.Manifest(nil, distro.ImageOptions{Size: imageType.Size(0)}, repositories, packageSpecSets, manifestSeed)
+		options := distro.ImageOptions{Size: imageType.Size(0)}
+		if ir.BootType != nil {
+			options.BootType = distro.BootType(*ir.BootType)
+		}
+		if ir.Compression != nil {
+			options.Compression = distro.Compression(*ir.Compression)
+		}
+
+		manifest, err := imageType.Manifest(nil, options, rpmmd.ResolveGPGKeys(repositories, arch.Name(), d.Releasever()), packageSpecSets, manifestSeed)
 		if err != nil {
 			return echo.NewHTTPError(http.StatusBadGateway, fmt.Sprintf("Failed to get manifest for for %s/%s/%s: %s", ir.ImageType, ir.Architecture, request.Distribution, err))
 		}
 
 		imageRequests[i].manifest = manifest
 		imageRequests[i].arch = arch.Name()
-		imageRequests[i].filename = imageType.Filename()
+		imageRequests[i].filename = distro.ImageFilename(imageType, options)
 		imageRequests[i].exports = imageType.Exports()
 		imageRequests[i].pipelineNames = &worker.PipelineNames{
 			Build:   imageType.BuildPipelines(),
@@ -152,7 +160,7 @@ func (h *apiHandlers) PostCompose(ctx echo.Context) error {
 			request.Version,
 			request.Release,
 			ir.Architecture,
-			splitExtension(imageType.Filename()),
+			splitExtension(imageRequests[i].filename),
 		)
 	}
 
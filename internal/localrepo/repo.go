@@ -0,0 +1,612 @@
+// Package localrepo implements a package repository managed by composer,
+// into which single RPM packages can be uploaded. The repository metadata is
+// generated the same way createrepo does, so that dnf can use the repository
+// like any other.
+package localrepo
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/osbuild/osbuild-composer/internal/rpmmd"
+)
+
+const (
+	// ID of the source under which the repository is made available
+	SourceID   = "local-rpms"
+	SourceName = "Locally uploaded packages"
+
+	packagesDir = "Packages"
+	repodataDir = "repodata"
+)
+
+var ErrPackageNotFound = errors.New("package not found")
+
+// Repo is a local package repository in a directory.
+type Repo struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// New opens the repository in dir, creating it if it does not exist. The
+// directory is only read on the composer host: packages depsolved from the
+// repository are embedded into the manifests sent to the workers.
+func New(dir string) (*Repo, error) {
+	err := os.MkdirAll(filepath.Join(dir, packagesDir), 0755)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create local repository: %v", err)
+	}
+
+	return &Repo{dir: dir}, nil
+}
+
+// URL returns the base URL of the repository.
+func (r *Repo) URL() string {
+	return "file://" + r.dir + "/"
+}
+
+// RepoConfig returns the configuration to use the repository with dnf.
+func (r *Repo) RepoConfig() rpmmd.RepoConfig {
+	return rpmmd.RepoConfig{
+		Name:          SourceID,
+		BaseURL:       r.URL(),
+		EmbedPackages: true,
+	}
+}
+
+// List returns the metadata of all packages in the repository, sorted by
+// name.
+func (r *Repo) List() ([]*Package, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	packages, _, err := r.readPackages()
+	return packages, err
+}
+
+// Add reads an RPM package from reader and adds it to the repository,
+// replacing a package with the same file name. The repository metadata is
+// regenerated.
+func (r *Repo) Add(reader io.Reader) (*Package, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tmpfile, err := ioutil.TempFile(r.dir, "upload-*.rpm")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmpfile.Name())
+	defer tmpfile.Close()
+
+	_, err = io.Copy(tmpfile, reader)
+	if err != nil {
+		return nil, fmt.Errorf("cannot write package: %v", err)
+	}
+
+	_, err = tmpfile.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, err := ReadPackage(tmpfile)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tmpfile.Chmod(0644)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := r.packagePath(pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.Rename(tmpfile.Name(), path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot add package: %v", err)
+	}
+
+	err = r.writeMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	return pkg, nil
+}
+
+// packagePath returns the path under which pkg is stored. The file name is
+// built from header fields of an uploaded file, which must not be able to
+// point outside of the packages directory.
+func (r *Repo) packagePath(pkg *Package) (string, error) {
+	for _, field := range []string{pkg.Name, pkg.Version, pkg.Release, pkg.Arch} {
+		if strings.ContainsAny(field, "/\x00") || strings.Contains(field, "..") {
+			return "", fmt.Errorf("invalid package header: %q is not allowed in a file name", field)
+		}
+	}
+
+	dir := filepath.Join(r.dir, packagesDir)
+	path := filepath.Clean(filepath.Join(dir, pkg.Filename()))
+	if !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid package file name: %s", pkg.Filename())
+	}
+
+	return path, nil
+}
+
+// Delete removes all packages with the given name from the repository and
+// regenerates its metadata.
+func (r *Repo) Delete(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	packages, files, err := r.readPackages()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i, pkg := range packages {
+		if pkg.Name != name {
+			continue
+		}
+		err = os.Remove(files[i])
+		if err != nil {
+			return fmt.Errorf("cannot delete package: %v", err)
+		}
+		found = true
+	}
+	if !found {
+		return ErrPackageNotFound
+	}
+
+	return r.writeMetadata()
+}
+
+// readPackages reads the headers of all packages and returns them together
+// with their paths.
+func (r *Repo) readPackages() ([]*Package, []string, error) {
+	files, err := filepath.Glob(filepath.Join(r.dir, packagesDir, "*.rpm"))
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(files)
+
+	packages := make([]*Package, 0, len(files))
+	for _, path := range files {
+		pkg, err := readPackageFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot read %s: %v", filepath.Base(path), err)
+		}
+		packages = append(packages, pkg)
+	}
+
+	return packages, files, nil
+}
+
+func readPackageFile(path string) (*Package, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadPackage(f)
+}
+
+type metadataChecksum struct {
+	Type  string `xml:"type,attr"`
+	PkgID string `xml:"pkgid,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+type metadataVersion struct {
+	Epoch   string `xml:"epoch,attr"`
+	Version string `xml:"ver,attr"`
+	Release string `xml:"rel,attr"`
+}
+
+type metadataEntry struct {
+	Name    string `xml:"name,attr"`
+	Flags   string `xml:"flags,attr,omitempty"`
+	Epoch   string `xml:"epoch,attr,omitempty"`
+	Version string `xml:"ver,attr,omitempty"`
+	Release string `xml:"rel,attr,omitempty"`
+	Pre     string `xml:"pre,attr,omitempty"`
+}
+
+type metadataEntries struct {
+	Entries []metadataEntry `xml:"rpm:entry"`
+}
+
+type primaryPackage struct {
+	Type        string           `xml:"type,attr"`
+	Name        string           `xml:"name"`
+	Arch        string           `xml:"arch"`
+	Version     metadataVersion  `xml:"version"`
+	Checksum    metadataChecksum `xml:"checksum"`
+	Summary     string           `xml:"summary"`
+	Description string           `xml:"description"`
+	Packager    string           `xml:"packager"`
+	URL         string           `xml:"url"`
+	Time        struct {
+		File  int64  `xml:"file,attr"`
+		Build uint32 `xml:"build,attr"`
+	} `xml:"time"`
+	Size struct {
+		Package   int64  `xml:"package,attr"`
+		Installed uint32 `xml:"installed,attr"`
+		Archive   uint32 `xml:"archive,attr"`
+	} `xml:"size"`
+	Location struct {
+		Href string `xml:"href,attr"`
+	} `xml:"location"`
+	Format struct {
+		License     string `xml:"rpm:license"`
+		Vendor      string `xml:"rpm:vendor"`
+		Group       string `xml:"rpm:group"`
+		BuildHost   string `xml:"rpm:buildhost"`
+		SourceRPM   string `xml:"rpm:sourcerpm"`
+		HeaderRange struct {
+			Start int64 `xml:"start,attr"`
+			End   int64 `xml:"end,attr"`
+		} `xml:"rpm:header-range"`
+		Provides  *metadataEntries `xml:"rpm:provides,omitempty"`
+		Requires  *metadataEntries `xml:"rpm:requires,omitempty"`
+		Conflicts *metadataEntries `xml:"rpm:conflicts,omitempty"`
+		Obsoletes *metadataEntries `xml:"rpm:obsoletes,omitempty"`
+		Files     []string         `xml:"file"`
+	} `xml:"format"`
+}
+
+type primaryMetadata struct {
+	XMLName  xml.Name         `xml:"metadata"`
+	Xmlns    string           `xml:"xmlns,attr"`
+	XmlnsRPM string           `xml:"xmlns:rpm,attr"`
+	Count    int              `xml:"packages,attr"`
+	Packages []primaryPackage `xml:"package"`
+}
+
+type filelistsPackage struct {
+	PkgID   string          `xml:"pkgid,attr"`
+	Name    string          `xml:"name,attr"`
+	Arch    string          `xml:"arch,attr"`
+	Version metadataVersion `xml:"version"`
+	Files   []string        `xml:"file"`
+}
+
+type filelistsMetadata struct {
+	XMLName  xml.Name           `xml:"filelists"`
+	Xmlns    string             `xml:"xmlns,attr"`
+	Count    int                `xml:"packages,attr"`
+	Packages []filelistsPackage `xml:"package"`
+}
+
+type repomdData struct {
+	Type         string           `xml:"type,attr"`
+	Checksum     metadataChecksum `xml:"checksum"`
+	OpenChecksum metadataChecksum `xml:"open-checksum"`
+	Location     struct {
+		Href string `xml:"href,attr"`
+	} `xml:"location"`
+	Timestamp int64 `xml:"timestamp"`
+	Size      int64 `xml:"size"`
+	OpenSize  int64 `xml:"open-size"`
+}
+
+type repomd struct {
+	XMLName  xml.Name     `xml:"repomd"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	XmlnsRPM string       `xml:"xmlns:rpm,attr"`
+	Revision int64        `xml:"revision"`
+	Data     []repomdData `xml:"data"`
+}
+
+func dependencyEntries(deps []Dependency) *metadataEntries {
+	if len(deps) == 0 {
+		return nil
+	}
+
+	entries := &metadataEntries{}
+	for _, dep := range deps {
+		entry := metadataEntry{
+			Name:    dep.Name,
+			Flags:   dep.Flags,
+			Epoch:   dep.Epoch,
+			Version: dep.Version,
+			Release: dep.Release,
+		}
+		if dep.Pre {
+			entry.Pre = "1"
+		}
+		entries.Entries = append(entries.Entries, entry)
+	}
+	return entries
+}
+
+// primaryFile returns whether a file is listed in the primary metadata,
+// following createrepo's rules
+func primaryFile(path string) bool {
+	return strings.HasPrefix(path, "/etc/") ||
+		strings.Contains(path, "bin/") ||
+		path == "/usr/lib/sendmail"
+}
+
+func fileChecksum(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// writeMetadata regenerates the repodata directory.
+func (r *Repo) writeMetadata() error {
+	packages, files, err := r.readPackages()
+	if err != nil {
+		return err
+	}
+
+	primary := primaryMetadata{
+		Xmlns:    "http://linux.duke.edu/metadata/common",
+		XmlnsRPM: "http://linux.duke.edu/metadata/rpm",
+		Count:    len(packages),
+	}
+	filelists := filelistsMetadata{
+		Xmlns: "http://linux.duke.edu/metadata/filelists",
+		Count: len(packages),
+	}
+
+	for i, pkg := range packages {
+		checksum, size, err := fileChecksum(files[i])
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(files[i])
+		if err != nil {
+			return err
+		}
+
+		version := metadataVersion{
+			Epoch:   fmt.Sprintf("%d", pkg.Epoch),
+			Version: pkg.Version,
+			Release: pkg.Release,
+		}
+
+		p := primaryPackage{
+			Type:        "rpm",
+			Name:        pkg.Name,
+			Arch:        pkg.Arch,
+			Version:     version,
+			Checksum:    metadataChecksum{Type: "sha256", PkgID: "YES", Value: checksum},
+			Summary:     pkg.Summary,
+			Description: pkg.Description,
+			Packager:    pkg.Packager,
+			URL:         pkg.URL,
+		}
+		p.Time.File = info.ModTime().Unix()
+		p.Time.Build = pkg.BuildTime
+		p.Size.Package = size
+		p.Size.Installed = pkg.InstalledSize
+		p.Size.Archive = pkg.ArchiveSize
+		p.Location.Href = packagesDir + "/" + filepath.Base(files[i])
+		p.Format.License = pkg.License
+		p.Format.Vendor = pkg.Vendor
+		p.Format.Group = pkg.Group
+		p.Format.BuildHost = pkg.BuildHost
+		p.Format.SourceRPM = pkg.SourceRPM
+		p.Format.HeaderRange.Start = pkg.HeaderStart
+		p.Format.HeaderRange.End = pkg.HeaderEnd
+		p.Format.Provides = dependencyEntries(pkg.Provides)
+		p.Format.Requires = dependencyEntries(pkg.Requires)
+		p.Format.Conflicts = dependencyEntries(pkg.Conflicts)
+		p.Format.Obsoletes = dependencyEntries(pkg.Obsoletes)
+		for _, file := range pkg.Files {
+			if primaryFile(file) {
+				p.Format.Files = append(p.Format.Files, file)
+			}
+		}
+		primary.Packages = append(primary.Packages, p)
+
+		filelists.Packages = append(filelists.Packages, filelistsPackage{
+			PkgID:   checksum,
+			Name:    pkg.Name,
+			Arch:    pkg.Arch,
+			Version: version,
+			Files:   pkg.Files,
+		})
+	}
+
+	// write the new metadata into a directory of its own, which the
+	// repodata symlink is switched to once it is complete
+	tmpdir, err := ioutil.TempDir(r.dir, repodataDir+"-*")
+	if err != nil {
+		return err
+	}
+	swapped := false
+	defer func() {
+		if !swapped {
+			os.RemoveAll(tmpdir)
+		}
+	}()
+
+	now := time.Now().Unix()
+	md := repomd{
+		Xmlns:    "http://linux.duke.edu/metadata/repo",
+		XmlnsRPM: "http://linux.duke.edu/metadata/rpm",
+		Revision: now,
+	}
+
+	for _, m := range []struct {
+		name     string
+		document interface{}
+	}{
+		{"primary", primary},
+		{"filelists", filelists},
+	} {
+		data, err := writeCompressedXML(tmpdir, m.name, m.document)
+		if err != nil {
+			return err
+		}
+		data.Timestamp = now
+		md.Data = append(md.Data, *data)
+	}
+
+	f, err := os.OpenFile(filepath.Join(tmpdir, "repomd.xml"), os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(xml.Header)
+	if err != nil {
+		return err
+	}
+	err = xml.NewEncoder(f).Encode(md)
+	if err != nil {
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(tmpdir, 0755)
+	if err != nil {
+		return err
+	}
+
+	err = r.swapRepodata(filepath.Base(tmpdir))
+	if err != nil {
+		return err
+	}
+	swapped = true
+	return nil
+}
+
+// swapRepodata points the repodata symlink to the directory target and
+// removes the directory it pointed to before. Renaming a symlink over
+// another one is atomic, so readers always find complete metadata.
+func (r *Repo) swapRepodata(target string) error {
+	repodata := filepath.Join(r.dir, repodataDir)
+
+	var old string
+	info, err := os.Lstat(repodata)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return err
+	case info.Mode()&os.ModeSymlink != 0:
+		old, err = os.Readlink(repodata)
+		if err != nil {
+			return err
+		}
+	default:
+		// repositories created before repodata was a symlink have a real
+		// directory in its place, which a symlink cannot be renamed over
+		old = filepath.Base(repodata) + "-old"
+		err = os.Rename(repodata, filepath.Join(r.dir, old))
+		if err != nil {
+			return err
+		}
+	}
+
+	link := filepath.Join(r.dir, target+".link")
+	err = os.Symlink(target, link)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(link, repodata)
+	if err != nil {
+		os.Remove(link)
+		return err
+	}
+
+	if old != "" {
+		return os.RemoveAll(filepath.Join(r.dir, filepath.Base(old)))
+	}
+	return nil
+}
+
+// writeCompressedXML writes document as gzipped XML file into dir, named by
+// its checksum the way createrepo does it.
+func writeCompressedXML(dir, name string, document interface{}) (*repomdData, error) {
+	tmpfile, err := ioutil.TempFile(dir, name+"-*.xml.gz")
+	if err != nil {
+		return nil, err
+	}
+	defer tmpfile.Close()
+
+	openHash := sha256.New()
+	hash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(tmpfile, hash)}
+	gz := gzip.NewWriter(counter)
+	openCounter := &countingWriter{w: io.MultiWriter(gz, openHash)}
+
+	_, err = io.WriteString(openCounter, xml.Header)
+	if err != nil {
+		return nil, err
+	}
+	err = xml.NewEncoder(openCounter).Encode(document)
+	if err != nil {
+		return nil, err
+	}
+	err = gz.Close()
+	if err != nil {
+		return nil, err
+	}
+	err = tmpfile.Chmod(0644)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	filename := fmt.Sprintf("%s-%s.xml.gz", checksum, name)
+	err = os.Rename(tmpfile.Name(), filepath.Join(dir, filename))
+	if err != nil {
+		return nil, err
+	}
+
+	data := &repomdData{
+		Type:         name,
+		Checksum:     metadataChecksum{Type: "sha256", Value: checksum},
+		OpenChecksum: metadataChecksum{Type: "sha256", Value: hex.EncodeToString(openHash.Sum(nil))},
+		Size:         counter.n,
+		OpenSize:     openCounter.n,
+	}
+	data.Location.Href = repodataDir + "/" + filename
+
+	return data, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,230 @@
+package localrepo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testTag struct {
+	tag   int32
+	value interface{}
+}
+
+// encodeHeader creates an RPM header structure containing tags
+func encodeHeader(tags []testTag) []byte {
+	var index, data bytes.Buffer
+	for _, t := range tags {
+		var typ, count uint32
+		var offset int
+		switch v := t.value.(type) {
+		case string:
+			typ, count, offset = typeString, 1, data.Len()
+			data.WriteString(v + "\x00")
+		case []string:
+			typ, count, offset = typeStringArray, uint32(len(v)), data.Len()
+			for _, s := range v {
+				data.WriteString(s + "\x00")
+			}
+		case []uint32:
+			for data.Len()%4 != 0 {
+				data.WriteByte(0)
+			}
+			typ, count, offset = typeInt32, uint32(len(v)), data.Len()
+			_ = binary.Write(&data, binary.BigEndian, v)
+		}
+		_ = binary.Write(&index, binary.BigEndian, indexEntry{t.tag, typ, int32(offset), count})
+	}
+
+	var h bytes.Buffer
+	_ = binary.Write(&h, binary.BigEndian, []uint32{headerMagic, 0, uint32(len(tags)), uint32(data.Len())})
+	h.Write(index.Bytes())
+	h.Write(data.Bytes())
+	return h.Bytes()
+}
+
+func testRPM(name, version string, requires []string) []byte {
+	var rpm bytes.Buffer
+	lead := make([]byte, leadSize)
+	binary.BigEndian.PutUint32(lead, leadMagic)
+	rpm.Write(lead)
+
+	sig := encodeHeader([]testTag{{sigTagPayloadSize, []uint32{4096}}})
+	rpm.Write(sig)
+	for rpm.Len()%8 != 0 {
+		rpm.WriteByte(0)
+	}
+
+	flags := make([]uint32, len(requires))
+	versions := make([]string, len(requires))
+	rpm.Write(encodeHeader([]testTag{
+		{tagName, name},
+		{tagVersion, version},
+		{tagRelease, "1.el8"},
+		{tagArch, "x86_64"},
+		{tagSummary, "A test package"},
+		{tagSourceRPM, name + "-" + version + "-1.el8.src.rpm"},
+		{tagSize, []uint32{1024}},
+		{tagProvideName, []string{name, name + "(x86-64)"}},
+		{tagProvideFlags, []uint32{senseEqual, senseEqual}},
+		{tagProvideVersion, []string{version + "-1.el8", version + "-1.el8"}},
+		{tagRequireName, append([]string{"rpmlib(CompressedFileNames)"}, requires...)},
+		{tagRequireFlags, append([]uint32{senseLess | senseEqual | senseRPMLib}, flags...)},
+		{tagRequireVersion, append([]string{"3.0.4-1"}, versions...)},
+		{tagDirIndexes, []uint32{0, 1}},
+		{tagBaseNames, []string{name, name + ".conf"}},
+		{tagDirNames, []string{"/usr/bin/", "/usr/share/" + name + "/"}},
+	}))
+
+	rpm.WriteString("payload")
+	return rpm.Bytes()
+}
+
+func TestReadPackage(t *testing.T) {
+	pkg, err := ReadPackage(bytes.NewReader(testRPM("hotfix", "1.2", []string{"bash"})))
+	require.NoError(t, err)
+
+	assert.Equal(t, "hotfix", pkg.Name)
+	assert.Equal(t, "hotfix-1.2-1.el8.x86_64", pkg.NEVRA())
+	assert.Equal(t, "hotfix-1.2-1.el8.x86_64.rpm", pkg.Filename())
+	assert.Equal(t, "A test package", pkg.Summary)
+	assert.Equal(t, uint32(1024), pkg.InstalledSize)
+	assert.Equal(t, uint32(4096), pkg.ArchiveSize)
+	assert.Equal(t, []Dependency{
+		{Name: "hotfix", Flags: "EQ", Epoch: "0", Version: "1.2", Release: "1.el8"},
+		{Name: "hotfix(x86-64)", Flags: "EQ", Epoch: "0", Version: "1.2", Release: "1.el8"},
+	}, pkg.Provides)
+	// rpmlib() requirements are dropped
+	assert.Equal(t, []Dependency{{Name: "bash"}}, pkg.Requires)
+	assert.Equal(t, []string{"/usr/bin/hotfix", "/usr/share/hotfix/hotfix.conf"}, pkg.Files)
+	// the signature header takes 36 bytes, padded to 40
+	assert.Equal(t, int64(leadSize+40), pkg.HeaderStart)
+
+	_, err = ReadPackage(bytes.NewReader([]byte("definitely not an rpm")))
+	assert.Equal(t, ErrNotRPM, err)
+}
+
+func TestSplitEVR(t *testing.T) {
+	for evr, expected := range map[string][3]string{
+		"1.0":         {"0", "1.0", ""},
+		"1.0-1.el8":   {"0", "1.0", "1.el8"},
+		"2:1.0-1.el8": {"2", "1.0", "1.el8"},
+		"1.0-rc1-1":   {"0", "1.0-rc1", "1"},
+	} {
+		epoch, version, release := splitEVR(evr)
+		assert.Equal(t, expected, [3]string{epoch, version, release}, evr)
+	}
+}
+
+func TestRepo(t *testing.T) {
+	dir := t.TempDir()
+
+	repo, err := New(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "file://"+dir+"/", repo.RepoConfig().BaseURL)
+
+	pkg, err := repo.Add(bytes.NewReader(testRPM("hotfix", "1.2", []string{"bash"})))
+	require.NoError(t, err)
+	assert.Equal(t, "hotfix", pkg.Name)
+	_, err = repo.Add(bytes.NewReader(testRPM("agent", "3.0", nil)))
+	require.NoError(t, err)
+
+	_, err = repo.Add(bytes.NewReader([]byte("not an rpm")))
+	require.Error(t, err)
+
+	packages, err := repo.List()
+	require.NoError(t, err)
+	require.Len(t, packages, 2)
+	assert.Equal(t, "agent", packages[0].Name)
+	assert.Equal(t, "hotfix", packages[1].Name)
+
+	// no leftovers of failed uploads
+	leftovers, err := filepath.Glob(filepath.Join(dir, "upload-*"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers)
+
+	var md repomd
+	data, err := ioutil.ReadFile(filepath.Join(dir, "repodata", "repomd.xml"))
+	require.NoError(t, err)
+	require.NoError(t, xml.Unmarshal(data, &md))
+	require.Len(t, md.Data, 2)
+	assert.Equal(t, "primary", md.Data[0].Type)
+
+	f, err := os.Open(filepath.Join(dir, md.Data[0].Location.Href))
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	primaryXML, err := ioutil.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Contains(t, string(primaryXML), `<location href="Packages/hotfix-1.2-1.el8.x86_64.rpm"></location>`)
+	assert.Contains(t, string(primaryXML), `<rpm:entry name="bash"></rpm:entry>`)
+	assert.Contains(t, string(primaryXML), `<file>/usr/bin/hotfix</file>`)
+	assert.NotContains(t, string(primaryXML), `hotfix.conf`)
+
+	err = repo.Delete("hotfix")
+	require.NoError(t, err)
+	err = repo.Delete("hotfix")
+	require.Equal(t, ErrPackageNotFound, err)
+
+	packages, err = repo.List()
+	require.NoError(t, err)
+	require.Len(t, packages, 1)
+}
+
+func TestRepoInvalidHeader(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := New(dir)
+	require.NoError(t, err)
+
+	for _, name := range []string{"../../evil", "a/b", ".."} {
+		_, err = repo.Add(bytes.NewReader(testRPM(name, "1.0", nil)))
+		assert.Error(t, err, name)
+	}
+	_, err = repo.Add(bytes.NewReader(testRPM("hotfix", "../1.0", nil)))
+	assert.Error(t, err)
+
+	// nothing was written next to the repository
+	files, err := ioutil.ReadDir(filepath.Dir(dir))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	packages, err := repo.List()
+	require.NoError(t, err)
+	assert.Empty(t, packages)
+}
+
+func TestRepoMetadataSwap(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := New(dir)
+	require.NoError(t, err)
+
+	// a repository with a real repodata directory is migrated
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "repodata"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "repodata", "repomd.xml"), nil, 0644))
+
+	_, err = repo.Add(bytes.NewReader(testRPM("hotfix", "1.2", nil)))
+	require.NoError(t, err)
+	first, err := os.Readlink(filepath.Join(dir, "repodata"))
+	require.NoError(t, err)
+
+	_, err = repo.Add(bytes.NewReader(testRPM("agent", "3.0", nil)))
+	require.NoError(t, err)
+	second, err := os.Readlink(filepath.Join(dir, "repodata"))
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	// only the current metadata is kept
+	dirs, err := filepath.Glob(filepath.Join(dir, "repodata*"))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{filepath.Join(dir, "repodata"), filepath.Join(dir, second)}, dirs)
+	_, err = os.Stat(filepath.Join(dir, "repodata", "repomd.xml"))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,391 @@
+package localrepo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// RPM header tags, see rpmtag.h
+const (
+	tagName            = 1000
+	tagVersion         = 1001
+	tagRelease         = 1002
+	tagEpoch           = 1003
+	tagSummary         = 1004
+	tagDescription     = 1005
+	tagBuildTime       = 1006
+	tagBuildHost       = 1007
+	tagSize            = 1009
+	tagVendor          = 1011
+	tagLicense         = 1014
+	tagPackager        = 1015
+	tagGroup           = 1016
+	tagURL             = 1020
+	tagArch            = 1022
+	tagSourceRPM       = 1044
+	tagProvideName     = 1047
+	tagRequireFlags    = 1048
+	tagRequireName     = 1049
+	tagRequireVersion  = 1050
+	tagConflictFlags   = 1053
+	tagConflictName    = 1054
+	tagConflictVersion = 1055
+	tagObsoleteName    = 1090
+	tagProvideFlags    = 1112
+	tagProvideVersion  = 1113
+	tagObsoleteFlags   = 1114
+	tagObsoleteVersion = 1115
+	tagDirIndexes      = 1116
+	tagBaseNames       = 1117
+	tagDirNames        = 1118
+
+	// in the signature header
+	sigTagPayloadSize = 1007
+)
+
+// RPM header entry types
+const (
+	typeInt16       = 3
+	typeInt32       = 4
+	typeString      = 6
+	typeStringArray = 8
+	typeI18NString  = 9
+)
+
+// dependency flags, see rpmds.h
+const (
+	senseLess       = 0x02
+	senseGreater    = 0x04
+	senseEqual      = 0x08
+	sensePrereq     = 0x40
+	senseScriptPre  = 0x200
+	senseScriptPost = 0x400
+	senseRPMLib     = 0x1000000
+)
+
+const (
+	leadSize    = 96
+	leadMagic   = 0xedabeedb
+	headerMagic = 0x8eade801
+)
+
+var ErrNotRPM = errors.New("not an RPM package")
+
+// Dependency is an entry of one of the provides, requires, conflicts or
+// obsoletes lists of a package.
+type Dependency struct {
+	Name    string
+	Flags   string
+	Epoch   string
+	Version string
+	Release string
+	Pre     bool
+}
+
+// Package holds the metadata of an RPM package which is needed to create
+// repository metadata for it.
+type Package struct {
+	Name        string
+	Epoch       uint
+	Version     string
+	Release     string
+	Arch        string
+	Summary     string
+	Description string
+	URL         string
+	License     string
+	Vendor      string
+	Group       string
+	BuildHost   string
+	Packager    string
+	SourceRPM   string
+	BuildTime   uint32
+
+	InstalledSize uint32
+	ArchiveSize   uint32
+
+	// byte range of the main header in the package file
+	HeaderStart int64
+	HeaderEnd   int64
+
+	Provides  []Dependency
+	Requires  []Dependency
+	Conflicts []Dependency
+	Obsoletes []Dependency
+	Files     []string
+}
+
+// NEVRA returns the name-[epoch:]version-release.arch string of the package.
+func (p *Package) NEVRA() string {
+	if p.Epoch != 0 {
+		return fmt.Sprintf("%s-%d:%s-%s.%s", p.Name, p.Epoch, p.Version, p.Release, p.Arch)
+	}
+	return fmt.Sprintf("%s-%s-%s.%s", p.Name, p.Version, p.Release, p.Arch)
+}
+
+// Filename returns the canonical file name of the package.
+func (p *Package) Filename() string {
+	return fmt.Sprintf("%s-%s-%s.%s.rpm", p.Name, p.Version, p.Release, p.Arch)
+}
+
+type indexEntry struct {
+	Tag    int32
+	Type   uint32
+	Offset int32
+	Count  uint32
+}
+
+type header struct {
+	entries map[int32]indexEntry
+	data    []byte
+}
+
+// readHeader reads a header structure from r and returns it together with
+// its total size in bytes.
+func readHeader(r io.Reader) (*header, int64, error) {
+	var intro struct {
+		Magic    uint32
+		Reserved uint32
+		NIndex   uint32
+		HSize    uint32
+	}
+	err := binary.Read(r, binary.BigEndian, &intro)
+	if err != nil {
+		return nil, 0, ErrNotRPM
+	}
+	if intro.Magic != headerMagic {
+		return nil, 0, ErrNotRPM
+	}
+
+	// sanity limits, rpm itself refuses headers larger than 256MiB
+	if intro.NIndex > 0xffff || intro.HSize > 256*1024*1024 {
+		return nil, 0, fmt.Errorf("RPM header is too large")
+	}
+
+	index := make([]indexEntry, intro.NIndex)
+	err = binary.Read(r, binary.BigEndian, index)
+	if err != nil {
+		return nil, 0, fmt.Errorf("cannot read RPM header index: %v", err)
+	}
+
+	h := &header{
+		entries: make(map[int32]indexEntry, len(index)),
+		data:    make([]byte, intro.HSize),
+	}
+	_, err = io.ReadFull(r, h.data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("cannot read RPM header data: %v", err)
+	}
+
+	for _, e := range index {
+		if e.Offset < 0 || int(e.Offset) > len(h.data) {
+			return nil, 0, fmt.Errorf("invalid offset for RPM header tag %d", e.Tag)
+		}
+		h.entries[e.Tag] = e
+	}
+
+	return h, int64(16 + 16*len(index) + len(h.data)), nil
+}
+
+func (h *header) strings(tag int32) []string {
+	e, ok := h.entries[tag]
+	if !ok || (e.Type != typeString && e.Type != typeStringArray && e.Type != typeI18NString) {
+		return nil
+	}
+
+	data := h.data[e.Offset:]
+	values := make([]string, 0, e.Count)
+	for i := uint32(0); i < e.Count; i++ {
+		end := bytes.IndexByte(data, 0)
+		if end < 0 {
+			break
+		}
+		values = append(values, string(data[:end]))
+		data = data[end+1:]
+	}
+	return values
+}
+
+func (h *header) string(tag int32) string {
+	values := h.strings(tag)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (h *header) ints(tag int32) []uint32 {
+	e, ok := h.entries[tag]
+	if !ok {
+		return nil
+	}
+
+	var size int
+	switch e.Type {
+	case typeInt16:
+		size = 2
+	case typeInt32:
+		size = 4
+	default:
+		return nil
+	}
+
+	data := h.data[e.Offset:]
+	if len(data) < size*int(e.Count) {
+		return nil
+	}
+
+	values := make([]uint32, e.Count)
+	for i := range values {
+		if size == 2 {
+			values[i] = uint32(binary.BigEndian.Uint16(data[i*2:]))
+		} else {
+			values[i] = binary.BigEndian.Uint32(data[i*4:])
+		}
+	}
+	return values
+}
+
+func (h *header) int(tag int32) uint32 {
+	values := h.ints(tag)
+	if len(values) == 0 {
+		return 0
+	}
+	return values[0]
+}
+
+func (h *header) dependencies(nameTag, flagsTag, versionTag int32) []Dependency {
+	names := h.strings(nameTag)
+	flags := h.ints(flagsTag)
+	versions := h.strings(versionTag)
+
+	deps := make([]Dependency, 0, len(names))
+	for i, name := range names {
+		var dep Dependency
+		dep.Name = name
+
+		var f uint32
+		if i < len(flags) {
+			f = flags[i]
+		}
+		// rpmlib() dependencies are satisfied by rpm itself
+		if f&senseRPMLib != 0 || strings.HasPrefix(name, "rpmlib(") {
+			continue
+		}
+
+		switch f & (senseLess | senseGreater | senseEqual) {
+		case senseLess:
+			dep.Flags = "LT"
+		case senseGreater:
+			dep.Flags = "GT"
+		case senseEqual:
+			dep.Flags = "EQ"
+		case senseLess | senseEqual:
+			dep.Flags = "LE"
+		case senseGreater | senseEqual:
+			dep.Flags = "GE"
+		}
+		dep.Pre = f&(sensePrereq|senseScriptPre|senseScriptPost) != 0
+
+		if i < len(versions) && versions[i] != "" {
+			dep.Epoch, dep.Version, dep.Release = splitEVR(versions[i])
+		}
+		deps = append(deps, dep)
+	}
+	return deps
+}
+
+// splitEVR splits a [epoch:]version[-release] string
+func splitEVR(evr string) (string, string, string) {
+	epoch := "0"
+	if i := strings.IndexByte(evr, ':'); i >= 0 {
+		if _, err := strconv.ParseUint(evr[:i], 10, 32); err == nil {
+			epoch = evr[:i]
+			evr = evr[i+1:]
+		}
+	}
+
+	release := ""
+	if i := strings.LastIndexByte(evr, '-'); i >= 0 {
+		release = evr[i+1:]
+		evr = evr[:i]
+	}
+
+	return epoch, evr, release
+}
+
+// ReadPackage reads the headers of the RPM package in r.
+func ReadPackage(r io.Reader) (*Package, error) {
+	lead := make([]byte, leadSize)
+	_, err := io.ReadFull(r, lead)
+	if err != nil || binary.BigEndian.Uint32(lead) != leadMagic {
+		return nil, ErrNotRPM
+	}
+
+	sig, sigSize, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// the signature header is padded to a multiple of 8 bytes
+	padding := (8 - sigSize%8) % 8
+	_, err = io.CopyN(io.Discard, r, padding)
+	if err != nil {
+		return nil, ErrNotRPM
+	}
+
+	h, size, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.string(tagSourceRPM) == "" {
+		return nil, errors.New("source packages are not supported")
+	}
+
+	pkg := &Package{
+		Name:          h.string(tagName),
+		Epoch:         uint(h.int(tagEpoch)),
+		Version:       h.string(tagVersion),
+		Release:       h.string(tagRelease),
+		Arch:          h.string(tagArch),
+		Summary:       h.string(tagSummary),
+		Description:   h.string(tagDescription),
+		URL:           h.string(tagURL),
+		License:       h.string(tagLicense),
+		Vendor:        h.string(tagVendor),
+		Group:         h.string(tagGroup),
+		BuildHost:     h.string(tagBuildHost),
+		Packager:      h.string(tagPackager),
+		SourceRPM:     h.string(tagSourceRPM),
+		BuildTime:     h.int(tagBuildTime),
+		InstalledSize: h.int(tagSize),
+		ArchiveSize:   sig.int(sigTagPayloadSize),
+		HeaderStart:   leadSize + sigSize + padding,
+		Provides:      h.dependencies(tagProvideName, tagProvideFlags, tagProvideVersion),
+		Requires:      h.dependencies(tagRequireName, tagRequireFlags, tagRequireVersion),
+		Conflicts:     h.dependencies(tagConflictName, tagConflictFlags, tagConflictVersion),
+		Obsoletes:     h.dependencies(tagObsoleteName, tagObsoleteFlags, tagObsoleteVersion),
+	}
+	pkg.HeaderEnd = pkg.HeaderStart + size
+
+	if pkg.Name == "" || pkg.Version == "" || pkg.Release == "" || pkg.Arch == "" {
+		return nil, errors.New("RPM header is missing name, version, release or arch")
+	}
+
+	dirs := h.strings(tagDirNames)
+	indexes := h.ints(tagDirIndexes)
+	for i, base := range h.strings(tagBaseNames) {
+		if i >= len(indexes) || int(indexes[i]) >= len(dirs) {
+			break
+		}
+		pkg.Files = append(pkg.Files, dirs[indexes[i]]+base)
+	}
+
+	return pkg, nil
+}
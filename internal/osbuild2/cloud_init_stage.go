@@ -86,11 +86,9 @@ func (c CloudInitConfigFile) validate() error {
 			return err
 		}
 	}
-	if len(c.DatasourceList) > 0 {
-		for _, d := range c.DatasourceList {
-			if d != "Azure" {
-				return fmt.Errorf("only 'Azure' is allowed as an item in the datasource_list")
-			}
+	for _, d := range c.DatasourceList {
+		if err := validateCloudInitDatasource(d); err != nil {
+			return err
 		}
 	}
 	if c.Output != nil {
@@ -143,6 +141,16 @@ func (d CloudInitConfigDatasource) validate() error {
 	return nil
 }
 
+func validateCloudInitDatasource(d string) error {
+	allowed_values := []string{"Azure", "AliYun", "ConfigDrive", "Ec2", "NoCloud", "None", "Oracle"}
+	for _, v := range allowed_values {
+		if v == d {
+			return nil
+		}
+	}
+	return fmt.Errorf("datasource_list items must be one of 'Azure', 'AliYun', 'ConfigDrive', 'Ec2', 'NoCloud', 'None', 'Oracle'")
+}
+
 func (o CloudInitConfigOutput) validate() error {
 	if o.Init == nil && o.Config == nil && o.Final == nil && o.All == nil {
 		return fmt.Errorf("at least one configuration option must be specified for 'output' section")
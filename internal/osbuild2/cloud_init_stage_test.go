@@ -72,6 +72,15 @@ func TestCloudInitStage_NewStage_Invalid(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "unknown-datasource",
+			options: CloudInitStageOptions{
+				Filename: "10-datasource.cfg",
+				Config: CloudInitConfigFile{
+					DatasourceList: []string{"Oracle", "Unknown"},
+				},
+			},
+		},
 	}
 	for idx, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
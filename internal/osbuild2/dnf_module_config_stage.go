@@ -0,0 +1,25 @@
+package osbuild2
+
+// DNFModuleConfigStageOptions represents the state of a module stream, as
+// it is stored by DNF in /etc/dnf/modules.d/<name>.module.
+type DNFModuleConfigStageOptions struct {
+	Conf *DNFModuleConfig `json:"conf"`
+}
+
+func (DNFModuleConfigStageOptions) isStageOptions() {}
+
+type DNFModuleConfig struct {
+	Name   string `json:"name"`
+	Stream string `json:"stream"`
+	// "enabled" or "disabled"
+	State    string   `json:"state"`
+	Profiles []string `json:"profiles"`
+}
+
+// NewDNFModuleConfigStage creates a new DNF module config Stage object.
+func NewDNFModuleConfigStage(options *DNFModuleConfigStageOptions) *Stage {
+	return &Stage{
+		Type:    "org.osbuild.dnf.module-config",
+		Options: options,
+	}
+}
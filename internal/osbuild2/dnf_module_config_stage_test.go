@@ -0,0 +1,33 @@
+package osbuild2
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDNFModuleConfigStage(t *testing.T) {
+	expectedStage := &Stage{
+		Type:    "org.osbuild.dnf.module-config",
+		Options: &DNFModuleConfigStageOptions{},
+	}
+	actualStage := NewDNFModuleConfigStage(&DNFModuleConfigStageOptions{})
+	assert.Equal(t, expectedStage, actualStage)
+}
+
+func TestJSONDNFModuleConfigStage(t *testing.T) {
+	options := DNFModuleConfigStageOptions{
+		Conf: &DNFModuleConfig{
+			Name:     "postgresql",
+			Stream:   "13",
+			State:    "enabled",
+			Profiles: []string{"server"},
+		},
+	}
+
+	data, err := json.Marshal(options)
+	require.NoError(t, err)
+	assert.Equal(t, `{"conf":{"name":"postgresql","stream":"13","state":"enabled","profiles":["server"]}}`, string(data))
+}
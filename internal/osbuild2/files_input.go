@@ -24,6 +24,9 @@ func NewFilesInputs(references FilesInputReferences) *FilesInputs {
 // inputs accepted by the XZ stage
 func (FilesInputs) isXzStageInputs() {}
 
+// inputs accepted by the Zstd stage
+func (FilesInputs) isZstdStageInputs() {}
+
 // inputs accepted by the Copy stage
 func (FilesInputs) isCopyStageInputs() {}
 
@@ -0,0 +1,24 @@
+package osbuild2
+
+import "encoding/base64"
+
+// InlineSource provides files whose content is embedded in the manifest,
+// keyed by their checksum.
+type InlineSource struct {
+	Items map[string]InlineSourceItem `json:"items"`
+}
+
+func (InlineSource) isSource() {}
+
+type InlineSourceItem struct {
+	Encoding string `json:"encoding"`
+	Data     string `json:"data"`
+}
+
+// NewInlineSourceItem returns an item embedding data, encoded in base64.
+func NewInlineSourceItem(data []byte) InlineSourceItem {
+	return InlineSourceItem{
+		Encoding: "base64",
+		Data:     base64.StdEncoding.EncodeToString(data),
+	}
+}
@@ -20,6 +20,7 @@ type OCIArchiveStageOptions struct {
 }
 
 type OCIArchiveConfig struct {
+	Entrypoint   []string          `json:"Entrypoint,omitempty"`
 	Cmd          []string          `json:"Cmd,omitempty"`
 	Env          []string          `json:"Env,omitempty"`
 	ExposedPorts []string          `json:"ExposedPorts,omitempty"`
@@ -1,5 +1,10 @@
 package osbuild2
 
+import (
+	"bytes"
+	"encoding/json"
+)
+
 type RPMStageOptions struct {
 	// Array of GPG key contents to import
 	GPGKeys []string `json:"gpgkeys,omitempty"`
@@ -8,6 +13,9 @@ type RPMStageOptions struct {
 	DisableDracut bool `json:"disable_dracut,omitempty"`
 
 	Exclude *Exclude `json:"exclude,omitempty"`
+
+	// Only install the translations of these languages
+	InstallLangs []string `json:"install_langs,omitempty"`
 }
 
 type Exclude struct {
@@ -34,15 +42,60 @@ func (RPMStageInputs) isStageInputs() {}
 
 type RPMStageInput struct {
 	inputCommon
-	References RPMStageReferences `json:"references"`
+	// Either RPMStageReferences or RPMStageReferencesWithOptions
+	References References `json:"references"`
 }
 
 func (RPMStageInput) isStageInput() {}
 
+func (input *RPMStageInput) UnmarshalJSON(data []byte) error {
+	var rawInput struct {
+		inputCommon
+		References json.RawMessage `json:"references"`
+	}
+	if err := json.Unmarshal(data, &rawInput); err != nil {
+		return err
+	}
+	input.inputCommon = rawInput.inputCommon
+
+	// references with options are an object, plain references a list
+	if bytes.HasPrefix(bytes.TrimSpace(rawInput.References), []byte("{")) {
+		var refs RPMStageReferencesWithOptions
+		if err := json.Unmarshal(rawInput.References, &refs); err != nil {
+			return err
+		}
+		input.References = refs
+	} else {
+		var refs RPMStageReferences
+		if err := json.Unmarshal(rawInput.References, &refs); err != nil {
+			return err
+		}
+		input.References = refs
+	}
+	return nil
+}
+
+// RPMStageReferences are the checksums of the packages to install
 type RPMStageReferences []string
 
 func (RPMStageReferences) isReferences() {}
 
+// RPMStageReferencesWithOptions maps the checksums of the packages to
+// install to options for each package.
+type RPMStageReferencesWithOptions map[string]RPMStageReferenceOptions
+
+func (RPMStageReferencesWithOptions) isReferences() {}
+
+type RPMStageReferenceOptions struct {
+	Metadata RPMStageReferenceMetadata `json:"metadata"`
+}
+
+type RPMStageReferenceMetadata struct {
+	// Fail if the package is not signed with one of the GPGKeys given in
+	// the RPMStageOptions
+	CheckGPG bool `json:"rpm.check_gpg,omitempty"`
+}
+
 // NewRPMStage creates a new RPM stage.
 func NewRPMStage(options *RPMStageOptions, inputs *RPMStageInputs) *Stage {
 	return &Stage{
@@ -1,6 +1,7 @@
 package osbuild2
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -15,3 +16,38 @@ func TestNewRPMStage(t *testing.T) {
 	actualStage := NewRPMStage(&RPMStageOptions{}, &RPMStageInputs{})
 	assert.Equal(t, expectedStage, actualStage)
 }
+
+func TestRPMStageInputReferences(t *testing.T) {
+	tests := []struct {
+		data  string
+		input RPMStageInput
+	}{
+		{
+			data: `{"type":"org.osbuild.files","origin":"org.osbuild.source","references":["sha256:aaa","sha256:bbb"]}`,
+			input: RPMStageInput{
+				inputCommon: inputCommon{Type: "org.osbuild.files", Origin: "org.osbuild.source"},
+				References:  RPMStageReferences{"sha256:aaa", "sha256:bbb"},
+			},
+		},
+		{
+			data: `{"type":"org.osbuild.files","origin":"org.osbuild.source","references":{"sha256:aaa":{"metadata":{"rpm.check_gpg":true}},"sha256:bbb":{"metadata":{}}}}`,
+			input: RPMStageInput{
+				inputCommon: inputCommon{Type: "org.osbuild.files", Origin: "org.osbuild.source"},
+				References: RPMStageReferencesWithOptions{
+					"sha256:aaa": RPMStageReferenceOptions{Metadata: RPMStageReferenceMetadata{CheckGPG: true}},
+					"sha256:bbb": RPMStageReferenceOptions{},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		var input RPMStageInput
+		err := json.Unmarshal([]byte(tt.data), &input)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.input, input)
+
+		data, err := json.Marshal(tt.input)
+		assert.NoError(t, err)
+		assert.JSONEq(t, tt.data, string(data))
+	}
+}
@@ -146,6 +146,22 @@ func TestStage_UnmarshalJSON(t *testing.T) {
 				data: []byte(`{"type":"org.osbuild.dnf.config","options":{}}`),
 			},
 		},
+		{
+			name: "dnf-module-config",
+			fields: fields{
+				Type: "org.osbuild.dnf.module-config",
+				Options: &DNFModuleConfigStageOptions{
+					Conf: &DNFModuleConfig{
+						Name:     "nodejs",
+						State:    "disabled",
+						Profiles: []string{},
+					},
+				},
+			},
+			args: args{
+				data: []byte(`{"type":"org.osbuild.dnf.module-config","options":{"conf":{"name":"nodejs","stream":"","state":"disabled","profiles":[]}}}`),
+			},
+		},
 		{
 			name: "dnf-automatic-config",
 			fields: fields{
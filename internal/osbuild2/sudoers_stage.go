@@ -0,0 +1,46 @@
+package osbuild2
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// sudo skips the files of /etc/sudoers.d, whose names contain a '.' or end
+// with a '~'
+const sudoersFilenameRegex = "^[\\w-]{1,250}$"
+
+// SudoersStageOptions represents a single drop-in sudoers configuration file,
+// which is created in /etc/sudoers.d and is readable by root only.
+type SudoersStageOptions struct {
+	// Filename of the configuration file to be created in /etc/sudoers.d.
+	Filename string `json:"filename"`
+	// List of sudoers rules. The list must contain at least one item.
+	Config []string `json:"config"`
+}
+
+func (SudoersStageOptions) isStageOptions() {}
+
+func (o SudoersStageOptions) validate() error {
+	if len(o.Config) == 0 {
+		return fmt.Errorf("at least one rule is required")
+	}
+
+	nameRegex := regexp.MustCompile(sudoersFilenameRegex)
+	if !nameRegex.MatchString(o.Filename) {
+		return fmt.Errorf("sudoers filename %q doesn't conform to schema (%s)", o.Filename, nameRegex.String())
+	}
+
+	return nil
+}
+
+// NewSudoersStage creates a new Sudoers Stage object.
+func NewSudoersStage(options *SudoersStageOptions) *Stage {
+	if err := options.validate(); err != nil {
+		panic(err)
+	}
+
+	return &Stage{
+		Type:    "org.osbuild.sudoers",
+		Options: options,
+	}
+}
@@ -0,0 +1,53 @@
+package osbuild2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSudoersStage(t *testing.T) {
+	options := &SudoersStageOptions{
+		Filename: "vagrant",
+		Config:   []string{"vagrant ALL=(ALL) NOPASSWD: ALL"},
+	}
+	expectedStage := &Stage{
+		Type:    "org.osbuild.sudoers",
+		Options: options,
+	}
+	actualStage := NewSudoersStage(options)
+	assert.Equal(t, expectedStage, actualStage)
+}
+
+func TestSudoersStage_NewStage_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		options SudoersStageOptions
+	}{
+		{
+			name: "no-rules",
+			options: SudoersStageOptions{
+				Filename: "vagrant",
+			},
+		},
+		{
+			name: "skipped-filename",
+			options: SudoersStageOptions{
+				Filename: "vagrant.conf",
+				Config:   []string{"vagrant ALL=(ALL) NOPASSWD: ALL"},
+			},
+		},
+		{
+			name: "path-filename",
+			options: SudoersStageOptions{
+				Filename: "../sudoers",
+				Config:   []string{"vagrant ALL=(ALL) NOPASSWD: ALL"},
+			},
+		},
+	}
+	for idx, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Panics(t, func() { NewSudoersStage(&tt.options) }, "NewSudoersStage didn't panic, but it should [idx: %d]", idx)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package osbuild2
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+const udevRulesFilenameRegex = "^/(etc|usr/lib)/udev/rules.d/[\\w.-]{1,250}\\.rules$"
+
+// UdevRulesStageOptions represents a udev rules file.
+type UdevRulesStageOptions struct {
+	Filename string    `json:"filename"`
+	Rules    UdevRules `json:"rules"`
+}
+
+func (UdevRulesStageOptions) isStageOptions() {}
+
+func (o UdevRulesStageOptions) validate() error {
+	if len(o.Rules) == 0 {
+		return fmt.Errorf("at least one rule is required")
+	}
+
+	nameRegex := regexp.MustCompile(udevRulesFilenameRegex)
+	if !nameRegex.MatchString(o.Filename) {
+		return fmt.Errorf("udev rules filename %q doesn't conform to schema (%s)", o.Filename, nameRegex.String())
+	}
+
+	return nil
+}
+
+func NewUdevRulesStage(options *UdevRulesStageOptions) *Stage {
+	if err := options.validate(); err != nil {
+		panic(err)
+	}
+
+	return &Stage{
+		Type:    "org.osbuild.udev.rules",
+		Options: options,
+	}
+}
+
+type UdevRule interface {
+	isUdevRule()
+}
+
+// UdevRules represents the content of a udev rules file, which contains
+// comments and rules.
+type UdevRules []UdevRule
+
+func (rules *UdevRules) UnmarshalJSON(data []byte) error {
+	var rawRules []json.RawMessage
+
+	if err := json.Unmarshal(data, &rawRules); err != nil {
+		return err
+	}
+
+	for _, rawRule := range rawRules {
+		// comments are objects, rules are lists of key-value pairs
+		var comment UdevRuleComment
+		if err := json.Unmarshal(rawRule, &comment); err == nil {
+			*rules = append(*rules, &comment)
+			continue
+		}
+		var rule UdevRuleKV
+		if err := json.Unmarshal(rawRule, &rule); err != nil {
+			return fmt.Errorf("unexpected udev rule format: %v", err)
+		}
+		*rules = append(*rules, rule)
+	}
+
+	return nil
+}
+
+// UdevRuleComment represents comment lines in the udev rules file.
+type UdevRuleComment struct {
+	Comment []string `json:"comment"`
+}
+
+func (UdevRuleComment) isUdevRule() {}
+
+// UdevRuleKV represents a single rule, a list of match and assignment
+// key-value pairs.
+type UdevRuleKV []UdevKV
+
+func (UdevRuleKV) isUdevRule() {}
+
+// UdevKV is a key-value pair of a rule, e.g. SUBSYSTEM=="net" or, with the
+// key argument, ENV{NM_UNMANAGED}="1".
+type UdevKV struct {
+	Key string
+	Arg string
+	Op  string
+	Val string
+}
+
+type udevKey struct {
+	Name string `json:"name"`
+	Arg  string `json:"arg"`
+}
+
+type udevKV struct {
+	Key interface{} `json:"key"`
+	Op  string      `json:"op"`
+	Val string      `json:"val"`
+}
+
+func (kv UdevKV) MarshalJSON() ([]byte, error) {
+	raw := udevKV{Key: kv.Key, Op: kv.Op, Val: kv.Val}
+	if kv.Arg != "" {
+		raw.Key = udevKey{Name: kv.Key, Arg: kv.Arg}
+	}
+	return json.Marshal(raw)
+}
+
+func (kv *UdevKV) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Key json.RawMessage `json:"key"`
+		Op  string          `json:"op"`
+		Val string          `json:"val"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	kv.Op, kv.Val = raw.Op, raw.Val
+	if err := json.Unmarshal(raw.Key, &kv.Key); err == nil {
+		return nil
+	}
+	var key udevKey
+	if err := json.Unmarshal(raw.Key, &key); err != nil {
+		return fmt.Errorf("'key' item should be string or object with 'name' and 'arg'")
+	}
+	kv.Key, kv.Arg = key.Name, key.Arg
+	return nil
+}
@@ -0,0 +1,80 @@
+package osbuild2
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUdevRulesStage(t *testing.T) {
+	options := &UdevRulesStageOptions{
+		Filename: "/etc/udev/rules.d/68-azure-sriov-nm-unmanaged.rules",
+		Rules: UdevRules{
+			UdevRuleComment{Comment: []string{"SR-IOV interfaces"}},
+		},
+	}
+	expectedStage := &Stage{
+		Type:    "org.osbuild.udev.rules",
+		Options: options,
+	}
+	actualStage := NewUdevRulesStage(options)
+	assert.Equal(t, expectedStage, actualStage)
+}
+
+func TestUdevRulesStage_NewStage_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		options UdevRulesStageOptions
+	}{
+		{
+			name: "no-rules",
+			options: UdevRulesStageOptions{
+				Filename: "/etc/udev/rules.d/10-test.rules",
+			},
+		},
+		{
+			name: "invalid-filename",
+			options: UdevRulesStageOptions{
+				Filename: "/etc/10-test.rules",
+				Rules:    UdevRules{UdevRuleComment{Comment: []string{"test"}}},
+			},
+		},
+	}
+	for idx, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Panics(t, func() { NewUdevRulesStage(&tt.options) }, "NewUdevRulesStage didn't panic, but it should [idx: %d]", idx)
+		})
+	}
+}
+
+func TestUdevRulesStageOptionsJSON(t *testing.T) {
+	options := &UdevRulesStageOptions{
+		Filename: "/etc/udev/rules.d/68-azure-sriov-nm-unmanaged.rules",
+		Rules: UdevRules{
+			&UdevRuleComment{Comment: []string{"SR-IOV interfaces"}},
+			UdevRuleKV{
+				{Key: "SUBSYSTEM", Op: "==", Val: "net"},
+				{Key: "ENV", Arg: "NM_UNMANAGED", Op: "=", Val: "1"},
+			},
+		},
+	}
+	expected := `{
+		"filename": "/etc/udev/rules.d/68-azure-sriov-nm-unmanaged.rules",
+		"rules": [
+			{"comment": ["SR-IOV interfaces"]},
+			[
+				{"key": "SUBSYSTEM", "op": "==", "val": "net"},
+				{"key": {"name": "ENV", "arg": "NM_UNMANAGED"}, "op": "=", "val": "1"}
+			]
+		]
+	}`
+	data, err := json.Marshal(options)
+	require.NoError(t, err)
+	assert.JSONEq(t, expected, string(data))
+
+	var unmarshalled UdevRulesStageOptions
+	require.NoError(t, json.Unmarshal([]byte(expected), &unmarshalled))
+	assert.Equal(t, options, &unmarshalled)
+}
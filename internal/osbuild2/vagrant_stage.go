@@ -0,0 +1,39 @@
+package osbuild2
+
+type VagrantProvider string
+
+// valid values for the 'provider' Vagrant stage option
+const (
+	VagrantProviderLibvirt    VagrantProvider = "libvirt"
+	VagrantProviderVirtualBox VagrantProvider = "virtualbox"
+)
+
+// VagrantStageOptions represents the Vagrant box of a provider, which is
+// assembled from the image (metadata.json, Vagrantfile and the image in the
+// format of the provider).
+type VagrantStageOptions struct {
+	Provider VagrantProvider `json:"provider"`
+}
+
+func (VagrantStageOptions) isStageOptions() {}
+
+type VagrantStageInputs struct {
+	Image *FilesInput `json:"image"`
+}
+
+func (VagrantStageInputs) isStageInputs() {}
+
+func NewVagrantStageInputs(references FilesInputReferences) *VagrantStageInputs {
+	return &VagrantStageInputs{
+		Image: NewFilesInput(references),
+	}
+}
+
+// NewVagrantStage creates a new Vagrant Stage object.
+func NewVagrantStage(options *VagrantStageOptions, inputs *VagrantStageInputs) *Stage {
+	return &Stage{
+		Type:    "org.osbuild.vagrant",
+		Options: options,
+		Inputs:  inputs,
+	}
+}
@@ -0,0 +1,21 @@
+package osbuild2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewVagrantStage(t *testing.T) {
+	options := &VagrantStageOptions{Provider: VagrantProviderLibvirt}
+	inputs := NewVagrantStageInputs(NewFilesInputReferencesPipeline("qcow2", "box.img"))
+
+	expectedStage := &Stage{
+		Type:    "org.osbuild.vagrant",
+		Options: options,
+		Inputs:  inputs,
+	}
+	actualStage := NewVagrantStage(options, inputs)
+	assert.Equal(t, expectedStage, actualStage)
+	assert.Equal(t, InputOriginPipeline, actualStage.Inputs.(*VagrantStageInputs).Image.Origin)
+}
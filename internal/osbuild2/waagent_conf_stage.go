@@ -0,0 +1,27 @@
+package osbuild2
+
+// WAAgentConfStageOptions represents the configuration of the Azure Linux
+// Agent (/etc/waagent.conf). Only the set options are changed in the file.
+type WAAgentConfStageOptions struct {
+	Config WAAgentConfig `json:"config"`
+}
+
+func (WAAgentConfStageOptions) isStageOptions() {}
+
+type WAAgentConfig struct {
+	// Leave the provisioning of the instance to cloud-init
+	ProvisioningUseCloudInit *bool `json:"Provisioning.UseCloudInit,omitempty"`
+	ProvisioningEnabled      *bool `json:"Provisioning.Enabled,omitempty"`
+	// Format and mount the resource disk, cloud-init does it when unset
+	RDFormat     *bool `json:"ResourceDisk.Format,omitempty"`
+	RDEnableSwap *bool `json:"ResourceDisk.EnableSwap,omitempty"`
+}
+
+// NewWAAgentConfStage creates a new Azure Linux Agent configuration Stage
+// object.
+func NewWAAgentConfStage(options *WAAgentConfStageOptions) *Stage {
+	return &Stage{
+		Type:    "org.osbuild.waagent.conf",
+		Options: options,
+	}
+}
@@ -0,0 +1,31 @@
+package osbuild2
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/osbuild/osbuild-composer/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWAAgentConfStage(t *testing.T) {
+	expectedStage := &Stage{
+		Type:    "org.osbuild.waagent.conf",
+		Options: &WAAgentConfStageOptions{},
+	}
+	actualStage := NewWAAgentConfStage(&WAAgentConfStageOptions{})
+	assert.Equal(t, expectedStage, actualStage)
+}
+
+func TestWAAgentConfStageOptionsJSON(t *testing.T) {
+	options := &WAAgentConfStageOptions{
+		Config: WAAgentConfig{
+			ProvisioningUseCloudInit: common.BoolToPtr(true),
+			RDFormat:                 common.BoolToPtr(false),
+		},
+	}
+	data, err := json.Marshal(options)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"config": {"Provisioning.UseCloudInit": true, "ResourceDisk.Format": false}}`, string(data))
+}
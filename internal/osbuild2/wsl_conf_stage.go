@@ -0,0 +1,28 @@
+package osbuild2
+
+// WSLConfStageOptions represents the configuration of the distribution in
+// the Windows Subsystem for Linux (/etc/wsl.conf).
+type WSLConfStageOptions struct {
+	Boot *WSLConfBootOptions `json:"boot,omitempty"`
+	User *WSLConfUserOptions `json:"user,omitempty"`
+}
+
+func (WSLConfStageOptions) isStageOptions() {}
+
+type WSLConfBootOptions struct {
+	// Start systemd when the distribution is launched
+	Systemd bool `json:"systemd"`
+}
+
+type WSLConfUserOptions struct {
+	// Name of the user logged in when the distribution is launched
+	Default string `json:"default"`
+}
+
+// NewWSLConfStage creates a new WSL configuration Stage object.
+func NewWSLConfStage(options *WSLConfStageOptions) *Stage {
+	return &Stage{
+		Type:    "org.osbuild.wsl.conf",
+		Options: options,
+	}
+}
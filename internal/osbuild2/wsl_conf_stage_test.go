@@ -0,0 +1,32 @@
+package osbuild2
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWSLConfStage(t *testing.T) {
+	expectedStage := &Stage{
+		Type:    "org.osbuild.wsl.conf",
+		Options: &WSLConfStageOptions{},
+	}
+	actualStage := NewWSLConfStage(&WSLConfStageOptions{})
+	assert.Equal(t, expectedStage, actualStage)
+}
+
+func TestWSLConfStageOptionsJSON(t *testing.T) {
+	options := &WSLConfStageOptions{
+		Boot: &WSLConfBootOptions{Systemd: true},
+		User: &WSLConfUserOptions{Default: "user"},
+	}
+	data, err := json.Marshal(options)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"boot": {"systemd": true}, "user": {"default": "user"}}`, string(data))
+
+	data, err = json.Marshal(&WSLConfStageOptions{Boot: &WSLConfBootOptions{Systemd: true}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"boot": {"systemd": true}}`, string(data))
+}
@@ -0,0 +1,32 @@
+package osbuild2
+
+type ZstdStageOptions struct {
+	// Filename for zstd archive
+	Filename string `json:"filename"`
+}
+
+func (ZstdStageOptions) isStageOptions() {}
+
+func NewZstdStageOptions(filename string) *ZstdStageOptions {
+	return &ZstdStageOptions{
+		Filename: filename,
+	}
+}
+
+type ZstdStageInputs interface {
+	isZstdStageInputs()
+}
+
+// Compresses a file into a zstd archive.
+func NewZstdStage(options *ZstdStageOptions, inputs ZstdStageInputs) *Stage {
+	var stageInputs Inputs
+	if inputs != nil {
+		stageInputs = inputs.(Inputs)
+	}
+
+	return &Stage{
+		Type:    "org.osbuild.zstd",
+		Options: options,
+		Inputs:  stageInputs,
+	}
+}
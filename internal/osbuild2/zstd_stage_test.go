@@ -0,0 +1,47 @@
+package osbuild2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewZstdStageOptions(t *testing.T) {
+	filename := "image.raw.zst"
+
+	expectedOptions := &ZstdStageOptions{
+		Filename: filename,
+	}
+
+	actualOptions := NewZstdStageOptions(filename)
+	assert.Equal(t, expectedOptions, actualOptions)
+}
+
+func TestNewZstdStage(t *testing.T) {
+	inputFilename := "image.raw"
+	filename := "image.raw.zst"
+	pipeline := "os"
+
+	expectedStage := &Stage{
+		Type:    "org.osbuild.zstd",
+		Options: NewZstdStageOptions(filename),
+		Inputs:  NewFilesInputs(NewFilesInputReferencesPipeline(pipeline, inputFilename)),
+	}
+
+	actualStage := NewZstdStage(NewZstdStageOptions(filename),
+		NewFilesInputs(NewFilesInputReferencesPipeline(pipeline, inputFilename)))
+	assert.Equal(t, expectedStage, actualStage)
+}
+
+func TestNewZstdStageNoInputs(t *testing.T) {
+	filename := "image.raw.zst"
+
+	expectedStage := &Stage{
+		Type:    "org.osbuild.zstd",
+		Options: &ZstdStageOptions{Filename: filename},
+		Inputs:  nil,
+	}
+
+	actualStage := NewZstdStage(&ZstdStageOptions{Filename: filename}, nil)
+	assert.Equal(t, expectedStage, actualStage)
+}
@@ -0,0 +1,44 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const rpmmdSubsystem = "composer_rpmmd"
+
+var (
+	MetadataCacheHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:      "metadata_cache_hits_total",
+		Namespace: namespace,
+		Subsystem: rpmmdSubsystem,
+		Help:      "Number of requests which used the cached metadata of a repository",
+	}, []string{"repo"})
+)
+
+var (
+	MetadataCacheMisses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:      "metadata_cache_misses_total",
+		Namespace: namespace,
+		Subsystem: rpmmdSubsystem,
+		Help:      "Number of requests which downloaded new metadata of a repository",
+	}, []string{"repo"})
+)
+
+var (
+	MetadataCacheBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "metadata_cache_bytes",
+		Namespace: namespace,
+		Subsystem: rpmmdSubsystem,
+		Help:      "Size of the cached metadata of a repository",
+	}, []string{"repo"})
+)
+
+var (
+	MetadataCacheLastRefresh = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "metadata_cache_last_refresh_timestamp_seconds",
+		Namespace: namespace,
+		Subsystem: rpmmdSubsystem,
+		Help:      "Time the metadata of a repository was last downloaded",
+	}, []string{"repo"})
+)
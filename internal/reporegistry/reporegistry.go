@@ -2,7 +2,15 @@ package reporegistry
 
 import (
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
 
 	"github.com/osbuild/osbuild-composer/internal/distro"
 	"github.com/osbuild/osbuild-composer/internal/rpmmd"
@@ -11,23 +19,195 @@ import (
 // RepoRegistry represents a database of distro and architecture
 // specific RPM repositories. Image types are considered only
 // if the loaded repository definition contains any ImageTypeTags.
+//
+// A registry loaded from repository definition files can be reloaded, when
+// the files change, and updated at runtime.
 type RepoRegistry struct {
+	mu    sync.RWMutex // protects repos
 	repos rpmmd.DistrosRepoConfigs
+
+	// serializes reloads and updates, which may take a while
+	updateMu        sync.Mutex
+	repoConfigPaths []string
+	fingerprint     string
 }
 
 // New returns a new RepoRegistry instance with the data
 // loaded from the given repoConfigPaths
 func New(repoConfigPaths []string) (*RepoRegistry, error) {
+	fingerprint := repoFilesFingerprint(repoConfigPaths)
 	repositories, err := rpmmd.LoadAllRepositories(repoConfigPaths)
 	if err != nil {
 		return nil, err
 	}
 
-	return &RepoRegistry{repositories}, nil
+	return &RepoRegistry{
+		repos:           repositories,
+		repoConfigPaths: repoConfigPaths,
+		fingerprint:     fingerprint,
+	}, nil
 }
 
 func NewFromDistrosRepoConfigs(distrosRepoConfigs rpmmd.DistrosRepoConfigs) *RepoRegistry {
-	return &RepoRegistry{distrosRepoConfigs}
+	return &RepoRegistry{repos: distrosRepoConfigs}
+}
+
+// repoFilesFingerprint returns a string which changes whenever any of the
+// repository definition files in repoConfigPaths changes
+func repoFilesFingerprint(repoConfigPaths []string) string {
+	var fingerprint strings.Builder
+	for _, confPath := range repoConfigPaths {
+		reposPath := filepath.Join(confPath, "repositories")
+		fileEntries, err := ioutil.ReadDir(reposPath)
+		if err != nil {
+			continue
+		}
+		for _, fileEntry := range fileEntries {
+			if fileEntry.IsDir() || !strings.HasSuffix(fileEntry.Name(), ".json") {
+				continue
+			}
+			fmt.Fprintf(&fingerprint, "%s:%d:%d\n", filepath.Join(reposPath, fileEntry.Name()), fileEntry.Size(), fileEntry.ModTime().UnixNano())
+		}
+	}
+	return fingerprint.String()
+}
+
+// Reload loads the repository definition files again, if any of them
+// changed. The repositories are replaced at once, when all files were loaded
+// successfully. Otherwise, the previously loaded repositories are kept.
+func (r *RepoRegistry) Reload() (bool, error) {
+	r.updateMu.Lock()
+	defer r.updateMu.Unlock()
+
+	if r.repoConfigPaths == nil {
+		return false, nil
+	}
+
+	fingerprint := repoFilesFingerprint(r.repoConfigPaths)
+	if fingerprint == r.fingerprint {
+		return false, nil
+	}
+
+	repositories, err := rpmmd.LoadAllRepositories(r.repoConfigPaths)
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	r.repos = repositories
+	r.mu.Unlock()
+	r.fingerprint = fingerprint
+
+	return true, nil
+}
+
+// Watch reloads the repository definition files every interval, whenever
+// they changed, until stop is closed.
+func (r *RepoRegistry) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			reloaded, err := r.Reload()
+			if err != nil {
+				logrus.Errorf("Error reloading repository definitions, keeping the previous ones: %v", err)
+			} else if reloaded {
+				logrus.Info("Reloaded repository definitions")
+			}
+		}
+	}
+}
+
+// ListDistros returns the names of all distributions which have
+// repositories, sorted by name.
+func (r *RepoRegistry) ListDistros() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	distros := make([]string, 0, len(r.repos))
+	for name := range r.repos {
+		distros = append(distros, name)
+	}
+	sort.Strings(distros)
+	return distros
+}
+
+// DistroRepos returns the repositories of all architectures of the distro,
+// and a found flag
+func (r *RepoRegistry) DistroRepos(distro string) (map[string][]rpmmd.RepoConfig, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	distroRepos, found := r.repos[distro]
+	return distroRepos, found
+}
+
+// SetRepository adds repo to the repositories of the distro and arch, or
+// replaces the repository of the same name. validate is called with the
+// resulting repositories of the architecture, the change is only applied
+// when it returns no error.
+//
+// When the registry was loaded from repository definition files, repo is
+// saved in the override file of the distro in the first of its paths. The
+// definition files themselves are left untouched, so that later updates of
+// the other repositories still apply.
+func (r *RepoRegistry) SetRepository(distro, arch string, repo rpmmd.RepoConfig, validate func([]rpmmd.RepoConfig) error) error {
+	r.updateMu.Lock()
+	defer r.updateMu.Unlock()
+
+	distroRepos, _ := r.DistroRepos(distro)
+
+	// never modify the current repositories, they might be in use
+	archRepos := make([]rpmmd.RepoConfig, 0, len(distroRepos[arch])+1)
+	replaced := false
+	for _, archRepo := range distroRepos[arch] {
+		if archRepo.Name == repo.Name {
+			archRepo = repo
+			replaced = true
+		}
+		archRepos = append(archRepos, archRepo)
+	}
+	if !replaced {
+		archRepos = append(archRepos, repo)
+	}
+
+	if validate != nil {
+		err := validate(archRepos)
+		if err != nil {
+			return err
+		}
+	}
+
+	newDistroRepos := make(map[string][]rpmmd.RepoConfig, len(distroRepos)+1)
+	for a, repos := range distroRepos {
+		newDistroRepos[a] = repos
+	}
+	newDistroRepos[arch] = archRepos
+
+	if len(r.repoConfigPaths) > 0 {
+		filename := rpmmd.RepoOverrideFile(r.repoConfigPaths[0], distro)
+		err := rpmmd.SaveRepoOverride(filename, arch, repo)
+		if err != nil {
+			return fmt.Errorf("error saving repositories of %s: %v", distro, err)
+		}
+		r.fingerprint = repoFilesFingerprint(r.repoConfigPaths)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	newRepos := make(rpmmd.DistrosRepoConfigs, len(r.repos)+1)
+	for d, repos := range r.repos {
+		newRepos[d] = repos
+	}
+	newRepos[distro] = newDistroRepos
+	r.repos = newRepos
+
+	return nil
 }
 
 // ReposByImageType returns a slice of rpmmd.RepoConfig instances, which should be used for building the specific
@@ -115,6 +295,9 @@ func (r *RepoRegistry) ReposByArchName(distro, arch string, includeTagged bool)
 
 // DistroHasRepos returns the repositories for the distro+arch, and a found flag
 func (r *RepoRegistry) DistroHasRepos(distro, arch string) (repos []rpmmd.RepoConfig, found bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	distroRepos, found := r.repos[distro]
 	if !found {
 		return repos, false
@@ -1,6 +1,10 @@
 package reporegistry
 
 import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -8,10 +12,11 @@ import (
 	"github.com/osbuild/osbuild-composer/internal/distro/test_distro"
 	"github.com/osbuild/osbuild-composer/internal/rpmmd"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func getTestingRepoRegistry() *RepoRegistry {
-	return &RepoRegistry{
+	return NewFromDistrosRepoConfigs(
 		map[string]map[string][]rpmmd.RepoConfig{
 			test_distro.TestDistroName: {
 				test_distro.TestArchName: {
@@ -47,7 +52,7 @@ func getTestingRepoRegistry() *RepoRegistry {
 				},
 			},
 		},
-	}
+	)
 }
 
 func TestReposByImageType_reposByImageTypeName(t *testing.T) {
@@ -402,3 +407,98 @@ func TestInvalidReposByArchName(t *testing.T) {
 		})
 	}
 }
+
+func writeRepoFile(t *testing.T, dir, distro, baseURL string) {
+	data := `{"x86_64": [{"name": "baseos", "baseurl": "` + baseURL + `"}]}`
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "repositories"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "repositories", distro+".json"), []byte(data), 0644))
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	writeRepoFile(t, dir, "rhel-86", "https://example.com/1")
+
+	rr, err := New([]string{dir})
+	require.NoError(t, err)
+
+	reloaded, err := rr.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	writeRepoFile(t, dir, "rhel-86", "https://example.com/2-changed")
+	writeRepoFile(t, dir, "rhel-90", "https://example.com/3")
+	reloaded, err = rr.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, []string{"rhel-86", "rhel-90"}, rr.ListDistros())
+	repos, err := rr.ReposByArchName("rhel-86", "x86_64", false)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/2-changed", repos[0].BaseURL)
+
+	// broken files don't replace the loaded repositories
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "repositories", "rhel-86.json"), []byte("{"), 0644))
+	_, err = rr.Reload()
+	require.Error(t, err)
+	repos, err = rr.ReposByArchName("rhel-86", "x86_64", false)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/2-changed", repos[0].BaseURL)
+}
+
+func TestSetRepository(t *testing.T) {
+	// repositories are saved in the first path, which overrides the others
+	etcDir := t.TempDir()
+	usrDir := t.TempDir()
+	writeRepoFile(t, usrDir, "rhel-86", "https://example.com/baseos")
+
+	rr, err := New([]string{etcDir, usrDir})
+	require.NoError(t, err)
+
+	extra := rpmmd.RepoConfig{Name: "extra", BaseURL: "https://example.com/extra", ImageTypeTags: []string{"qcow2"}}
+	err = rr.SetRepository("rhel-86", "x86_64", extra, func(repos []rpmmd.RepoConfig) error {
+		return errors.New("invalid")
+	})
+	require.EqualError(t, err, "invalid")
+	repos, _ := rr.DistroHasRepos("rhel-86", "x86_64")
+	assert.Len(t, repos, 1)
+
+	err = rr.SetRepository("rhel-86", "x86_64", extra, func(repos []rpmmd.RepoConfig) error {
+		assert.Len(t, repos, 2)
+		return nil
+	})
+	require.NoError(t, err)
+	err = rr.SetRepository("rhel-86", "x86_64", rpmmd.RepoConfig{Name: "extra", BaseURL: "https://example.com/extra-2"}, nil)
+	require.NoError(t, err)
+
+	expected := []rpmmd.RepoConfig{
+		{Name: "baseos", BaseURL: "https://example.com/baseos"},
+		{Name: "extra", BaseURL: "https://example.com/extra-2"},
+	}
+	repos, _ = rr.DistroHasRepos("rhel-86", "x86_64")
+	assert.Equal(t, expected, repos)
+
+	// only the changed repositories are saved, readable by the owner only
+	overrideFile := filepath.Join(etcDir, "repositories", "rhel-86.override.json")
+	info, err := os.Stat(overrideFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	overrides, err := rpmmd.LoadRepositories([]string{etcDir}, "rhel-86")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]rpmmd.RepoConfig{"x86_64": expected[1:]}, overrides)
+	_, err = os.Stat(filepath.Join(etcDir, "repositories", "rhel-86.json"))
+	assert.True(t, os.IsNotExist(err))
+
+	reloaded, err := New([]string{etcDir, usrDir})
+	require.NoError(t, err)
+	repos, _ = reloaded.DistroHasRepos("rhel-86", "x86_64")
+	assert.Equal(t, expected, repos)
+
+	// updates of the definition file still apply
+	writeRepoFile(t, usrDir, "rhel-86", "https://example.com/baseos-2")
+	_, err = rr.Reload()
+	require.NoError(t, err)
+	repos, _ = rr.DistroHasRepos("rhel-86", "x86_64")
+	assert.Equal(t, []rpmmd.RepoConfig{
+		{Name: "baseos", BaseURL: "https://example.com/baseos-2"},
+		{Name: "extra", BaseURL: "https://example.com/extra-2"},
+	}, repos)
+}
@@ -0,0 +1,372 @@
+package rpmmd
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/osbuild/osbuild-composer/internal/prometheus"
+)
+
+// CacheConfig limits the repository metadata kept in the cache.
+type CacheConfig struct {
+	// The metadata of repositories which were not used for this long is
+	// removed
+	TTL time.Duration
+
+	// When the cache grows larger than this many bytes, the metadata of the
+	// least recently used repositories is removed. 0 means no limit.
+	MaxSize int64
+}
+
+var DefaultCacheConfig = CacheConfig{
+	TTL: 24 * time.Hour,
+}
+
+// RepoCacheStats describes the cached metadata of a repository.
+type RepoCacheStats struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+
+	// Number of requests which used the cached metadata as it was
+	Hits uint64 `json:"hits"`
+	// Number of requests which downloaded new metadata
+	Misses uint64 `json:"misses"`
+
+	// Size of the cached metadata when the repository was used last
+	Bytes       int64     `json:"bytes"`
+	LastUsed    time.Time `json:"last_used"`
+	LastRefresh time.Time `json:"last_refresh"`
+}
+
+var ErrRepoNotCached = errors.New("repository is not cached")
+
+// CacheManager keeps track of the repository metadata which dnf-json caches
+// in a directory. The cleanup itself is done by dnf-json, according to the
+// limits it is passed with every request.
+//
+// Workers use a cache manager of their own for each job. The refresh
+// requests are passed on to it with RefreshRequests() and its statistics are
+// added back with Merge().
+type CacheManager struct {
+	dir    string
+	config CacheConfig
+
+	mu sync.Mutex
+	// stats of all repositories used so far, by URL
+	repos map[string]*RepoCacheStats
+	// when a refresh of all repositories was requested last
+	refreshRequested time.Time
+	// when a refresh of single repositories was requested last, by URL
+	repoRefreshRequested map[string]time.Time
+}
+
+func NewCacheManager(dir string, config CacheConfig) *CacheManager {
+	return &CacheManager{
+		dir:                  dir,
+		config:               config,
+		repos:                make(map[string]*RepoCacheStats),
+		repoRefreshRequested: make(map[string]time.Time),
+	}
+}
+
+// Dir returns the directory of the cache.
+func (m *CacheManager) Dir() string {
+	return m.dir
+}
+
+// Config returns the limits of the cache.
+func (m *CacheManager) Config() CacheConfig {
+	return m.config
+}
+
+// Refresh makes the next request for each repository download its metadata
+// again, even if the cached metadata did not expire yet.
+func (m *CacheManager) Refresh() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.refreshRequested = time.Now()
+}
+
+// RefreshRepo makes the next request for the repositories with the given
+// name download their metadata again. It returns ErrRepoNotCached if no
+// repository with that name was used yet.
+func (m *CacheManager) RefreshRepo(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	found := false
+	now := time.Now()
+	for url, repo := range m.repos {
+		if repo.Name == name {
+			m.repoRefreshRequested[url] = now
+			found = true
+		}
+	}
+	if !found {
+		return ErrRepoNotCached
+	}
+	return nil
+}
+
+// refreshRequestedFor returns when a refresh of the repository with the
+// given URL was requested last. The mutex must be held.
+func (m *CacheManager) refreshRequestedFor(url string) time.Time {
+	if requested := m.repoRefreshRequested[url]; requested.After(m.refreshRequested) {
+		return requested
+	}
+	return m.refreshRequested
+}
+
+// RefreshRequests returns when a refresh of the metadata of repos was
+// requested last, by the URL of the repositories, to pass them on to the
+// cache manager of a worker with AddRefreshRequests().
+func (m *CacheManager) RefreshRequests(repos []RepoConfig) map[string]time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	requests := make(map[string]time.Time)
+	for _, repo := range repos {
+		url := cacheURL(repo)
+		if requested := m.refreshRequestedFor(url); !requested.IsZero() {
+			requests[url] = requested
+		}
+	}
+	return requests
+}
+
+// AddRefreshRequests adds the refresh requests returned by RefreshRequests()
+// of another cache manager.
+func (m *CacheManager) AddRefreshRequests(requests map[string]time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for url, requested := range requests {
+		if requested.After(m.repoRefreshRequested[url]) {
+			m.repoRefreshRequested[url] = requested
+		}
+	}
+}
+
+// Merge adds the statistics of another cache manager, which used the
+// metadata of the repositories in a cache of its own.
+func (m *CacheManager) Merge(stats []RepoCacheStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, s := range stats {
+		r := m.repo(s.URL, s.Name)
+		r.Hits += s.Hits
+		r.Misses += s.Misses
+		r.Bytes = s.Bytes
+		if s.LastUsed.After(r.LastUsed) {
+			r.LastUsed = s.LastUsed
+		}
+		if s.LastRefresh.After(r.LastRefresh) {
+			r.LastRefresh = s.LastRefresh
+		}
+		prometheus.MetadataCacheHits.WithLabelValues(r.Name).Add(float64(s.Hits))
+		prometheus.MetadataCacheMisses.WithLabelValues(r.Name).Add(float64(s.Misses))
+		prometheus.MetadataCacheBytes.WithLabelValues(r.Name).Set(float64(r.Bytes))
+		prometheus.MetadataCacheLastRefresh.WithLabelValues(r.Name).Set(float64(r.LastRefresh.Unix()))
+	}
+}
+
+// repo returns the statistics of the repository with the given URL, which
+// are created if it was not used yet. The mutex must be held.
+func (m *CacheManager) repo(url, name string) *RepoCacheStats {
+	r, ok := m.repos[url]
+	if !ok {
+		r = &RepoCacheStats{URL: url}
+		m.repos[url] = r
+	}
+	if name != "" {
+		r.Name = name
+	} else if r.Name == "" {
+		r.Name = url
+	}
+	return r
+}
+
+// Stats returns the statistics of all repositories used since composer
+// started, sorted by name.
+func (m *CacheManager) Stats() []RepoCacheStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make([]RepoCacheStats, 0, len(m.repos))
+	for _, repo := range m.repos {
+		stats = append(stats, *repo)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Name == stats[j].Name {
+			return stats[i].URL < stats[j].URL
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+// cacheURL returns the URL dnf uses to name the cache directory of repo
+func cacheURL(repo RepoConfig) string {
+	switch {
+	case repo.Metalink != "":
+		return repo.Metalink
+	case repo.MirrorList != "":
+		return repo.MirrorList
+	default:
+		return repo.BaseURL
+	}
+}
+
+func urlDigest(url string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(url)))[:16]
+}
+
+// repoDir returns the cache directory dnf uses for the repository with the
+// given id and URL.
+func (m *CacheManager) repoDir(id, url string) string {
+	return filepath.Join(m.dir, id+"-"+urlDigest(url))
+}
+
+// size returns the size of all cache directories of the repository with the
+// given URL, which is cached once for each id it was used with.
+func (m *CacheManager) size(url string) int64 {
+	dirs, _ := filepath.Glob(filepath.Join(m.dir, "*-"+urlDigest(url)))
+
+	var size int64
+	for _, dir := range dirs {
+		_ = filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+			if err == nil && info.Mode().IsRegular() {
+				size += info.Size()
+			}
+			return nil
+		})
+	}
+	return size
+}
+
+func (m *CacheManager) repomdPath(id, url string) string {
+	return filepath.Join(m.repoDir(id, url), "repodata", "repomd.xml")
+}
+
+func (m *CacheManager) repomdChecksum(id, url string) string {
+	data, err := ioutil.ReadFile(m.repomdPath(id, url))
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// repomdModTime returns when the metadata of a repository was downloaded
+// last, the zero time if it is not cached
+func (m *CacheManager) repomdModTime(id, url string) time.Time {
+	info, err := os.Stat(m.repomdPath(id, url))
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// cacheRequest is the state of the cache before a request to dnf-json
+type cacheRequest struct {
+	refresh   bool
+	checksums []string
+}
+
+// prepare records the state of the cached metadata of repos before they are
+// used and returns whether their metadata has to be refreshed.
+func (m *CacheManager) prepare(repos []RepoConfig) *cacheRequest {
+	req := &cacheRequest{
+		checksums: make([]string, len(repos)),
+	}
+	modTimes := make([]time.Time, len(repos))
+	for i, repo := range repos {
+		url := cacheURL(repo)
+		req.checksums[i] = m.repomdChecksum(strconv.Itoa(i), url)
+		modTimes[i] = m.repomdModTime(strconv.Itoa(i), url)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, repo := range repos {
+		url := cacheURL(repo)
+		// metadata cached by another process was refreshed when it was
+		// written
+		lastRefresh := modTimes[i]
+		if r, ok := m.repos[url]; ok {
+			lastRefresh = r.LastRefresh
+		}
+		if lastRefresh.Before(m.refreshRequestedFor(url)) {
+			req.refresh = true
+		}
+	}
+	// metadata which is not cached yet is downloaded anyway
+	if req.refresh {
+		for _, checksum := range req.checksums {
+			if checksum != "" {
+				return req
+			}
+		}
+		req.refresh = false
+	}
+	return req
+}
+
+// update updates the statistics of repos after they were used.
+func (m *CacheManager) update(repos []RepoConfig, req *cacheRequest) {
+	// look at the cache before taking the mutex, walking it takes a while
+	checksums := make([]string, len(repos))
+	modTimes := make([]time.Time, len(repos))
+	sizes := make([]int64, len(repos))
+	for i, repo := range repos {
+		url := cacheURL(repo)
+		checksums[i] = m.repomdChecksum(strconv.Itoa(i), url)
+		if checksums[i] != "" {
+			modTimes[i] = m.repomdModTime(strconv.Itoa(i), url)
+			sizes[i] = m.size(url)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for i, repo := range repos {
+		url := cacheURL(repo)
+		checksum := checksums[i]
+		if checksum == "" {
+			// dnf-json failed to fetch the metadata
+			continue
+		}
+
+		r := m.repo(url, repo.Name)
+		r.LastUsed = now
+		if req.refresh {
+			r.LastRefresh = now
+		} else if r.LastRefresh.IsZero() {
+			// cached before composer started
+			r.LastRefresh = modTimes[i]
+		}
+		if checksum == req.checksums[i] {
+			r.Hits++
+			prometheus.MetadataCacheHits.WithLabelValues(r.Name).Inc()
+		} else {
+			r.Misses++
+			r.LastRefresh = now
+			prometheus.MetadataCacheMisses.WithLabelValues(r.Name).Inc()
+		}
+		r.Bytes = sizes[i]
+		prometheus.MetadataCacheBytes.WithLabelValues(r.Name).Set(float64(r.Bytes))
+		prometheus.MetadataCacheLastRefresh.WithLabelValues(r.Name).Set(float64(r.LastRefresh.Unix()))
+	}
+}
@@ -0,0 +1,127 @@
+package rpmmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheManager(t *testing.T) {
+	dir := t.TempDir()
+	m := NewCacheManager(dir, DefaultCacheConfig)
+	repos := []RepoConfig{{Name: "baseos", BaseURL: "https://example.com/baseos/"}}
+
+	// pretend to be dnf-json, which writes the metadata into the cache
+	writeRepomd := func(content string) {
+		repodata := filepath.Join(m.repoDir("0", repos[0].BaseURL), "repodata")
+		require.NoError(t, os.MkdirAll(repodata, 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(repodata, "repomd.xml"), []byte(content), 0644))
+	}
+
+	req := m.prepare(repos)
+	assert.False(t, req.refresh)
+	writeRepomd("first")
+	m.update(repos, req)
+
+	stats := m.Stats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, "baseos", stats[0].Name)
+	assert.Equal(t, "https://example.com/baseos/", stats[0].URL)
+	assert.Equal(t, uint64(0), stats[0].Hits)
+	assert.Equal(t, uint64(1), stats[0].Misses)
+	assert.Equal(t, int64(len("first")), stats[0].Bytes)
+	assert.False(t, stats[0].LastRefresh.IsZero())
+	firstRefresh := stats[0].LastRefresh
+
+	req = m.prepare(repos)
+	assert.False(t, req.refresh)
+	m.update(repos, req)
+	stats = m.Stats()
+	assert.Equal(t, uint64(1), stats[0].Hits)
+	assert.Equal(t, firstRefresh, stats[0].LastRefresh)
+
+	m.Refresh()
+	req = m.prepare(repos)
+	assert.True(t, req.refresh)
+	writeRepomd("second")
+	m.update(repos, req)
+	stats = m.Stats()
+	assert.Equal(t, uint64(2), stats[0].Misses)
+	assert.True(t, stats[0].LastRefresh.After(firstRefresh))
+
+	// refreshed already
+	req = m.prepare(repos)
+	assert.False(t, req.refresh)
+}
+
+func TestCacheManagerRefreshRepo(t *testing.T) {
+	dir := t.TempDir()
+	m := NewCacheManager(dir, DefaultCacheConfig)
+	repos := []RepoConfig{
+		{Name: "baseos", BaseURL: "https://example.com/baseos/"},
+		{Name: "appstream", BaseURL: "https://example.com/appstream/"},
+	}
+	for i, repo := range repos {
+		repodata := filepath.Join(m.repoDir(strconv.Itoa(i), repo.BaseURL), "repodata")
+		require.NoError(t, os.MkdirAll(repodata, 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(repodata, "repomd.xml"), []byte(repo.Name), 0644))
+	}
+	m.update(repos, m.prepare(repos))
+
+	assert.Equal(t, ErrRepoNotCached, m.RefreshRepo("unknown"))
+	assert.Empty(t, m.RefreshRequests(repos))
+
+	require.NoError(t, m.RefreshRepo("appstream"))
+	requests := m.RefreshRequests(repos)
+	assert.Len(t, requests, 1)
+	assert.Contains(t, requests, "https://example.com/appstream/")
+	assert.True(t, m.prepare(repos).refresh)
+	assert.False(t, m.prepare(repos[:1]).refresh)
+
+	// a worker's cache manager, which did not use the repositories yet,
+	// refreshes metadata which was written before the request
+	worker := NewCacheManager(dir, DefaultCacheConfig)
+	assert.False(t, worker.prepare(repos).refresh)
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(worker.repomdPath("1", repos[1].BaseURL), old, old))
+	worker.AddRefreshRequests(requests)
+	assert.True(t, worker.prepare(repos).refresh)
+}
+
+func TestCacheManagerMerge(t *testing.T) {
+	m := NewCacheManager(t.TempDir(), DefaultCacheConfig)
+	used := time.Now()
+
+	m.Merge([]RepoCacheStats{{Name: "baseos", URL: "https://example.com/baseos/", Hits: 2, Misses: 1, Bytes: 100, LastUsed: used, LastRefresh: used}})
+	m.Merge([]RepoCacheStats{{Name: "baseos", URL: "https://example.com/baseos/", Hits: 1, Bytes: 120, LastUsed: used.Add(time.Minute), LastRefresh: used.Add(-time.Hour)}})
+
+	assert.Equal(t, []RepoCacheStats{{
+		Name:        "baseos",
+		URL:         "https://example.com/baseos/",
+		Hits:        3,
+		Misses:      1,
+		Bytes:       120,
+		LastUsed:    used.Add(time.Minute),
+		LastRefresh: used,
+	}}, m.Stats())
+}
+
+func TestCacheManagerFailedFetch(t *testing.T) {
+	m := NewCacheManager(t.TempDir(), DefaultCacheConfig)
+	repos := []RepoConfig{{Metalink: "https://example.com/metalink"}}
+
+	m.update(repos, m.prepare(repos))
+	assert.Empty(t, m.Stats())
+}
+
+func TestCacheURL(t *testing.T) {
+	assert.Equal(t, "https://example.com/metalink", cacheURL(RepoConfig{BaseURL: "https://example.com/os", Metalink: "https://example.com/metalink"}))
+	assert.Equal(t, "https://example.com/mirrors", cacheURL(RepoConfig{BaseURL: "https://example.com/os", MirrorList: "https://example.com/mirrors"}))
+	assert.Equal(t, "https://example.com/os", cacheURL(RepoConfig{BaseURL: "https://example.com/os"}))
+}
@@ -0,0 +1,322 @@
+package rpmmd
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const gpgKeyHeader = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+
+// IsInlineGPGKey returns true if key holds ASCII armored key data instead of
+// the location of a key.
+func IsInlineGPGKey(key string) bool {
+	return strings.Contains(key, gpgKeyHeader)
+}
+
+// IsGPGKeyURL returns true if key is the http:// or https:// URL of a key.
+func IsGPGKeyURL(key string) bool {
+	u, err := url.Parse(key)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// GPGKeyCache fetches GPG keys from their locations on first use and keeps
+// them for the following manifests. Keys are fetched again once they are
+// older than the TTL. If that fails, the previously fetched key is used.
+type GPGKeyCache struct {
+	client *http.Client
+	ttl    time.Duration
+	// time after which a key that could not be fetched is tried again
+	retry time.Duration
+
+	mu   sync.Mutex
+	keys map[string]gpgKeyCacheEntry
+}
+
+type gpgKeyCacheEntry struct {
+	data    string
+	fetched time.Time
+	failed  time.Time
+}
+
+// NewGPGKeyCache returns a cache which keeps fetched keys for ttl.
+func NewGPGKeyCache(ttl time.Duration) *GPGKeyCache {
+	return &GPGKeyCache{
+		client: &http.Client{Timeout: 10 * time.Second},
+		ttl:    ttl,
+		retry:  time.Minute,
+		keys:   make(map[string]gpgKeyCacheEntry),
+	}
+}
+
+// DefaultGPGKeyCache is used by ResolveGPGKeys.
+var DefaultGPGKeyCache = NewGPGKeyCache(24 * time.Hour)
+
+// ResolveGPGKeys returns a copy of repos, with the GPG key locations of each
+// repository replaced by the key data from DefaultGPGKeyCache.
+func ResolveGPGKeys(repos []RepoConfig, arch, releasever string) []RepoConfig {
+	resolved := make([]RepoConfig, len(repos))
+	for i, repo := range repos {
+		resolved[i] = repo
+		resolved[i].GPGKeys = DefaultGPGKeyCache.Keys(repo.GPGKeys, arch, releasever)
+	}
+	return resolved
+}
+
+// Keys returns the ASCII armored data of the GPG keys in keys. Each entry
+// either holds the key data itself, or a file path, file:// URL or
+// http(s):// URL to read the key from, in which $basearch and $releasever
+// are substituted. Keys which cannot be read are logged and left out.
+func (c *GPGKeyCache) Keys(keys []string, arch, releasever string) []string {
+	replacer := strings.NewReplacer("$basearch", arch, "$releasever", releasever)
+
+	var data []string
+	for _, key := range keys {
+		if IsInlineGPGKey(key) {
+			data = append(data, key)
+			continue
+		}
+
+		content, err := c.key(replacer.Replace(key))
+		if err != nil {
+			logrus.Warnf("Leaving out GPG key: %v", err)
+			continue
+		}
+		data = append(data, content)
+	}
+	return data
+}
+
+// key returns the key data from location, reading it if the cached data is
+// missing or expired
+func (c *GPGKeyCache) key(location string) (string, error) {
+	c.mu.Lock()
+	entry, ok := c.keys[location]
+	c.mu.Unlock()
+
+	now := time.Now()
+	if ok && now.Sub(entry.fetched) < c.ttl {
+		return entry.data, nil
+	}
+	if ok && now.Sub(entry.failed) < c.retry {
+		if entry.data != "" {
+			return entry.data, nil
+		}
+		return "", fmt.Errorf("cannot fetch GPG key from %s, retrying after %v", location, c.retry)
+	}
+
+	// read the key without holding the lock, a slow server must not block
+	// the keys of other repositories
+	content, err := c.fetch(location)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry = c.keys[location]
+	if err != nil {
+		entry.failed = now
+		c.keys[location] = entry
+		if entry.data != "" {
+			logrus.Warnf("Using previously fetched GPG key: %v", err)
+			return entry.data, nil
+		}
+		return "", err
+	}
+	c.keys[location] = gpgKeyCacheEntry{data: content, fetched: now}
+	return content, nil
+}
+
+func (c *GPGKeyCache) fetch(location string) (string, error) {
+	var content []byte
+	var err error
+	u, _ := url.Parse(location)
+	switch {
+	case strings.HasPrefix(location, "/"):
+		content, err = ioutil.ReadFile(location)
+	case u != nil && u.Scheme == "file":
+		content, err = ioutil.ReadFile(u.Path)
+	case IsGPGKeyURL(location):
+		content, err = fetchURL(c.client, location)
+	default:
+		return "", fmt.Errorf("invalid GPG key '%s': neither key data nor a path or URL", location)
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot fetch GPG key from %s: %v", location, err)
+	}
+	if !IsInlineGPGKey(string(content)) {
+		return "", fmt.Errorf("%s does not contain an ASCII armored GPG public key", location)
+	}
+	return string(content), nil
+}
+
+func fetchURL(client *http.Client, u string) ([]byte, error) {
+	resp, err := client.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// OpenPGP signature subpacket types, see RFC 4880, section 5.2.3.1
+const (
+	subpacketIssuer            = 16
+	subpacketIssuerFingerprint = 33
+)
+
+var errInvalidSignature = errors.New("invalid OpenPGP signature")
+
+// SignatureKeyID returns the ID of the key which created an OpenPGP
+// signature, given as hex string like the SIGPGP and SIGGPG RPM header tags.
+// The ID is returned as 16 lower case hex digits.
+func SignatureKeyID(signature string) (string, error) {
+	data, err := hex.DecodeString(signature)
+	if err != nil {
+		return "", errInvalidSignature
+	}
+
+	body, err := signaturePacketBody(data)
+	if err != nil {
+		return "", err
+	}
+
+	switch body[0] {
+	case 3:
+		// version, hashed length (always 5), type, creation time, key ID
+		if len(body) < 15 {
+			return "", errInvalidSignature
+		}
+		return hex.EncodeToString(body[7:15]), nil
+	case 4:
+		// version, type, public key algorithm, hash algorithm, followed by
+		// the hashed and unhashed subpackets
+		rest := body[4:]
+		for i := 0; i < 2; i++ {
+			if len(rest) < 2 {
+				return "", errInvalidSignature
+			}
+			length := int(binary.BigEndian.Uint16(rest))
+			if len(rest) < 2+length {
+				return "", errInvalidSignature
+			}
+			if id := subpacketsKeyID(rest[2 : 2+length]); id != "" {
+				return id, nil
+			}
+			rest = rest[2+length:]
+		}
+		return "", errors.New("OpenPGP signature does not name its issuer")
+	default:
+		return "", fmt.Errorf("unsupported OpenPGP signature version %d", body[0])
+	}
+}
+
+// signaturePacketBody returns the body of the signature packet in data
+func signaturePacketBody(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0]&0x80 == 0 {
+		return nil, errInvalidSignature
+	}
+
+	var tag byte
+	var length, offset int
+	if data[0]&0x40 == 0 {
+		// old format packet
+		tag = (data[0] >> 2) & 0x0f
+		switch data[0] & 0x03 {
+		case 0:
+			length, offset = int(data[1]), 2
+		case 1:
+			if len(data) < 3 {
+				return nil, errInvalidSignature
+			}
+			length, offset = int(binary.BigEndian.Uint16(data[1:])), 3
+		case 2:
+			if len(data) < 5 {
+				return nil, errInvalidSignature
+			}
+			length, offset = int(binary.BigEndian.Uint32(data[1:])), 5
+		default:
+			length, offset = len(data)-1, 1
+		}
+	} else {
+		// new format packet
+		tag = data[0] & 0x3f
+		switch {
+		case data[1] < 192:
+			length, offset = int(data[1]), 2
+		case data[1] < 224:
+			if len(data) < 3 {
+				return nil, errInvalidSignature
+			}
+			length, offset = (int(data[1])-192)<<8+int(data[2])+192, 3
+		case data[1] == 255:
+			if len(data) < 6 {
+				return nil, errInvalidSignature
+			}
+			length, offset = int(binary.BigEndian.Uint32(data[2:])), 6
+		default:
+			return nil, errors.New("partial OpenPGP packet lengths are not supported")
+		}
+	}
+
+	if tag != 2 {
+		return nil, fmt.Errorf("OpenPGP packet with tag %d is not a signature", tag)
+	}
+	if length < 1 || offset+length > len(data) {
+		return nil, errInvalidSignature
+	}
+	return data[offset : offset+length], nil
+}
+
+// subpacketsKeyID returns the issuer key ID named by the signature
+// subpackets in data, or an empty string if there is none
+func subpacketsKeyID(data []byte) string {
+	for len(data) > 0 {
+		var length, offset int
+		switch {
+		case data[0] < 192:
+			length, offset = int(data[0]), 1
+		case data[0] < 255:
+			if len(data) < 2 {
+				return ""
+			}
+			length, offset = (int(data[0])-192)<<8+int(data[1])+192, 2
+		default:
+			if len(data) < 5 {
+				return ""
+			}
+			length, offset = int(binary.BigEndian.Uint32(data[1:])), 5
+		}
+		if length < 1 || offset+length > len(data) {
+			return ""
+		}
+
+		subpacket := data[offset : offset+length]
+		// the highest bit of the type marks critical subpackets
+		switch subpacket[0] & 0x7f {
+		case subpacketIssuer:
+			if len(subpacket) == 9 {
+				return hex.EncodeToString(subpacket[1:])
+			}
+		case subpacketIssuerFingerprint:
+			// version followed by the fingerprint, whose last 8 bytes
+			// are the key ID of v4 keys
+			if len(subpacket) == 22 {
+				return hex.EncodeToString(subpacket[14:])
+			}
+		}
+		data = data[offset+length:]
+	}
+	return ""
+}
@@ -0,0 +1,124 @@
+package rpmmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGPGKey = `-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+mQINBErgSTsBEACh2A4b0O9t+vzC9VrVtL1AKvUWi9OPCjkvR7Xd8DtJxeeMZ5eF
+-----END PGP PUBLIC KEY BLOCK-----
+`
+
+func TestGPGKeyCache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "RPM-GPG-KEY-test")
+	require.NoError(t, ioutil.WriteFile(path, []byte(testGPGKey), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "RPM-GPG-KEY-34-x86_64"), []byte(testGPGKey), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "not-a-key"), []byte("hello"), 0600))
+
+	requests := 0
+	fileServer := http.FileServer(http.Dir(dir))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fileServer.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	cache := NewGPGKeyCache(time.Hour)
+	keys := cache.Keys([]string{
+		testGPGKey,
+		path,
+		"file://" + path,
+		server.URL + "/RPM-GPG-KEY-test",
+		server.URL + "/RPM-GPG-KEY-$releasever-$basearch",
+	}, "x86_64", "34")
+	assert.Equal(t, []string{testGPGKey, testGPGKey, testGPGKey, testGPGKey, testGPGKey}, keys)
+	assert.Equal(t, 2, requests)
+
+	// keys are read only once
+	keys = cache.Keys([]string{server.URL + "/RPM-GPG-KEY-test"}, "x86_64", "34")
+	assert.Equal(t, []string{testGPGKey}, keys)
+	assert.Equal(t, 2, requests)
+
+	// keys which cannot be read are left out
+	for _, invalid := range []string{
+		"RPM-GPG-KEY-test",
+		filepath.Join(dir, "missing"),
+		server.URL + "/missing",
+		server.URL + "/not-a-key",
+	} {
+		keys = cache.Keys([]string{invalid, testGPGKey}, "x86_64", "34")
+		assert.Equal(t, []string{testGPGKey}, keys, invalid)
+	}
+
+	// expired keys are read again, but kept if that fails
+	cache.ttl = 0
+	cache.retry = 0
+	require.NoError(t, os.Remove(path))
+	keys = cache.Keys([]string{path, server.URL + "/RPM-GPG-KEY-test"}, "x86_64", "34")
+	assert.Equal(t, []string{testGPGKey, testGPGKey}, keys)
+	assert.Equal(t, 5, requests)
+}
+
+func TestResolveGPGKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "RPM-GPG-KEY-test")
+	require.NoError(t, ioutil.WriteFile(path, []byte(testGPGKey), 0600))
+
+	repos := []RepoConfig{{Name: "repo", GPGKeys: []string{path}}}
+	resolved := ResolveGPGKeys(repos, "x86_64", "34")
+	assert.Equal(t, []RepoConfig{{Name: "repo", GPGKeys: []string{testGPGKey}}}, resolved)
+	// the locations of the given repositories are kept
+	assert.Equal(t, []string{path}, repos[0].GPGKeys)
+}
+
+func TestSignatureKeyID(t *testing.T) {
+	// v3 signature of a RHEL package, made with the Red Hat release key 2
+	v3 := "8902150305005c144def199e2f91fd431d510108a5a10fff59ded2755aee521d" +
+		"f20b65506c9e0bd5fb947a6bb67ad2b4a8a61b6e4785b071ce6a03249a3cecd8" +
+		"9f38b65aada8deba04f5ff01db38857f9900ff2a51064f8ac6fd8262a4f70c55" +
+		"280800b887df66459f13b977b37fab7c24519d13013c025d06acb353511a66f8" +
+		"05f13070d6daca5b32410742fcc0420c92f84c182e4745560e1b3aceb770664a" +
+		"7639b835fe3b45bd61d92efe417d75248a7d827a10747ee7de242ff900a7a517" +
+		"ab20b4fdee6e70c0bd80017c9ed7ea82a2c3edc50a146ba3b1f94b04ad344c93" +
+		"89fa4e9241d5a735f89d7d6f29024f328d83826cad7220de2ce4ef40147a0b51" +
+		"41efd139fe7bdede484238ea37f614465493058cfd57b89a97fa6b4d1de68138" +
+		"e51282ba0405b20d3484122839fee6aaaa0406b19fb630e0b074e243a9fb851d" +
+		"fb6620b4603a59209784d87a2e7f9a37586f9c082b232a94edf674e3888eab8f" +
+		"d511f84e0ed84bd17182f72a0d17b894be7eadcafe2383c367082a414d3a8335" +
+		"a0f1ee976dc3d475d14beaf64f750c3dc7008e53c4245ca4bdb15597bb21ec09" +
+		"3b3516a2b6b209ea081863bee863bb85d3fd0529b06793e0c78ec9a693a9b427" +
+		"0e84db2683c6a279bef0aa323137559c7373adebb13ff1c2c5f8fa6c45f45891" +
+		"73cc1caf03c50f53bdf11d417797a148ee97becf3c995edc872d0773d941f1eb" +
+		"69f9e827f53580e9bae94501f2b0eac65280126b4afffdca"
+	keyID, err := SignatureKeyID(v3)
+	require.NoError(t, err)
+	assert.Equal(t, "199e2f91fd431d51", keyID)
+
+	// v4 signatures, with the key ID in an issuer subpacket in the unhashed
+	// area and in an issuer fingerprint subpacket in the hashed area
+	body := "0400010800" + "06" + "0502" + "5c144def" + "000a" + "0910" + "199e2f91fd431d51" + "beef"
+	keyID, err = SignatureKeyID(fmt.Sprintf("c2%02x", len(body)/2) + body)
+	require.NoError(t, err)
+	assert.Equal(t, "199e2f91fd431d51", keyID)
+
+	body = "0400010800" + "17" + "1621" + "04" + "567e347ad0044ade55ba8a5f199e2f91fd431d51" + "0000" + "beef"
+	keyID, err = SignatureKeyID(fmt.Sprintf("89%04x", len(body)/2) + body)
+	require.NoError(t, err)
+	assert.Equal(t, "199e2f91fd431d51", keyID)
+
+	for _, invalid := range []string{"", "zz", "8902", "b90003010203", "8803030000"} {
+		_, err = SignatureKeyID(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
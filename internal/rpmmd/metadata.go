@@ -59,6 +59,20 @@ func PackageMetadataToSignature(pkg osbuild.RPMPackageMetadata) *string {
 	return nil
 }
 
+// PackageMetadataToSignerKeyID returns the ID of the key which signed the
+// package, or nil if the package is not signed.
+func PackageMetadataToSignerKeyID(pkg osbuild.RPMPackageMetadata) *string {
+	signature := PackageMetadataToSignature(pkg)
+	if signature == nil {
+		return nil
+	}
+	keyID, err := SignatureKeyID(*signature)
+	if err != nil {
+		return nil
+	}
+	return &keyID
+}
+
 // Deduplicate a list of RPMs based on NEVRA string
 func DeduplicateRPMs(rpms []RPM) []RPM {
 	rpmMap := make(map[string]struct{}, len(rpms))
@@ -3,12 +3,15 @@ package rpmmd
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
@@ -26,23 +29,31 @@ type repository struct {
 	Metalink       string   `json:"metalink,omitempty"`
 	MirrorList     string   `json:"mirrorlist,omitempty"`
 	GPGKey         string   `json:"gpgkey,omitempty"`
+	GPGKeys        []string `json:"gpgkeys,omitempty"`
 	CheckGPG       bool     `json:"check_gpg,omitempty"`
 	RHSM           bool     `json:"rhsm,omitempty"`
 	MetadataExpire string   `json:"metadata_expire,omitempty"`
 	ImageTypeTags  []string `json:"image_type_tags,omitempty"`
+	SSLCACert      string   `json:"sslcacert,omitempty"`
+	SSLClientKey   string   `json:"sslclientkey,omitempty"`
+	SSLClientCert  string   `json:"sslclientcert,omitempty"`
+	Proxy          string   `json:"proxy,omitempty"`
 }
 
 type dnfRepoConfig struct {
-	ID             string `json:"id"`
-	BaseURL        string `json:"baseurl,omitempty"`
-	Metalink       string `json:"metalink,omitempty"`
-	MirrorList     string `json:"mirrorlist,omitempty"`
-	GPGKey         string `json:"gpgkey,omitempty"`
-	IgnoreSSL      bool   `json:"ignoressl"`
-	SSLCACert      string `json:"sslcacert,omitempty"`
-	SSLClientKey   string `json:"sslclientkey,omitempty"`
-	SSLClientCert  string `json:"sslclientcert,omitempty"`
-	MetadataExpire string `json:"metadata_expire,omitempty"`
+	ID             string   `json:"id"`
+	BaseURL        string   `json:"baseurl,omitempty"`
+	Metalink       string   `json:"metalink,omitempty"`
+	MirrorList     string   `json:"mirrorlist,omitempty"`
+	GPGKeys        []string `json:"gpgkeys,omitempty"`
+	IgnoreSSL      bool     `json:"ignoressl"`
+	SSLCACert      string   `json:"sslcacert,omitempty"`
+	SSLClientKey   string   `json:"sslclientkey,omitempty"`
+	SSLClientCert  string   `json:"sslclientcert,omitempty"`
+	Proxy          string   `json:"proxy,omitempty"`
+	Username       string   `json:"username,omitempty"`
+	Password       string   `json:"password,omitempty"`
+	MetadataExpire string   `json:"metadata_expire,omitempty"`
 }
 
 type RepoConfig struct {
@@ -50,12 +61,23 @@ type RepoConfig struct {
 	BaseURL        string
 	Metalink       string
 	MirrorList     string
-	GPGKey         string
+	GPGKeys        []string
 	CheckGPG       bool
 	IgnoreSSL      bool
 	MetadataExpire string
 	RHSM           bool
 	ImageTypeTags  []string
+	// The TLS settings are either paths to PEM files or inline PEM data
+	SSLCACert     string
+	SSLClientKey  string
+	SSLClientCert string
+	Proxy         string
+	Username      string
+	Password      string
+	// EmbedPackages is set for repositories on the local filesystem, whose
+	// packages cannot be downloaded by the workers and are embedded into
+	// the manifest instead
+	EmbedPackages bool
 }
 
 type DistrosRepoConfigs map[string]map[string][]RepoConfig
@@ -110,13 +132,25 @@ func (pkg Package) ToPackageInfo() PackageInfo {
 type PackageSet struct {
 	Include []string
 	Exclude []string
+
+	// Module streams ("name:stream") to enable and modules to disable
+	// before the packages are depsolved
+	EnabledModules  []string `json:",omitempty"`
+	DisabledModules []string `json:",omitempty"`
+
+	// Do not pull in weak dependencies (Recommends) of the packages
+	ExcludeWeakDeps bool `json:",omitempty"`
 }
 
-// Append the Include and Exclude package list from another PackageSet and
-// return the result.
+// Append the Include and Exclude package list and the module streams from
+// another PackageSet and return the result. Weak dependencies are excluded
+// if either set excludes them.
 func (ps PackageSet) Append(other PackageSet) PackageSet {
 	ps.Include = append(ps.Include, other.Include...)
 	ps.Exclude = append(ps.Exclude, other.Exclude...)
+	ps.EnabledModules = append(ps.EnabledModules, other.EnabledModules...)
+	ps.DisabledModules = append(ps.DisabledModules, other.DisabledModules...)
+	ps.ExcludeWeakDeps = ps.ExcludeWeakDeps || other.ExcludeWeakDeps
 	return ps
 }
 
@@ -131,6 +165,9 @@ type PackageSpec struct {
 	Checksum       string `json:"checksum,omitempty"`
 	Secrets        string `json:"secrets,omitempty"`
 	CheckGPG       bool   `json:"check_gpg,omitempty"`
+	// Path of the package on the local filesystem, for packages of
+	// repositories with EmbedPackages set
+	Path string `json:"path,omitempty"`
 }
 
 type dnfPackageSpec struct {
@@ -203,6 +240,184 @@ func (re *RepositoryError) Error() string {
 	return re.msg
 }
 
+// repoConfig returns the RepoConfig of a repository definition. The GPG keys
+// are only fetched from their locations by ResolveGPGKeys when they are used.
+func (repo repository) repoConfig() RepoConfig {
+	keys := repo.GPGKeys
+	if repo.GPGKey != "" {
+		keys = append([]string{repo.GPGKey}, keys...)
+	}
+
+	return RepoConfig{
+		Name:           repo.Name,
+		BaseURL:        repo.BaseURL,
+		Metalink:       repo.Metalink,
+		MirrorList:     repo.MirrorList,
+		GPGKeys:        keys,
+		CheckGPG:       repo.CheckGPG,
+		RHSM:           repo.RHSM,
+		MetadataExpire: repo.MetadataExpire,
+		ImageTypeTags:  repo.ImageTypeTags,
+		SSLCACert:      repo.SSLCACert,
+		SSLClientKey:   repo.SSLClientKey,
+		SSLClientCert:  repo.SSLClientCert,
+		Proxy:          repo.Proxy,
+	}
+}
+
+func newRepository(config RepoConfig) repository {
+	return repository{
+		Name:           config.Name,
+		BaseURL:        config.BaseURL,
+		Metalink:       config.Metalink,
+		MirrorList:     config.MirrorList,
+		GPGKeys:        config.GPGKeys,
+		CheckGPG:       config.CheckGPG,
+		RHSM:           config.RHSM,
+		MetadataExpire: config.MetadataExpire,
+		ImageTypeTags:  config.ImageTypeTags,
+		SSLCACert:      config.SSLCACert,
+		SSLClientKey:   config.SSLClientKey,
+		SSLClientCert:  config.SSLClientCert,
+		Proxy:          config.Proxy,
+	}
+}
+
+// UnmarshalRepoConfig parses a repository in the format of the repository
+// definition files.
+func UnmarshalRepoConfig(data []byte) (RepoConfig, error) {
+	var repo repository
+	err := json.Unmarshal(data, &repo)
+	if err != nil {
+		return RepoConfig{}, err
+	}
+	if repo.Name == "" {
+		return RepoConfig{}, errors.New("repository is missing a name")
+	}
+	if repo.BaseURL == "" && repo.Metalink == "" && repo.MirrorList == "" {
+		return RepoConfig{}, fmt.Errorf("repository %s: one of baseurl, metalink or mirrorlist is required", repo.Name)
+	}
+	return repo.repoConfig(), nil
+}
+
+// MarshalRepoConfig returns a repository in the format of the repository
+// definition files.
+func MarshalRepoConfig(config RepoConfig) ([]byte, error) {
+	return json.Marshal(newRepository(config))
+}
+
+// repoOverrideSuffix is the suffix of the files holding the repositories of a
+// distribution which were set at runtime, e.g. "fedora-35.override.json".
+// They are applied on top of the repository definition file of the
+// distribution, which may be updated independently.
+const repoOverrideSuffix = ".override.json"
+
+// RepoOverrideFile returns the file in confPath holding the repositories of
+// distro which were set at runtime.
+func RepoOverrideFile(confPath, distro string) string {
+	return filepath.Join(confPath, "repositories", distro+repoOverrideSuffix)
+}
+
+// SaveRepoOverride adds repo to the repositories of arch in the override
+// file filename, or replaces the repository of the same name.
+func SaveRepoOverride(filename, arch string, repo RepoConfig) error {
+	overrides, err := loadRepositoriesFromFile(filename)
+	if os.IsNotExist(err) {
+		overrides = make(map[string][]RepoConfig)
+	} else if err != nil {
+		return err
+	}
+
+	overrides = applyRepoOverrides(overrides, map[string][]RepoConfig{arch: {repo}})
+	return saveRepositories(filename, overrides)
+}
+
+// applyRepoOverrides returns the repositories of each architecture in repos
+// with the repositories in overrides replacing those of the same name. The
+// other overrides are added after them.
+func applyRepoOverrides(repos, overrides map[string][]RepoConfig) map[string][]RepoConfig {
+	result := make(map[string][]RepoConfig, len(repos)+len(overrides))
+	for arch, archRepos := range repos {
+		result[arch] = archRepos
+	}
+
+	for arch, archOverrides := range overrides {
+		archRepos := append([]RepoConfig{}, result[arch]...)
+		for _, override := range archOverrides {
+			replaced := false
+			for i := range archRepos {
+				if archRepos[i].Name == override.Name {
+					archRepos[i] = override
+					replaced = true
+				}
+			}
+			if !replaced {
+				archRepos = append(archRepos, override)
+			}
+		}
+		result[arch] = archRepos
+	}
+
+	return result
+}
+
+// loadRepoOverrides returns the repository overrides of distro from the first
+// of confPaths which has them, or nil if there are none
+func loadRepoOverrides(confPaths []string, distro string) (map[string][]RepoConfig, error) {
+	for _, confPath := range confPaths {
+		overrides, err := loadRepositoriesFromFile(RepoOverrideFile(confPath, distro))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		return overrides, nil
+	}
+	return nil, nil
+}
+
+// saveRepositories replaces the repository definition file filename with the
+// given repositories of each architecture. The file is only readable by its
+// owner, as the repositories may contain client keys.
+func saveRepositories(filename string, repoConfigs map[string][]RepoConfig) error {
+	reposMap := make(map[string][]repository, len(repoConfigs))
+	for arch, configs := range repoConfigs {
+		for _, config := range configs {
+			reposMap[arch] = append(reposMap[arch], newRepository(config))
+		}
+	}
+
+	data, err := json.MarshalIndent(reposMap, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so that the file is replaced atomically
+	f, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(append(data, '\n'))
+	if err == nil {
+		err = f.Chmod(0600)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), filename)
+}
+
 func loadRepositoriesFromFile(filename string) (map[string][]RepoConfig, error) {
 	f, err := os.Open(filename)
 	if err != nil {
@@ -220,19 +435,7 @@ func loadRepositoriesFromFile(filename string) (map[string][]RepoConfig, error)
 
 	for arch, repos := range reposMap {
 		for _, repo := range repos {
-			config := RepoConfig{
-				Name:           repo.Name,
-				BaseURL:        repo.BaseURL,
-				Metalink:       repo.Metalink,
-				MirrorList:     repo.MirrorList,
-				GPGKey:         repo.GPGKey,
-				CheckGPG:       repo.CheckGPG,
-				RHSM:           repo.RHSM,
-				MetadataExpire: repo.MetadataExpire,
-				ImageTypeTags:  repo.ImageTypeTags,
-			}
-
-			repoConfigs[arch] = append(repoConfigs[arch], config)
+			repoConfigs[arch] = append(repoConfigs[arch], repo.repoConfig())
 		}
 	}
 
@@ -260,6 +463,12 @@ func LoadAllRepositories(confPaths []string) (DistrosRepoConfigs, error) {
 				continue
 			}
 
+			// overrides are applied below, once the definition of the
+			// distro was found
+			if strings.HasSuffix(fileEntry.Name(), repoOverrideSuffix) {
+				continue
+			}
+
 			// distro repositories definition is expected to be named "<distro_name>.json"
 			if strings.HasSuffix(fileEntry.Name(), ".json") {
 				distro := strings.TrimSuffix(fileEntry.Name(), ".json")
@@ -283,6 +492,30 @@ func LoadAllRepositories(confPaths []string) (DistrosRepoConfigs, error) {
 		}
 	}
 
+	for _, confPath := range confPaths {
+		overrideFiles, err := filepath.Glob(RepoOverrideFile(confPath, "*"))
+		if err != nil {
+			return nil, err
+		}
+		for _, overrideFile := range overrideFiles {
+			distro := strings.TrimSuffix(filepath.Base(overrideFile), repoOverrideSuffix)
+			if _, ok := distrosRepoConfigs[distro]; ok {
+				continue
+			}
+			distrosRepoConfigs[distro] = nil
+		}
+	}
+
+	for distro, distroRepos := range distrosRepoConfigs {
+		overrides, err := loadRepoOverrides(confPaths, distro)
+		if err != nil {
+			return nil, err
+		}
+		if overrides != nil {
+			distrosRepoConfigs[distro] = applyRepoOverrides(distroRepos, overrides)
+		}
+	}
+
 	return distrosRepoConfigs, nil
 }
 
@@ -309,6 +542,14 @@ func LoadRepositories(confPaths []string, distro string) (map[string][]RepoConfi
 		}
 	}
 
+	overrides, err := loadRepoOverrides(confPaths, distro)
+	if err != nil {
+		return nil, err
+	}
+	if overrides != nil {
+		repoConfigs = applyRepoOverrides(repoConfigs, overrides)
+	}
+
 	if repoConfigs == nil {
 		return nil, &RepositoryError{"LoadRepositories failed: none of the provided paths contain distro configuration"}
 	}
@@ -370,10 +611,19 @@ func runDNF(command string, arguments interface{}, result interface{}) error {
 
 type rpmmdImpl struct {
 	CacheDir      string
+	cache         *CacheManager
 	subscriptions *rhsm.Subscriptions
 }
 
+// NewRPMMD returns an RPMMD which caches repository metadata in cacheDir
+// with the default limits.
 func NewRPMMD(cacheDir string) RPMMD {
+	return NewRPMMDWithCache(NewCacheManager(cacheDir, DefaultCacheConfig))
+}
+
+// NewRPMMDWithCache returns an RPMMD which caches repository metadata as
+// configured in cache.
+func NewRPMMDWithCache(cache *CacheManager) RPMMD {
 	subscriptions, err := rhsm.LoadSystemSubscriptions()
 	if err != nil {
 		log.Println("Failed to load subscriptions. osbuild-composer will fail to build images if the "+
@@ -383,11 +633,66 @@ func NewRPMMD(cacheDir string) RPMMD {
 			"the configured sources don't enable \"rhsm\".")
 	}
 	return &rpmmdImpl{
-		CacheDir:      cacheDir,
+		CacheDir:      cache.Dir(),
+		cache:         cache,
 		subscriptions: subscriptions,
 	}
 }
 
+// dnfCacheArguments are passed to dnf-json with every request
+type dnfCacheArguments struct {
+	CacheDir string `json:"cachedir"`
+	// in seconds
+	CacheTTL     int64 `json:"cache_ttl"`
+	CacheMaxSize int64 `json:"cache_max_size,omitempty"`
+	Refresh      bool  `json:"refresh,omitempty"`
+}
+
+func (r *rpmmdImpl) cacheArguments(req *cacheRequest) dnfCacheArguments {
+	config := r.cache.Config()
+	return dnfCacheArguments{
+		CacheDir:     r.CacheDir,
+		CacheTTL:     int64(config.TTL.Seconds()),
+		CacheMaxSize: config.MaxSize,
+		Refresh:      req.refresh,
+	}
+}
+
+// HasClientCert returns true if the repository requires a TLS client
+// certificate that isn't provided by RHSM.
+func (repo RepoConfig) HasClientCert() bool {
+	return !repo.RHSM && repo.SSLClientCert != ""
+}
+
+// IsInlinePEM returns true if a TLS setting of a repository holds PEM data
+// instead of a path to a file.
+func IsInlinePEM(value string) bool {
+	return strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN ")
+}
+
+// pemPath returns the path of a file containing the PEM data in value. If
+// value is a path already, it is returned as it is, otherwise the data is
+// written into the certs directory in the cache, named by its checksum.
+func pemPath(cacheDir, value string) (string, error) {
+	if !IsInlinePEM(value) {
+		return value, nil
+	}
+
+	dir := filepath.Join(cacheDir, "certs")
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return "", fmt.Errorf("cannot create directory for certificates: %v", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%x.pem", sha256.Sum256([]byte(value))))
+	err = ioutil.WriteFile(path, []byte(value), 0600)
+	if err != nil {
+		return "", fmt.Errorf("cannot write certificate: %v", err)
+	}
+
+	return path, nil
+}
+
 func (repo RepoConfig) toDNFRepoConfig(rpmmd *rpmmdImpl, i int, arch, releasever string) (dnfRepoConfig, error) {
 	id := strconv.Itoa(i)
 	dnfRepo := dnfRepoConfig{
@@ -395,10 +700,29 @@ func (repo RepoConfig) toDNFRepoConfig(rpmmd *rpmmdImpl, i int, arch, releasever
 		BaseURL:        repo.BaseURL,
 		Metalink:       repo.Metalink,
 		MirrorList:     repo.MirrorList,
-		GPGKey:         repo.GPGKey,
+		GPGKeys:        repo.GPGKeys,
 		IgnoreSSL:      repo.IgnoreSSL,
+		Proxy:          repo.Proxy,
+		Username:       repo.Username,
+		Password:       repo.Password,
 		MetadataExpire: repo.MetadataExpire,
 	}
+
+	// dnf only accepts paths, inline PEM data is written into the cache
+	var err error
+	dnfRepo.SSLCACert, err = pemPath(rpmmd.CacheDir, repo.SSLCACert)
+	if err != nil {
+		return dnfRepoConfig{}, err
+	}
+	dnfRepo.SSLClientKey, err = pemPath(rpmmd.CacheDir, repo.SSLClientKey)
+	if err != nil {
+		return dnfRepoConfig{}, err
+	}
+	dnfRepo.SSLClientCert, err = pemPath(rpmmd.CacheDir, repo.SSLClientCert)
+	if err != nil {
+		return dnfRepoConfig{}, err
+	}
+
 	if repo.RHSM {
 		if rpmmd.subscriptions == nil {
 			return dnfRepoConfig{}, fmt.Errorf("This system does not have any valid subscriptions. Subscribe it before specifying rhsm: true in sources.")
@@ -424,18 +748,20 @@ func (r *rpmmdImpl) FetchMetadata(repos []RepoConfig, modulePlatformID, arch, re
 		dnfRepoConfigs = append(dnfRepoConfigs, dnfRepo)
 	}
 
+	cacheReq := r.cache.prepare(repos)
 	var arguments = struct {
-		Repos            []dnfRepoConfig `json:"repos"`
-		CacheDir         string          `json:"cachedir"`
-		ModulePlatformID string          `json:"module_platform_id"`
-		Arch             string          `json:"arch"`
-	}{dnfRepoConfigs, r.CacheDir, modulePlatformID, arch}
+		Repos []dnfRepoConfig `json:"repos"`
+		dnfCacheArguments
+		ModulePlatformID string `json:"module_platform_id"`
+		Arch             string `json:"arch"`
+	}{dnfRepoConfigs, r.cacheArguments(cacheReq), modulePlatformID, arch}
 	var reply struct {
 		Checksums map[string]string `json:"checksums"`
 		Packages  PackageList       `json:"packages"`
 	}
 
 	err := runDNF("dump", arguments, &reply)
+	r.cache.update(repos, cacheReq)
 
 	sort.Slice(reply.Packages, func(i, j int) bool {
 		return reply.Packages[i].Name < reply.Packages[j].Name
@@ -458,19 +784,24 @@ func (r *rpmmdImpl) Depsolve(packageSet PackageSet, repos []RepoConfig, modulePl
 		dnfRepoConfigs = append(dnfRepoConfigs, dnfRepo)
 	}
 
+	cacheReq := r.cache.prepare(repos)
 	var arguments = struct {
-		PackageSpecs     []string        `json:"package-specs"`
-		ExcludSpecs      []string        `json:"exclude-specs"`
-		Repos            []dnfRepoConfig `json:"repos"`
-		CacheDir         string          `json:"cachedir"`
-		ModulePlatformID string          `json:"module_platform_id"`
-		Arch             string          `json:"arch"`
-	}{packageSet.Include, packageSet.Exclude, dnfRepoConfigs, r.CacheDir, modulePlatformID, arch}
+		PackageSpecs       []string        `json:"package-specs"`
+		ExcludSpecs        []string        `json:"exclude-specs"`
+		ModuleEnableSpecs  []string        `json:"module-enable-specs,omitempty"`
+		ModuleDisableSpecs []string        `json:"module-disable-specs,omitempty"`
+		InstallWeakDeps    bool            `json:"install_weak_deps"`
+		Repos              []dnfRepoConfig `json:"repos"`
+		dnfCacheArguments
+		ModulePlatformID string `json:"module_platform_id"`
+		Arch             string `json:"arch"`
+	}{packageSet.Include, packageSet.Exclude, packageSet.EnabledModules, packageSet.DisabledModules, !packageSet.ExcludeWeakDeps, dnfRepoConfigs, r.cacheArguments(cacheReq), modulePlatformID, arch}
 	var reply struct {
 		Checksums    map[string]string `json:"checksums"`
 		Dependencies []dnfPackageSpec  `json:"dependencies"`
 	}
 	err := runDNF("depsolve", arguments, &reply)
+	r.cache.update(repos, cacheReq)
 
 	dependencies := make([]PackageSpec, len(reply.Dependencies))
 	for i, pack := range reply.Dependencies {
@@ -488,14 +819,53 @@ func (r *rpmmdImpl) Depsolve(packageSet PackageSet, repos []RepoConfig, modulePl
 		dependencies[i].RemoteLocation = dep.RemoteLocation
 		dependencies[i].Checksum = dep.Checksum
 		dependencies[i].CheckGPG = repo.CheckGPG
+		// the secrets are resolved by osbuild on the worker, the
+		// certificates and keys of the repository never leave composer
 		if repo.RHSM {
 			dependencies[i].Secrets = "org.osbuild.rhsm"
+		} else if repo.HasClientCert() {
+			dependencies[i].Secrets = "org.osbuild.mtls"
+		}
+		if repo.EmbedPackages {
+			u, err := url.Parse(dep.RemoteLocation)
+			if err != nil || u.Scheme != "file" {
+				return nil, nil, fmt.Errorf("cannot embed package %s from %s: not a local file", dep.Name, dep.RemoteLocation)
+			}
+			dependencies[i].Path = u.Path
 		}
 	}
 
 	return dependencies, reply.Checksums, err
 }
 
+// NamedChecksums returns the repository metadata checksums returned by
+// Depsolve() or FetchMetadata(), which are keyed by the index of each
+// repository in repos, keyed by the name of the repository instead. Unnamed
+// repositories are keyed by their URL.
+func NamedChecksums(repos []RepoConfig, checksums map[string]string) map[string]string {
+	named := make(map[string]string, len(checksums))
+	for id, checksum := range checksums {
+		i, err := strconv.Atoi(id)
+		if err != nil || i < 0 || i >= len(repos) {
+			named[id] = checksum
+			continue
+		}
+
+		repo := repos[i]
+		switch {
+		case repo.Name != "":
+			named[repo.Name] = checksum
+		case repo.BaseURL != "":
+			named[repo.BaseURL] = checksum
+		case repo.Metalink != "":
+			named[repo.Metalink] = checksum
+		default:
+			named[repo.MirrorList] = checksum
+		}
+	}
+	return named
+}
+
 func (packages PackageList) Search(globPatterns ...string) (PackageList, error) {
 	var globs []glob.Glob
 
@@ -0,0 +1,56 @@
+package rpmmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPEMPath(t *testing.T) {
+	cacheDir := t.TempDir()
+
+	path, err := pemPath(cacheDir, "/etc/pki/tls/certs/ca.pem")
+	require.NoError(t, err)
+	assert.Equal(t, "/etc/pki/tls/certs/ca.pem", path)
+
+	pem := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+	path, err = pemPath(cacheDir, pem)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(cacheDir, "certs"), filepath.Dir(path))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, pem, string(data))
+
+	again, err := pemPath(cacheDir, pem)
+	require.NoError(t, err)
+	assert.Equal(t, path, again)
+}
+
+func TestRepoConfigHasClientCert(t *testing.T) {
+	assert.False(t, RepoConfig{}.HasClientCert())
+	assert.True(t, RepoConfig{SSLClientCert: "/etc/pki/client.pem"}.HasClientCert())
+	assert.False(t, RepoConfig{SSLClientCert: "/etc/pki/client.pem", RHSM: true}.HasClientCert())
+}
+
+func TestNamedChecksums(t *testing.T) {
+	repos := []RepoConfig{
+		{Name: "baseos", BaseURL: "https://example.com/baseos"},
+		{BaseURL: "https://example.com/appstream"},
+		{Metalink: "https://example.com/metalink"},
+	}
+	checksums := map[string]string{
+		"0": "sha256:aaaa",
+		"1": "sha256:bbbb",
+		"2": "sha256:cccc",
+	}
+
+	assert.Equal(t, map[string]string{
+		"baseos":                        "sha256:aaaa",
+		"https://example.com/appstream": "sha256:bbbb",
+		"https://example.com/metalink":  "sha256:cccc",
+	}, NamedChecksums(repos, checksums))
+}
@@ -0,0 +1,331 @@
+// Package snapshot records the packages a compose was depsolved to, together
+// with the checksums of the repository metadata they were resolved from, so
+// that later composes can reuse the exact same set of packages without
+// depsolving again.
+//
+// Snapshots are stored as JSON documents in a directory, one per snapshot,
+// and are shared between the Weldr and the Cloud API.
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/osbuild/osbuild-composer/internal/jsondb"
+	"github.com/osbuild/osbuild-composer/internal/rpmmd"
+)
+
+var (
+	ErrNotFound    = errors.New("snapshot not found")
+	ErrExists      = errors.New("snapshot already exists")
+	ErrInvalidName = errors.New("invalid snapshot name")
+)
+
+// ValidName matches the names snapshots can be saved under.
+var ValidName = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// Snapshot is the frozen result of depsolving the package sets of an image
+// type.
+type Snapshot struct {
+	Name      string    `json:"name"`
+	Created   time.Time `json:"created"`
+	Distro    string    `json:"distro"`
+	Arch      string    `json:"arch"`
+	ImageType string    `json:"image_type"`
+
+	// Checksums of the repository metadata, keyed by repository name
+	RepoChecksums map[string]string `json:"repo_checksums,omitempty"`
+
+	// The package sets which were depsolved, as requested by the blueprint
+	// and the image type
+	PackageRequests map[string]rpmmd.PackageSet `json:"package_requests,omitempty"`
+
+	PackageSets map[string][]rpmmd.PackageSpec `json:"package_sets"`
+}
+
+// Matches returns an error if the snapshot was not taken for the given
+// distribution, architecture and image type.
+func (s *Snapshot) Matches(distro, arch, imageType string) error {
+	if s.Distro != distro || s.Arch != arch || s.ImageType != imageType {
+		return fmt.Errorf("snapshot %s was taken for %s/%s/%s, not for %s/%s/%s",
+			s.Name, s.Distro, s.Arch, s.ImageType, distro, arch, imageType)
+	}
+	return nil
+}
+
+// MatchesPackageRequests returns an error if the package sets requested for a
+// compose differ from the ones the snapshot was depsolved from, for example
+// because packages were added to the blueprint since it was taken.
+func (s *Snapshot) MatchesPackageRequests(requests map[string]rpmmd.PackageSet) error {
+	if !reflect.DeepEqual(normalizePackageRequests(s.PackageRequests), normalizePackageRequests(requests)) {
+		return fmt.Errorf("the packages requested by the blueprint differ from the ones snapshot %s was taken for", s.Name)
+	}
+	return nil
+}
+
+// normalizePackageRequests returns a copy of requests with sorted package
+// and module lists, so that they can be compared regardless of the order of
+// packages and modules. The weak dependency setting is kept as well.
+func normalizePackageRequests(requests map[string]rpmmd.PackageSet) map[string]rpmmd.PackageSet {
+	normalized := make(map[string]rpmmd.PackageSet, len(requests))
+	for name, set := range requests {
+		normalized[name] = rpmmd.PackageSet{
+			Include:         sortedCopy(set.Include),
+			Exclude:         sortedCopy(set.Exclude),
+			EnabledModules:  sortedCopy(set.EnabledModules),
+			DisabledModules: sortedCopy(set.DisabledModules),
+			ExcludeWeakDeps: set.ExcludeWeakDeps,
+		}
+	}
+	return normalized
+}
+
+func sortedCopy(list []string) []string {
+	sorted := append([]string{}, list...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// Packages returns the packages of all package sets of the snapshot, without
+// duplicates.
+func (s *Snapshot) Packages() []rpmmd.PackageSpec {
+	seen := make(map[string]bool)
+	var packages []rpmmd.PackageSpec
+	for _, set := range s.PackageSets {
+		for _, pkg := range set {
+			if seen[pkg.RemoteLocation] {
+				continue
+			}
+			seen[pkg.RemoteLocation] = true
+			packages = append(packages, pkg)
+		}
+	}
+	return packages
+}
+
+// Store holds all snapshots, backed by a directory if one was given.
+type Store struct {
+	mu        sync.RWMutex
+	db        *jsondb.JSONDatabase
+	snapshots map[string]*Snapshot
+}
+
+// NewStore loads all snapshots from dir. If dir is nil, snapshots are only
+// kept in memory.
+func NewStore(dir *string) (*Store, error) {
+	store := &Store{
+		snapshots: make(map[string]*Snapshot),
+	}
+
+	if dir == nil {
+		return store, nil
+	}
+
+	err := os.MkdirAll(*dir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create snapshot directory: %v", err)
+	}
+
+	store.db = jsondb.New(*dir, 0600)
+	names, err := store.db.List()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list snapshots: %v", err)
+	}
+
+	for _, name := range names {
+		var snapshot Snapshot
+		exists, err := store.db.Read(name, &snapshot)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			store.snapshots[name] = &snapshot
+		}
+	}
+
+	return store, nil
+}
+
+// List returns the names of all snapshots, sorted.
+func (s *Store) List() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.snapshots))
+	for name := range s.snapshots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the snapshot with the given name, or nil if it does not exist.
+func (s *Store) Get(name string) *Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.snapshots[name]
+}
+
+// Push saves a new snapshot. Snapshots are never overwritten, ErrExists is
+// returned if one with the same name exists already.
+func (s *Store) Push(snapshot *Snapshot) error {
+	if !ValidName.MatchString(snapshot.Name) {
+		return ErrInvalidName
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.snapshots[snapshot.Name]; exists {
+		return ErrExists
+	}
+
+	if s.db != nil {
+		err := s.db.Write(snapshot.Name, snapshot)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.snapshots[snapshot.Name] = snapshot
+	return nil
+}
+
+// Delete removes the snapshot with the given name.
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.snapshots[name]; !exists {
+		return ErrNotFound
+	}
+
+	if s.db != nil {
+		err := s.db.Delete(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	delete(s.snapshots, name)
+	return nil
+}
+
+// MissingPackagesError lists the packages of a snapshot which cannot be
+// downloaded anymore.
+type MissingPackagesError struct {
+	URLs []string
+}
+
+func (e *MissingPackagesError) Error() string {
+	return fmt.Sprintf("%d package(s) of the snapshot are no longer available: %s", len(e.URLs), strings.Join(e.URLs, ", "))
+}
+
+// number of packages checked in parallel
+const checkWorkers = 16
+
+// CheckPackages makes sure all packages can still be downloaded from their
+// remote locations. It returns a *MissingPackagesError listing all packages
+// that are gone.
+//
+// Packages which need secrets to be downloaded cannot be checked and are
+// assumed to be available. Packages from local repositories only exist on
+// the composer host and are skipped; use CheckLocalPackages for those.
+func CheckPackages(client *http.Client, packages []rpmmd.PackageSpec) error {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	seen := make(map[string]bool)
+	var urls []string
+	for _, pkg := range packages {
+		if pkg.Secrets != "" || isLocal(pkg.RemoteLocation) || seen[pkg.RemoteLocation] {
+			continue
+		}
+		seen[pkg.RemoteLocation] = true
+		urls = append(urls, pkg.RemoteLocation)
+	}
+
+	queue := make(chan string)
+	var mu sync.Mutex
+	var missing []string
+	var wg sync.WaitGroup
+	for i := 0; i < checkWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range queue {
+				if !packageAvailable(client, u) {
+					mu.Lock()
+					missing = append(missing, u)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, u := range urls {
+		queue <- u
+	}
+	close(queue)
+	wg.Wait()
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return &MissingPackagesError{URLs: missing}
+	}
+	return nil
+}
+
+// CheckLocalPackages makes sure all packages of local repositories still
+// exist on this host. It returns a *MissingPackagesError listing all local
+// packages that are gone. Packages with remote locations are ignored.
+func CheckLocalPackages(packages []rpmmd.PackageSpec) error {
+	seen := make(map[string]bool)
+	var missing []string
+	for _, pkg := range packages {
+		if !isLocal(pkg.RemoteLocation) || seen[pkg.RemoteLocation] {
+			continue
+		}
+		seen[pkg.RemoteLocation] = true
+
+		u, _ := url.Parse(pkg.RemoteLocation)
+		if _, err := os.Stat(u.Path); err != nil {
+			missing = append(missing, pkg.RemoteLocation)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return &MissingPackagesError{URLs: missing}
+	}
+	return nil
+}
+
+func isLocal(location string) bool {
+	u, err := url.Parse(location)
+	return err == nil && u.Scheme == "file"
+}
+
+func packageAvailable(client *http.Client, location string) bool {
+	if _, err := url.Parse(location); err != nil || location == "" {
+		return false
+	}
+
+	resp, err := client.Head(location)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode >= 200 && resp.StatusCode < 400
+}
@@ -0,0 +1,171 @@
+package snapshot_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/osbuild-composer/internal/rpmmd"
+	"github.com/osbuild/osbuild-composer/internal/snapshot"
+)
+
+func testSnapshot(name string) *snapshot.Snapshot {
+	return &snapshot.Snapshot{
+		Name:      name,
+		Created:   time.Date(2021, 11, 2, 10, 0, 0, 0, time.UTC),
+		Distro:    "rhel-85",
+		Arch:      "x86_64",
+		ImageType: "qcow2",
+		RepoChecksums: map[string]string{
+			"baseos": "sha256:aaaa",
+		},
+		PackageRequests: map[string]rpmmd.PackageSet{
+			"packages": {Include: []string{"bash", "@core"}, Exclude: []string{"rng-tools"}},
+		},
+		PackageSets: map[string][]rpmmd.PackageSpec{
+			"packages": {
+				{Name: "bash", Version: "5.1.8", Release: "1.el8", Arch: "x86_64"},
+			},
+		},
+	}
+}
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := snapshot.NewStore(&dir)
+	require.NoError(t, err)
+	require.Empty(t, store.List())
+
+	err = store.Push(testSnapshot("base"))
+	require.NoError(t, err)
+	err = store.Push(testSnapshot("base"))
+	require.Equal(t, snapshot.ErrExists, err)
+	err = store.Push(testSnapshot("no/slashes"))
+	require.Equal(t, snapshot.ErrInvalidName, err)
+	err = store.Push(testSnapshot("other"))
+	require.NoError(t, err)
+
+	// snapshots are loaded from disk
+	store, err = snapshot.NewStore(&dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"base", "other"}, store.List())
+	require.Equal(t, testSnapshot("base"), store.Get("base"))
+	require.Nil(t, store.Get("missing"))
+
+	err = store.Delete("base")
+	require.NoError(t, err)
+	err = store.Delete("base")
+	require.Equal(t, snapshot.ErrNotFound, err)
+
+	store, err = snapshot.NewStore(&dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"other"}, store.List())
+}
+
+func TestMatches(t *testing.T) {
+	s := testSnapshot("base")
+	require.NoError(t, s.Matches("rhel-85", "x86_64", "qcow2"))
+	require.Error(t, s.Matches("rhel-85", "x86_64", "ami"))
+	require.Error(t, s.Matches("rhel-90", "x86_64", "qcow2"))
+}
+
+func TestMatchesPackageRequests(t *testing.T) {
+	s := testSnapshot("base")
+	require.NoError(t, s.MatchesPackageRequests(map[string]rpmmd.PackageSet{
+		"packages": {Include: []string{"@core", "bash"}, Exclude: []string{"rng-tools"}},
+	}))
+	require.Error(t, s.MatchesPackageRequests(map[string]rpmmd.PackageSet{
+		"packages": {Include: []string{"@core", "bash", "tmux"}, Exclude: []string{"rng-tools"}},
+	}))
+	require.Error(t, s.MatchesPackageRequests(map[string]rpmmd.PackageSet{
+		"packages": {Include: []string{"@core", "bash"}, Exclude: []string{"rng-tools"}},
+		"build":    {Include: []string{"rpm"}},
+	}))
+
+	s.PackageRequests["packages"] = rpmmd.PackageSet{
+		Include:         []string{"@core", "bash"},
+		Exclude:         []string{"rng-tools"},
+		EnabledModules:  []string{"postgresql:13", "nodejs:14"},
+		DisabledModules: []string{"php"},
+	}
+	require.NoError(t, s.MatchesPackageRequests(map[string]rpmmd.PackageSet{
+		"packages": {Include: []string{"bash", "@core"}, Exclude: []string{"rng-tools"}, EnabledModules: []string{"nodejs:14", "postgresql:13"}, DisabledModules: []string{"php"}},
+	}))
+	require.Error(t, s.MatchesPackageRequests(map[string]rpmmd.PackageSet{
+		"packages": {Include: []string{"@core", "bash"}, Exclude: []string{"rng-tools"}, EnabledModules: []string{"postgresql:12", "nodejs:14"}, DisabledModules: []string{"php"}},
+	}))
+	require.Error(t, s.MatchesPackageRequests(map[string]rpmmd.PackageSet{
+		"packages": {Include: []string{"@core", "bash"}, Exclude: []string{"rng-tools"}, EnabledModules: []string{"postgresql:13", "nodejs:14"}},
+	}))
+	require.Error(t, s.MatchesPackageRequests(map[string]rpmmd.PackageSet{
+		"packages": {Include: []string{"@core", "bash"}, Exclude: []string{"rng-tools"}, EnabledModules: []string{"postgresql:13", "nodejs:14"}, DisabledModules: []string{"php"}, ExcludeWeakDeps: true},
+	}))
+}
+
+func TestPackages(t *testing.T) {
+	s := testSnapshot("base")
+	s.PackageSets["build"] = []rpmmd.PackageSpec{
+		{Name: "bash", RemoteLocation: "https://example.com/bash.rpm"},
+		{Name: "rpm", RemoteLocation: "https://example.com/rpm.rpm"},
+	}
+	s.PackageSets["packages"] = []rpmmd.PackageSpec{
+		{Name: "bash", RemoteLocation: "https://example.com/bash.rpm"},
+	}
+	require.ElementsMatch(t, []rpmmd.PackageSpec{
+		{Name: "bash", RemoteLocation: "https://example.com/bash.rpm"},
+		{Name: "rpm", RemoteLocation: "https://example.com/rpm.rpm"},
+	}, s.Packages())
+}
+
+func TestCheckPackages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone.rpm" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	packages := []rpmmd.PackageSpec{
+		{Name: "bash", RemoteLocation: server.URL + "/bash.rpm"},
+		{Name: "bash", RemoteLocation: server.URL + "/bash.rpm"},
+		{Name: "subscribed", RemoteLocation: server.URL + "/gone.rpm", Secrets: "org.osbuild.rhsm"},
+		{Name: "internal", RemoteLocation: server.URL + "/gone.rpm", Secrets: "org.osbuild.mtls"},
+		{Name: "local", RemoteLocation: "file:///nonexistent/local.rpm"},
+	}
+	require.NoError(t, snapshot.CheckPackages(server.Client(), packages))
+
+	packages = append(packages, rpmmd.PackageSpec{Name: "gone", RemoteLocation: server.URL + "/gone.rpm"})
+	err := snapshot.CheckPackages(server.Client(), packages)
+	require.Error(t, err)
+	missing, ok := err.(*snapshot.MissingPackagesError)
+	require.True(t, ok)
+	require.Equal(t, []string{server.URL + "/gone.rpm"}, missing.URLs)
+}
+
+func TestCheckLocalPackages(t *testing.T) {
+	dir := t.TempDir()
+	present := filepath.Join(dir, "present.rpm")
+	require.NoError(t, ioutil.WriteFile(present, nil, 0600))
+	gone := filepath.Join(dir, "gone.rpm")
+
+	packages := []rpmmd.PackageSpec{
+		{Name: "present", RemoteLocation: "file://" + present},
+		{Name: "remote", RemoteLocation: "https://example.com/remote.rpm"},
+	}
+	require.NoError(t, snapshot.CheckLocalPackages(packages))
+
+	packages = append(packages, rpmmd.PackageSpec{Name: "gone", RemoteLocation: "file://" + gone})
+	err := snapshot.CheckLocalPackages(packages)
+	require.Error(t, err)
+	missing, ok := err.(*snapshot.MissingPackagesError)
+	require.True(t, ok)
+	require.Equal(t, []string{"file://" + gone}, missing.URLs)
+}
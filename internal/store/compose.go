@@ -30,6 +30,9 @@ type ImageBuild struct {
 	JobFinished time.Time
 	Size        uint64
 	JobID       uuid.UUID
+	// Compression selected in the image options, which determines the
+	// file name and MIME type of the image
+	Compression distro.Compression
 	// Kept for backwards compatibility. Image builds which were done
 	// before the move to the job queue use this to store whether they
 	// finished successfully.
@@ -55,9 +58,20 @@ func (ib *ImageBuild) DeepCopy() ImageBuild {
 		JobFinished: ib.JobFinished,
 		Size:        ib.Size,
 		JobID:       ib.JobID,
+		Compression: ib.Compression,
 	}
 }
 
+// ImageFilename returns the file name of the image.
+func (ib *ImageBuild) ImageFilename() string {
+	return distro.ImageFilename(ib.ImageType, distro.ImageOptions{Compression: ib.Compression})
+}
+
+// ImageMIMEType returns the MIME type of the image.
+func (ib *ImageBuild) ImageMIMEType() string {
+	return distro.ImageMIMEType(ib.ImageType, distro.ImageOptions{Compression: ib.Compression})
+}
+
 func (ib *ImageBuild) GetLocalTargetOptions() *target.LocalTargetOptions {
 	for _, t := range ib.Targets {
 		switch options := t.Options.(type) {
@@ -76,6 +90,8 @@ type Compose struct {
 	Blueprint  *blueprint.Blueprint
 	ImageBuild ImageBuild
 	Packages   []rpmmd.PackageSpec
+	// Upload results of the image build, keyed by the UUID of their target
+	TargetResults map[uuid.UUID]*target.TargetResult
 }
 
 // DeepCopy creates a copy of the Compose structure
@@ -88,9 +104,19 @@ func (c *Compose) DeepCopy() Compose {
 	pkgs := make([]rpmmd.PackageSpec, len(c.Packages))
 	copy(pkgs, c.Packages)
 
+	var results map[uuid.UUID]*target.TargetResult
+	if c.TargetResults != nil {
+		results = make(map[uuid.UUID]*target.TargetResult, len(c.TargetResults))
+		for id, result := range c.TargetResults {
+			resultCopy := *result
+			results[id] = &resultCopy
+		}
+	}
+
 	return Compose{
-		Blueprint:  newBpPtr,
-		ImageBuild: c.ImageBuild.DeepCopy(),
-		Packages:   pkgs,
+		Blueprint:     newBpPtr,
+		ImageBuild:    c.ImageBuild.DeepCopy(),
+		Packages:      pkgs,
+		TargetResults: results,
 	}
 }
@@ -33,21 +33,24 @@ type composeV0 struct {
 	Blueprint   *blueprint.Blueprint `json:"blueprint"`
 	ImageBuilds []imageBuildV0       `json:"image_builds"`
 	Packages    []rpmmd.PackageSpec  `json:"packages"`
+	// Upload results of the image build, keyed by the UUID of their target
+	TargetResults map[uuid.UUID]*target.TargetResult `json:"target_results,omitempty"`
 }
 
 type composesV0 map[uuid.UUID]composeV0
 
 // ImageBuild represents a single image build inside a compose
 type imageBuildV0 struct {
-	ID          int              `json:"id"`
-	ImageType   string           `json:"image_type"`
-	Manifest    distro.Manifest  `json:"manifest"`
-	Targets     []*target.Target `json:"targets"`
-	JobCreated  time.Time        `json:"job_created"`
-	JobStarted  time.Time        `json:"job_started"`
-	JobFinished time.Time        `json:"job_finished"`
-	Size        uint64           `json:"size"`
-	JobID       uuid.UUID        `json:"jobid,omitempty"`
+	ID          int                `json:"id"`
+	ImageType   string             `json:"image_type"`
+	Manifest    distro.Manifest    `json:"manifest"`
+	Targets     []*target.Target   `json:"targets"`
+	JobCreated  time.Time          `json:"job_created"`
+	JobStarted  time.Time          `json:"job_started"`
+	JobFinished time.Time          `json:"job_finished"`
+	Size        uint64             `json:"size"`
+	JobID       uuid.UUID          `json:"jobid,omitempty"`
+	Compression distro.Compression `json:"compression,omitempty"`
 
 	// Kept for backwards compatibility. Image builds which were done
 	// before the move to the job queue use this to store whether they
@@ -64,6 +67,14 @@ type sourceV0 struct {
 	System   bool     `json:"system"`
 	Distros  []string `json:"distros"`
 	RHSM     bool     `json:"rhsm"`
+
+	SSLCACert     string   `json:"sslcacert,omitempty"`
+	SSLClientKey  string   `json:"sslclientkey,omitempty"`
+	SSLClientCert string   `json:"sslclientcert,omitempty"`
+	Proxy         string   `json:"proxy,omitempty"`
+	Username      string   `json:"username,omitempty"`
+	Password      string   `json:"password,omitempty"`
+	GPGKeyURLs    []string `json:"gpgkey_urls,omitempty"`
 }
 
 type sourcesV0 map[string]sourceV0
@@ -139,6 +150,7 @@ func newImageBuildFromV0(imageBuildStruct imageBuildV0, arch distro.Arch) (Image
 		JobFinished: imageBuildStruct.JobFinished,
 		Size:        imageBuildStruct.Size,
 		JobID:       imageBuildStruct.JobID,
+		Compression: imageBuildStruct.Compression,
 		QueueStatus: queueStatus,
 	}, nil
 }
@@ -157,9 +169,10 @@ func newComposeFromV0(composeStruct composeV0, arch distro.Arch) (Compose, error
 	copy(pkgs, composeStruct.Packages)
 
 	return Compose{
-		Blueprint:  &bp,
-		ImageBuild: ib,
-		Packages:   pkgs,
+		Blueprint:     &bp,
+		ImageBuild:    ib,
+		Packages:      pkgs,
+		TargetResults: composeStruct.TargetResults,
 	}, nil
 }
 
@@ -280,10 +293,12 @@ func newComposeV0(compose Compose) composeV0 {
 				JobFinished: compose.ImageBuild.JobFinished,
 				Size:        compose.ImageBuild.Size,
 				JobID:       compose.ImageBuild.JobID,
+				Compression: compose.ImageBuild.Compression,
 				QueueStatus: compose.ImageBuild.QueueStatus,
 			},
 		},
-		Packages: pkgs,
+		Packages:      pkgs,
+		TargetResults: compose.TargetResults,
 	}
 }
 
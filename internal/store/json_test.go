@@ -1076,6 +1076,26 @@ func Test_newComposeV0(t *testing.T) {
 	}
 }
 
+func Test_composeV0TargetResults(t *testing.T) {
+	targetID := uuid.MustParse("f53b49c0-d321-447e-8ab8-6e827891e3f0")
+	result := target.NewAWSTargetResult(&target.AWSTargetResultOptions{
+		Ami:    "ami-0123456789abcdef0",
+		Region: "eu-central-1",
+	})
+	result.TargetUuid = targetID
+
+	compose := composeV0{
+		TargetResults: map[uuid.UUID]*target.TargetResult{targetID: result},
+	}
+	data, err := json.Marshal(compose)
+	require.NoError(t, err)
+
+	var got composeV0
+	err = json.Unmarshal(data, &got)
+	require.NoError(t, err)
+	require.Equal(t, compose.TargetResults, got.TargetResults)
+}
+
 func Test_newComposeFromV0(t *testing.T) {
 	bp := blueprint.Blueprint{
 		Name:        "tmux",
@@ -1570,6 +1590,29 @@ func Test_newImageBuildFromV0(t *testing.T) {
 				QueueStatus: common.IBFinished,
 			},
 		},
+		{
+			name:  "compressed image build",
+			arch:  testArch,
+			errOk: false,
+			ib: imageBuildV0{
+				ID:          0,
+				ImageType:   test_distro.TestImageTypeName,
+				Manifest:    []byte("JSON MANIFEST GOES HERE"),
+				JobCreated:  MustParseTime("2020-08-12T09:21:50.07040195-07:00"),
+				Size:        2147483648,
+				Compression: distro.ZstdCompression,
+				QueueStatus: common.IBFinished,
+			},
+			want: ImageBuild{
+				ID:          0,
+				ImageType:   testImageType,
+				Manifest:    []byte("JSON MANIFEST GOES HERE"),
+				JobCreated:  MustParseTime("2020-08-12T09:21:50.07040195-07:00"),
+				Size:        2147483648,
+				Compression: distro.ZstdCompression,
+				QueueStatus: common.IBFinished,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -53,6 +53,15 @@ type SourceConfig struct {
 	System   bool     `json:"system" toml:"system"`
 	Distros  []string `json:"distros" toml:"distros"`
 	RHSM     bool     `json:"rhsm" toml:"rhsm"`
+	// The TLS settings are either paths to PEM files or inline PEM data
+	SSLCACert     string `json:"sslcacert,omitempty" toml:"sslcacert,omitempty"`
+	SSLClientKey  string `json:"sslclientkey,omitempty" toml:"sslclientkey,omitempty"`
+	SSLClientCert string `json:"sslclientcert,omitempty" toml:"sslclientcert,omitempty"`
+	Proxy         string `json:"proxy,omitempty" toml:"proxy,omitempty"`
+	Username      string `json:"username,omitempty" toml:"username,omitempty"`
+	Password      string `json:"password,omitempty" toml:"-"`
+	// The GPG keys are fetched from these every time the source is used
+	GPGKeyURLs []string `json:"gpgkey_urls,omitempty" toml:"gpgkey_urls,omitempty"`
 }
 
 type NotFoundError struct {
@@ -342,6 +351,7 @@ func (s *Store) PushCompose(composeID uuid.UUID,
 	imageType distro.ImageType,
 	bp *blueprint.Blueprint,
 	size uint64,
+	compression distro.Compression,
 	targets []*target.Target,
 	jobId uuid.UUID,
 	packages []rpmmd.PackageSpec) error {
@@ -359,12 +369,13 @@ func (s *Store) PushCompose(composeID uuid.UUID,
 		s.composes[composeID] = Compose{
 			Blueprint: bp,
 			ImageBuild: ImageBuild{
-				Manifest:   manifest,
-				ImageType:  imageType,
-				Targets:    targets,
-				JobCreated: time.Now(),
-				Size:       size,
-				JobID:      jobId,
+				Manifest:    manifest,
+				ImageType:   imageType,
+				Targets:     targets,
+				JobCreated:  time.Now(),
+				Size:        size,
+				JobID:       jobId,
+				Compression: compression,
 			},
 			Packages: packages,
 		}
@@ -381,6 +392,7 @@ func (s *Store) PushTestCompose(composeID uuid.UUID,
 	imageType distro.ImageType,
 	bp *blueprint.Blueprint,
 	size uint64,
+	compression distro.Compression,
 	targets []*target.Target,
 	testSuccess bool,
 	packages []rpmmd.PackageSpec) error {
@@ -408,6 +420,7 @@ func (s *Store) PushTestCompose(composeID uuid.UUID,
 				JobCreated:  time.Now(),
 				JobStarted:  time.Now(),
 				Size:        size,
+				Compression: compression,
 			},
 			Packages: packages,
 		}
@@ -431,6 +444,22 @@ func (s *Store) DeleteCompose(id uuid.UUID) error {
 	})
 }
 
+// SetComposeTargetResults stores the upload results of the compose, keyed by
+// the UUID of the target they belong to
+func (s *Store) SetComposeTargetResults(id uuid.UUID, results map[uuid.UUID]*target.TargetResult) error {
+	return s.change(func() error {
+		compose, exists := s.composes[id]
+		if !exists {
+			return &NotFoundError{}
+		}
+
+		compose.TargetResults = results
+		s.composes[id] = compose
+
+		return nil
+	})
+}
+
 // PushSource stores a SourceConfig in store.Sources
 func (s *Store) PushSource(key string, source SourceConfig) {
 	// FIXME: handle or comment this possible error
@@ -560,6 +589,13 @@ func NewSourceConfig(repo rpmmd.RepoConfig, system bool) SourceConfig {
 		CheckSSL: !repo.IgnoreSSL,
 		System:   system,
 		RHSM:     repo.RHSM,
+
+		SSLCACert:     repo.SSLCACert,
+		SSLClientKey:  repo.SSLClientKey,
+		SSLClientCert: repo.SSLClientCert,
+		Proxy:         repo.Proxy,
+		Username:      repo.Username,
+		Password:      repo.Password,
 	}
 
 	if repo.BaseURL != "" {
@@ -582,7 +618,14 @@ func (s *SourceConfig) RepoConfig(name string) rpmmd.RepoConfig {
 	repo.Name = name
 	repo.IgnoreSSL = !s.CheckSSL
 	repo.CheckGPG = s.CheckGPG
+	repo.GPGKeys = s.GPGKeyURLs
 	repo.RHSM = s.RHSM
+	repo.SSLCACert = s.SSLCACert
+	repo.SSLClientKey = s.SSLClientKey
+	repo.SSLClientCert = s.SSLClientCert
+	repo.Proxy = s.Proxy
+	repo.Username = s.Username
+	repo.Password = s.Password
 
 	if s.Type == "yum-baseurl" {
 		repo.BaseURL = s.URL
@@ -1,5 +1,16 @@
 package target
 
+import "regexp"
+
+// awsRegionRegex matches the names of AWS regions, e.g. "eu-central-1" or
+// "us-gov-west-1"
+var awsRegionRegex = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+
+// IsValidAWSRegion returns true if region is a well-formed AWS region name.
+func IsValidAWSRegion(region string) bool {
+	return awsRegionRegex.MatchString(region)
+}
+
 type AWSTargetOptions struct {
 	Filename          string   `json:"filename"`
 	Region            string   `json:"region"`
@@ -9,6 +20,8 @@ type AWSTargetOptions struct {
 	Bucket            string   `json:"bucket"`
 	Key               string   `json:"key"`
 	ShareWithAccounts []string `json:"shareWithAccounts"`
+	// Additional regions the AMI is copied to once it's registered in Region
+	CopyToRegions []string `json:"copyToRegions,omitempty"`
 }
 
 func (AWSTargetOptions) isTargetOptions() {}
@@ -20,6 +33,16 @@ func NewAWSTarget(options *AWSTargetOptions) *Target {
 type AWSTargetResultOptions struct {
 	Ami    string `json:"ami"`
 	Region string `json:"region"`
+	// Copies of the AMI in the regions requested in CopyToRegions
+	Copies []AWSImageCopy `json:"copies,omitempty"`
+}
+
+// AWSImageCopy is the result of copying the AMI to one region. Either the
+// ID of the copied AMI or the error of the failed copy is set.
+type AWSImageCopy struct {
+	Ami    string `json:"ami,omitempty"`
+	Region string `json:"region"`
+	Error  string `json:"error,omitempty"`
 }
 
 func (AWSTargetResultOptions) isTargetResultOptions() {}
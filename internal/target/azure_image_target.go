@@ -6,6 +6,9 @@ type AzureImageTargetOptions struct {
 	Location       string `json:"location"`
 	SubscriptionID string `json:"subscription_id"`
 	ResourceGroup  string `json:"resource_group"`
+	// Generation of the Hyper-V virtual machines the image is registered
+	// for, "V1" or "V2", V1 when unset
+	HyperVGeneration string `json:"hyperv_generation,omitempty"`
 }
 
 func (AzureImageTargetOptions) isTargetOptions() {}
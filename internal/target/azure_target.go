@@ -22,3 +22,13 @@ func (AzureTargetOptions) isTargetOptions() {}
 func NewAzureTarget(options *AzureTargetOptions) *Target {
 	return newTarget("org.osbuild.azure", options)
 }
+
+type AzureTargetResultOptions struct {
+	URL string `json:"url"`
+}
+
+func (AzureTargetResultOptions) isTargetResultOptions() {}
+
+func NewAzureTargetResult(options *AzureTargetResultOptions) *TargetResult {
+	return newTargetResult("org.osbuild.azure", options)
+}
@@ -0,0 +1,30 @@
+package target
+
+type GenericS3TargetOptions struct {
+	AWSS3TargetOptions
+	Endpoint  string `json:"endpoint"`
+	PathStyle bool   `json:"pathStyle"`
+}
+
+func (GenericS3TargetOptions) isTargetOptions() {}
+
+// NewGenericS3Target creates org.osbuild.generic.s3 target
+//
+// This target uploads the image to an S3-compatible object storage which is
+// not necessarily AWS, e.g. MinIO or Ceph RGW. The storage is accessed using
+// the Endpoint URL instead of the region-based AWS endpoints. Endpoints which
+// don't support virtual-hosted style addressing need PathStyle enabled.
+//
+// The CA bundle used to verify the TLS certificate of the endpoint is part
+// of the worker configuration and cannot be set by the client.
+func NewGenericS3Target(options *GenericS3TargetOptions) *Target {
+	return newTarget("org.osbuild.generic.s3", options)
+}
+
+type GenericS3TargetResultOptions AWSS3TargetResultOptions
+
+func (GenericS3TargetResultOptions) isTargetResultOptions() {}
+
+func NewGenericS3TargetResult(options *GenericS3TargetResultOptions) *TargetResult {
+	return newTargetResult("org.osbuild.generic.s3", options)
+}
@@ -0,0 +1,43 @@
+package target
+
+type LibvirtTargetOptions struct {
+	Filename string `json:"filename"`
+	URI      string `json:"uri"`
+	Pool     string `json:"pool"`
+	Format   string `json:"format"`
+
+	DefineDomain   bool   `json:"defineDomain"`
+	DomainTemplate string `json:"domainTemplate,omitempty"`
+	Memory         uint64 `json:"memory,omitempty"` // MiB
+	VCPUs          uint   `json:"vcpus,omitempty"`
+}
+
+func (LibvirtTargetOptions) isTargetOptions() {}
+
+// NewLibvirtTarget creates org.osbuild.libvirt target
+//
+// This target uploads the image as a new volume into a libvirt storage pool.
+// The libvirt daemon is reached using the URI, which can point to a remote
+// host, e.g. qemu+ssh://user@host/system, or to the local socket, e.g.
+// qemu:///system. Remote connections authenticate using the SSH
+// configuration of the worker.
+//
+// If DefineDomain is set, a domain named after the image is defined from
+// DomainTemplate, a Go text/template of the libvirt domain XML. The default
+// template is used if none is given.
+func NewLibvirtTarget(options *LibvirtTargetOptions) *Target {
+	return newTarget("org.osbuild.libvirt", options)
+}
+
+type LibvirtTargetResultOptions struct {
+	Pool       string `json:"pool"`
+	Volume     string `json:"volume"`
+	VolumePath string `json:"volume_path"`
+	Domain     string `json:"domain,omitempty"`
+}
+
+func (LibvirtTargetResultOptions) isTargetResultOptions() {}
+
+func NewLibvirtTargetResult(options *LibvirtTargetResultOptions) *TargetResult {
+	return newTargetResult("org.osbuild.libvirt", options)
+}
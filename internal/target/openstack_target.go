@@ -0,0 +1,38 @@
+package target
+
+type OpenStackTargetOptions struct {
+	Filename        string            `json:"filename"`
+	AuthURL         string            `json:"authURL"`
+	Username        string            `json:"username"`
+	Password        string            `json:"password"`
+	ProjectName     string            `json:"projectName"`
+	ProjectID       string            `json:"projectID"`
+	DomainName      string            `json:"domainName"`
+	Region          string            `json:"region"`
+	DiskFormat      string            `json:"diskFormat"`
+	ContainerFormat string            `json:"containerFormat"`
+	Visibility      string            `json:"visibility"`
+	Properties      map[string]string `json:"properties,omitempty"`
+}
+
+func (OpenStackTargetOptions) isTargetOptions() {}
+
+// NewOpenStackTarget creates org.osbuild.openstack target
+//
+// This target creates a new image in the OpenStack Image service (Glance)
+// and uploads the built image into it. Properties are set on the image
+// as-is, e.g. hw_disk_bus or os_distro.
+func NewOpenStackTarget(options *OpenStackTargetOptions) *Target {
+	return newTarget("org.osbuild.openstack", options)
+}
+
+type OpenStackTargetResultOptions struct {
+	ImageID string `json:"image_id"`
+	Region  string `json:"region,omitempty"`
+}
+
+func (OpenStackTargetResultOptions) isTargetResultOptions() {}
+
+func NewOpenStackTargetResult(options *OpenStackTargetResultOptions) *TargetResult {
+	return newTargetResult("org.osbuild.openstack", options)
+}
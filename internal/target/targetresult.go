@@ -3,11 +3,15 @@ package target
 import (
 	"encoding/json"
 	"fmt"
+
+	"github.com/google/uuid"
 )
 
 type TargetResult struct {
-	Name    string              `json:"name"`
-	Options TargetResultOptions `json:"options"`
+	Name string `json:"name"`
+	// UUID of the target this is the result of
+	TargetUuid uuid.UUID           `json:"target_uuid"`
+	Options    TargetResultOptions `json:"options"`
 }
 
 func newTargetResult(name string, options TargetResultOptions) *TargetResult {
@@ -22,8 +26,9 @@ type TargetResultOptions interface {
 }
 
 type rawTargetResult struct {
-	Name    string          `json:"name"`
-	Options json.RawMessage `json:"options"`
+	Name       string          `json:"name"`
+	TargetUuid uuid.UUID       `json:"target_uuid"`
+	Options    json.RawMessage `json:"options"`
 }
 
 func (targetResult *TargetResult) UnmarshalJSON(data []byte) error {
@@ -38,6 +43,7 @@ func (targetResult *TargetResult) UnmarshalJSON(data []byte) error {
 	}
 
 	targetResult.Name = rawTR.Name
+	targetResult.TargetUuid = rawTR.TargetUuid
 	targetResult.Options = options
 	return nil
 }
@@ -49,10 +55,20 @@ func UnmarshalTargetResultOptions(trName string, rawOptions json.RawMessage) (Ta
 		options = new(AWSTargetResultOptions)
 	case "org.osbuild.aws.s3":
 		options = new(AWSS3TargetResultOptions)
+	case "org.osbuild.generic.s3":
+		options = new(GenericS3TargetResultOptions)
 	case "org.osbuild.gcp":
 		options = new(GCPTargetResultOptions)
+	case "org.osbuild.azure":
+		options = new(AzureTargetResultOptions)
 	case "org.osbuild.azure.image":
 		options = new(AzureImageTargetResultOptions)
+	case "org.osbuild.openstack":
+		options = new(OpenStackTargetResultOptions)
+	case "org.osbuild.libvirt":
+		options = new(LibvirtTargetResultOptions)
+	case "org.osbuild.vmware":
+		options = new(VMWareTargetResultOptions)
 	default:
 		return nil, fmt.Errorf("Unexpected target result name: %s", trName)
 	}
@@ -15,3 +15,16 @@ func (VMWareTargetOptions) isTargetOptions() {}
 func NewVMWareTarget(options *VMWareTargetOptions) *Target {
 	return newTarget("org.osbuild.vmware", options)
 }
+
+type VMWareTargetResultOptions struct {
+	Host       string `json:"host"`
+	Datacenter string `json:"datacenter"`
+	Datastore  string `json:"datastore"`
+	ImageName  string `json:"image_name"`
+}
+
+func (VMWareTargetResultOptions) isTargetResultOptions() {}
+
+func NewVMWareTargetResult(options *VMWareTargetResultOptions) *TargetResult {
+	return newTargetResult("org.osbuild.vmware", options)
+}
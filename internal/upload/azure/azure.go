@@ -5,9 +5,9 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/Azure/azure-sdk-for-go/profiles/2019-03-01/compute/mgmt/compute"
 	"github.com/Azure/azure-sdk-for-go/profiles/2019-03-01/resources/mgmt/resources"
 	"github.com/Azure/azure-sdk-for-go/profiles/2019-03-01/storage/mgmt/storage"
+	"github.com/Azure/azure-sdk-for-go/profiles/2020-09-01/compute/mgmt/compute"
 	"github.com/Azure/go-autorest/autorest"
 	"github.com/Azure/go-autorest/autorest/azure/auth"
 )
@@ -111,17 +111,39 @@ func (ac Client) GetStorageAccountKey(ctx context.Context, subscriptionID, resou
 	return *(*keys.Keys)[0].Value, nil
 }
 
-// RegisterImage creates a generalized V1 Linux image from a given blob.
-func (ac Client) RegisterImage(ctx context.Context, subscriptionID, resourceGroup, storageAccount, storageContainer, blobName, imageName, location string) error {
+// HyperVGenerationType is the generation of the Hyper-V virtual machines an
+// image is registered for.
+type HyperVGenerationType string
+
+const (
+	HyperVGenV1 HyperVGenerationType = "V1"
+	// Gen2 virtual machines boot only with UEFI
+	HyperVGenV2 HyperVGenerationType = "V2"
+)
+
+// RegisterImage creates a generalized Linux image of the given Hyper-V
+// generation from a given blob. V1 is used if the generation is empty.
+func (ac Client) RegisterImage(ctx context.Context, subscriptionID, resourceGroup, storageAccount, storageContainer, blobName, imageName, location string, hyperVGen HyperVGenerationType) error {
 	c := compute.NewImagesClient(subscriptionID)
 	c.Authorizer = ac.authorizer
 
 	blobURI := fmt.Sprintf("https://%s.blob.core.windows.net/%s/%s", storageAccount, storageContainer, blobName)
 
+	var hyperVGeneration compute.HyperVGenerationTypes
+	switch hyperVGen {
+	case "", HyperVGenV1:
+		hyperVGeneration = compute.HyperVGenerationTypesV1
+	case HyperVGenV2:
+		hyperVGeneration = compute.HyperVGenerationTypesV2
+	default:
+		return fmt.Errorf("unknown Hyper-V generation %q", hyperVGen)
+	}
+
 	imageFuture, err := c.CreateOrUpdate(ctx, resourceGroup, imageName, compute.Image{
 		Response: autorest.Response{},
 		ImageProperties: &compute.ImageProperties{
 			SourceVirtualMachine: nil,
+			HyperVGeneration:     hyperVGeneration,
 			StorageProfile: &compute.ImageStorageProfile{
 				OsDisk: &compute.ImageOSDisk{
 					OsType:  compute.Linux,
@@ -50,6 +50,16 @@ type BlobMetadata struct {
 	BlobName       string
 }
 
+// URL returns the address of the blob described by the metadata. The blob
+// name gets the same .vhd suffix as in UploadPageBlob.
+func (m BlobMetadata) URL() string {
+	blobName := m.BlobName
+	if !strings.HasSuffix(blobName, ".vhd") {
+		blobName += ".vhd"
+	}
+	return fmt.Sprintf("https://%s.blob.core.windows.net/%s/%s", m.StorageAccount, m.ContainerName, blobName)
+}
+
 // DefaultUploadThreads defines a tested default value for the UploadPageBlob method's threads parameter.
 const DefaultUploadThreads = 16
 
@@ -15,3 +15,15 @@ func TestRandomStorageAccountName(t *testing.T) {
 	r := regexp.MustCompile(`^[\d\w]{24}$`)
 	assert.True(t, r.MatchString(randomName), "the returned name should be 24 characters long and contain only alphanumerical characters")
 }
+
+func TestBlobMetadataURL(t *testing.T) {
+	metadata := BlobMetadata{
+		StorageAccount: "account",
+		ContainerName:  "container",
+		BlobName:       "image",
+	}
+	assert.Equal(t, "https://account.blob.core.windows.net/container/image.vhd", metadata.URL())
+
+	metadata.BlobName = "image.vhd"
+	assert.Equal(t, "https://account.blob.core.windows.net/container/image.vhd", metadata.URL())
+}
@@ -0,0 +1,202 @@
+package libvirt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+)
+
+// DefaultDomainTemplate is used to define a domain when no template is
+// given. It boots the uploaded volume as the only disk of a KVM guest with
+// a virtio network interface on the default network.
+const DefaultDomainTemplate = `<domain type='kvm'>
+  <name>{{.Name}}</name>
+  <memory unit='MiB'>{{.Memory}}</memory>
+  <vcpu>{{.VCPUs}}</vcpu>
+  <os>
+    <type arch='{{.Arch}}'>hvm</type>
+    <boot dev='hd'/>
+  </os>
+  <features>
+    <acpi/>
+  </features>
+  <cpu mode='host-passthrough'/>
+  <devices>
+    <disk type='file' device='disk'>
+      <driver name='qemu' type='{{.Format}}'/>
+      <source file='{{.VolumePath}}'/>
+      <target dev='vda' bus='virtio'/>
+    </disk>
+    <interface type='network'>
+      <source network='default'/>
+      <model type='virtio'/>
+    </interface>
+    <serial type='pty'/>
+    <console type='pty'/>
+    <graphics type='vnc'/>
+  </devices>
+</domain>
+`
+
+type Connection struct {
+	// URI of the libvirt daemon, e.g. qemu+ssh://user@host/system or
+	// qemu:///system for the local socket.
+	URI string
+}
+
+type VolumeOptions struct {
+	Pool   string
+	Name   string
+	Format string
+}
+
+// DomainOptions are available to the domain template. All string values are
+// XML-escaped before they are inserted.
+type DomainOptions struct {
+	Name       string
+	Memory     uint64
+	VCPUs      uint
+	Arch       string
+	Format     string
+	VolumePath string
+}
+
+func (c Connection) virsh(command string, args ...string) (string, error) {
+	args = append([]string{command}, args...)
+	if c.URI != "" {
+		args = append([]string{"--connect", c.URI}, args...)
+	}
+	/* #nosec G204 */
+	cmd := exec.Command("/usr/bin/virsh", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("virsh %s failed: %v: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// UploadVolume creates a new volume in a storage pool and uploads the image
+// into it. The path of the volume in the pool is returned.
+func (c Connection) UploadVolume(imagePath string, options VolumeOptions) (string, error) {
+	info, err := os.Stat(imagePath)
+	if err != nil {
+		return "", err
+	}
+
+	format := options.Format
+	if format == "" {
+		format = "qcow2"
+	}
+
+	_, err = c.virsh("vol-create-as", options.Pool, options.Name, fmt.Sprintf("%d", info.Size()), "--format", format)
+	if err != nil {
+		return "", fmt.Errorf("creating volume failed: %v", err)
+	}
+
+	_, err = c.virsh("vol-upload", "--pool", options.Pool, options.Name, imagePath)
+	if err != nil {
+		// don't leave an empty volume behind
+		_ = c.DeleteVolume(options.Pool, options.Name)
+		return "", fmt.Errorf("uploading volume failed: %v", err)
+	}
+
+	// make libvirt pick up the real capacity of the uploaded image
+	_, err = c.virsh("pool-refresh", options.Pool)
+	if err != nil {
+		_ = c.DeleteVolume(options.Pool, options.Name)
+		return "", fmt.Errorf("refreshing pool failed: %v", err)
+	}
+
+	volumePath, err := c.virsh("vol-path", "--pool", options.Pool, options.Name)
+	if err != nil {
+		_ = c.DeleteVolume(options.Pool, options.Name)
+		return "", fmt.Errorf("getting volume path failed: %v", err)
+	}
+
+	return volumePath, nil
+}
+
+// DeleteVolume deletes a volume from a storage pool.
+func (c Connection) DeleteVolume(pool, name string) error {
+	_, err := c.virsh("vol-delete", "--pool", pool, name)
+	if err != nil {
+		return fmt.Errorf("deleting volume failed: %v", err)
+	}
+	return nil
+}
+
+// escapeXML escapes all characters of s which have a special meaning in XML
+// character data and attribute values.
+func escapeXML(s string) string {
+	var escaped bytes.Buffer
+	// writing into a bytes.Buffer never fails
+	_ = xml.EscapeText(&escaped, []byte(s))
+	return escaped.String()
+}
+
+// renderDomain renders the domain template with the given options. If
+// domainTemplate is empty, DefaultDomainTemplate is used.
+func renderDomain(domainTemplate string, options DomainOptions) ([]byte, error) {
+	if domainTemplate == "" {
+		domainTemplate = DefaultDomainTemplate
+	}
+
+	options.Name = escapeXML(options.Name)
+	options.Arch = escapeXML(options.Arch)
+	options.Format = escapeXML(options.Format)
+	options.VolumePath = escapeXML(options.VolumePath)
+
+	tmpl, err := template.New("domain").Parse(domainTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parsing domain template failed: %v", err)
+	}
+
+	var domain bytes.Buffer
+	err = tmpl.Execute(&domain, options)
+	if err != nil {
+		return nil, fmt.Errorf("rendering domain template failed: %v", err)
+	}
+
+	return domain.Bytes(), nil
+}
+
+// DefineDomain renders the domain template with the given options and
+// defines a new (persistent, not started) domain from it. If domainTemplate
+// is empty, DefaultDomainTemplate is used.
+func (c Connection) DefineDomain(domainTemplate string, options DomainOptions) error {
+	domain, err := renderDomain(domainTemplate, options)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile("", "osbuild-libvirt-domain-*.xml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(domain)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.virsh("define", f.Name())
+	if err != nil {
+		return fmt.Errorf("defining domain failed: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package libvirt
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderDomain(t *testing.T) {
+	options := DomainOptions{
+		Name:       "my-image",
+		Memory:     4096,
+		VCPUs:      4,
+		Arch:       "x86_64",
+		Format:     "qcow2",
+		VolumePath: "/var/lib/libvirt/images/my-image.qcow2",
+	}
+
+	domain, err := renderDomain("", options)
+	require.NoError(t, err)
+
+	var parsed struct {
+		Name   string `xml:"name"`
+		Memory uint64 `xml:"memory"`
+		VCPUs  uint   `xml:"vcpu"`
+		Disk   struct {
+			Driver struct {
+				Type string `xml:"type,attr"`
+			} `xml:"driver"`
+			Source struct {
+				File string `xml:"file,attr"`
+			} `xml:"source"`
+		} `xml:"devices>disk"`
+	}
+	err = xml.Unmarshal(domain, &parsed)
+	require.NoError(t, err)
+	require.Equal(t, "my-image", parsed.Name)
+	require.Equal(t, uint64(4096), parsed.Memory)
+	require.Equal(t, uint(4), parsed.VCPUs)
+	require.Equal(t, "qcow2", parsed.Disk.Driver.Type)
+	require.Equal(t, "/var/lib/libvirt/images/my-image.qcow2", parsed.Disk.Source.File)
+
+	domain, err = renderDomain("<domain><name>{{.Name}}-custom</name></domain>", options)
+	require.NoError(t, err)
+	require.Equal(t, "<domain><name>my-image-custom</name></domain>", string(domain))
+
+	_, err = renderDomain("<domain>{{.Unknown}}</domain>", options)
+	require.Error(t, err)
+
+	_, err = renderDomain("<domain>{{.Name</domain>", options)
+	require.Error(t, err)
+}
+
+func TestRenderDomainEscaping(t *testing.T) {
+	options := DomainOptions{
+		Name:       "evil</name><devices><disk type='block'/></devices><name>x",
+		Memory:     2048,
+		VCPUs:      2,
+		Arch:       "x86_64",
+		Format:     "qcow2",
+		VolumePath: "/var/lib/libvirt/images/a'/><source file='/dev/sda.qcow2",
+	}
+
+	domain, err := renderDomain("", options)
+	require.NoError(t, err)
+
+	var parsed struct {
+		Name  string `xml:"name"`
+		Disks []struct {
+			Source struct {
+				File string `xml:"file,attr"`
+			} `xml:"source"`
+		} `xml:"devices>disk"`
+	}
+	err = xml.Unmarshal(domain, &parsed)
+	require.NoError(t, err)
+	require.Equal(t, options.Name, parsed.Name)
+	require.Len(t, parsed.Disks, 1)
+	require.Equal(t, options.VolumePath, parsed.Disks[0].Source.File)
+}
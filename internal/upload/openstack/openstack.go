@@ -0,0 +1,141 @@
+package openstack
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack"
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/imagedata"
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
+)
+
+// How long to wait for Glance to process the uploaded image data
+const waitTimeout = 30 * 60 // 30 minutes in seconds
+
+type Credentials struct {
+	AuthURL     string
+	Username    string
+	Password    string
+	ProjectName string
+	ProjectID   string
+	DomainName  string
+	Region      string
+}
+
+type ImageOptions struct {
+	Name            string
+	DiskFormat      string
+	ContainerFormat string
+	Visibility      string
+	Properties      map[string]string
+}
+
+func newImageServiceClient(creds Credentials) (*gophercloud.ServiceClient, error) {
+	domainName := creds.DomainName
+	if domainName == "" {
+		domainName = "Default"
+	}
+
+	provider, err := openstack.AuthenticatedClient(gophercloud.AuthOptions{
+		IdentityEndpoint: creds.AuthURL,
+		Username:         creds.Username,
+		Password:         creds.Password,
+		DomainName:       domainName,
+		TenantName:       creds.ProjectName,
+		TenantID:         creds.ProjectID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed: %v", err)
+	}
+
+	client, err := openstack.NewImageServiceV2(provider, gophercloud.EndpointOpts{
+		Region: creds.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating ImageService client: %v", err)
+	}
+
+	return client, nil
+}
+
+// UploadImage creates a new image in OpenStack Glance, uploads the image data
+// into it and waits until the image becomes active. If any step after creating
+// the image fails, the image is deleted again. Only if that deletion fails as
+// well, the ID of the leftover image is returned along with the error, so that
+// the caller can report it.
+func UploadImage(creds Credentials, imagePath string, options ImageOptions) (string, error) {
+	client, err := newImageServiceClient(creds)
+	if err != nil {
+		return "", err
+	}
+
+	return uploadImage(client, imagePath, options)
+}
+
+func uploadImage(client *gophercloud.ServiceClient, imagePath string, options ImageOptions) (string, error) {
+	createOpts := images.CreateOpts{
+		Name:            options.Name,
+		DiskFormat:      options.DiskFormat,
+		ContainerFormat: options.ContainerFormat,
+		Properties:      options.Properties,
+	}
+	if createOpts.DiskFormat == "" {
+		createOpts.DiskFormat = "qcow2"
+	}
+	if createOpts.ContainerFormat == "" {
+		createOpts.ContainerFormat = "bare"
+	}
+	if options.Visibility != "" {
+		visibility := images.ImageVisibility(options.Visibility)
+		createOpts.Visibility = &visibility
+	}
+
+	// create a new image which gives us the ID
+	image, err := images.Create(client, createOpts).Extract()
+	if err != nil {
+		return "", fmt.Errorf("creating image failed: %v", err)
+	}
+
+	err = uploadImageData(client, image.ID, imagePath)
+	if err != nil {
+		deleteErr := images.Delete(client, image.ID).ExtractErr()
+		if deleteErr != nil {
+			return image.ID, fmt.Errorf("%v (deleting image %s failed as well: %v)", err, image.ID, deleteErr)
+		}
+		return "", err
+	}
+
+	return image.ID, nil
+}
+
+// uploadImageData uploads the binary data into an existing image and waits
+// for the status to change from Queued to Active.
+func uploadImageData(client *gophercloud.ServiceClient, imageID, imagePath string) error {
+	imageData, err := os.Open(imagePath)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %v", imagePath, err)
+	}
+	defer imageData.Close()
+
+	err = imagedata.Upload(client, imageID, imageData).ExtractErr()
+	if err != nil {
+		return fmt.Errorf("upload to OpenStack failed: %v", err)
+	}
+
+	err = gophercloud.WaitFor(waitTimeout, func() (bool, error) {
+		actual, err := images.Get(client, imageID).Extract()
+		if err != nil {
+			return false, err
+		}
+		if actual.Status == images.ImageStatusKilled || actual.Status == images.ImageStatusDeleted {
+			return false, fmt.Errorf("image ended up in %s state", actual.Status)
+		}
+		return actual.Status == images.ImageStatusActive, nil
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for image to become active failed: %v", err)
+	}
+
+	return nil
+}
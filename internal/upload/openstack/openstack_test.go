@@ -0,0 +1,105 @@
+package openstack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/stretchr/testify/require"
+)
+
+const testImageID = "1bea47ed-f6a9-463b-b423-14b9cca9ad27"
+
+// fakeGlance is a minimal image service which creates one image, fails the
+// data upload if requested and records whether the image was deleted.
+type fakeGlance struct {
+	failUpload bool
+	failDelete bool
+	deleted    bool
+}
+
+func (g *fakeGlance) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	imageURL := "/v2/images/" + testImageID
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/v2/images":
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id": "%s", "status": "queued"}`, testImageID)
+	case r.Method == http.MethodPut && r.URL.Path == imageURL+"/file":
+		if g.failUpload {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && r.URL.Path == imageURL:
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id": "%s", "status": "active"}`, testImageID)
+	case r.Method == http.MethodDelete && r.URL.Path == imageURL:
+		if g.failDelete {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		g.deleted = true
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestUploadImage(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "openstack-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	imagePath := filepath.Join(tmpdir, "disk.qcow2")
+	require.NoError(t, ioutil.WriteFile(imagePath, []byte("image"), 0600))
+
+	upload := func(glance *fakeGlance, imagePath string) (string, error) {
+		server := httptest.NewServer(glance)
+		defer server.Close()
+
+		client := &gophercloud.ServiceClient{
+			ProviderClient: &gophercloud.ProviderClient{},
+			Endpoint:       server.URL + "/",
+			ResourceBase:   server.URL + "/v2/",
+		}
+		return uploadImage(client, imagePath, ImageOptions{Name: "my-image"})
+	}
+
+	t.Run("success", func(t *testing.T) {
+		glance := &fakeGlance{}
+		imageID, err := upload(glance, imagePath)
+		require.NoError(t, err)
+		require.Equal(t, testImageID, imageID)
+		require.False(t, glance.deleted)
+	})
+
+	t.Run("upload fails", func(t *testing.T) {
+		glance := &fakeGlance{failUpload: true}
+		imageID, err := upload(glance, imagePath)
+		require.Error(t, err)
+		require.Empty(t, imageID)
+		require.True(t, glance.deleted)
+	})
+
+	t.Run("image file missing", func(t *testing.T) {
+		glance := &fakeGlance{}
+		imageID, err := upload(glance, filepath.Join(tmpdir, "missing.qcow2"))
+		require.Error(t, err)
+		require.Empty(t, imageID)
+		require.True(t, glance.deleted)
+	})
+
+	t.Run("cleanup fails", func(t *testing.T) {
+		glance := &fakeGlance{failUpload: true, failDelete: true}
+		imageID, err := upload(glance, imagePath)
+		require.Error(t, err)
+		require.Equal(t, testImageID, imageID)
+		require.Contains(t, err.Error(), "deleting image")
+	})
+}
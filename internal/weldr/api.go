@@ -33,20 +33,25 @@ import (
 	"github.com/osbuild/osbuild-composer/internal/distro"
 	"github.com/osbuild/osbuild-composer/internal/distroregistry"
 	"github.com/osbuild/osbuild-composer/internal/jobqueue"
+	"github.com/osbuild/osbuild-composer/internal/localrepo"
 	osbuild "github.com/osbuild/osbuild-composer/internal/osbuild2"
 	"github.com/osbuild/osbuild-composer/internal/ostree"
 	"github.com/osbuild/osbuild-composer/internal/reporegistry"
 	"github.com/osbuild/osbuild-composer/internal/rpmmd"
+	"github.com/osbuild/osbuild-composer/internal/snapshot"
 	"github.com/osbuild/osbuild-composer/internal/store"
 	"github.com/osbuild/osbuild-composer/internal/target"
 	"github.com/osbuild/osbuild-composer/internal/worker"
 )
 
 type API struct {
-	store   *store.Store
-	workers *worker.Server
+	store     *store.Store
+	workers   *worker.Server
+	snapshots *snapshot.Store
+	localRepo *localrepo.Repo // nil if uploading packages is not supported
 
 	rpmmd        rpmmd.RPMMD
+	rpmmdCache   *rpmmd.CacheManager // nil if the cache cannot be managed
 	arch         distro.Arch
 	repoRegistry *reporegistry.RepoRegistry
 
@@ -58,6 +63,7 @@ type API struct {
 	hostDistroName string                   // Name of the host distro
 	distroRegistry *distroregistry.Registry // Available distros
 	distros        []string                 // Supported distro names
+	distroAliases  map[string]string        // Aliases of supported distros
 
 	//  List of ImageType names, which should not be exposed by the API
 	distrosImageTypeDenylist map[string][]string
@@ -116,6 +122,18 @@ func validDistros(rr *reporegistry.RepoRegistry, dr *distroregistry.Registry, ar
 	return distros
 }
 
+// validDistroAliases returns the distro aliases which resolve to one of the
+// given supported distributions
+func validDistroAliases(dr *distroregistry.Registry, distros []string) map[string]string {
+	aliases := map[string]string{}
+	for alias, target := range dr.Aliases() {
+		if common.IsStringInSortedSlice(distros, target) {
+			aliases[alias] = target
+		}
+	}
+	return aliases
+}
+
 var ValidBlueprintName = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
 
 // NewTestAPI is used for the test framework, sets up a single distro
@@ -126,9 +144,12 @@ func NewTestAPI(rpm rpmmd.RPMMD, arch distro.Arch, dr *distroregistry.Registry,
 
 	// Use the first entry as the host distribution
 	hostDistro := dr.GetDistro(dr.List()[0])
+	snapshots, err := snapshot.NewStore(nil)
+	common.PanicOnError(err)
 	api := &API{
 		store:                    store,
 		workers:                  workers,
+		snapshots:                snapshots,
 		rpmmd:                    rpm,
 		arch:                     arch,
 		repoRegistry:             rr,
@@ -139,11 +160,13 @@ func NewTestAPI(rpm rpmmd.RPMMD, arch distro.Arch, dr *distroregistry.Registry,
 		distros:                  validDistros(rr, dr, arch.Name(), logger),
 		distrosImageTypeDenylist: distrosImageTypeDenylist,
 	}
+	api.distroAliases = validDistroAliases(dr, api.distros)
 	return setupRouter(api)
 }
 
-func New(repoPaths []string, stateDir string, rpm rpmmd.RPMMD, dr *distroregistry.Registry,
-	logger *log.Logger, workers *worker.Server, distrosImageTypeDenylist map[string][]string) (*API, error) {
+func New(rr *reporegistry.RepoRegistry, stateDir string, rpm rpmmd.RPMMD, rpmCache *rpmmd.CacheManager, dr *distroregistry.Registry,
+	logger *log.Logger, workers *worker.Server, snapshots *snapshot.Store,
+	distrosImageTypeDenylist map[string][]string) (*API, error) {
 	if logger == nil {
 		logger = log.New(os.Stdout, "", 0)
 	}
@@ -164,11 +187,6 @@ func New(repoPaths []string, stateDir string, rpm rpmmd.RPMMD, dr *distroregistr
 		return nil, fmt.Errorf("Host distro does not support host architecture: %v", err)
 	}
 
-	rr, err := reporegistry.New(repoPaths)
-	if err != nil {
-		return nil, fmt.Errorf("error loading repository definitions: %v", err)
-	}
-
 	// Check if repositories for the host distro and arch were loaded
 	_, err = rr.ReposByArch(hostArch, false)
 	if err != nil {
@@ -178,10 +196,18 @@ func New(repoPaths []string, stateDir string, rpm rpmmd.RPMMD, dr *distroregistr
 	store := store.New(&stateDir, hostArch, logger)
 	compatOutputDir := path.Join(stateDir, "outputs")
 
+	localRepo, err := localrepo.New(path.Join(stateDir, "local-repo"))
+	if err != nil {
+		return nil, err
+	}
+
 	api := &API{
 		store:                    store,
 		workers:                  workers,
+		snapshots:                snapshots,
+		localRepo:                localRepo,
 		rpmmd:                    rpm,
+		rpmmdCache:               rpmCache,
 		arch:                     hostArch,
 		repoRegistry:             rr,
 		logger:                   logger,
@@ -191,6 +217,7 @@ func New(repoPaths []string, stateDir string, rpm rpmmd.RPMMD, dr *distroregistr
 		distros:                  validDistros(rr, dr, hostArch.Name(), logger),
 		distrosImageTypeDenylist: distrosImageTypeDenylist,
 	}
+	api.distroAliases = validDistroAliases(dr, api.distros)
 	return setupRouter(api), nil
 }
 
@@ -208,6 +235,13 @@ func setupRouter(api *API) *API {
 	api.router.POST("/api/v:version/projects/source/new", api.sourceNewHandler)
 	api.router.DELETE("/api/v:version/projects/source/delete/*source", api.sourceDeleteHandler)
 
+	api.router.POST("/api/v:version/projects/local/upload", api.localUploadHandler)
+	api.router.GET("/api/v:version/projects/local/list", api.localListHandler)
+	api.router.DELETE("/api/v:version/projects/local/delete/:package", api.localDeleteHandler)
+
+	api.router.GET("/api/v:version/projects/cache/info", api.projectsCacheInfoHandler)
+	api.router.POST("/api/v:version/projects/cache/refresh", api.projectsCacheRefreshHandler)
+
 	api.router.GET("/api/v:version/projects/depsolve", api.projectsDepsolveHandler)
 	api.router.GET("/api/v:version/projects/depsolve/*projects", api.projectsDepsolveHandler)
 
@@ -262,6 +296,13 @@ func setupRouter(api *API) *API {
 	api.router.DELETE("/api/v:version/upload/providers/delete/:provider/:profile", api.providersDeleteHandler)
 
 	api.router.GET("/api/v:version/distros/list", api.distrosListHandler)
+	api.router.GET("/api/v:version/distros/repos", api.distrosReposListHandler)
+	api.router.GET("/api/v:version/distros/repos/:distro", api.distrosReposInfoHandler)
+	api.router.POST("/api/v:version/distros/repos/new", api.distrosReposNewHandler)
+
+	api.router.GET("/api/v:version/snapshots/list", api.snapshotsListHandler)
+	api.router.GET("/api/v:version/snapshots/info/:snapshots", api.snapshotsInfoHandler)
+	api.router.DELETE("/api/v:version/snapshots/delete/:snapshot", api.snapshotsDeleteHandler)
 	return api
 }
 
@@ -286,11 +327,12 @@ func (api *API) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 }
 
 type composeStatus struct {
-	State    ComposeState
-	Queued   time.Time
-	Started  time.Time
-	Finished time.Time
-	Result   *osbuild.Result
+	State         ComposeState
+	Queued        time.Time
+	Started       time.Time
+	Finished      time.Time
+	Result        *osbuild.Result
+	TargetResults map[uuid.UUID]*target.TargetResult
 }
 
 func composeStateFromJobStatus(js *worker.JobStatus, result *worker.OSBuildJobResult) ComposeState {
@@ -316,7 +358,7 @@ func composeStateFromJobStatus(js *worker.JobStatus, result *worker.OSBuildJobRe
 // Returns the state of the image in `compose` and the times the job was
 // queued, started, and finished. Assumes that there's only one image in the
 // compose.
-func (api *API) getComposeStatus(compose store.Compose) *composeStatus {
+func (api *API) getComposeStatus(id uuid.UUID, compose store.Compose) *composeStatus {
 	jobId := compose.ImageBuild.JobID
 
 	// backwards compatibility: composes that were around before splitting
@@ -351,20 +393,54 @@ func (api *API) getComposeStatus(compose store.Compose) *composeStatus {
 		panic(err)
 	}
 
+	// The upload results are stored on the compose once its job has
+	// finished, so that they don't depend on the job queue afterwards.
+	targetResults := compose.TargetResults
+	if targetResults == nil && !jobStatus.Finished.IsZero() {
+		targetResults = targetResultsByUUID(compose.ImageBuild.Targets, result.TargetResults)
+		err = api.store.SetComposeTargetResults(id, targetResults)
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	return &composeStatus{
-		State:    composeStateFromJobStatus(jobStatus, &result),
-		Queued:   jobStatus.Queued,
-		Started:  jobStatus.Started,
-		Finished: jobStatus.Finished,
-		Result:   result.OSBuildOutput,
+		State:         composeStateFromJobStatus(jobStatus, &result),
+		Queued:        jobStatus.Queued,
+		Started:       jobStatus.Started,
+		Finished:      jobStatus.Finished,
+		Result:        result.OSBuildOutput,
+		TargetResults: targetResults,
+	}
+}
+
+// Returns the results of a job keyed by the UUID of their target. Results of
+// older workers don't carry the UUID of their target and are matched to the
+// target by name instead.
+func targetResultsByUUID(targets []*target.Target, results []*target.TargetResult) map[uuid.UUID]*target.TargetResult {
+	targetResults := make(map[uuid.UUID]*target.TargetResult)
+	for _, result := range results {
+		if result.TargetUuid != uuid.Nil {
+			targetResults[result.TargetUuid] = result
+			continue
+		}
+
+		for _, t := range targets {
+			if t.Name == result.Name {
+				targetResults[t.Uuid] = result
+				break
+			}
+		}
 	}
+
+	return targetResults
 }
 
 // Opens the image file for `compose`. This asks the worker server for the
 // artifact first, and then falls back to looking in
 // `{outputs}/{composeId}/{imageBuildId}` for backwards compatibility.
 func (api *API) openImageFile(composeId uuid.UUID, compose store.Compose) (io.Reader, int64, error) {
-	name := compose.ImageBuild.ImageType.Filename()
+	name := compose.ImageBuild.ImageFilename()
 
 	reader, size, err := api.workers.JobArtifact(compose.ImageBuild.JobID, name)
 	if err != nil {
@@ -444,6 +520,7 @@ func (api *API) getImageType(distroName, imageType string) (distro.ImageType, er
 
 func (api *API) parseDistro(query url.Values) (string, error) {
 	if distro := query.Get("distro"); distro != "" {
+		distro = api.resolveDistroName(distro)
 		if common.IsStringInSortedSlice(api.distros, distro) {
 			return distro, nil
 		}
@@ -452,9 +529,19 @@ func (api *API) parseDistro(query url.Values) (string, error) {
 	return api.hostDistroName, nil
 }
 
+// resolveDistroName returns the name of the distro the given name is an
+// alias of, or the name itself if it is not an alias
+func (api *API) resolveDistroName(name string) string {
+	if target, ok := api.distroAliases[name]; ok {
+		return target
+	}
+	return name
+}
+
 // getDistro returns the named distro or nil
 // It excludes unsupported distros by first checking the api.distros list
 func (api *API) getDistro(name string) distro.Distro {
+	name = api.resolveDistroName(name)
 	if !common.IsStringInSortedSlice(api.distros, name) {
 		return nil
 	}
@@ -973,6 +1060,178 @@ func (api *API) sourceDeleteHandler(writer http.ResponseWriter, request *http.Re
 	statusResponseOK(writer)
 }
 
+// verifyRPMMDCache returns false and writes an error if the metadata cache
+// cannot be managed
+func (api *API) verifyRPMMDCache(writer http.ResponseWriter) bool {
+	if api.rpmmdCache == nil {
+		errors := responseError{
+			ID:  "MetadataCacheError",
+			Msg: "the metadata cache cannot be managed",
+		}
+		statusResponseError(writer, http.StatusBadRequest, errors)
+		return false
+	}
+	return true
+}
+
+func (api *API) projectsCacheInfoHandler(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	if !verifyRequestVersion(writer, params, 1) || !api.verifyRPMMDCache(writer) {
+		return
+	}
+
+	config := api.rpmmdCache.Config()
+	reply := struct {
+		TTL     int64                  `json:"ttl"`
+		MaxSize int64                  `json:"max_size"`
+		Repos   []rpmmd.RepoCacheStats `json:"repos"`
+	}{
+		TTL:     int64(config.TTL.Seconds()),
+		MaxSize: config.MaxSize,
+		Repos:   api.rpmmdCache.Stats(),
+	}
+
+	err := json.NewEncoder(writer).Encode(reply)
+	common.PanicOnError(err)
+}
+
+// projectsCacheRefreshHandler makes the next request for each repository
+// download its metadata again
+func (api *API) projectsCacheRefreshHandler(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	if !verifyRequestVersion(writer, params, 1) || !api.verifyRPMMDCache(writer) {
+		return
+	}
+
+	api.rpmmdCache.Refresh()
+	statusResponseOK(writer)
+}
+
+type localPackage struct {
+	Name    string `json:"name"`
+	Epoch   uint   `json:"epoch"`
+	Version string `json:"version"`
+	Release string `json:"release"`
+	Arch    string `json:"arch"`
+	Summary string `json:"summary"`
+}
+
+func newLocalPackage(pkg *localrepo.Package) localPackage {
+	return localPackage{
+		Name:    pkg.Name,
+		Epoch:   pkg.Epoch,
+		Version: pkg.Version,
+		Release: pkg.Release,
+		Arch:    pkg.Arch,
+		Summary: pkg.Summary,
+	}
+}
+
+// verifyLocalRepo returns false and writes an error if uploading packages is
+// not supported
+func (api *API) verifyLocalRepo(writer http.ResponseWriter) bool {
+	if api.localRepo == nil {
+		errors := responseError{
+			ID:  "LocalRepoError",
+			Msg: "uploading packages is not supported",
+		}
+		statusResponseError(writer, http.StatusBadRequest, errors)
+		return false
+	}
+	return true
+}
+
+// localUploadHandler adds the RPM package in the request body to the local
+// repository and makes sure the repository is available as a source
+func (api *API) localUploadHandler(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	if !verifyRequestVersion(writer, params, 1) || !api.verifyLocalRepo(writer) {
+		return
+	}
+
+	if request.Header.Get("Content-Type") != "application/x-rpm" {
+		errors := responseError{
+			ID:  "HTTPError",
+			Msg: "Content-Type must be application/x-rpm",
+		}
+		statusResponseError(writer, http.StatusBadRequest, errors)
+		return
+	}
+
+	pkg, err := api.localRepo.Add(request.Body)
+	if err != nil {
+		errors := responseError{
+			ID:  "LocalRepoError",
+			Msg: "Cannot add package: " + err.Error(),
+		}
+		statusResponseError(writer, http.StatusBadRequest, errors)
+		return
+	}
+
+	// (re-)register the source in case it was deleted
+	api.store.PushSource(localrepo.SourceID, store.SourceConfig{
+		Name:     localrepo.SourceName,
+		Type:     "yum-baseurl",
+		URL:      api.localRepo.URL(),
+		CheckGPG: false,
+		CheckSSL: false,
+	})
+
+	err = json.NewEncoder(writer).Encode(struct {
+		Package localPackage `json:"package"`
+	}{newLocalPackage(pkg)})
+	common.PanicOnError(err)
+}
+
+func (api *API) localListHandler(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	if !verifyRequestVersion(writer, params, 1) || !api.verifyLocalRepo(writer) {
+		return
+	}
+
+	packages, err := api.localRepo.List()
+	if err != nil {
+		errors := responseError{
+			ID:  "LocalRepoError",
+			Msg: err.Error(),
+		}
+		statusResponseError(writer, http.StatusInternalServerError, errors)
+		return
+	}
+
+	reply := struct {
+		Packages []localPackage `json:"packages"`
+	}{[]localPackage{}}
+	for _, pkg := range packages {
+		reply.Packages = append(reply.Packages, newLocalPackage(pkg))
+	}
+
+	err = json.NewEncoder(writer).Encode(reply)
+	common.PanicOnError(err)
+}
+
+func (api *API) localDeleteHandler(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	if !verifyRequestVersion(writer, params, 1) || !api.verifyLocalRepo(writer) {
+		return
+	}
+
+	name := params.ByName("package")
+	err := api.localRepo.Delete(name)
+	if err == localrepo.ErrPackageNotFound {
+		errors := responseError{
+			ID:  "UnknownPackage",
+			Msg: fmt.Sprintf("%s: package not found", name),
+		}
+		statusResponseError(writer, http.StatusBadRequest, errors)
+		return
+	} else if err != nil {
+		errors := responseError{
+			ID:  "LocalRepoError",
+			Msg: err.Error(),
+		}
+		statusResponseError(writer, http.StatusInternalServerError, errors)
+		return
+	}
+
+	statusResponseOK(writer)
+}
+
 func (api *API) modulesListHandler(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
 	if !verifyRequestVersion(writer, params, 0) {
 		return
@@ -1912,7 +2171,7 @@ func (api *API) blueprintsNewHandler(writer http.ResponseWriter, request *http.R
 
 	// Check the blueprint's distro to make sure it is valid
 	if len(blueprint.Distro) > 0 {
-		if !common.IsStringInSortedSlice(api.distros, blueprint.Distro) {
+		if !common.IsStringInSortedSlice(api.distros, api.resolveDistroName(blueprint.Distro)) {
 			errors := responseError{
 				ID:  "BlueprintsError",
 				Msg: fmt.Sprintf("'%s' is not a valid distribution", blueprint.Distro),
@@ -2101,38 +2360,73 @@ func (api *API) blueprintsTagHandler(writer http.ResponseWriter, request *http.R
 }
 
 // depsolveBlueprintForImageType handles depsolving the blueprint package list and
-// the packages required for the image type.
+// the packages required for the image type. It also returns the checksums of
+// the metadata of the repositories that were used, keyed by repository name.
 // NOTE: The imageType *must* be from the same distribution as the blueprint.
-func (api *API) depsolveBlueprintForImageType(bp blueprint.Blueprint, imageType distro.ImageType) (map[string][]rpmmd.PackageSpec, error) {
+func (api *API) depsolveBlueprintForImageType(bp blueprint.Blueprint, imageType distro.ImageType) (map[string][]rpmmd.PackageSpec, map[string]string, error) {
 	// Depsolve using the host distro if none has been specified
+	bp.Distro = api.resolveDistroName(bp.Distro)
 	if bp.Distro == "" {
 		bp.Distro = api.hostDistroName
 	}
 
 	if bp.Distro != imageType.Arch().Distro().Name() {
-		return nil, fmt.Errorf("Blueprint distro %s does not match imageType distro %s", bp.Distro, imageType.Arch().Distro().Name())
+		return nil, nil, fmt.Errorf("Blueprint distro %s does not match imageType distro %s", bp.Distro, imageType.Arch().Distro().Name())
 	}
 	packageSets := imageType.PackageSets(bp)
 	packageSpecSets := make(map[string][]rpmmd.PackageSpec)
+	repoChecksums := make(map[string]string)
 
 	imageTypeRepos, err := api.allRepositoriesByImageType(imageType)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	platformID := imageType.Arch().Distro().ModulePlatformID()
 	releasever := imageType.Arch().Distro().Releasever()
 	for name, packageSet := range packageSets {
-		packageSpecs, _, err := api.rpmmd.Depsolve(packageSet,
+		packageSpecs, checksums, err := api.rpmmd.Depsolve(packageSet,
 			imageTypeRepos,
 			platformID,
 			api.arch.Name(),
 			releasever)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		packageSpecSets[name] = packageSpecs
+		for repo, checksum := range rpmmd.NamedChecksums(imageTypeRepos, checksums) {
+			repoChecksums[repo] = checksum
+		}
+	}
+	return packageSpecSets, repoChecksums, nil
+}
+
+// composeSnapshot returns the named snapshot after making sure that it was
+// taken for the same distribution, architecture and image type, and for the
+// packages the blueprint requests.
+func (api *API) composeSnapshot(name, distroName string, bp blueprint.Blueprint, imageType distro.ImageType) (*snapshot.Snapshot, error) {
+	s := api.snapshots.Get(name)
+	if s == nil {
+		return nil, fmt.Errorf("Unknown snapshot: %s", name)
+	}
+
+	err := s.Matches(distroName, api.arch.Name(), imageType.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.MatchesPackageRequests(imageType.PackageSets(bp))
+	if err != nil {
+		return nil, err
 	}
-	return packageSpecSets, nil
+
+	// packages of local repositories only exist on this host, the worker
+	// checks the remote ones
+	err = snapshot.CheckLocalPackages(s.Packages())
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
 }
 
 // Schedule new compose by first translating the appropriate blueprint into a pipeline and then
@@ -2150,6 +2444,10 @@ func (api *API) composeHandler(writer http.ResponseWriter, request *http.Request
 		OSTree        ostree.OSTreeRequest `json:"ostree"`
 		Branch        string               `json:"branch"`
 		Upload        *uploadRequest       `json:"upload"`
+		Snapshot      string               `json:"snapshot"`
+		SaveSnapshot  string               `json:"save_snapshot"`
+		BootType      distro.BootType      `json:"boot_type"`
+		Compression   distro.Compression   `json:"compression"`
 	}
 	type ComposeReply struct {
 		BuildID uuid.UUID `json:"build_id"`
@@ -2182,6 +2480,29 @@ func (api *API) composeHandler(writer http.ResponseWriter, request *http.Request
 		return
 	}
 
+	if cr.Snapshot != "" && cr.SaveSnapshot != "" {
+		errors := responseError{
+			ID:  "SnapshotError",
+			Msg: "Supply at most one of snapshot and save_snapshot",
+		}
+		statusResponseError(writer, http.StatusBadRequest, errors)
+		return
+	}
+
+	if cr.SaveSnapshot != "" {
+		if !verifyStringsWithRegex(writer, []string{cr.SaveSnapshot}, snapshot.ValidName) {
+			return
+		}
+		if api.snapshots.Get(cr.SaveSnapshot) != nil {
+			errors := responseError{
+				ID:  "SnapshotError",
+				Msg: fmt.Sprintf("Snapshot %s already exists", cr.SaveSnapshot),
+			}
+			statusResponseError(writer, http.StatusBadRequest, errors)
+			return
+		}
+	}
+
 	bp := api.store.GetBlueprintCommitted(cr.BlueprintName)
 	if bp == nil {
 		errors := responseError{
@@ -2192,7 +2513,7 @@ func (api *API) composeHandler(writer http.ResponseWriter, request *http.Request
 		return
 	}
 
-	distroName := bp.Distro
+	distroName := api.resolveDistroName(bp.Distro)
 	if distroName == "" {
 		distroName = api.hostDistroName
 	}
@@ -2232,7 +2553,16 @@ func (api *API) composeHandler(writer http.ResponseWriter, request *http.Request
 
 	var targets []*target.Target
 	if isRequestVersionAtLeast(params, 1) && cr.Upload != nil {
-		t := uploadRequestToTarget(*cr.Upload, imageType)
+		err = cr.Upload.validate()
+		if err != nil {
+			errors := responseError{
+				ID:  "UploadError",
+				Msg: err.Error(),
+			}
+			statusResponseError(writer, http.StatusBadRequest, errors)
+			return
+		}
+		t := uploadRequestToTarget(*cr.Upload, distro.ImageFilename(imageType, distro.ImageOptions{Compression: cr.Compression}))
 		targets = append(targets, t)
 	}
 
@@ -2280,14 +2610,55 @@ func (api *API) composeHandler(writer http.ResponseWriter, request *http.Request
 		cr.OSTree.Parent = parent
 	}
 
-	packageSets, err := api.depsolveBlueprintForImageType(*bp, imageType)
-	if err != nil {
-		errors := responseError{
-			ID:  "DepsolveError",
-			Msg: err.Error(),
+	// the worker makes sure the packages of a snapshot are still available
+	// before building
+	var packageSets map[string][]rpmmd.PackageSpec
+	var checkPackages []rpmmd.PackageSpec
+	if cr.Snapshot != "" {
+		var s *snapshot.Snapshot
+		s, err = api.composeSnapshot(cr.Snapshot, distroName, *bp, imageType)
+		if err != nil {
+			errors := responseError{
+				ID:  "SnapshotError",
+				Msg: err.Error(),
+			}
+			statusResponseError(writer, http.StatusBadRequest, errors)
+			return
+		}
+		packageSets = s.PackageSets
+		checkPackages = s.Packages()
+	} else {
+		var repoChecksums map[string]string
+		packageSets, repoChecksums, err = api.depsolveBlueprintForImageType(*bp, imageType)
+		if err != nil {
+			errors := responseError{
+				ID:  "DepsolveError",
+				Msg: err.Error(),
+			}
+			statusResponseError(writer, http.StatusInternalServerError, errors)
+			return
+		}
+
+		if cr.SaveSnapshot != "" {
+			err = api.snapshots.Push(&snapshot.Snapshot{
+				Name:            cr.SaveSnapshot,
+				Created:         time.Now(),
+				Distro:          distroName,
+				Arch:            api.arch.Name(),
+				ImageType:       imageType.Name(),
+				RepoChecksums:   repoChecksums,
+				PackageRequests: imageType.PackageSets(*bp),
+				PackageSets:     packageSets,
+			})
+			if err != nil {
+				errors := responseError{
+					ID:  "SnapshotError",
+					Msg: fmt.Sprintf("cannot save snapshot %s: %v", cr.SaveSnapshot, err),
+				}
+				statusResponseError(writer, http.StatusBadRequest, errors)
+				return
+			}
 		}
-		statusResponseError(writer, http.StatusInternalServerError, errors)
-		return
 	}
 
 	var size uint64
@@ -2326,8 +2697,10 @@ func (api *API) composeHandler(writer http.ResponseWriter, request *http.Request
 				Parent: cr.OSTree.Parent,
 				URL:    cr.OSTree.URL,
 			},
+			BootType:    cr.BootType,
+			Compression: cr.Compression,
 		},
-		imageRepos,
+		rpmmd.ResolveGPGKeys(imageRepos, imageType.Arch().Name(), imageType.Arch().Distro().Releasever()),
 		packageSets,
 		seed)
 	if err != nil {
@@ -2341,26 +2714,27 @@ func (api *API) composeHandler(writer http.ResponseWriter, request *http.Request
 
 	if testMode == "1" {
 		// Create a failed compose
-		err = api.store.PushTestCompose(composeID, manifest, imageType, bp, size, targets, false, packageSets["packages"])
+		err = api.store.PushTestCompose(composeID, manifest, imageType, bp, size, cr.Compression, targets, false, packageSets["packages"])
 	} else if testMode == "2" {
 		// Create a successful compose
-		err = api.store.PushTestCompose(composeID, manifest, imageType, bp, size, targets, true, packageSets["packages"])
+		err = api.store.PushTestCompose(composeID, manifest, imageType, bp, size, cr.Compression, targets, true, packageSets["packages"])
 	} else {
 		var jobId uuid.UUID
 
 		jobId, err = api.workers.EnqueueOSBuild(api.arch.Name(), &worker.OSBuildJob{
 			Manifest:        manifest,
 			Targets:         targets,
-			ImageName:       imageType.Filename(),
+			ImageName:       distro.ImageFilename(imageType, distro.ImageOptions{Compression: cr.Compression}),
 			StreamOptimized: imageType.Name() == "vmdk", // https://github.com/osbuild/osbuild/issues/528
 			Exports:         imageType.Exports(),
 			PipelineNames: &worker.PipelineNames{
 				Build:   imageType.BuildPipelines(),
 				Payload: imageType.PayloadPipelines(),
 			},
+			CheckPackages: checkPackages,
 		})
 		if err == nil {
-			err = api.store.PushCompose(composeID, manifest, imageType, bp, size, targets, jobId, packageSets["packages"])
+			err = api.store.PushCompose(composeID, manifest, imageType, bp, size, cr.Compression, targets, jobId, packageSets["packages"])
 		}
 	}
 
@@ -2422,7 +2796,7 @@ func (api *API) composeDeleteHandler(writer http.ResponseWriter, request *http.R
 			continue
 		}
 
-		composeStatus := api.getComposeStatus(compose)
+		composeStatus := api.getComposeStatus(id, compose)
 		if composeStatus.State != ComposeFinished && composeStatus.State != ComposeFailed {
 			errors = append(errors, composeDeleteError{
 				"BuildInWrongState",
@@ -2487,7 +2861,7 @@ func (api *API) composeCancelHandler(writer http.ResponseWriter, request *http.R
 		return
 	}
 
-	composeStatus := api.getComposeStatus(compose)
+	composeStatus := api.getComposeStatus(id, compose)
 	if composeStatus.State == ComposeWaiting {
 		errors := responseError{
 			ID:  "BuildInWrongState",
@@ -2591,7 +2965,7 @@ func (api *API) composeQueueHandler(writer http.ResponseWriter, request *http.Re
 
 	composes := api.store.GetAllComposes()
 	for id, compose := range composes {
-		composeStatus := api.getComposeStatus(compose)
+		composeStatus := api.getComposeStatus(id, compose)
 		switch composeStatus.State {
 		case ComposeWaiting:
 			reply.New = append(reply.New, composeToComposeEntry(id, compose, composeStatus, includeUploads))
@@ -2662,7 +3036,7 @@ func (api *API) composeStatusHandler(writer http.ResponseWriter, request *http.R
 		if !exists {
 			continue
 		}
-		composeStatus := api.getComposeStatus(compose)
+		composeStatus := api.getComposeStatus(id, compose)
 		if filterBlueprint != "" && compose.Blueprint.Name != filterBlueprint {
 			continue
 		} else if filterStatus != "" && composeStatus.State.ToString() != filterStatus {
@@ -2677,7 +3051,7 @@ func (api *API) composeStatusHandler(writer http.ResponseWriter, request *http.R
 	includeUploads := isRequestVersionAtLeast(params, 1)
 	for _, id := range filteredUUIDs {
 		if compose, exists := composes[id]; exists {
-			composeStatus := api.getComposeStatus(compose)
+			composeStatus := api.getComposeStatus(id, compose)
 			reply.UUIDs = append(reply.UUIDs, composeToComposeEntry(id, compose, composeStatus, includeUploads))
 		}
 	}
@@ -2732,13 +3106,13 @@ func (api *API) composeInfoHandler(writer http.ResponseWriter, request *http.Req
 	reply.Blueprint = compose.Blueprint
 	// Weldr API assumes only one image build per compose, that's why only the
 	// 1st build is considered
-	composeStatus := api.getComposeStatus(compose)
+	composeStatus := api.getComposeStatus(id, compose)
 	reply.ComposeType = compose.ImageBuild.ImageType.Name()
 	reply.QueueStatus = composeStatus.State.ToString()
 	reply.ImageSize = compose.ImageBuild.Size
 
 	if isRequestVersionAtLeast(params, 1) {
-		reply.Uploads = targetsToUploadResponses(compose.ImageBuild.Targets, composeStatus.State)
+		reply.Uploads = targetsToUploadResponses(compose.ImageBuild.Targets, composeStatus.State, composeStatus.TargetResults)
 	}
 
 	// Add package dependencies from the compose
@@ -2779,7 +3153,7 @@ func (api *API) composeImageHandler(writer http.ResponseWriter, request *http.Re
 		return
 	}
 
-	composeStatus := api.getComposeStatus(compose)
+	composeStatus := api.getComposeStatus(uuid, compose)
 	if composeStatus.State != ComposeFinished {
 		errors := responseError{
 			ID:  "BuildInWrongState",
@@ -2789,8 +3163,8 @@ func (api *API) composeImageHandler(writer http.ResponseWriter, request *http.Re
 		return
 	}
 
-	imageName := compose.ImageBuild.ImageType.Filename()
-	imageMime := compose.ImageBuild.ImageType.MIMEType()
+	imageName := compose.ImageBuild.ImageFilename()
+	imageMime := compose.ImageBuild.ImageMIMEType()
 
 	reader, fileSize, err := api.openImageFile(uuid, compose)
 	if err != nil {
@@ -2837,7 +3211,7 @@ func (api *API) composeMetadataHandler(writer http.ResponseWriter, request *http
 		return
 	}
 
-	composeStatus := api.getComposeStatus(compose)
+	composeStatus := api.getComposeStatus(uuid, compose)
 	if composeStatus.State != ComposeFinished && composeStatus.State != ComposeFailed {
 		errors := responseError{
 			ID:  "BuildInWrongState",
@@ -2898,7 +3272,7 @@ func (api *API) composeResultsHandler(writer http.ResponseWriter, request *http.
 		return
 	}
 
-	composeStatus := api.getComposeStatus(compose)
+	composeStatus := api.getComposeStatus(uuid, compose)
 	if composeStatus.State != ComposeFinished && composeStatus.State != ComposeFailed {
 		errors := responseError{
 			ID:  "BuildInWrongState",
@@ -2948,7 +3322,7 @@ func (api *API) composeResultsHandler(writer http.ResponseWriter, request *http.
 	reader, fileSize, err := api.openImageFile(uuid, compose)
 	if err == nil {
 		hdr = &tar.Header{
-			Name:    uuid.String() + "-" + compose.ImageBuild.ImageType.Filename(),
+			Name:    uuid.String() + "-" + compose.ImageBuild.ImageFilename(),
 			Mode:    0644,
 			Size:    int64(fileSize),
 			ModTime: time.Now().Truncate(time.Second),
@@ -2989,7 +3363,7 @@ func (api *API) composeLogsHandler(writer http.ResponseWriter, request *http.Req
 		return
 	}
 
-	composeStatus := api.getComposeStatus(compose)
+	composeStatus := api.getComposeStatus(id, compose)
 	if composeStatus.State != ComposeFinished && composeStatus.State != ComposeFailed {
 		errors := responseError{
 			ID:  "BuildInWrongState",
@@ -3053,7 +3427,7 @@ func (api *API) composeLogHandler(writer http.ResponseWriter, request *http.Requ
 		return
 	}
 
-	composeStatus := api.getComposeStatus(compose)
+	composeStatus := api.getComposeStatus(id, compose)
 	if composeStatus.State == ComposeWaiting {
 		errors := responseError{
 			ID:  "BuildInWrongState",
@@ -3083,7 +3457,7 @@ func (api *API) composeFinishedHandler(writer http.ResponseWriter, request *http
 
 	includeUploads := isRequestVersionAtLeast(params, 1)
 	for id, compose := range api.store.GetAllComposes() {
-		composeStatus := api.getComposeStatus(compose)
+		composeStatus := api.getComposeStatus(id, compose)
 		if composeStatus.State != ComposeFinished {
 			continue
 		}
@@ -3106,7 +3480,7 @@ func (api *API) composeFailedHandler(writer http.ResponseWriter, request *http.R
 
 	includeUploads := isRequestVersionAtLeast(params, 1)
 	for id, compose := range api.store.GetAllComposes() {
-		composeStatus := api.getComposeStatus(compose)
+		composeStatus := api.getComposeStatus(id, compose)
 		if composeStatus.State != ComposeFailed {
 			continue
 		}
@@ -3145,12 +3519,21 @@ func (api *API) allRepositoriesByImageType(imageType distro.ImageType) ([]rpmmd.
 
 	repos := append([]rpmmd.RepoConfig{}, imageTypeRepos...)
 	for id, source := range api.store.GetAllDistroSources(imageType.Arch().Distro().Name()) {
-		repos = append(repos, source.RepoConfig(id))
+		repos = append(repos, sourceRepoConfig(id, source))
 	}
 
 	return repos, nil
 }
 
+// sourceRepoConfig returns the repository of a user defined source
+func sourceRepoConfig(id string, source store.SourceConfig) rpmmd.RepoConfig {
+	repo := source.RepoConfig(id)
+	// the local repository is only readable on this host, its packages are
+	// embedded into the manifests
+	repo.EmbedPackages = id == localrepo.SourceID
+	return repo
+}
+
 // Returns all configured repositories (base + sources) as rpmmd.RepoConfig
 func (api *API) allRepositories(distroName string) ([]rpmmd.RepoConfig, error) {
 	archRepos, err := api.repoRegistry.ReposByArchName(distroName, api.arch.Name(), false)
@@ -3160,7 +3543,7 @@ func (api *API) allRepositories(distroName string) ([]rpmmd.RepoConfig, error) {
 
 	repos := append([]rpmmd.RepoConfig{}, archRepos...)
 	for id, source := range api.store.GetAllDistroSources(distroName) {
-		repos = append(repos, source.RepoConfig(id))
+		repos = append(repos, sourceRepoConfig(id, source))
 	}
 
 	return repos, nil
@@ -3168,6 +3551,7 @@ func (api *API) allRepositories(distroName string) ([]rpmmd.RepoConfig, error) {
 
 func (api *API) depsolveBlueprint(bp blueprint.Blueprint) ([]rpmmd.PackageSpec, error) {
 	// Depsolve using the host distro if none has been specified
+	bp.Distro = api.resolveDistroName(bp.Distro)
 	if bp.Distro == "" {
 		bp.Distro = api.hostDistroName
 	}
@@ -3181,7 +3565,14 @@ func (api *API) depsolveBlueprint(bp blueprint.Blueprint) ([]rpmmd.PackageSpec,
 		return nil, err
 	}
 
-	packages, _, err := api.rpmmd.Depsolve(rpmmd.PackageSet{Include: bp.GetPackages()}, repos, d.ModulePlatformID(), api.arch.Name(), d.Releasever())
+	enabledModules, disabledModules := bp.Customizations.GetModuleSpecs()
+	packageSet := rpmmd.PackageSet{
+		Include:         bp.GetPackages(),
+		EnabledModules:  enabledModules,
+		DisabledModules: disabledModules,
+		ExcludeWeakDeps: !*bp.Customizations.GetInstall().WeakDeps,
+	}
+	packages, _, err := api.rpmmd.Depsolve(packageSet, repos, d.ModulePlatformID(), api.arch.Name(), d.Releasever())
 	if err != nil {
 		return nil, err
 	}
@@ -3212,8 +3603,45 @@ func (api *API) uploadsInfoHandler(writer http.ResponseWriter, request *http.Req
 		return
 	}
 
-	// TODO: implement this route (it is v1 only)
-	notImplementedHandler(writer, request, params)
+	uuidString := params.ByName("uuid")
+	uploadID, err := uuid.Parse(uuidString)
+	if err != nil {
+		errors := responseError{
+			ID:  "UnknownUUID",
+			Msg: fmt.Sprintf("%s is not a valid upload uuid", uuidString),
+		}
+		statusResponseError(writer, http.StatusBadRequest, errors)
+		return
+	}
+
+	for id, compose := range api.store.GetAllComposes() {
+		for _, t := range compose.ImageBuild.Targets {
+			if t.Uuid != uploadID {
+				continue
+			}
+
+			composeStatus := api.getComposeStatus(id, compose)
+			uploads := targetsToUploadResponses([]*target.Target{t}, composeStatus.State, composeStatus.TargetResults)
+			if len(uploads) == 0 {
+				// local targets are not uploads
+				break
+			}
+
+			reply := UploadInfoResponseV1{
+				Status: true,
+				Upload: uploads[0],
+			}
+			err = json.NewEncoder(writer).Encode(reply)
+			common.PanicOnError(err)
+			return
+		}
+	}
+
+	errors := responseError{
+		ID:  "UnknownUUID",
+		Msg: fmt.Sprintf("%s is not a valid upload uuid", uuidString),
+	}
+	statusResponseError(writer, http.StatusBadRequest, errors)
 }
 
 func (api *API) uploadsLogHandler(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
@@ -3276,10 +3704,231 @@ func (api *API) distrosListHandler(writer http.ResponseWriter, request *http.Req
 	}
 
 	var reply struct {
-		Distros []string `json:"distros"`
+		Distros []string          `json:"distros"`
+		Aliases map[string]string `json:"aliases,omitempty"`
 	}
 	reply.Distros = api.distros
+	if len(api.distroAliases) > 0 {
+		reply.Aliases = api.distroAliases
+	}
+
+	err := json.NewEncoder(writer).Encode(reply)
+	common.PanicOnError(err)
+}
+
+// distrosReposListHandler returns the distributions which have repositories
+func (api *API) distrosReposListHandler(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	if !verifyRequestVersion(writer, params, 1) {
+		return
+	}
+
+	var reply struct {
+		Distros []string `json:"distros"`
+	}
+	reply.Distros = api.repoRegistry.ListDistros()
+
+	err := json.NewEncoder(writer).Encode(reply)
+	common.PanicOnError(err)
+}
+
+// distrosReposInfoHandler returns the repositories of all architectures of a
+// distribution, in the format of the repository definition files
+func (api *API) distrosReposInfoHandler(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	if !verifyRequestVersion(writer, params, 1) {
+		return
+	}
+
+	distroName := params.ByName("distro")
+	distroRepos, found := api.repoRegistry.DistroRepos(distroName)
+	if !found {
+		errors := responseError{
+			ID:  "DistroError",
+			Msg: fmt.Sprintf("there are no repositories for distribution %s", distroName),
+		}
+		statusResponseError(writer, http.StatusNotFound, errors)
+		return
+	}
+
+	reply := struct {
+		Distro       string                       `json:"distro"`
+		Repositories map[string][]json.RawMessage `json:"repositories"`
+	}{
+		Distro:       distroName,
+		Repositories: make(map[string][]json.RawMessage, len(distroRepos)),
+	}
+	for arch, repos := range distroRepos {
+		reply.Repositories[arch] = make([]json.RawMessage, 0, len(repos))
+		for _, repo := range repos {
+			// inline client keys are secrets
+			if rpmmd.IsInlinePEM(repo.SSLClientKey) {
+				repo.SSLClientKey = ""
+			}
+			data, err := rpmmd.MarshalRepoConfig(repo)
+			common.PanicOnError(err)
+			reply.Repositories[arch] = append(reply.Repositories[arch], data)
+		}
+	}
+
+	err := json.NewEncoder(writer).Encode(reply)
+	common.PanicOnError(err)
+}
+
+// distrosReposNewHandler adds a repository to a distribution and
+// architecture, or replaces the repository of the same name. The change is
+// only applied when the repositories can be used to depsolve.
+func (api *API) distrosReposNewHandler(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	if !verifyRequestVersion(writer, params, 1) {
+		return
+	}
+
+	contentType := request.Header["Content-Type"]
+	if len(contentType) != 1 || contentType[0] != "application/json" {
+		errors := responseError{
+			ID:  "HTTPError",
+			Msg: "repository must be json",
+		}
+		statusResponseError(writer, http.StatusUnsupportedMediaType, errors)
+		return
+	}
+
+	var body struct {
+		Distro     string          `json:"distro"`
+		Arch       string          `json:"arch"`
+		Repository json.RawMessage `json:"repository"`
+	}
+	err := json.NewDecoder(request.Body).Decode(&body)
+	if err != nil {
+		errors := responseError{
+			ID:  "RepositoryError",
+			Msg: "Problem parsing POST body: " + err.Error(),
+		}
+		statusResponseError(writer, http.StatusBadRequest, errors)
+		return
+	}
+
+	d := api.distroRegistry.GetDistro(body.Distro)
+	if d == nil {
+		errors := responseError{
+			ID:  "DistroError",
+			Msg: fmt.Sprintf("Unknown distribution: %s", body.Distro),
+		}
+		statusResponseError(writer, http.StatusBadRequest, errors)
+		return
+	}
+	arch, err := d.GetArch(body.Arch)
+	if err != nil {
+		errors := responseError{
+			ID:  "RepositoryError",
+			Msg: err.Error(),
+		}
+		statusResponseError(writer, http.StatusBadRequest, errors)
+		return
+	}
+
+	repo, err := rpmmd.UnmarshalRepoConfig(body.Repository)
+	if err != nil {
+		errors := responseError{
+			ID:  "RepositoryError",
+			Msg: "Invalid repository: " + err.Error(),
+		}
+		statusResponseError(writer, http.StatusBadRequest, errors)
+		return
+	}
+
+	err = api.repoRegistry.SetRepository(d.Name(), arch.Name(), repo, func(repos []rpmmd.RepoConfig) error {
+		_, _, err := api.rpmmd.Depsolve(rpmmd.PackageSet{}, repos, d.ModulePlatformID(), arch.Name(), d.Releasever())
+		if err != nil {
+			return fmt.Errorf("cannot depsolve with the repositories of %s/%s: %v", d.Name(), arch.Name(), err)
+		}
+		return nil
+	})
+	if err != nil {
+		errors := responseError{
+			ID:  "RepositoryError",
+			Msg: err.Error(),
+		}
+		statusResponseError(writer, http.StatusBadRequest, errors)
+		return
+	}
+
+	statusResponseOK(writer)
+}
+
+func (api *API) snapshotsListHandler(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	if !verifyRequestVersion(writer, params, 1) {
+		return
+	}
+
+	var reply struct {
+		Snapshots []string `json:"snapshots"`
+	}
+	reply.Snapshots = api.snapshots.List()
 
 	err := json.NewEncoder(writer).Encode(reply)
 	common.PanicOnError(err)
 }
+
+func (api *API) snapshotsInfoHandler(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	if !verifyRequestVersion(writer, params, 1) {
+		return
+	}
+
+	type reply struct {
+		Snapshots []*snapshot.Snapshot `json:"snapshots"`
+		Errors    []responseError      `json:"errors"`
+	}
+
+	names := strings.Split(params.ByName("snapshots"), ",")
+	if !verifyStringsWithRegex(writer, names, snapshot.ValidName) {
+		return
+	}
+
+	snapshots := []*snapshot.Snapshot{}
+	errors := []responseError{}
+	for _, name := range names {
+		s := api.snapshots.Get(name)
+		if s == nil {
+			errors = append(errors, responseError{
+				ID:  "UnknownSnapshot",
+				Msg: fmt.Sprintf("%s: snapshot not found", name),
+			})
+			continue
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	err := json.NewEncoder(writer).Encode(reply{
+		Snapshots: snapshots,
+		Errors:    errors,
+	})
+	common.PanicOnError(err)
+}
+
+func (api *API) snapshotsDeleteHandler(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	if !verifyRequestVersion(writer, params, 1) {
+		return
+	}
+
+	name := params.ByName("snapshot")
+	if !verifyStringsWithRegex(writer, []string{name}, snapshot.ValidName) {
+		return
+	}
+
+	err := api.snapshots.Delete(name)
+	if err == snapshot.ErrNotFound {
+		errors := responseError{
+			ID:  "UnknownSnapshot",
+			Msg: fmt.Sprintf("%s: snapshot not found", name),
+		}
+		statusResponseError(writer, http.StatusBadRequest, errors)
+		return
+	} else if err != nil {
+		errors := responseError{
+			ID:  "SnapshotError",
+			Msg: err.Error(),
+		}
+		statusResponseError(writer, http.StatusInternalServerError, errors)
+		return
+	}
+	statusResponseOK(writer)
+}
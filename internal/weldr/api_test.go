@@ -3,6 +3,7 @@ package weldr
 import (
 	"archive/tar"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,6 +12,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path"
 	"strconv"
 	"testing"
 	"time"
@@ -20,15 +22,20 @@ import (
 	"github.com/osbuild/osbuild-composer/internal/distro"
 	"github.com/osbuild/osbuild-composer/internal/distro/test_distro"
 	"github.com/osbuild/osbuild-composer/internal/distroregistry"
+	"github.com/osbuild/osbuild-composer/internal/localrepo"
 	rpmmd_mock "github.com/osbuild/osbuild-composer/internal/mocks/rpmmd"
+	osbuild "github.com/osbuild/osbuild-composer/internal/osbuild2"
 	"github.com/osbuild/osbuild-composer/internal/reporegistry"
 	"github.com/osbuild/osbuild-composer/internal/rpmmd"
+	"github.com/osbuild/osbuild-composer/internal/snapshot"
 	"github.com/osbuild/osbuild-composer/internal/store"
 	"github.com/osbuild/osbuild-composer/internal/target"
 	"github.com/osbuild/osbuild-composer/internal/test"
+	"github.com/osbuild/osbuild-composer/internal/worker"
 
 	"github.com/BurntSushi/toml"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -134,6 +141,36 @@ func TestBasic(t *testing.T) {
 	}
 }
 
+func TestDistroAliases(t *testing.T) {
+	var cases = []struct {
+		Method         string
+		Path           string
+		Body           string
+		ExpectedStatus int
+		ExpectedJSON   string
+	}{
+		{"GET", "/api/v1/distros/list", ``, http.StatusOK, `{"distros": ["test-distro", "test-distro-2"], "aliases": {"test": "test-distro", "test-latest": "test-distro-2"}}`},
+		{"GET", "/api/v1/compose/types?distro=test-latest", ``, http.StatusOK, `{"types": [{"enabled":true, "name":"test_type"}]}`},
+		{"POST", "/api/v0/blueprints/new", `{"name":"test","description":"Test","distro":"test-latest","packages":[],"version":""}`, http.StatusOK, `{"status":true}`},
+		{"POST", "/api/v0/blueprints/new", `{"name":"test","description":"Test","distro":"test-oldest","packages":[],"version":""}`, http.StatusBadRequest, `{"status":false,"errors":[{"id":"BlueprintsError","msg":"'test-oldest' is not a valid distribution"}]}`},
+	}
+
+	tempdir, err := ioutil.TempDir("", "weldr-tests-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempdir)
+
+	for _, c := range cases {
+		api, _ := createWeldrAPI(tempdir, rpmmd_mock.BaseFixture)
+		err := api.distroRegistry.SetAliases(map[string]string{
+			"test":        "test-distro",
+			"test-latest": "test-distro*",
+		})
+		require.NoError(t, err)
+		api.distroAliases = validDistroAliases(api.distroRegistry, api.distros)
+		test.TestRoute(t, api, true, c.Method, c.Path, c.Body, c.ExpectedStatus, c.ExpectedJSON)
+	}
+}
+
 func TestBlueprintsNew(t *testing.T) {
 	var cases = []struct {
 		Method         string
@@ -697,6 +734,138 @@ func TestCompose(t *testing.T) {
 		},
 		Packages: []rpmmd.PackageSpec{},
 	}
+	expectedComposeLocalAndAwsZstd := &store.Compose{
+		Blueprint: &blueprint.Blueprint{
+			Name:           "test",
+			Version:        "0.0.0",
+			Packages:       []blueprint.Package{},
+			Modules:        []blueprint.Package{},
+			Groups:         []blueprint.Group{},
+			Customizations: nil,
+		},
+		ImageBuild: store.ImageBuild{
+			QueueStatus: common.IBWaiting,
+			ImageType:   imgType,
+			Manifest:    manifest,
+			Compression: distro.ZstdCompression,
+			Targets: []*target.Target{
+				{
+					Name:      "org.osbuild.aws",
+					Status:    common.IBWaiting,
+					ImageName: "test_upload",
+					Options: &target.AWSTargetOptions{
+						Filename:        "test.img.zst",
+						Region:          "frankfurt",
+						AccessKeyID:     "accesskey",
+						SecretAccessKey: "secretkey",
+						Bucket:          "clay",
+						Key:             "imagekey",
+					},
+				},
+			},
+		},
+		Packages: []rpmmd.PackageSpec{},
+	}
+	expectedComposeLocalAndGenericS3 := &store.Compose{
+		Blueprint: &blueprint.Blueprint{
+			Name:           "test",
+			Version:        "0.0.0",
+			Packages:       []blueprint.Package{},
+			Modules:        []blueprint.Package{},
+			Groups:         []blueprint.Group{},
+			Customizations: nil,
+		},
+		ImageBuild: store.ImageBuild{
+			QueueStatus: common.IBWaiting,
+			ImageType:   imgType,
+			Manifest:    manifest,
+			Targets: []*target.Target{
+				{
+					Name:      "org.osbuild.generic.s3",
+					Status:    common.IBWaiting,
+					ImageName: "test_upload",
+					Options: &target.GenericS3TargetOptions{
+						AWSS3TargetOptions: target.AWSS3TargetOptions{
+							Filename:        "test.img",
+							Region:          "us-east-1",
+							AccessKeyID:     "accesskey",
+							SecretAccessKey: "secretkey",
+							Bucket:          "clay",
+							Key:             "imagekey",
+						},
+						Endpoint:  "http://minio:9000",
+						PathStyle: true,
+					},
+				},
+			},
+		},
+		Packages: []rpmmd.PackageSpec{},
+	}
+	expectedComposeLocalAndOpenStack := &store.Compose{
+		Blueprint: &blueprint.Blueprint{
+			Name:           "test",
+			Version:        "0.0.0",
+			Packages:       []blueprint.Package{},
+			Modules:        []blueprint.Package{},
+			Groups:         []blueprint.Group{},
+			Customizations: nil,
+		},
+		ImageBuild: store.ImageBuild{
+			QueueStatus: common.IBWaiting,
+			ImageType:   imgType,
+			Manifest:    manifest,
+			Targets: []*target.Target{
+				{
+					Name:      "org.osbuild.openstack",
+					Status:    common.IBWaiting,
+					ImageName: "test_upload",
+					Options: &target.OpenStackTargetOptions{
+						Filename:    "test.img",
+						AuthURL:     "https://keystone.example.com:5000/v3",
+						Username:    "user",
+						Password:    "password",
+						ProjectName: "images",
+						DomainName:  "Default",
+						Visibility:  "private",
+						Properties: map[string]string{
+							"hw_disk_bus": "scsi",
+						},
+					},
+				},
+			},
+		},
+		Packages: []rpmmd.PackageSpec{},
+	}
+	expectedComposeLocalAndLibvirt := &store.Compose{
+		Blueprint: &blueprint.Blueprint{
+			Name:           "test",
+			Version:        "0.0.0",
+			Packages:       []blueprint.Package{},
+			Modules:        []blueprint.Package{},
+			Groups:         []blueprint.Group{},
+			Customizations: nil,
+		},
+		ImageBuild: store.ImageBuild{
+			QueueStatus: common.IBWaiting,
+			ImageType:   imgType,
+			Manifest:    manifest,
+			Targets: []*target.Target{
+				{
+					Name:      "org.osbuild.libvirt",
+					Status:    common.IBWaiting,
+					ImageName: "test_upload",
+					Options: &target.LibvirtTargetOptions{
+						Filename:     "test.img",
+						URI:          "qemu+ssh://root@kvm.example.com/system",
+						Pool:         "default",
+						DefineDomain: true,
+						Memory:       4096,
+					},
+				},
+			},
+		},
+		Packages: []rpmmd.PackageSpec{},
+	}
 	expectedComposeOSTreeRef := &store.Compose{
 		Blueprint: &blueprint.Blueprint{
 			Name:           "test",
@@ -769,6 +938,11 @@ func TestCompose(t *testing.T) {
 		{true, "POST", "/api/v0/compose", fmt.Sprintf(`{"blueprint_name": "http-server","compose_type": "%s","branch": "master"}`, test_distro.TestImageTypeName), http.StatusBadRequest, `{"status":false,"errors":[{"id":"UnknownBlueprint","msg":"Unknown blueprint name: http-server"}]}`, nil, []string{"build_id"}},
 		{false, "POST", "/api/v0/compose", fmt.Sprintf(`{"blueprint_name": "test","compose_type": "%s","branch": "master"}`, test_distro.TestImageTypeName), http.StatusOK, `{"status": true}`, expectedComposeLocal, []string{"build_id"}},
 		{false, "POST", "/api/v1/compose", fmt.Sprintf(`{"blueprint_name": "test","compose_type":"%s","branch":"master","upload":{"image_name":"test_upload","provider":"aws","settings":{"region":"frankfurt","accessKeyID":"accesskey","secretAccessKey":"secretkey","bucket":"clay","key":"imagekey"}}}`, test_distro.TestImageTypeName), http.StatusOK, `{"status": true}`, expectedComposeLocalAndAws, []string{"build_id"}},
+		{false, "POST", "/api/v1/compose", fmt.Sprintf(`{"blueprint_name": "test","compose_type":"%s","branch":"master","upload":{"image_name":"test_upload","provider":"aws","settings":{"region":"frankfurt","accessKeyID":"accesskey","secretAccessKey":"secretkey","bucket":"clay","key":"imagekey","copyToRegions":["eu-central-1","../evil"]}}}`, test_distro.TestImageTypeName), http.StatusBadRequest, `{"status":false,"errors":[{"id":"UploadError","msg":"invalid AWS region: \"../evil\""}]}`, nil, []string{"build_id"}},
+		{false, "POST", "/api/v1/compose", fmt.Sprintf(`{"blueprint_name": "test","compose_type":"%s","branch":"master","compression":"zstd","upload":{"image_name":"test_upload","provider":"aws","settings":{"region":"frankfurt","accessKeyID":"accesskey","secretAccessKey":"secretkey","bucket":"clay","key":"imagekey"}}}`, test_distro.TestImageTypeName), http.StatusOK, `{"status": true}`, expectedComposeLocalAndAwsZstd, []string{"build_id"}},
+		{false, "POST", "/api/v1/compose", fmt.Sprintf(`{"blueprint_name": "test","compose_type":"%s","branch":"master","upload":{"image_name":"test_upload","provider":"generic.s3","settings":{"endpoint":"http://minio:9000","pathStyle":true,"region":"us-east-1","accessKeyID":"accesskey","secretAccessKey":"secretkey","bucket":"clay","key":"imagekey"}}}`, test_distro.TestImageTypeName), http.StatusOK, `{"status": true}`, expectedComposeLocalAndGenericS3, []string{"build_id"}},
+		{false, "POST", "/api/v1/compose", fmt.Sprintf(`{"blueprint_name": "test","compose_type":"%s","branch":"master","upload":{"image_name":"test_upload","provider":"openstack","settings":{"authURL":"https://keystone.example.com:5000/v3","username":"user","password":"password","projectName":"images","domainName":"Default","visibility":"private","properties":{"hw_disk_bus":"scsi"}}}}`, test_distro.TestImageTypeName), http.StatusOK, `{"status": true}`, expectedComposeLocalAndOpenStack, []string{"build_id"}},
+		{false, "POST", "/api/v1/compose", fmt.Sprintf(`{"blueprint_name": "test","compose_type":"%s","branch":"master","upload":{"image_name":"test_upload","provider":"libvirt","settings":{"uri":"qemu+ssh://root@kvm.example.com/system","pool":"default","defineDomain":true,"memory":4096}}}`, test_distro.TestImageTypeName), http.StatusOK, `{"status": true}`, expectedComposeLocalAndLibvirt, []string{"build_id"}},
 		{false, "POST", "/api/v1/compose", fmt.Sprintf(`{"blueprint_name": "test","compose_type":"%s","branch":"master","ostree":{"ref":"refid","parent":"parentid","url":""}}`, test_distro.TestImageTypeName), http.StatusOK, `{"status": true}`, expectedComposeOSTreeRef, []string{"build_id"}},
 		{false, "POST", "/api/v1/compose?test=2", fmt.Sprintf(`{"blueprint_name": "test","compose_type":"%s","branch":"master","ostree":{"ref":"refid","parent":"","url":"http://ostree/"}}`, test_distro.TestImageTypeName), http.StatusOK, `{"status": true}`, expectedComposeOSTreeURL, []string{"build_id"}},
 		{false, "POST", "/api/v1/compose", fmt.Sprintf(`{"blueprint_name": "test","compose_type":"%s","branch":"master","ostree":{"ref":"refid","parent":"","url":"invalid-url"}}`, test_distro.TestImageTypeName), http.StatusBadRequest, `{"status":false,"errors":[{"id":"OSTreeCommitError","msg":"Get \"invalid-url/refs/heads/refid\": unsupported protocol scheme \"\""}]}`, nil, []string{"build_id"}},
@@ -904,6 +1078,111 @@ func TestComposeInfo(t *testing.T) {
 	}
 }
 
+// pushFinishedCompose adds a compose with the given targets to the store
+// whose job finished successfully with the given target results.
+func pushFinishedCompose(t *testing.T, api *API, composeID uuid.UUID, targets []*target.Target, results []*target.TargetResult) {
+	imageType, err := api.arch.GetImageType(test_distro.TestImageTypeName)
+	require.NoError(t, err)
+	manifest, err := imageType.Manifest(nil, distro.ImageOptions{Size: imageType.Size(0)}, nil, nil, 0)
+	require.NoError(t, err)
+
+	jobID, err := api.workers.EnqueueOSBuild(api.arch.Name(), &worker.OSBuildJob{Manifest: manifest, Targets: targets})
+	require.NoError(t, err)
+	_, token, _, _, _, err := api.workers.RequestJob(context.Background(), api.arch.Name(), []string{"osbuild"})
+	require.NoError(t, err)
+	rawResult, err := json.Marshal(worker.OSBuildJobResult{
+		Success:       true,
+		OSBuildOutput: &osbuild.Result{Success: true},
+		TargetResults: results,
+		UploadStatus:  "success",
+	})
+	require.NoError(t, err)
+	require.NoError(t, api.workers.FinishJob(token, rawResult))
+
+	bp := &blueprint.Blueprint{Name: "test", Version: "0.0.0"}
+	require.NoError(t, api.store.PushCompose(composeID, manifest, imageType, bp, 0, distro.UnsetCompression, targets, jobID, []rpmmd.PackageSpec{}))
+}
+
+func targetResultsFixture() ([]*target.Target, []*target.TargetResult) {
+	created := time.Date(2019, 11, 27, 13, 19, 0, 0, time.FixedZone("UTC+1", 60*60))
+	newAWSTarget := func(id, imageName string) *target.Target {
+		t := target.NewAWSTarget(&target.AWSTargetOptions{
+			Region: "frankfurt",
+			Bucket: "clay",
+			Key:    imageName,
+		})
+		t.Uuid = uuid.MustParse(id)
+		t.ImageName = imageName
+		t.Created = created
+		return t
+	}
+
+	targets := []*target.Target{
+		newAWSTarget("10000000-0000-0000-0000-000000000000", "awsimage"),
+		newAWSTarget("40000000-0000-0000-0000-000000000000", "awsimage2"),
+	}
+
+	// the result belongs to the second of the two targets with the same name
+	result := target.NewAWSTargetResult(&target.AWSTargetResultOptions{
+		Ami:    "ami-0123456789abcdef0",
+		Region: "frankfurt",
+	})
+	result.TargetUuid = targets[1].Uuid
+
+	return targets, []*target.TargetResult{result}
+}
+
+func TestComposeInfoTargetResults(t *testing.T) {
+	if len(os.Getenv("OSBUILD_COMPOSER_TEST_EXTERNAL")) > 0 {
+		t.Skip("This test is for internal testing only")
+	}
+
+	tempdir, err := ioutil.TempDir("", "weldr-tests-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempdir)
+
+	api, _ := createWeldrAPI(tempdir, rpmmd_mock.NoComposesFixture)
+	targets, results := targetResultsFixture()
+	pushFinishedCompose(t, api, uuid.MustParse("30000000-0000-0000-0000-000000000000"), targets, results)
+
+	test.TestRoute(t, api, false, "GET", "/api/v1/compose/info/30000000-0000-0000-0000-000000000000", ``, http.StatusOK, fmt.Sprintf(`{"id":"30000000-0000-0000-0000-000000000000","config":"","blueprint":{"name":"test","description":"","distro":"","version":"0.0.0","packages":null,"modules":null,"groups":null},"commit":"","deps":{"packages":[]},"compose_type":"%s","queue_status":"FINISHED","image_size":0,"uploads":[{"uuid":"10000000-0000-0000-0000-000000000000","status":"FINISHED","provider_name":"aws","image_name":"awsimage","creation_time":1574857140,"settings":{"region":"frankfurt","bucket":"clay","key":"awsimage"}},{"uuid":"40000000-0000-0000-0000-000000000000","status":"FINISHED","provider_name":"aws","image_name":"awsimage2","creation_time":1574857140,"settings":{"region":"frankfurt","bucket":"clay","key":"awsimage2"},"result":{"name":"org.osbuild.aws","target_uuid":"40000000-0000-0000-0000-000000000000","options":{"ami":"ami-0123456789abcdef0","region":"frankfurt"}}}]}`, test_distro.TestImageTypeName))
+
+	// the results are stored on the compose, keyed by their target
+	compose, exists := api.store.GetCompose(uuid.MustParse("30000000-0000-0000-0000-000000000000"))
+	require.True(t, exists)
+	require.Equal(t, map[uuid.UUID]*target.TargetResult{targets[1].Uuid: results[0]}, compose.TargetResults)
+}
+
+func TestUploadsInfo(t *testing.T) {
+	var cases = []struct {
+		Method         string
+		Path           string
+		Body           string
+		ExpectedStatus int
+		ExpectedJSON   string
+	}{
+		{"GET", "/api/v1/upload/info/40000000-0000-0000-0000-000000000000", ``, http.StatusOK, `{"status":true,"upload":{"uuid":"40000000-0000-0000-0000-000000000000","status":"FINISHED","provider_name":"aws","image_name":"awsimage2","creation_time":1574857140,"settings":{"region":"frankfurt","bucket":"clay","key":"awsimage2"},"result":{"name":"org.osbuild.aws","target_uuid":"40000000-0000-0000-0000-000000000000","options":{"ami":"ami-0123456789abcdef0","region":"frankfurt"}}}}`},
+		{"GET", "/api/v1/upload/info/20000000-0000-0000-0000-000000000000", ``, http.StatusBadRequest, `{"status":false,"errors":[{"id":"UnknownUUID","msg":"20000000-0000-0000-0000-000000000000 is not a valid upload uuid"}]}`},
+		{"GET", "/api/v1/upload/info/10000000-0000-0000-0000", ``, http.StatusBadRequest, `{"status":false,"errors":[{"id":"UnknownUUID","msg":"10000000-0000-0000-0000 is not a valid upload uuid"}]}`},
+		{"GET", "/api/v0/upload/info/10000000-0000-0000-0000-000000000000", ``, http.StatusNotFound, `{"status":false,"errors":[{"code":404,"id":"HTTPError","msg":"Not Found"}]}`},
+	}
+
+	if len(os.Getenv("OSBUILD_COMPOSER_TEST_EXTERNAL")) > 0 {
+		t.Skip("This test is for internal testing only")
+	}
+
+	tempdir, err := ioutil.TempDir("", "weldr-tests-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempdir)
+
+	for _, c := range cases {
+		api, _ := createWeldrAPI(tempdir, rpmmd_mock.NoComposesFixture)
+		targets, results := targetResultsFixture()
+		pushFinishedCompose(t, api, uuid.New(), targets, results)
+		test.TestRoute(t, api, false, c.Method, c.Path, c.Body, c.ExpectedStatus, c.ExpectedJSON)
+	}
+}
+
 func TestComposeLogs(t *testing.T) {
 	if len(os.Getenv("OSBUILD_COMPOSER_TEST_EXTERNAL")) > 0 {
 		t.Skip("This test is for internal testing only")
@@ -1249,6 +1528,127 @@ rhsm = true
 	test.TestRoute(t, api, true, "GET", "/api/v1/projects/source/info/fish?format=json", ``, 200, `{"sources":{"fish":`+sourceStr+`},"errors":[]}`)
 }
 
+func TestSourcesInfoClientCertV1(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "weldr-tests-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempdir)
+
+	source := `
+id = "internal"
+name = "internal"
+url = "https://repo.example.com/internal/"
+type = "yum-baseurl"
+check_ssl = true
+sslcacert = "/etc/pki/internal/ca.pem"
+sslclientkey = "/etc/pki/internal/client.key"
+sslclientcert = "/etc/pki/internal/client.pem"
+proxy = "http://proxy.example.com:3128"
+username = "builder"
+password = "secret"
+`
+
+	// the password is never returned
+	sourceStr := `{"check_gpg":false,"check_ssl":true,"id":"internal","name":"internal","proxy":"http://proxy.example.com:3128","rhsm":false,"sslcacert":"/etc/pki/internal/ca.pem","sslclientcert":"/etc/pki/internal/client.pem","sslclientkey":"/etc/pki/internal/client.key","system":false,"type":"yum-baseurl","url":"https://repo.example.com/internal/","username":"builder"}`
+
+	req := httptest.NewRequest("POST", "/api/v1/projects/source/new", bytes.NewReader([]byte(source)))
+	req.Header.Set("Content-Type", "text/x-toml")
+	recorder := httptest.NewRecorder()
+
+	api, sf := createWeldrAPI(tempdir, rpmmd_mock.BaseFixture)
+	api.ServeHTTP(recorder, req)
+
+	r := recorder.Result()
+	require.Equal(t, http.StatusOK, r.StatusCode)
+	test.TestRoute(t, api, true, "GET", "/api/v1/projects/source/info/internal?format=json", ``, 200, `{"sources":{"internal":`+sourceStr+`},"errors":[]}`)
+
+	repo := sf.GetSource("internal").RepoConfig("internal")
+	require.Equal(t, "secret", repo.Password)
+	require.Equal(t, "/etc/pki/internal/client.pem", repo.SSLClientCert)
+}
+
+func TestProjectsCacheV1(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "weldr-tests-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempdir)
+
+	api, _ := createWeldrAPI(tempdir, rpmmd_mock.BaseFixture)
+	test.TestRoute(t, api, true, "GET", "/api/v1/projects/cache/info", ``, http.StatusBadRequest, `{"status":false,"errors":[{"id":"MetadataCacheError","msg":"the metadata cache cannot be managed"}]}`)
+
+	api.rpmmdCache = rpmmd.NewCacheManager(tempdir, rpmmd.CacheConfig{TTL: time.Hour, MaxSize: 1024})
+	test.TestRoute(t, api, true, "GET", "/api/v1/projects/cache/info", ``, http.StatusOK, `{"ttl":3600,"max_size":1024,"repos":[]}`)
+	test.TestRoute(t, api, true, "POST", "/api/v1/projects/cache/refresh", ``, http.StatusOK, `{"status":true}`)
+	test.TestRoute(t, api, true, "POST", "/api/v0/projects/cache/refresh", ``, http.StatusNotFound, `{"status":false,"errors":[{"id":"HTTPError","code":404,"msg":"Not Found"}]}`)
+}
+
+func TestDistrosReposV1(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "weldr-tests-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempdir)
+
+	api, _ := createWeldrAPI(tempdir, rpmmd_mock.BaseFixture)
+	test.TestRoute(t, api, true, "GET", "/api/v1/distros/repos", ``, http.StatusOK, `{"distros":["test-distro","test-distro-2"]}`)
+	test.TestRoute(t, api, true, "GET", "/api/v1/distros/repos/test-distro", ``, http.StatusOK, `{"distro":"test-distro","repositories":{"test_arch":[{"name":"test-id","baseurl":"http://example.com/test/os/x86_64","check_gpg":true}]}}`)
+	test.TestRoute(t, api, true, "GET", "/api/v1/distros/repos/unknown", ``, http.StatusNotFound, `{"status":false,"errors":[{"id":"DistroError","msg":"there are no repositories for distribution unknown"}]}`)
+
+	// override a repository and add a new one
+	test.TestRoute(t, api, true, "POST", "/api/v1/distros/repos/new", `{"distro":"test-distro","arch":"test_arch","repository":{"name":"test-id","baseurl":"http://example.com/test/os/x86_64-new"}}`, http.StatusOK, `{"status":true}`)
+	test.TestRoute(t, api, true, "POST", "/api/v1/distros/repos/new", `{"distro":"test-distro","arch":"test_arch","repository":{"name":"extra","baseurl":"http://example.com/extra","image_type_tags":["test_type"]}}`, http.StatusOK, `{"status":true}`)
+	test.TestRoute(t, api, true, "GET", "/api/v1/distros/repos/test-distro", ``, http.StatusOK, `{"distro":"test-distro","repositories":{"test_arch":[{"name":"test-id","baseurl":"http://example.com/test/os/x86_64-new"},{"name":"extra","baseurl":"http://example.com/extra","image_type_tags":["test_type"]}]}}`)
+
+	test.TestRoute(t, api, true, "POST", "/api/v1/distros/repos/new", `{"distro":"test-distro","arch":"test_arch","repository":{"name":"no-url"}}`, http.StatusBadRequest, `{"status":false,"errors":[{"id":"RepositoryError","msg":"Invalid repository: repository no-url: one of baseurl, metalink or mirrorlist is required"}]}`)
+	test.TestRoute(t, api, true, "POST", "/api/v1/distros/repos/new", `{"distro":"unknown","arch":"test_arch","repository":{"name":"extra","baseurl":"http://example.com/extra"}}`, http.StatusBadRequest, `{"status":false,"errors":[{"id":"DistroError","msg":"Unknown distribution: unknown"}]}`)
+
+	// repositories which cannot be used are not applied
+	api, _ = createWeldrAPI(tempdir, rpmmd_mock.BadDepsolve)
+	test.TestRoute(t, api, true, "POST", "/api/v1/distros/repos/new", `{"distro":"test-distro","arch":"test_arch","repository":{"name":"extra","baseurl":"http://example.com/extra"}}`, http.StatusBadRequest, `{"status":false,"errors":[{"id":"RepositoryError","msg":"cannot depsolve with the repositories of test-distro/test_arch: DNF error occured: DepsolveError: There was a problem depsolving ['go2rpm']: \n Problem: conflicting requests\n  - nothing provides askalono-cli needed by go2rpm-1-4.fc31.noarch"}]}`)
+	test.TestRoute(t, api, true, "GET", "/api/v1/distros/repos/test-distro", ``, http.StatusOK, `{"distro":"test-distro","repositories":{"test_arch":[{"name":"test-id","baseurl":"http://example.com/test/os/x86_64","check_gpg":true}]}}`)
+}
+
+func TestLocalRepoV1(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "weldr-tests-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempdir)
+
+	api, sf := createWeldrAPI(tempdir, rpmmd_mock.BaseFixture)
+	test.TestRoute(t, api, true, "GET", "/api/v1/projects/local/list", ``, http.StatusBadRequest, `{"status":false,"errors":[{"id":"LocalRepoError","msg":"uploading packages is not supported"}]}`)
+
+	api.localRepo, err = localrepo.New(path.Join(tempdir, "local-repo"))
+	require.NoError(t, err)
+
+	rpm, err := ioutil.ReadFile("testdata/hotfix-1.2-1.el8.x86_64.rpm")
+	require.NoError(t, err)
+
+	upload := func(body []byte, contentType string) *http.Response {
+		req := httptest.NewRequest("POST", "/api/v1/projects/local/upload", bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, req)
+		return recorder.Result()
+	}
+
+	require.Equal(t, http.StatusBadRequest, upload(rpm, "application/octet-stream").StatusCode)
+	require.Equal(t, http.StatusBadRequest, upload([]byte("not an rpm"), "application/x-rpm").StatusCode)
+	require.Nil(t, sf.GetSource(localrepo.SourceID))
+
+	resp := upload(rpm, "application/x-rpm")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"package":{"name":"hotfix","epoch":0,"version":"1.2","release":"1.el8","arch":"x86_64","summary":"A test package"}}`, string(body))
+
+	// the repository is available as a regular source
+	source := sf.GetSource(localrepo.SourceID)
+	require.NotNil(t, source)
+	require.Equal(t, "file://"+path.Join(tempdir, "local-repo")+"/", source.URL)
+	require.Equal(t, "yum-baseurl", source.Type)
+
+	test.TestRoute(t, api, true, "GET", "/api/v1/projects/local/list", ``, http.StatusOK, `{"packages":[{"name":"hotfix","epoch":0,"version":"1.2","release":"1.el8","arch":"x86_64","summary":"A test package"}]}`)
+
+	test.TestRoute(t, api, true, "DELETE", "/api/v1/projects/local/delete/hotfix", ``, http.StatusOK, `{"status":true}`)
+	test.TestRoute(t, api, true, "DELETE", "/api/v1/projects/local/delete/hotfix", ``, http.StatusBadRequest, `{"status":false,"errors":[{"id":"UnknownPackage","msg":"hotfix: package not found"}]}`)
+	test.TestRoute(t, api, true, "GET", "/api/v1/projects/local/list", ``, http.StatusOK, `{"packages":[]}`)
+}
+
 // TestSourcesNewWrongTomlV1 Tests that Empty TOML, and invalid TOML should return an error
 func TestSourcesNewWrongTomlV1(t *testing.T) {
 	tempdir, err := ioutil.TempDir("", "weldr-tests-")
@@ -1748,3 +2148,71 @@ func TestComposePOST_ImageTypeDenylist(t *testing.T) {
 		}
 	}
 }
+
+func TestComposeSnapshots(t *testing.T) {
+	if len(os.Getenv("OSBUILD_COMPOSER_TEST_EXTERNAL")) > 0 {
+		t.Skip("This test is for internal testing only")
+	}
+
+	tempdir, err := ioutil.TempDir("", "weldr-tests-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempdir)
+
+	api, _ := createWeldrAPI(tempdir, rpmmd_mock.BaseFixture)
+
+	test.TestRoute(t, api, false, "POST", "/api/v1/compose?test=2", fmt.Sprintf(`{"blueprint_name": "test","compose_type":"%s","branch":"master","save_snapshot":"snap1"}`, test_distro.TestImageTypeName), http.StatusOK, `{"status": true}`, "build_id")
+	test.TestRoute(t, api, false, "POST", "/api/v1/compose?test=2", fmt.Sprintf(`{"blueprint_name": "test","compose_type":"%s","branch":"master","save_snapshot":"snap1"}`, test_distro.TestImageTypeName), http.StatusBadRequest, `{"status":false,"errors":[{"id":"SnapshotError","msg":"Snapshot snap1 already exists"}]}`)
+	test.TestRoute(t, api, false, "POST", "/api/v1/compose?test=2", fmt.Sprintf(`{"blueprint_name": "test","compose_type":"%s","branch":"master","snapshot":"snap1","save_snapshot":"snap2"}`, test_distro.TestImageTypeName), http.StatusBadRequest, `{"status":false,"errors":[{"id":"SnapshotError","msg":"Supply at most one of snapshot and save_snapshot"}]}`)
+
+	test.TestRoute(t, api, false, "GET", "/api/v1/snapshots/list", ``, http.StatusOK, `{"snapshots":["snap1"]}`)
+	test.TestRoute(t, api, false, "GET", "/api/v1/snapshots/info/snap1,snap2", ``, http.StatusOK, fmt.Sprintf(`{"snapshots":[{"name":"snap1","distro":"%s","arch":"%s","image_type":"%s","package_sets":{}}],"errors":[{"id":"UnknownSnapshot","msg":"snap2: snapshot not found"}]}`, test_distro.TestDistroName, test_distro.TestArchName, test_distro.TestImageTypeName), "created")
+
+	test.TestRoute(t, api, false, "POST", "/api/v1/compose?test=2", fmt.Sprintf(`{"blueprint_name": "test","compose_type":"%s","branch":"master","snapshot":"snap1"}`, test_distro.TestImageTypeName), http.StatusOK, `{"status": true}`, "build_id")
+	test.TestRoute(t, api, false, "POST", "/api/v1/compose?test=2", fmt.Sprintf(`{"blueprint_name": "test","compose_type":"%s","branch":"master","snapshot":"snap2"}`, test_distro.TestImageTypeName), http.StatusBadRequest, `{"status":false,"errors":[{"id":"SnapshotError","msg":"Unknown snapshot: snap2"}]}`)
+
+	test.TestRoute(t, api, false, "DELETE", "/api/v1/snapshots/delete/snap1", ``, http.StatusOK, `{"status":true}`)
+	test.TestRoute(t, api, false, "DELETE", "/api/v1/snapshots/delete/snap1", ``, http.StatusBadRequest, `{"status":false,"errors":[{"id":"UnknownSnapshot","msg":"snap1: snapshot not found"}]}`)
+	test.TestRoute(t, api, false, "GET", "/api/v1/snapshots/list", ``, http.StatusOK, `{"snapshots":[]}`)
+}
+
+func TestComposeFromSnapshot(t *testing.T) {
+	if len(os.Getenv("OSBUILD_COMPOSER_TEST_EXTERNAL")) > 0 {
+		t.Skip("This test is for internal testing only")
+	}
+
+	tempdir, err := ioutil.TempDir("", "weldr-tests-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempdir)
+
+	api, _ := createWeldrAPI(tempdir, rpmmd_mock.BaseFixture)
+
+	pkg := rpmmd.PackageSpec{Name: "bash", Version: "5.1.8", Release: "1.fc30", Arch: "x86_64", RemoteLocation: "https://example.com/bash.rpm", Checksum: "sha256:aaaa"}
+	require.NoError(t, api.snapshots.Push(&snapshot.Snapshot{
+		Name:        "frozen",
+		Distro:      test_distro.TestDistroName,
+		Arch:        test_distro.TestArchName,
+		ImageType:   test_distro.TestImageTypeName,
+		PackageSets: map[string][]rpmmd.PackageSpec{"packages": {pkg}},
+	}))
+	require.NoError(t, api.snapshots.Push(&snapshot.Snapshot{
+		Name:      "changed",
+		Distro:    test_distro.TestDistroName,
+		Arch:      test_distro.TestArchName,
+		ImageType: test_distro.TestImageTypeName,
+		PackageRequests: map[string]rpmmd.PackageSet{
+			"packages": {Include: []string{"tmux"}},
+		},
+		PackageSets: map[string][]rpmmd.PackageSpec{"packages": {pkg}},
+	}))
+
+	// the blueprint must request the packages the snapshot was taken for
+	test.TestRoute(t, api, false, "POST", "/api/v1/compose", fmt.Sprintf(`{"blueprint_name": "test","compose_type":"%s","branch":"master","snapshot":"changed"}`, test_distro.TestImageTypeName), http.StatusBadRequest, `{"status":false,"errors":[{"id":"SnapshotError","msg":"the packages requested by the blueprint differ from the ones snapshot changed was taken for"}]}`)
+
+	// the worker checks whether the packages are still available
+	test.TestRoute(t, api, false, "POST", "/api/v1/compose", fmt.Sprintf(`{"blueprint_name": "test","compose_type":"%s","branch":"master","snapshot":"frozen"}`, test_distro.TestImageTypeName), http.StatusOK, `{"status": true}`, "build_id")
+	_, _, _, rawArgs, _, err := api.workers.RequestJob(context.Background(), api.arch.Name(), []string{"osbuild"})
+	require.NoError(t, err)
+	var args worker.OSBuildJob
+	require.NoError(t, json.Unmarshal(rawArgs, &args))
+	require.Equal(t, []rpmmd.PackageSpec{pkg}, args.CheckPackages)
+}
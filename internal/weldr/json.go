@@ -120,7 +120,6 @@ type SourceConfig interface {
 }
 
 // NewSourceConfigV0 converts a store.SourceConfig to a SourceConfigV0
-// The store does not support proxy and gpgkey_urls
 func NewSourceConfigV0(s store.SourceConfig) SourceConfigV0 {
 	var sc SourceConfigV0
 
@@ -130,6 +129,8 @@ func NewSourceConfigV0(s store.SourceConfig) SourceConfigV0 {
 	sc.CheckGPG = s.CheckGPG
 	sc.CheckSSL = s.CheckSSL
 	sc.System = s.System
+	sc.Proxy = s.Proxy
+	sc.GPGUrls = s.GPGKeyURLs
 
 	return sc
 }
@@ -162,13 +163,14 @@ func (s SourceConfigV0) GetType() string {
 }
 
 // SourceConfig returns a SourceConfig struct populated with the supported variables
-// The store does not support proxy and gpgkey_urls
 func (s SourceConfigV0) SourceConfig() (ssc store.SourceConfig) {
 	ssc.Name = s.Name
 	ssc.Type = s.Type
 	ssc.URL = s.URL
 	ssc.CheckGPG = s.CheckGPG
 	ssc.CheckSSL = s.CheckSSL
+	ssc.Proxy = s.Proxy
+	ssc.GPGKeyURLs = s.GPGUrls
 
 	return ssc
 }
@@ -180,7 +182,8 @@ type SourceInfoResponseV0 struct {
 }
 
 // NewSourceConfigV1 converts a store.SourceConfig to a SourceConfigV1
-// The store does not support proxy and gpgkey_urls
+//
+// The password and inline client keys are secrets and are not included.
 func NewSourceConfigV1(id string, s store.SourceConfig) SourceConfigV1 {
 	var sc SourceConfigV1
 
@@ -193,6 +196,14 @@ func NewSourceConfigV1(id string, s store.SourceConfig) SourceConfigV1 {
 	sc.System = s.System
 	sc.Distros = s.Distros
 	sc.RHSM = s.RHSM
+	sc.Proxy = s.Proxy
+	sc.SSLCACert = s.SSLCACert
+	sc.SSLClientCert = s.SSLClientCert
+	if !rpmmd.IsInlinePEM(s.SSLClientKey) {
+		sc.SSLClientKey = s.SSLClientKey
+	}
+	sc.Username = s.Username
+	sc.GPGUrls = s.GPGKeyURLs
 
 	return sc
 }
@@ -210,6 +221,12 @@ type SourceConfigV1 struct {
 	GPGUrls  []string `json:"gpgkey_urls,omitempty" toml:"gpgkey_urls,omitempty"`
 	Distros  []string `json:"distros,omitempty" toml:"distros,omitempty"`
 	RHSM     bool     `json:"rhsm" toml:"rhsm"`
+	// Paths to PEM files or inline PEM data
+	SSLCACert     string `json:"sslcacert,omitempty" toml:"sslcacert,omitempty"`
+	SSLClientKey  string `json:"sslclientkey,omitempty" toml:"sslclientkey,omitempty"`
+	SSLClientCert string `json:"sslclientcert,omitempty" toml:"sslclientcert,omitempty"`
+	Username      string `json:"username,omitempty" toml:"username,omitempty"`
+	Password      string `json:"password,omitempty" toml:"password,omitempty"`
 }
 
 // Key returns the key, .ID in this case
@@ -228,7 +245,6 @@ func (s SourceConfigV1) GetType() string {
 }
 
 // SourceConfig returns a SourceConfig struct populated with the supported variables
-// The store does not support proxy and gpgkey_urls
 func (s SourceConfigV1) SourceConfig() (ssc store.SourceConfig) {
 	ssc.Name = s.Name
 	ssc.Type = s.Type
@@ -237,6 +253,13 @@ func (s SourceConfigV1) SourceConfig() (ssc store.SourceConfig) {
 	ssc.CheckSSL = s.CheckSSL
 	ssc.Distros = s.Distros
 	ssc.RHSM = s.RHSM
+	ssc.Proxy = s.Proxy
+	ssc.SSLCACert = s.SSLCACert
+	ssc.SSLClientKey = s.SSLClientKey
+	ssc.SSLClientCert = s.SSLClientCert
+	ssc.Username = s.Username
+	ssc.Password = s.Password
+	ssc.GPGKeyURLs = s.GPGUrls
 
 	return ssc
 }
@@ -354,6 +377,12 @@ type ComposeInfoResponseV0 struct {
 	Uploads     []uploadResponse `json:"uploads,omitempty"`
 }
 
+// UploadInfoResponseV1 is the response to /upload/info request
+type UploadInfoResponseV1 struct {
+	Status bool           `json:"status"`
+	Upload uploadResponse `json:"upload"`
+}
+
 type ComposeQueueResponseV0 struct {
 	New []ComposeEntryV0 `json:"new"`
 	Run []ComposeEntryV0 `json:"run"`
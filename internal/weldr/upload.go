@@ -3,10 +3,10 @@ package weldr
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/osbuild/osbuild-composer/internal/common"
-	"github.com/osbuild/osbuild-composer/internal/distro"
 
 	"github.com/google/uuid"
 	"github.com/osbuild/osbuild-composer/internal/target"
@@ -19,6 +19,46 @@ type uploadResponse struct {
 	ImageName    string                 `json:"image_name"`
 	CreationTime float64                `json:"creation_time"`
 	Settings     uploadSettings         `json:"settings"`
+	// Result holds the structured output of the upload, e.g. the ID of the
+	// created image. It is only set once the upload finished successfully.
+	Result *target.TargetResult `json:"result,omitempty"`
+}
+
+type rawUploadResponse struct {
+	UUID         uuid.UUID              `json:"uuid"`
+	Status       common.ImageBuildState `json:"status"`
+	ProviderName string                 `json:"provider_name"`
+	ImageName    string                 `json:"image_name"`
+	CreationTime float64                `json:"creation_time"`
+	Settings     json.RawMessage        `json:"settings"`
+	Result       *target.TargetResult   `json:"result,omitempty"`
+}
+
+func (u *uploadResponse) UnmarshalJSON(data []byte) error {
+	var rawUploadResponse rawUploadResponse
+	err := json.Unmarshal(data, &rawUploadResponse)
+	if err != nil {
+		return err
+	}
+
+	settings, err := newUploadSettings(rawUploadResponse.ProviderName)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(rawUploadResponse.Settings, settings)
+	if err != nil {
+		return err
+	}
+
+	u.UUID = rawUploadResponse.UUID
+	u.Status = rawUploadResponse.Status
+	u.ProviderName = rawUploadResponse.ProviderName
+	u.ImageName = rawUploadResponse.ImageName
+	u.CreationTime = rawUploadResponse.CreationTime
+	u.Settings = settings
+	u.Result = rawUploadResponse.Result
+
+	return nil
 }
 
 type uploadSettings interface {
@@ -32,10 +72,25 @@ type awsUploadSettings struct {
 	SessionToken    string `json:"sessionToken,omitempty"`
 	Bucket          string `json:"bucket"`
 	Key             string `json:"key"`
+	// Additional regions the AMI is copied to
+	CopyToRegions []string `json:"copyToRegions,omitempty"`
 }
 
 func (awsUploadSettings) isUploadSettings() {}
 
+type genericS3UploadSettings struct {
+	Region          string `json:"region"`
+	AccessKeyID     string `json:"accessKeyID,omitempty"`
+	SecretAccessKey string `json:"secretAccessKey,omitempty"`
+	SessionToken    string `json:"sessionToken,omitempty"`
+	Bucket          string `json:"bucket"`
+	Key             string `json:"key"`
+	Endpoint        string `json:"endpoint"`
+	PathStyle       bool   `json:"pathStyle,omitempty"`
+}
+
+func (genericS3UploadSettings) isUploadSettings() {}
+
 type azureUploadSettings struct {
 	StorageAccount   string `json:"storageAccount,omitempty"`
 	StorageAccessKey string `json:"storageAccessKey,omitempty"`
@@ -55,6 +110,55 @@ type vmwareUploadSettings struct {
 
 func (vmwareUploadSettings) isUploadSettings() {}
 
+type openStackUploadSettings struct {
+	AuthURL         string            `json:"authURL"`
+	Username        string            `json:"username,omitempty"`
+	Password        string            `json:"password,omitempty"`
+	ProjectName     string            `json:"projectName"`
+	ProjectID       string            `json:"projectID,omitempty"`
+	DomainName      string            `json:"domainName,omitempty"`
+	Region          string            `json:"region,omitempty"`
+	DiskFormat      string            `json:"diskFormat,omitempty"`
+	ContainerFormat string            `json:"containerFormat,omitempty"`
+	Visibility      string            `json:"visibility,omitempty"`
+	Properties      map[string]string `json:"properties,omitempty"`
+}
+
+func (openStackUploadSettings) isUploadSettings() {}
+
+type libvirtUploadSettings struct {
+	URI            string `json:"uri"`
+	Pool           string `json:"pool"`
+	Format         string `json:"format,omitempty"`
+	DefineDomain   bool   `json:"defineDomain,omitempty"`
+	DomainTemplate string `json:"domainTemplate,omitempty"`
+	Memory         uint64 `json:"memory,omitempty"`
+	VCPUs          uint   `json:"vcpus,omitempty"`
+}
+
+func (libvirtUploadSettings) isUploadSettings() {}
+
+// newUploadSettings returns empty settings of the type matching the
+// provider name.
+func newUploadSettings(provider string) (uploadSettings, error) {
+	switch provider {
+	case "azure":
+		return new(azureUploadSettings), nil
+	case "aws":
+		return new(awsUploadSettings), nil
+	case "generic.s3":
+		return new(genericS3UploadSettings), nil
+	case "vmware":
+		return new(vmwareUploadSettings), nil
+	case "openstack":
+		return new(openStackUploadSettings), nil
+	case "libvirt":
+		return new(libvirtUploadSettings), nil
+	default:
+		return nil, errors.New("unexpected provider name")
+	}
+}
+
 type uploadRequest struct {
 	Provider  string         `json:"provider"`
 	ImageName string         `json:"image_name"`
@@ -74,16 +178,9 @@ func (u *uploadRequest) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	var settings uploadSettings
-	switch rawUploadRequest.Provider {
-	case "azure":
-		settings = new(azureUploadSettings)
-	case "aws":
-		settings = new(awsUploadSettings)
-	case "vmware":
-		settings = new(vmwareUploadSettings)
-	default:
-		return errors.New("unexpected provider name")
+	settings, err := newUploadSettings(rawUploadRequest.Provider)
+	if err != nil {
+		return err
 	}
 	err = json.Unmarshal(rawUploadRequest.Settings, settings)
 	if err != nil {
@@ -105,13 +202,18 @@ func (u *uploadRequest) UnmarshalJSON(data []byte) error {
 //
 // This also ignores any sensitive data passed into targets. Access keys may
 // be passed as input to composer, but should not be possible to be queried.
-func targetsToUploadResponses(targets []*target.Target, state ComposeState) []uploadResponse {
+//
+// The results reported by the worker are attached to the upload of the
+// target they belong to. Results of workers which don't report the target
+// UUID yet are matched by the target name instead.
+func targetsToUploadResponses(targets []*target.Target, state ComposeState, results map[uuid.UUID]*target.TargetResult) []uploadResponse {
 	var uploads []uploadResponse
 	for _, t := range targets {
 		upload := uploadResponse{
 			UUID:         t.Uuid,
 			ImageName:    t.ImageName,
 			CreationTime: float64(t.Created.UnixNano()) / 1000000000,
+			Result:       results[t.Uuid],
 		}
 
 		switch state {
@@ -129,9 +231,21 @@ func targetsToUploadResponses(targets []*target.Target, state ComposeState) []up
 		case *target.AWSTargetOptions:
 			upload.ProviderName = "aws"
 			upload.Settings = &awsUploadSettings{
-				Region: options.Region,
-				Bucket: options.Bucket,
-				Key:    options.Key,
+				Region:        options.Region,
+				Bucket:        options.Bucket,
+				Key:           options.Key,
+				CopyToRegions: options.CopyToRegions,
+				// AccessKeyID and SecretAccessKey are intentionally not included.
+			}
+			uploads = append(uploads, upload)
+		case *target.GenericS3TargetOptions:
+			upload.ProviderName = "generic.s3"
+			upload.Settings = &genericS3UploadSettings{
+				Region:    options.Region,
+				Bucket:    options.Bucket,
+				Key:       options.Key,
+				Endpoint:  options.Endpoint,
+				PathStyle: options.PathStyle,
 				// AccessKeyID and SecretAccessKey are intentionally not included.
 			}
 			uploads = append(uploads, upload)
@@ -152,13 +266,53 @@ func targetsToUploadResponses(targets []*target.Target, state ComposeState) []up
 				// Username and Password are intentionally not included.
 			}
 			uploads = append(uploads, upload)
+		case *target.OpenStackTargetOptions:
+			upload.ProviderName = "openstack"
+			upload.Settings = &openStackUploadSettings{
+				AuthURL:         options.AuthURL,
+				ProjectName:     options.ProjectName,
+				ProjectID:       options.ProjectID,
+				DomainName:      options.DomainName,
+				Region:          options.Region,
+				DiskFormat:      options.DiskFormat,
+				ContainerFormat: options.ContainerFormat,
+				Visibility:      options.Visibility,
+				Properties:      options.Properties,
+				// Username and Password are intentionally not included.
+			}
+			uploads = append(uploads, upload)
+		case *target.LibvirtTargetOptions:
+			upload.ProviderName = "libvirt"
+			upload.Settings = &libvirtUploadSettings{
+				URI:            options.URI,
+				Pool:           options.Pool,
+				Format:         options.Format,
+				DefineDomain:   options.DefineDomain,
+				DomainTemplate: options.DomainTemplate,
+				Memory:         options.Memory,
+				VCPUs:          options.VCPUs,
+			}
+			uploads = append(uploads, upload)
 		}
 	}
 
 	return uploads
 }
 
-func uploadRequestToTarget(u uploadRequest, imageType distro.ImageType) *target.Target {
+// validate checks the settings of the upload request which can't be checked
+// when unmarshalling them.
+func (u *uploadRequest) validate() error {
+	if options, ok := u.Settings.(*awsUploadSettings); ok {
+		for _, region := range options.CopyToRegions {
+			if !target.IsValidAWSRegion(region) {
+				return fmt.Errorf("invalid AWS region: %q", region)
+			}
+		}
+	}
+	return nil
+}
+
+func uploadRequestToTarget(u uploadRequest, filename string) *target.Target {
 	var t target.Target
 
 	t.Uuid = uuid.New()
@@ -170,18 +324,34 @@ func uploadRequestToTarget(u uploadRequest, imageType distro.ImageType) *target.
 	case *awsUploadSettings:
 		t.Name = "org.osbuild.aws"
 		t.Options = &target.AWSTargetOptions{
-			Filename:        imageType.Filename(),
+			Filename:        filename,
 			Region:          options.Region,
 			AccessKeyID:     options.AccessKeyID,
 			SecretAccessKey: options.SecretAccessKey,
 			SessionToken:    options.SessionToken,
 			Bucket:          options.Bucket,
 			Key:             options.Key,
+			CopyToRegions:   options.CopyToRegions,
+		}
+	case *genericS3UploadSettings:
+		t.Name = "org.osbuild.generic.s3"
+		t.Options = &target.GenericS3TargetOptions{
+			AWSS3TargetOptions: target.AWSS3TargetOptions{
+				Filename:        filename,
+				Region:          options.Region,
+				AccessKeyID:     options.AccessKeyID,
+				SecretAccessKey: options.SecretAccessKey,
+				SessionToken:    options.SessionToken,
+				Bucket:          options.Bucket,
+				Key:             options.Key,
+			},
+			Endpoint:  options.Endpoint,
+			PathStyle: options.PathStyle,
 		}
 	case *azureUploadSettings:
 		t.Name = "org.osbuild.azure"
 		t.Options = &target.AzureTargetOptions{
-			Filename:         imageType.Filename(),
+			Filename:         filename,
 			StorageAccount:   options.StorageAccount,
 			StorageAccessKey: options.StorageAccessKey,
 			Container:        options.Container,
@@ -189,7 +359,7 @@ func uploadRequestToTarget(u uploadRequest, imageType distro.ImageType) *target.
 	case *vmwareUploadSettings:
 		t.Name = "org.osbuild.vmware"
 		t.Options = &target.VMWareTargetOptions{
-			Filename:   imageType.Filename(),
+			Filename:   filename,
 			Username:   options.Username,
 			Password:   options.Password,
 			Host:       options.Host,
@@ -197,6 +367,34 @@ func uploadRequestToTarget(u uploadRequest, imageType distro.ImageType) *target.
 			Datacenter: options.Datacenter,
 			Datastore:  options.Datastore,
 		}
+	case *openStackUploadSettings:
+		t.Name = "org.osbuild.openstack"
+		t.Options = &target.OpenStackTargetOptions{
+			Filename:        filename,
+			AuthURL:         options.AuthURL,
+			Username:        options.Username,
+			Password:        options.Password,
+			ProjectName:     options.ProjectName,
+			ProjectID:       options.ProjectID,
+			DomainName:      options.DomainName,
+			Region:          options.Region,
+			DiskFormat:      options.DiskFormat,
+			ContainerFormat: options.ContainerFormat,
+			Visibility:      options.Visibility,
+			Properties:      options.Properties,
+		}
+	case *libvirtUploadSettings:
+		t.Name = "org.osbuild.libvirt"
+		t.Options = &target.LibvirtTargetOptions{
+			Filename:       filename,
+			URI:            options.URI,
+			Pool:           options.Pool,
+			Format:         options.Format,
+			DefineDomain:   options.DefineDomain,
+			DomainTemplate: options.DomainTemplate,
+			Memory:         options.Memory,
+			VCPUs:          options.VCPUs,
+		}
 	}
 
 	return &t
@@ -19,11 +19,14 @@ const (
 	ErrorKojiFinalize         ClientErrorCode = 16
 	ErrorInvalidConfig        ClientErrorCode = 17
 	ErrorOldResultCompatible  ClientErrorCode = 18
+	ErrorSnapshotPackages     ClientErrorCode = 19
 
 	ErrorDNFDepsolveError ClientErrorCode = 20
 	ErrorDNFMarkingError  ClientErrorCode = 21
 	ErrorDNFOtherError    ClientErrorCode = 22
 	ErrorRPMMDError       ClientErrorCode = 23
+
+	ErrorSnapshotSave ClientErrorCode = 24
 )
 
 type ClientErrorCode int
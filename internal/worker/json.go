@@ -2,6 +2,7 @@ package worker
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/osbuild/osbuild-composer/internal/distro"
 	osbuild "github.com/osbuild/osbuild-composer/internal/osbuild2"
@@ -21,6 +22,9 @@ type OSBuildJob struct {
 	StreamOptimized bool             `json:"stream_optimized,omitempty"`
 	Exports         []string         `json:"export_stages,omitempty"`
 	PipelineNames   *PipelineNames   `json:"pipeline_names,omitempty"`
+	// Packages which must still be available before building, for
+	// composes reusing the packages of a snapshot
+	CheckPackages []rpmmd.PackageSpec `json:"check_packages,omitempty"`
 }
 
 type JobResult struct {
@@ -34,6 +38,8 @@ type OSBuildJobResult struct {
 	TargetErrors  []string               `json:"target_errors,omitempty"`
 	UploadStatus  string                 `json:"upload_status"`
 	PipelineNames *PipelineNames         `json:"pipeline_names,omitempty"`
+	// Size of the built image in bytes
+	ImageSize uint64 `json:"image_size,omitempty"`
 	JobResult
 }
 
@@ -109,6 +115,10 @@ type DepsolveJob struct {
 	Arch                    string                        `json:"arch"`
 	Releasever              string                        `json:"releasever"`
 	PackageSetsRepositories map[string][]rpmmd.RepoConfig `json:"package_sets_repositories,omitempty"`
+
+	// When a refresh of the metadata of the repositories was requested, by
+	// repository URL
+	CacheRefresh map[string]time.Time `json:"cache_refresh,omitempty"`
 }
 
 type ErrorType string
@@ -119,9 +129,32 @@ const (
 )
 
 type DepsolveJobResult struct {
-	PackageSpecs map[string][]rpmmd.PackageSpec `json:"package_specs"`
-	Error        string                         `json:"error"`
-	ErrorType    ErrorType                      `json:"error_type"`
+	PackageSpecs  map[string][]rpmmd.PackageSpec `json:"package_specs"`
+	RepoChecksums map[string]string              `json:"repo_checksums,omitempty"`
+	CacheStats    []rpmmd.RepoCacheStats         `json:"cache_stats,omitempty"`
+	Error         string                         `json:"error"`
+	ErrorType     ErrorType                      `json:"error_type"`
+	JobResult
+}
+
+// SearchJob searches the metadata of the repositories for packages
+// matching the glob patterns.
+type SearchJob struct {
+	Patterns         []string           `json:"patterns"`
+	Repos            []rpmmd.RepoConfig `json:"repos"`
+	ModulePlatformID string             `json:"module_platform_id"`
+	Arch             string             `json:"arch"`
+	Releasever       string             `json:"releasever"`
+
+	// When a refresh of the metadata of the repositories was requested, by
+	// repository URL
+	CacheRefresh map[string]time.Time `json:"cache_refresh,omitempty"`
+}
+
+type SearchJobResult struct {
+	Packages      rpmmd.PackageList      `json:"packages"`
+	RepoChecksums map[string]string      `json:"repo_checksums,omitempty"`
+	CacheStats    []rpmmd.RepoCacheStats `json:"cache_stats,omitempty"`
 	JobResult
 }
 
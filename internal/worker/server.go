@@ -12,6 +12,7 @@ import (
 	"os"
 	"path"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -30,6 +31,11 @@ type Server struct {
 	logger            *log.Logger
 	artifactsDir      string
 	requestJobTimeout time.Duration
+
+	// channels closed when a job finishes or is canceled through this
+	// server, for WaitForJob
+	waitersMu sync.Mutex
+	waiters   map[uuid.UUID]map[chan struct{}]struct{}
 }
 
 type JobStatus struct {
@@ -43,12 +49,17 @@ var ErrInvalidToken = errors.New("token does not exist")
 var ErrJobNotRunning = errors.New("job isn't running")
 var ErrInvalidJobType = errors.New("job has invalid type")
 
+// interval in which WaitForJob polls the job queue for jobs finished through
+// other servers sharing it
+const waitForJobPollInterval = time.Second
+
 func NewServer(logger *log.Logger, jobs jobqueue.JobQueue, artifactsDir string, requestJobTimeout time.Duration, basePath string) *Server {
 	s := &Server{
 		jobs:              jobs,
 		logger:            logger,
 		artifactsDir:      artifactsDir,
 		requestJobTimeout: requestJobTimeout,
+		waiters:           make(map[uuid.UUID]map[chan struct{}]struct{}),
 	}
 
 	api.BasePath = basePath
@@ -115,10 +126,20 @@ func (s *Server) EnqueueDepsolve(job *DepsolveJob) (uuid.UUID, error) {
 	return s.jobs.Enqueue("depsolve", job, nil)
 }
 
+func (s *Server) EnqueueSearch(job *SearchJob) (uuid.UUID, error) {
+	return s.jobs.Enqueue("search", job, nil)
+}
+
 func (s *Server) EnqueueManifestJobByID(job *ManifestJobByID, parent uuid.UUID) (uuid.UUID, error) {
 	return s.jobs.Enqueue("manifest-id-only", job, []uuid.UUID{parent})
 }
 
+// EnqueueManifestJob enqueues a manifest job which does not depend on a
+// depsolve job, because its packages are known already.
+func (s *Server) EnqueueManifestJob(job *ManifestJobByID) (uuid.UUID, error) {
+	return s.jobs.Enqueue("manifest-id-only", job, nil)
+}
+
 func (s *Server) JobStatus(id uuid.UUID, result interface{}) (*JobStatus, []uuid.UUID, error) {
 	rawResult, queued, started, finished, canceled, deps, err := s.jobs.JobStatus(id)
 	if err != nil {
@@ -197,7 +218,77 @@ func (s *Server) Job(id uuid.UUID, job interface{}) (string, json.RawMessage, []
 }
 
 func (s *Server) Cancel(id uuid.UUID) error {
-	return s.jobs.CancelJob(id)
+	err := s.jobs.CancelJob(id)
+	if err != nil {
+		return err
+	}
+	s.notifyWaiters(id)
+	return nil
+}
+
+// WaitForJob blocks until the job is finished or canceled, or until ctx is
+// done, in which case the context's error is returned.
+//
+// Jobs finished or canceled through this server wake the waiter up right
+// away. Other servers may share the job queue, so it is polled as well.
+func (s *Server) WaitForJob(ctx context.Context, id uuid.UUID) error {
+	// register before looking at the job, so that finishing it in between
+	// is not missed
+	done := s.addWaiter(id)
+	defer s.removeWaiter(id, done)
+
+	ticker := time.NewTicker(waitForJobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		_, _, _, finished, canceled, _, err := s.jobs.JobStatus(id)
+		if err != nil {
+			return err
+		}
+		if !finished.IsZero() || canceled {
+			return nil
+		}
+
+		select {
+		case <-done:
+			return nil
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Server) addWaiter(id uuid.UUID) chan struct{} {
+	s.waitersMu.Lock()
+	defer s.waitersMu.Unlock()
+
+	done := make(chan struct{})
+	if s.waiters[id] == nil {
+		s.waiters[id] = make(map[chan struct{}]struct{})
+	}
+	s.waiters[id][done] = struct{}{}
+	return done
+}
+
+func (s *Server) removeWaiter(id uuid.UUID, done chan struct{}) {
+	s.waitersMu.Lock()
+	defer s.waitersMu.Unlock()
+
+	delete(s.waiters[id], done)
+	if len(s.waiters[id]) == 0 {
+		delete(s.waiters, id)
+	}
+}
+
+func (s *Server) notifyWaiters(id uuid.UUID) {
+	s.waitersMu.Lock()
+	defer s.waitersMu.Unlock()
+
+	for done := range s.waiters[id] {
+		close(done)
+	}
+	delete(s.waiters, id)
 }
 
 // Provides access to artifacts of a job. Returns an io.Reader for the artifact
@@ -331,6 +422,7 @@ func (s *Server) FinishJob(token uuid.UUID, result json.RawMessage) error {
 			return fmt.Errorf("error finishing job: %v", err)
 		}
 	}
+	s.notifyWaiters(jobId)
 
 	var jobResult OSBuildJobResult
 	_, _, err = s.JobStatus(jobId, &jobResult)
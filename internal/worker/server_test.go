@@ -458,6 +458,94 @@ func TestRequestJobById(t *testing.T) {
 		fmt.Sprintf(`{"canceled":false,"href":"/api/worker/v1/jobs/%s","id":"%s","kind":"JobStatus"}`, token, token))
 }
 
+func TestWaitForJob(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "worker-tests-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempdir)
+
+	server := newTestServer(t, tempdir, time.Duration(0), "/api/worker/v1")
+
+	jobId, err := server.EnqueueSearch(&worker.SearchJob{Patterns: []string{"pkg*"}})
+	require.NoError(t, err)
+
+	// times out while the job is pending
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	require.Equal(t, context.DeadlineExceeded, server.WaitForJob(ctx, jobId))
+
+	waited := make(chan error)
+	go func() {
+		waited <- server.WaitForJob(context.Background(), jobId)
+	}()
+
+	_, token, typ, _, _, err := server.RequestJob(context.Background(), "arch", []string{"search"})
+	require.NoError(t, err)
+	require.Equal(t, "search", typ)
+	result, err := json.Marshal(worker.SearchJobResult{})
+	require.NoError(t, err)
+	require.NoError(t, server.FinishJob(token, result))
+
+	select {
+	case err := <-waited:
+		require.NoError(t, err)
+	case <-time.After(time.Second * 5):
+		t.Fatal("WaitForJob did not return after the job finished")
+	}
+
+	// returns immediately for finished jobs
+	require.NoError(t, server.WaitForJob(context.Background(), jobId))
+
+	// and for canceled ones
+	jobId, err = server.EnqueueSearch(&worker.SearchJob{})
+	require.NoError(t, err)
+	go func() {
+		waited <- server.WaitForJob(context.Background(), jobId)
+	}()
+	require.NoError(t, server.Cancel(jobId))
+	select {
+	case err := <-waited:
+		require.NoError(t, err)
+	case <-time.After(time.Second * 5):
+		t.Fatal("WaitForJob did not return after the job was canceled")
+	}
+}
+
+func TestWaitForJobFinishedElsewhere(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "worker-tests-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempdir)
+
+	q, err := fsjobqueue.New(tempdir)
+	require.NoError(t, err)
+	server := worker.NewServer(nil, q, "", time.Duration(0), "/api/worker/v1")
+
+	jobId, err := server.EnqueueSearch(&worker.SearchJob{Patterns: []string{"pkg*"}})
+	require.NoError(t, err)
+
+	waited := make(chan error)
+	go func() {
+		waited <- server.WaitForJob(context.Background(), jobId)
+	}()
+
+	// give WaitForJob time to find the job pending
+	time.Sleep(time.Millisecond * 100)
+
+	// another server sharing the job queue finishes the job
+	id, _, _, _, _, err := q.Dequeue(context.Background(), []string{"search"})
+	require.NoError(t, err)
+	require.Equal(t, jobId, id)
+	result, err := json.Marshal(worker.SearchJobResult{})
+	require.NoError(t, err)
+	require.NoError(t, q.FinishJob(jobId, result))
+
+	select {
+	case err := <-waited:
+		require.NoError(t, err)
+	case <-time.After(time.Second * 5):
+		t.Fatal("WaitForJob did not return after the job was finished by another server")
+	}
+}
+
 // Enqueue OSBuild jobs with and without additional data and read them off the queue to
 // check if the fallbacks are added for the old job and the new data are kept
 // for the new job.
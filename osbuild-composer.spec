@@ -317,6 +317,8 @@ The core osbuild-composer binary. This is suitable both for spawning in containe
 Summary:    The worker for osbuild-composer
 Requires:   systemd
 Requires:   qemu-img
+# virsh is used by the org.osbuild.libvirt upload target
+Recommends: libvirt-client
 Requires:   osbuild >= 41
 Requires:   osbuild-ostree >= 41
 Requires:   %{name}-dnf-json = %{version}-%{release}